@@ -1,30 +1,202 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"github.com/VATUSA/primary-api/internal"
+	"github.com/VATUSA/primary-api/internal/v1/event"
+	"github.com/VATUSA/primary-api/internal/v1/notification"
+	"github.com/VATUSA/primary-api/internal/v1/roster"
+	"github.com/VATUSA/primary-api/pkg/activity"
 	"github.com/VATUSA/primary-api/pkg/config"
 	"github.com/VATUSA/primary-api/pkg/database"
-	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/VATUSA/primary-api/pkg/database/migrations"
+	"github.com/VATUSA/primary-api/pkg/datafeed"
 	gochi "github.com/VATUSA/primary-api/pkg/go-chi"
+	"github.com/VATUSA/primary-api/pkg/health"
+	"github.com/VATUSA/primary-api/pkg/outbox"
+	"github.com/VATUSA/primary-api/pkg/pubsub"
+	"github.com/VATUSA/primary-api/pkg/search"
+	"github.com/VATUSA/primary-api/pkg/service"
 	"github.com/VATUSA/primary-api/pkg/storage"
+	"github.com/VATUSA/primary-api/pkg/vatsim"
+	"github.com/VATUSA/primary-api/pkg/webhook"
 	"github.com/joho/godotenv"
+	"log"
 	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 )
 
 func main() {
 	_ = godotenv.Load(".env")
 	cfg := config.New()
 
-	bucket, err := storage.NewS3Client(cfg.S3)
+	store, err := storage.New(cfg)
 	if err != nil {
 		panic(err)
 	}
 
-	storage.PublicBucket = bucket
+	pubsub.DefaultHub = pubsub.NewMemoryHub()
 	database.DB = database.Connect(cfg.Database)
-	models.AutoMigrate()
+	services := service.New(database.DB)
+
+	// Cancelled on SIGTERM or interrupt, which stops the background workers
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var workers sync.WaitGroup
+	start := func(run func(ctx context.Context)) {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			run(ctx)
+		}()
+	}
+
+	var syncer *vatsim.Syncer
+	if cfg.VATSIM.APIKey != "" {
+		syncer = vatsim.NewSyncer(vatsim.NewClient(cfg.VATSIM.APIURL, cfg.VATSIM.APIKey), cfg.VATSIM.Division)
+	}
+
+	// Migrations run once the server is up, so waiting on another replica's migrations doesn't fail liveness
+	// probes. /readyz reports the instance unready until they are done, and the workers only start after.
+	migrator := migrations.New(database.DB)
+	migrator.AllowUnknown = true
+	start(func(ctx context.Context) {
+		if !migrate(ctx, migrator) {
+			return
+		}
+
+		start(func(ctx context.Context) {
+			event.RunReminders(ctx, time.Minute)
+		})
+		start(func(ctx context.Context) {
+			roster.RunCertificationSweep(ctx, services.Roster, time.Minute)
+		})
+		start(func(ctx context.Context) {
+			activity.NewTracker(datafeed.New(cfg.Activity)).Run(ctx, cfg.Activity.PollInterval)
+		})
+		start(func(ctx context.Context) {
+			webhook.NewSender().Run(ctx, 10*time.Second)
+		})
+
+		dispatcher := outbox.NewDispatcher()
+		dispatcher.Register("webhook", webhook.Consume)
+		dispatcher.Register("notification", notification.Consume)
+		dispatcher.Register("audit", outbox.Audit)
+		start(func(ctx context.Context) {
+			dispatcher.Run(ctx, 5*time.Second)
+		})
+
+		if syncer != nil {
+			start(func(ctx context.Context) {
+				syncer.Run(ctx, cfg.VATSIM.SyncInterval)
+			})
+		}
+	})
+
+	checker := health.New()
+	checker.Add("database", health.Database(database.DB))
+	checker.Add("storage", health.Storage(store))
+	checker.Add("migrations", health.Migrations(migrator))
 
 	r := gochi.New(cfg)
-	internal.Router(r, cfg)
-	http.ListenAndServe(":8080", r)
+	r.Get("/healthz", health.Live)
+	r.Get("/readyz", checker.Ready)
+	internal.Router(r, cfg, services, store, search.NewMySQL(database.DB), syncer)
+
+	// The local backend serves its own presigned URLs
+	if local, ok := store.(*storage.LocalStorage); ok {
+		base, err := url.Parse(local.BaseURL)
+		if err != nil {
+			panic(err)
+		}
+		r.Mount(base.Path, http.StripPrefix(base.Path, local.Handler()))
+	}
+
+	srv := &http.Server{
+		Addr:              cfg.Server.Addr,
+		Handler:           r,
+		ReadHeaderTimeout: cfg.Server.ReadTimeout,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+	}
+	srv.RegisterOnShutdown(notification.CloseStreams)
+
+	failed := make(chan error, 1)
+	go func() {
+		log.Println("[Server] Listening on", cfg.Server.Addr)
+		if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+			failed <- err
+		}
+	}()
+
+	select {
+	case err := <-failed:
+		log.Fatal("[Server] Error:", err)
+	case <-ctx.Done():
+	}
+	// A second signal kills the process without waiting
+	stop()
+
+	log.Println("[Server] Shutting down")
+	checker.Drain()
+	time.Sleep(cfg.Server.DrainDelay)
+
+	shutdown, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdown); err != nil {
+		log.Println("[Server] Error draining connections:", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		log.Println("[Server] Stopped")
+	case <-shutdown.Done():
+		log.Println("[Server] Timed out waiting for background workers to stop")
+	}
+}
+
+// migrate applies any pending migrations, waiting while another instance holds the migration lock. Versions
+// applied by a newer build are only warned about, so a deploy can be rolled back. It returns false if the
+// server starts shutting down first.
+func migrate(ctx context.Context, m *migrations.Migrator) bool {
+	unknown, err := m.Unknown()
+	if err != nil {
+		log.Fatal("[Database] Migration Error:", err)
+	}
+	for _, record := range unknown {
+		log.Printf("[Database] Warning: migration %04d_%s was applied by a newer build", record.Version, record.Name)
+	}
+
+	for {
+		applied, err := m.Up()
+		for _, migration := range applied {
+			log.Println("[Database] Applied migration", migration)
+		}
+		if err == nil {
+			return true
+		}
+		if !errors.Is(err, migrations.ErrLocked) {
+			log.Fatal("[Database] Migration Error:", err)
+		}
+
+		log.Println("[Database] Waiting for another instance to finish migrating")
+		select {
+		case <-ctx.Done():
+			return false
+		default:
+		}
+	}
 }
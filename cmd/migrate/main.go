@@ -0,0 +1,104 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/VATUSA/primary-api/pkg/config"
+	"github.com/VATUSA/primary-api/pkg/database"
+	"github.com/VATUSA/primary-api/pkg/database/migrations"
+	"github.com/joho/godotenv"
+	"log"
+	"os"
+	"strconv"
+)
+
+const usage = `Usage: migrate [flags] <command>
+
+Commands:
+  up             apply every pending migration
+  down [steps]   roll back the last steps migrations (default 1)
+  status         list migrations and when they were applied
+  create <name>  add empty up and down files for a new migration
+
+Flags:
+`
+
+func main() {
+	dir := flag.String("dir", "pkg/database/migrations/sql", "directory new migrations are created in")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// Creating files doesn't need a database
+	if args[0] == "create" {
+		if len(args) != 2 {
+			flag.Usage()
+			os.Exit(2)
+		}
+		up, down, err := migrations.Create(*dir, args[1])
+		if err != nil {
+			log.Fatal("[Migrate] Create Error:", err)
+		}
+		fmt.Println("Created", up)
+		fmt.Println("Created", down)
+		return
+	}
+
+	_ = godotenv.Load(".env")
+	cfg := config.New()
+	database.DB = database.Connect(cfg.Database)
+	migrator := migrations.New(database.DB)
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up()
+		for _, m := range applied {
+			fmt.Println("Applied", m)
+		}
+		if err != nil {
+			log.Fatal("[Migrate] Up Error:", err)
+		}
+		if len(applied) == 0 {
+			fmt.Println("No pending migrations")
+		}
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				log.Fatal("[Migrate] Down Error: steps must be a positive number")
+			}
+			steps = n
+		}
+		rolledBack, err := migrator.Down(steps)
+		for _, m := range rolledBack {
+			fmt.Println("Rolled back", m)
+		}
+		if err != nil {
+			log.Fatal("[Migrate] Down Error:", err)
+		}
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			log.Fatal("[Migrate] Status Error:", err)
+		}
+		for _, s := range statuses {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = s.AppliedAt.Format("2006-01-02 15:04:05 MST")
+			}
+			fmt.Printf("%-40s %s\n", s.Migration, applied)
+		}
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/VATUSA/primary-api/internal/v1/roster"
+	"github.com/VATUSA/primary-api/pkg/activity"
+	"github.com/VATUSA/primary-api/pkg/admin"
+	"github.com/VATUSA/primary-api/pkg/constants"
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/VATUSA/primary-api/pkg/service"
+	"github.com/VATUSA/primary-api/pkg/vatsim"
+	"gorm.io/gorm"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func seedFacilities(ctx context.Context, fs *flag.FlagSet, dryRun *bool, args []string) error {
+	file := fs.String("file", "", "JSON list of facilities with id, name and url (defaults to the division's facilities)")
+	parse(fs, args, 0)
+
+	facilities := admin.DefaultFacilities()
+	if *file != "" {
+		data, err := os.ReadFile(*file)
+		if err != nil {
+			return err
+		}
+		facilities = []models.Facility{}
+		if err := json.Unmarshal(data, &facilities); err != nil {
+			return fmt.Errorf("reading %s: %w", *file, err)
+		}
+	}
+
+	connect()
+	return run(*dryRun, os.Stdout, func(tx *gorm.DB, s *admin.Summary) error {
+		return admin.SeedFacilities(tx, facilities, s)
+	})
+}
+
+func grantRole(ctx context.Context, fs *flag.FlagSet, dryRun *bool, args []string) error {
+	args = parse(fs, args, 3)
+
+	cid, err := strconv.ParseUint(args[0], 10, 32)
+	if err != nil {
+		return fmt.Errorf("invalid cid: %s", args[0])
+	}
+	role := constants.RoleID(strings.ToUpper(args[1]))
+	facility := strings.ToUpper(args[2])
+
+	connect()
+	return run(*dryRun, os.Stdout, func(tx *gorm.DB, s *admin.Summary) error {
+		return admin.GrantRole(tx, uint(cid), role, facility, s)
+	})
+}
+
+func importUsers(ctx context.Context, fs *flag.FlagSet, dryRun *bool, args []string) error {
+	args = parse(fs, args, 1)
+
+	file, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	connect()
+	return run(*dryRun, os.Stdout, func(tx *gorm.DB, s *admin.Summary) error {
+		return admin.ImportUsers(tx, file, s)
+	})
+}
+
+func exportRoster(ctx context.Context, fs *flag.FlagSet, dryRun *bool, args []string) error {
+	out := fs.String("out", "", "file to write (defaults to standard output)")
+	args = parse(fs, args, 1)
+	facility := strings.ToUpper(args[0])
+
+	// The summary goes to standard error when the roster is written to standard output
+	var w io.Writer = os.Stdout
+	summary := io.Writer(os.Stderr)
+	switch {
+	case *dryRun:
+		w, summary = io.Discard, os.Stdout
+	case *out != "":
+		file, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w, summary = file, os.Stdout
+	}
+
+	connect()
+	return run(*dryRun, summary, func(tx *gorm.DB, s *admin.Summary) error {
+		if err := tx.Where("id = ?", facility).First(&models.Facility{}).Error; err != nil {
+			return fmt.Errorf("unknown facility %s: %w", facility, err)
+		}
+		return admin.ExportRoster(tx, facility, w, s)
+	})
+}
+
+func syncVATSIM(ctx context.Context, fs *flag.FlagSet, dryRun *bool, args []string) error {
+	parse(fs, args, 0)
+
+	cfg := connect()
+	if cfg.VATSIM.APIKey == "" {
+		return errors.New("VATSIM_API_KEY is not set")
+	}
+	syncer := vatsim.NewSyncer(vatsim.NewClient(cfg.VATSIM.APIURL, cfg.VATSIM.APIKey), cfg.VATSIM.Division)
+
+	return run(*dryRun, os.Stdout, func(tx *gorm.DB, s *admin.Summary) error {
+		return admin.SyncVATSIM(ctx, syncer, time.Now(), s)
+	})
+}
+
+func sweepSolos(ctx context.Context, fs *flag.FlagSet, dryRun *bool, args []string) error {
+	parse(fs, args, 0)
+
+	connect()
+	return run(*dryRun, os.Stdout, func(tx *gorm.DB, s *admin.Summary) error {
+		expired, err := roster.SweepSoloCertifications(ctx, service.New(tx).Roster, time.Now())
+		for _, cert := range expired {
+			s.Add(admin.Removed, "%s %s solo certification for %d at %s", cert.Position, cert.Type, cert.CID, cert.Facility)
+		}
+		return err
+	})
+}
+
+func purgeInactive(ctx context.Context, fs *flag.FlagSet, dryRun *bool, args []string) error {
+	cfg := connect()
+	days := fs.Int("days", cfg.Activity.InactiveDays, "how many days back to look")
+	hours := fs.Float64("hours", cfg.Activity.InactiveHours, "fewest hours a member must have controlled in that time")
+	visiting := fs.Bool("include-visiting", false, "also remove inactive visiting controllers")
+	args = parse(fs, args, 1)
+	facility := strings.ToUpper(args[0])
+
+	th := activity.Thresholds{
+		Window:          time.Duration(*days) * 24 * time.Hour,
+		MinHours:        *hours,
+		IncludeVisiting: *visiting,
+	}
+
+	return run(*dryRun, os.Stdout, func(tx *gorm.DB, s *admin.Summary) error {
+		return admin.PurgeInactive(facility, th, time.Now(), s)
+	})
+}
+
+func rotateAPIKey(ctx context.Context, fs *flag.FlagSet, dryRun *bool, args []string) error {
+	args = parse(fs, args, 1)
+	facility := strings.ToUpper(args[0])
+
+	connect()
+	var key string
+	err := run(*dryRun, os.Stdout, func(tx *gorm.DB, s *admin.Summary) error {
+		var err error
+		key, err = admin.RotateAPIKey(tx, facility, s)
+		return err
+	})
+	if err != nil || *dryRun {
+		return err
+	}
+
+	fmt.Println("\nThe new key is shown once. Store it now:")
+	fmt.Println(key)
+	return nil
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/VATUSA/primary-api/pkg/admin"
+	"github.com/VATUSA/primary-api/pkg/config"
+	"github.com/VATUSA/primary-api/pkg/database"
+	"github.com/joho/godotenv"
+	"gorm.io/gorm"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// command is a vatusa-admin subcommand. run is given the command's flag set, with --dry-run already defined,
+// so it can add its own flags before parsing args.
+type command struct {
+	name  string
+	args  string
+	about string
+	run   func(ctx context.Context, fs *flag.FlagSet, dryRun *bool, args []string) error
+}
+
+var commands = []command{
+	{"seed-facilities", "[--file facilities.json]", "create or rename the division's facilities", seedFacilities},
+	{"grant-role", "<cid> <role> <facility>", "give a member a role, creating the member if needed", grantRole},
+	{"import-users", "<users.csv>", "create or update users from a CSV file", importUsers},
+	{"export-roster", "[--out roster.csv] <facility>", "write a facility's roster as CSV", exportRoster},
+	{"sync-vatsim", "", "run the VATSIM membership sync now", syncVATSIM},
+	{"sweep-solos", "", "remove expired solo certifications now", sweepSolos},
+	{"purge-inactive", "[--days n] [--hours n] [--include-visiting] <facility>", "remove inactive members from a facility's roster", purgeInactive},
+	{"rotate-api-key", "<facility>", "issue a facility a new API key, replacing its current one", rotateAPIKey},
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: vatusa-admin <command> [--dry-run] [flags] [args]")
+	fmt.Fprintln(os.Stderr, "\nEvery command takes --dry-run, which prints the changes it would make without saving them.")
+	fmt.Fprintln(os.Stderr, "\nCommands:")
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %-16s %s\n  %-16s %s\n", c.name, c.about, "", c.args)
+	}
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	for _, c := range commands {
+		if c.name != os.Args[1] {
+			continue
+		}
+
+		fs := flag.NewFlagSet(c.name, flag.ExitOnError)
+		fs.Usage = func() {
+			fmt.Fprintf(os.Stderr, "Usage: vatusa-admin %s [--dry-run] %s\n", c.name, c.args)
+			fs.PrintDefaults()
+		}
+		dryRun := fs.Bool("dry-run", false, "print the changes without saving them")
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		err := c.run(ctx, fs, dryRun, os.Args[2:])
+		stop()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[Admin] %s: %v\n", c.name, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	usage()
+	os.Exit(2)
+}
+
+// parse parses args, exiting with the command's usage unless exactly want positional arguments are left
+func parse(fs *flag.FlagSet, args []string, want int) []string {
+	fs.Parse(args)
+	if fs.NArg() != want {
+		fs.Usage()
+		os.Exit(2)
+	}
+	return fs.Args()
+}
+
+// connect loads the API's configuration and connects to its database
+func connect() *config.Config {
+	_ = godotenv.Load(".env")
+	cfg := config.New()
+	database.DB = database.Connect(cfg.Database)
+	return cfg
+}
+
+// run runs task through admin.Run and prints its summary to w
+func run(dryRun bool, w io.Writer, task func(tx *gorm.DB, s *admin.Summary) error) error {
+	s := &admin.Summary{}
+	err := admin.Run(database.DB, dryRun, func(tx *gorm.DB) error {
+		return task(tx, s)
+	})
+	if err != nil {
+		return err
+	}
+	s.Print(w, dryRun)
+	return nil
+}
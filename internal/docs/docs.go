@@ -616,7 +616,7 @@ const docTemplate = `{
             "post": {
                 "description": "Create a new document",
                 "consumes": [
-                    "application/json"
+                    "multipart/form-data"
                 ],
                 "produces": [
                     "application/json"
@@ -627,19 +627,51 @@ const docTemplate = `{
                 "summary": "Create a new document",
                 "parameters": [
                     {
-                        "description": "Document",
-                        "name": "document",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/document.Request"
-                        }
+                        "type": "string",
+                        "description": "Facility",
+                        "name": "facility",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Name",
+                        "name": "name",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Description",
+                        "name": "description",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Category",
+                        "name": "category",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Visibility, defaulting to the category's",
+                        "name": "visibility",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Description of the first version",
+                        "name": "change_note",
+                        "in": "formData"
                     },
                     {
                         "type": "file",
                         "description": "Document file",
                         "name": "file",
-                        "in": "formData"
+                        "in": "formData",
+                        "required": true
                     }
                 ],
                 "responses": {
@@ -655,6 +687,18 @@ const docTemplate = `{
                             "$ref": "#/definitions/utils.ErrResponse"
                         }
                     },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/utils.ErrResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -1294,7 +1338,7 @@ const docTemplate = `{
         },
         "/faq": {
             "get": {
-                "description": "List all FAQs",
+                "description": "List all FAQs. Drafts are only included for the facilities the caller manages.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1370,7 +1414,7 @@ const docTemplate = `{
         },
         "/faq/{id}": {
             "get": {
-                "description": "Get a FAQ",
+                "description": "Get a FAQ. Drafts are only shown to the management of the FAQ's facility and division staff.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1834,7 +1878,7 @@ const docTemplate = `{
         },
         "/news": {
             "get": {
-                "description": "List all news entries",
+                "description": "List all news entries. Drafts, scheduled and expired news are only included for the facilities the caller is staff of.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1916,7 +1960,7 @@ const docTemplate = `{
         },
         "/news/{id}": {
             "get": {
-                "description": "Get a news entry",
+                "description": "Get a news entry. News that isn't live is only shown to the staff of its facility.",
                 "consumes": [
                     "application/json"
                 ],
@@ -4041,10 +4085,6 @@ const docTemplate = `{
                 "updated_by": {
                     "type": "integer",
                     "example": 1293257
-                },
-                "url": {
-                    "type": "string",
-                    "example": "https://zdvartcc.org"
                 }
             }
         },
@@ -4,6 +4,10 @@ import (
 	_ "github.com/VATUSA/primary-api/internal/docs"
 	v1 "github.com/VATUSA/primary-api/internal/v1"
 	"github.com/VATUSA/primary-api/pkg/config"
+	"github.com/VATUSA/primary-api/pkg/search"
+	"github.com/VATUSA/primary-api/pkg/service"
+	"github.com/VATUSA/primary-api/pkg/storage"
+	"github.com/VATUSA/primary-api/pkg/vatsim"
 	"github.com/go-chi/chi/v5"
 	httpSwagger "github.com/swaggo/http-swagger"
 )
@@ -24,9 +28,9 @@ import (
 // @BasePath  /internal/v1
 // @schemes http
 
-func Router(r chi.Router, cfg *config.Config) {
+func Router(r chi.Router, cfg *config.Config, services *service.Services, store storage.Storage, searcher search.Searcher, syncer *vatsim.Syncer) {
 	r.Route("/internal", func(r chi.Router) {
-		v1.Router(r, cfg)
+		v1.Router(r, cfg, services, store, searcher, syncer)
 
 		r.Get("/swagger/*", httpSwagger.Handler(
 			httpSwagger.URL("http://api.vatusa.local/internal/swagger/doc.json"),
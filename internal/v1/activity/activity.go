@@ -0,0 +1,417 @@
+package activity
+
+import (
+	"errors"
+	"fmt"
+	"github.com/VATUSA/primary-api/pkg/activity"
+	"github.com/VATUSA/primary-api/pkg/config"
+	"github.com/VATUSA/primary-api/pkg/database"
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/VATUSA/primary-api/pkg/utils"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultWindows are the rolling windows, in days, hours are totalled over unless others are asked for
+var DefaultWindows = []int{30, 60, 90}
+
+// MaxWindowDays is the longest window hours can be totalled over
+const MaxWindowDays = 365
+
+type WindowHours struct {
+	Days  int     `json:"days" example:"30"`
+	Hours float64 `json:"hours" example:"12.5"`
+}
+
+type MemberHours struct {
+	RosterID uint          `json:"roster_id" example:"1"`
+	CID      uint          `json:"cid" example:"1293257"`
+	Home     bool          `json:"home" example:"true"`
+	Visiting bool          `json:"visiting" example:"false"`
+	Windows  []WindowHours `json:"windows"`
+}
+
+type HoursResponse struct {
+	Facility string        `json:"facility" example:"ZDV"`
+	Members  []MemberHours `json:"members"`
+}
+
+func (res *HoursResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+type InactiveResponse struct {
+	Facility        string            `json:"facility" example:"ZDV"`
+	Days            int               `json:"days" example:"90"`
+	MinHours        float64           `json:"min_hours" example:"3"`
+	IncludeVisiting bool              `json:"include_visiting" example:"false"`
+	Members         []activity.Member `json:"members"`
+}
+
+func (res *InactiveResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+type PurgeRequest struct {
+	Days            int      `json:"days" example:"90" validate:"omitempty,min=1,max=365"`
+	MinHours        *float64 `json:"min_hours" example:"3" validate:"omitempty,min=0"`
+	IncludeVisiting bool     `json:"include_visiting" example:"false"`
+	RosterIDs       []uint   `json:"roster_ids" example:"1,2" validate:"required,min=1,unique"`
+}
+
+func (req *PurgeRequest) Validate() error {
+	return validator.New().Struct(req)
+}
+
+func (req *PurgeRequest) Bind(r *http.Request) error {
+	return nil
+}
+
+type PurgeResponse struct {
+	Removed []activity.Member `json:"removed"`
+	Skipped []uint            `json:"skipped" example:"2"` // Roster IDs that were not removed because they are no longer inactive
+}
+
+func (res *PurgeResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+type PrefixRequest struct {
+	Prefixes []string `json:"prefixes" example:"DEN,COS,ASE" validate:"unique,dive,required,max=8,alphanum"`
+}
+
+func (req *PrefixRequest) Validate() error {
+	return validator.New().Struct(req)
+}
+
+func (req *PrefixRequest) Bind(r *http.Request) error {
+	return nil
+}
+
+type PrefixResponse struct {
+	Facility string   `json:"facility" example:"ZDV"`
+	Prefixes []string `json:"prefixes" example:"ASE,COS,DEN"`
+}
+
+func (res *PrefixResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// canView renders the error response and returns false unless the caller is facility or division staff
+func canView(w http.ResponseWriter, r *http.Request, facility string) bool {
+	self := utils.GetSelf(r)
+	if self == nil {
+		render.Render(w, r, utils.ErrUnauthorized)
+		return false
+	}
+	if !self.IsDivisionStaff() && !self.HasFacilityRole(facility) {
+		render.Render(w, r, utils.ErrForbidden)
+		return false
+	}
+	return true
+}
+
+// canManage renders the error response and returns false unless the caller manages the facility
+func canManage(w http.ResponseWriter, r *http.Request, facility string) bool {
+	self := utils.GetSelf(r)
+	if self == nil {
+		render.Render(w, r, utils.ErrUnauthorized)
+		return false
+	}
+	if !self.ManagesFacility(facility) {
+		render.Render(w, r, utils.ErrForbidden)
+		return false
+	}
+	return true
+}
+
+// parseWindows reads a comma-separated list of window lengths in days
+func parseWindows(value string) ([]int, error) {
+	if value == "" {
+		return DefaultWindows, nil
+	}
+
+	var windows []int
+	for _, v := range strings.Split(value, ",") {
+		days, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil || days < 1 || days > MaxWindowDays {
+			return nil, fmt.Errorf("windows must be between 1 and %d days", MaxWindowDays)
+		}
+		windows = append(windows, days)
+	}
+	return windows, nil
+}
+
+func days(n int) time.Duration {
+	return time.Duration(n) * 24 * time.Hour
+}
+
+// ListHours godoc
+// @Summary List controlling hours
+// @Description List the hours each roster member controlled at the facility over rolling windows
+// @Tags activity
+// @Produce  json
+// @Param facility path string true "Facility"
+// @Param windows query string false "Comma-separated window lengths in days, defaults to 30,60,90"
+// @Success 200 {object} HoursResponse
+// @Failure 400 {object} utils.ErrResponse
+// @Failure 401 {object} utils.ErrResponse
+// @Failure 403 {object} utils.ErrResponse
+// @Failure 500 {object} utils.ErrResponse
+// @Router /activity/{facility}/hours [get]
+func ListHours(w http.ResponseWriter, r *http.Request) {
+	facility := GetFacilityCtx(r)
+	if !canView(w, r, facility) {
+		return
+	}
+
+	windows, err := parseWindows(r.URL.Query().Get("windows"))
+	if err != nil {
+		render.Render(w, r, utils.ErrInvalidRequest(err))
+		return
+	}
+
+	longest := 0
+	for _, d := range windows {
+		if d > longest {
+			longest = d
+		}
+	}
+
+	now := time.Now()
+	sessions, err := models.GetControllingSessionsSince(facility, now.Add(-days(longest)))
+	if err != nil {
+		render.Render(w, r, utils.ErrInternalServer)
+		return
+	}
+
+	rosters, err := models.GetAllRostersByFacility(database.DB, facility)
+	if err != nil {
+		render.Render(w, r, utils.ErrInternalServer)
+		return
+	}
+
+	totals := make([]map[uint]time.Duration, len(windows))
+	for i, d := range windows {
+		totals[i] = activity.Hours(sessions, now.Add(-days(d)), now)
+	}
+
+	res := &HoursResponse{Facility: facility, Members: []MemberHours{}}
+	for _, roster := range rosters {
+		m := MemberHours{RosterID: roster.ID, CID: roster.CID, Home: roster.Home, Visiting: roster.Visiting}
+		for i, d := range windows {
+			m.Windows = append(m.Windows, WindowHours{Days: d, Hours: totals[i][roster.CID].Hours()})
+		}
+		res.Members = append(res.Members, m)
+	}
+
+	render.Render(w, r, res)
+}
+
+// ListInactive godoc
+// @Summary List inactive members
+// @Description List the facility's roster members who controlled less than the minimum hours over the window. Members on LOA and members who joined within the window are left out.
+// @Tags activity
+// @Produce  json
+// @Param facility path string true "Facility"
+// @Param days query int false "Window in days, defaults to ACTIVITY_INACTIVE_DAYS"
+// @Param min_hours query number false "Minimum hours, defaults to ACTIVITY_INACTIVE_HOURS"
+// @Param include_visiting query bool false "Include visiting controllers"
+// @Success 200 {object} InactiveResponse
+// @Failure 400 {object} utils.ErrResponse
+// @Failure 401 {object} utils.ErrResponse
+// @Failure 403 {object} utils.ErrResponse
+// @Failure 500 {object} utils.ErrResponse
+// @Router /activity/{facility}/inactive [get]
+func ListInactive(w http.ResponseWriter, r *http.Request, cfg *config.ActivityConfig) {
+	facility := GetFacilityCtx(r)
+	if !canView(w, r, facility) {
+		return
+	}
+
+	res := &InactiveResponse{
+		Facility:        facility,
+		Days:            cfg.InactiveDays,
+		MinHours:        cfg.InactiveHours,
+		IncludeVisiting: r.URL.Query().Get("include_visiting") == "true",
+	}
+
+	if v := r.URL.Query().Get("days"); v != "" {
+		d, err := strconv.Atoi(v)
+		if err != nil || d < 1 || d > MaxWindowDays {
+			render.Render(w, r, utils.ErrInvalidRequest(fmt.Errorf("days must be between 1 and %d", MaxWindowDays)))
+			return
+		}
+		res.Days = d
+	}
+
+	if v := r.URL.Query().Get("min_hours"); v != "" {
+		h, err := strconv.ParseFloat(v, 64)
+		if err != nil || h < 0 {
+			render.Render(w, r, utils.ErrInvalidRequest(errors.New("min_hours must be a positive number")))
+			return
+		}
+		res.MinHours = h
+	}
+
+	th := activity.Thresholds{Window: days(res.Days), MinHours: res.MinHours, IncludeVisiting: res.IncludeVisiting}
+	_, members, err := activity.FindInactive(facility, th, time.Now())
+	if err != nil {
+		render.Render(w, r, utils.ErrInternalServer)
+		return
+	}
+	res.Members = members
+
+	render.Render(w, r, res)
+}
+
+// PurgeInactive godoc
+// @Summary Remove inactive members
+// @Description Remove the given roster entries from the facility. The report is worked out again with the given thresholds, and only members it still lists are removed, so a report that has gone stale can't remove someone who has since controlled.
+// @Tags activity
+// @Accept  json
+// @Produce  json
+// @Param facility path string true "Facility"
+// @Param purge body PurgeRequest true "Purge"
+// @Success 200 {object} PurgeResponse
+// @Failure 400 {object} utils.ErrResponse
+// @Failure 401 {object} utils.ErrResponse
+// @Failure 403 {object} utils.ErrResponse
+// @Failure 500 {object} utils.ErrResponse
+// @Router /activity/{facility}/inactive/purge [post]
+func PurgeInactive(w http.ResponseWriter, r *http.Request, cfg *config.ActivityConfig) {
+	facility := GetFacilityCtx(r)
+	if !canManage(w, r, facility) {
+		return
+	}
+
+	data := &PurgeRequest{}
+	if err := render.Bind(r, data); err != nil {
+		render.Render(w, r, utils.ErrInvalidRequest(err))
+		return
+	}
+
+	if err := data.Validate(); err != nil {
+		render.Render(w, r, utils.ErrInvalidRequest(err))
+		return
+	}
+
+	th := activity.Thresholds{Window: days(cfg.InactiveDays), MinHours: cfg.InactiveHours, IncludeVisiting: data.IncludeVisiting}
+	if data.Days != 0 {
+		th.Window = days(data.Days)
+	}
+	if data.MinHours != nil {
+		th.MinHours = *data.MinHours
+	}
+
+	rosters, members, err := activity.FindInactive(facility, th, time.Now())
+	if err != nil {
+		render.Render(w, r, utils.ErrInternalServer)
+		return
+	}
+
+	byID := map[uint]models.Roster{}
+	for _, roster := range rosters {
+		byID[roster.ID] = roster
+	}
+	eligible := map[uint]activity.Member{}
+	for _, m := range members {
+		eligible[m.RosterID] = m
+	}
+
+	res := &PurgeResponse{Removed: []activity.Member{}, Skipped: []uint{}}
+	for _, id := range data.RosterIDs {
+		m, ok := eligible[id]
+		if !ok {
+			res.Skipped = append(res.Skipped, id)
+			continue
+		}
+
+		roster := byID[id]
+		if err := roster.Delete(); err != nil {
+			render.Render(w, r, utils.ErrInternalServer)
+			return
+		}
+		res.Removed = append(res.Removed, m)
+	}
+
+	render.Render(w, r, res)
+}
+
+// ListPrefixes godoc
+// @Summary List callsign prefixes
+// @Description List the callsign prefixes whose sessions count towards the facility
+// @Tags activity
+// @Produce  json
+// @Param facility path string true "Facility"
+// @Success 200 {object} PrefixResponse
+// @Failure 400 {object} utils.ErrResponse
+// @Failure 500 {object} utils.ErrResponse
+// @Router /activity/{facility}/prefixes [get]
+func ListPrefixes(w http.ResponseWriter, r *http.Request) {
+	facility := GetFacilityCtx(r)
+
+	prefixes, err := models.GetCallsignPrefixesByFacility(facility)
+	if err != nil {
+		render.Render(w, r, utils.ErrInternalServer)
+		return
+	}
+
+	res := &PrefixResponse{Facility: facility, Prefixes: []string{}}
+	for _, p := range prefixes {
+		res.Prefixes = append(res.Prefixes, p.Prefix)
+	}
+
+	render.Render(w, r, res)
+}
+
+// SetPrefixes godoc
+// @Summary Set callsign prefixes
+// @Description Replace the callsign prefixes whose sessions count towards the facility. Prefixes held by another facility can only be moved to this one by division staff.
+// @Tags activity
+// @Accept  json
+// @Produce  json
+// @Param facility path string true "Facility"
+// @Param prefixes body PrefixRequest true "Prefixes"
+// @Success 200 {object} PrefixResponse
+// @Failure 400 {object} utils.ErrResponse
+// @Failure 401 {object} utils.ErrResponse
+// @Failure 403 {object} utils.ErrResponse
+// @Failure 409 {object} utils.ErrResponse
+// @Failure 500 {object} utils.ErrResponse
+// @Router /activity/{facility}/prefixes [put]
+func SetPrefixes(w http.ResponseWriter, r *http.Request) {
+	facility := GetFacilityCtx(r)
+	if !canManage(w, r, facility) {
+		return
+	}
+
+	data := &PrefixRequest{}
+	if err := render.Bind(r, data); err != nil {
+		render.Render(w, r, utils.ErrInvalidRequest(err))
+		return
+	}
+
+	if err := data.Validate(); err != nil {
+		render.Render(w, r, utils.ErrInvalidRequest(err))
+		return
+	}
+
+	// Only division staff can take a prefix away from another facility
+	var taken *models.PrefixTakenError
+	err := models.SetCallsignPrefixes(facility, data.Prefixes, utils.GetSelf(r).IsDivisionStaff())
+	if errors.As(err, &taken) {
+		render.Render(w, r, utils.ErrConflict(err))
+		return
+	}
+	if err != nil {
+		render.Render(w, r, utils.ErrInternalServer)
+		return
+	}
+
+	ListPrefixes(w, r)
+}
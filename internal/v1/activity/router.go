@@ -0,0 +1,44 @@
+package activity
+
+import (
+	"context"
+	"github.com/VATUSA/primary-api/pkg/config"
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/VATUSA/primary-api/pkg/utils"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"net/http"
+	"strings"
+)
+
+func Router(r chi.Router, cfg *config.ActivityConfig) {
+	r.Route("/{Facility}", func(r chi.Router) {
+		r.Use(Ctx)
+		r.Get("/hours", ListHours)
+		r.Get("/inactive", func(w http.ResponseWriter, r *http.Request) {
+			ListInactive(w, r, cfg)
+		})
+		r.Post("/inactive/purge", func(w http.ResponseWriter, r *http.Request) {
+			PurgeInactive(w, r, cfg)
+		})
+		r.Get("/prefixes", ListPrefixes)
+		r.Put("/prefixes", SetPrefixes)
+	})
+}
+
+func Ctx(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		facility := strings.ToUpper(chi.URLParam(r, "Facility"))
+		if !models.IsValidFacility(facility) {
+			render.Render(w, r, utils.ErrInvalidFacility)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), "facility", facility)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func GetFacilityCtx(r *http.Request) string {
+	return r.Context().Value("facility").(string)
+}
@@ -0,0 +1,37 @@
+package admin
+
+import (
+	"github.com/VATUSA/primary-api/pkg/utils"
+	"github.com/VATUSA/primary-api/pkg/vatsim"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"net/http"
+)
+
+// Router mounts the division staff endpoints. syncer is nil when the VATSIM sync isn't configured.
+func Router(r chi.Router, syncer *vatsim.Syncer) {
+	r.Use(Ctx)
+	r.Get("/sync", func(w http.ResponseWriter, r *http.Request) {
+		GetSyncStatus(w, r, syncer)
+	})
+	r.Post("/sync", func(w http.ResponseWriter, r *http.Request) {
+		StartSync(w, r, syncer)
+	})
+}
+
+// Ctx limits every admin endpoint to division staff
+func Ctx(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		self := utils.GetSelf(r)
+		if self == nil {
+			render.Render(w, r, utils.ErrUnauthorized)
+			return
+		}
+		if !self.IsDivisionStaff() {
+			render.Render(w, r, utils.ErrForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,62 @@
+package admin
+
+import (
+	"errors"
+	"github.com/VATUSA/primary-api/pkg/utils"
+	"github.com/VATUSA/primary-api/pkg/vatsim"
+	"github.com/go-chi/render"
+	"net/http"
+)
+
+type SyncStatusResponse struct {
+	vatsim.Status
+}
+
+func (res *SyncStatusResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// GetSyncStatus godoc
+// @Summary Get VATSIM sync status
+// @Description Get the state of the current or most recent VATSIM membership sync
+// @Tags admin
+// @Produce  json
+// @Success 200 {object} SyncStatusResponse
+// @Failure 401 {object} utils.ErrResponse
+// @Failure 403 {object} utils.ErrResponse
+// @Failure 503 {object} utils.ErrResponse
+// @Router /admin/sync [get]
+func GetSyncStatus(w http.ResponseWriter, r *http.Request, syncer *vatsim.Syncer) {
+	if syncer == nil {
+		render.Render(w, r, utils.ErrUnavailable)
+		return
+	}
+
+	render.Render(w, r, &SyncStatusResponse{Status: syncer.Status()})
+}
+
+// StartSync godoc
+// @Summary Start a VATSIM sync
+// @Description Start a VATSIM membership sync now rather than waiting for the next scheduled one. The sync is run by the server's sync worker and stops when the server shuts down.
+// @Tags admin
+// @Produce  json
+// @Success 202 {object} SyncStatusResponse
+// @Failure 401 {object} utils.ErrResponse
+// @Failure 403 {object} utils.ErrResponse
+// @Failure 409 {object} utils.ErrResponse
+// @Failure 503 {object} utils.ErrResponse
+// @Router /admin/sync [post]
+func StartSync(w http.ResponseWriter, r *http.Request, syncer *vatsim.Syncer) {
+	if syncer == nil {
+		render.Render(w, r, utils.ErrUnavailable)
+		return
+	}
+
+	if !syncer.Trigger() {
+		render.Render(w, r, utils.ErrConflict(errors.New("a sync is already running")))
+		return
+	}
+
+	render.Status(r, http.StatusAccepted)
+	render.Render(w, r, &SyncStatusResponse{Status: syncer.Status()})
+}
@@ -59,7 +59,7 @@ func NewDisciplinaryLogEntryListResponse(dle []models.DisciplinaryLogEntry) []re
 // @Router /disciplinary-log [post]
 func CreateDisciplinaryLogEntry(w http.ResponseWriter, r *http.Request) {
 	data := &Request{}
-	if err := data.Bind(r); err != nil {
+	if err := render.Bind(r, data); err != nil {
 		render.Render(w, r, utils.ErrInvalidRequest(err))
 		return
 	}
@@ -150,7 +150,7 @@ func ListDisciplinaryLog(w http.ResponseWriter, r *http.Request) {
 func UpdateDisciplinaryLog(w http.ResponseWriter, r *http.Request) {
 	dle := GetDisciplinaryLogCtx(r)
 	data := &Request{}
-	if err := data.Bind(r); err != nil {
+	if err := render.Bind(r, data); err != nil {
 		render.Render(w, r, utils.ErrInvalidRequest(err))
 		return
 	}
@@ -195,7 +195,7 @@ func UpdateDisciplinaryLog(w http.ResponseWriter, r *http.Request) {
 func PatchDisciplinaryLog(w http.ResponseWriter, r *http.Request) {
 	dle := GetDisciplinaryLogCtx(r)
 	data := &Request{}
-	if err := data.Bind(r); err != nil {
+	if err := render.Bind(r, data); err != nil {
 		render.Render(w, r, utils.ErrInvalidRequest(err))
 		return
 	}
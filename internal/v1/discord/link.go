@@ -0,0 +1,131 @@
+package discord
+
+import (
+	"errors"
+	"github.com/VATUSA/primary-api/pkg/database"
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/VATUSA/primary-api/pkg/discord"
+	"github.com/VATUSA/primary-api/pkg/utils"
+	"github.com/go-chi/render"
+	"log"
+	"net/http"
+	"time"
+)
+
+type LinkResponse struct {
+	CID       uint   `json:"cid" example:"1293257"`
+	DiscordID string `json:"discord_id" example:"1234567890"`
+	Username  string `json:"username,omitempty" example:"raaj"`
+}
+
+func (res *LinkResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// StartLink godoc
+// @Summary Link a Discord account
+// @Description Redirect to Discord to approve linking the caller's Discord account
+// @Tags discord
+// @Success 302
+// @Failure 401 {object} utils.ErrResponse
+// @Router /discord/link [get]
+func StartLink(w http.ResponseWriter, r *http.Request, client *discord.Client, secret string) {
+	self := utils.GetSelf(r)
+	if self == nil {
+		render.Render(w, r, utils.ErrUnauthorized)
+		return
+	}
+
+	http.Redirect(w, r, client.AuthorizeURL(discord.SignState(secret, self.CID, time.Now())), http.StatusFound)
+}
+
+// FinishLink godoc
+// @Summary Finish linking a Discord account
+// @Description The Discord OAuth callback. Links the Discord account to the user who started the flow.
+// @Tags discord
+// @Produce  json
+// @Param code query string true "Authorization code"
+// @Param state query string true "State from the link request"
+// @Success 200 {object} LinkResponse
+// @Failure 400 {object} utils.ErrResponse
+// @Failure 409 {object} utils.ErrResponse
+// @Failure 500 {object} utils.ErrResponse
+// @Router /discord/callback [get]
+func FinishLink(w http.ResponseWriter, r *http.Request, client *discord.Client, secret string) {
+	if reason := r.URL.Query().Get("error"); reason != "" {
+		render.Render(w, r, utils.ErrInvalidRequest(errors.New("discord: "+reason)))
+		return
+	}
+
+	cid, err := discord.VerifyState(secret, r.URL.Query().Get("state"), time.Now())
+	if err != nil {
+		render.Render(w, r, utils.ErrInvalidRequest(err))
+		return
+	}
+
+	token, err := client.Exchange(r.Context(), r.URL.Query().Get("code"))
+	if errors.Is(err, discord.ErrInvalidGrant) {
+		render.Render(w, r, utils.ErrInvalidRequest(err))
+		return
+	}
+	if err != nil {
+		log.Println("[Discord] Error exchanging code:", err)
+		render.Render(w, r, utils.ErrInternalServer)
+		return
+	}
+
+	account, err := client.CurrentUser(r.Context(), token)
+	if err != nil {
+		log.Println("[Discord] Error fetching user:", err)
+		render.Render(w, r, utils.ErrInternalServer)
+		return
+	}
+
+	linked, err := models.IsDiscordLinked(account.ID, cid)
+	if err != nil {
+		render.Render(w, r, utils.ErrInternalServer)
+		return
+	}
+	if linked {
+		render.Render(w, r, utils.ErrConflict(errors.New("that Discord account is linked to another user")))
+		return
+	}
+
+	user := &models.User{CID: cid}
+	if err := user.Get(); err != nil {
+		render.Render(w, r, utils.ErrInvalidCID)
+		return
+	}
+
+	user.DiscordID = account.ID
+	if err := user.Update(); err != nil {
+		render.Render(w, r, utils.ErrInternalServer)
+		return
+	}
+
+	render.Render(w, r, &LinkResponse{CID: user.CID, DiscordID: account.ID, Username: account.Username})
+}
+
+// Unlink godoc
+// @Summary Unlink a Discord account
+// @Description Remove the link between the caller and their Discord account
+// @Tags discord
+// @Success 204
+// @Failure 401 {object} utils.ErrResponse
+// @Failure 500 {object} utils.ErrResponse
+// @Router /discord/link [delete]
+func Unlink(w http.ResponseWriter, r *http.Request) {
+	self := utils.GetSelf(r)
+	if self == nil {
+		render.Render(w, r, utils.ErrUnauthorized)
+		return
+	}
+
+	// Only the one column, since self is what the gateway sent rather than the stored user
+	if err := database.DB.Model(&models.User{}).Where("c_id = ?", self.CID).Update("discord_id", "").Error; err != nil {
+		render.Render(w, r, utils.ErrInternalServer)
+		return
+	}
+
+	render.Status(r, http.StatusNoContent)
+}
@@ -0,0 +1,249 @@
+package discord
+
+import (
+	"errors"
+	"github.com/VATUSA/primary-api/pkg/constants"
+	"github.com/VATUSA/primary-api/pkg/database"
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/VATUSA/primary-api/pkg/discord"
+	"github.com/VATUSA/primary-api/pkg/utils"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+	"net/http"
+)
+
+type MappingRequest struct {
+	DiscordRoleID string           `json:"discord_role_id" example:"485491681903247362" validate:"required,numeric,max=32"`
+	Facility      string           `json:"facility" example:"ZDV" validate:"omitempty,len=3"`
+	HomeOnly      bool             `json:"home_only" example:"false"`
+	RoleID        constants.RoleID `json:"role_id" example:"ATM"`
+	Rating        *uint            `json:"rating" example:"5" validate:"omitempty,max=12"`
+}
+
+func (req *MappingRequest) Validate() error {
+	return validator.New().Struct(req)
+}
+
+func (req *MappingRequest) Bind(r *http.Request) error {
+	return nil
+}
+
+type MappingResponse struct {
+	*models.DiscordRoleMapping
+}
+
+func NewMappingResponse(m *models.DiscordRoleMapping) *MappingResponse {
+	return &MappingResponse{DiscordRoleMapping: m}
+}
+
+func (res *MappingResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	if res.DiscordRoleMapping == nil {
+		return errors.New("role mapping not found")
+	}
+	return nil
+}
+
+func NewMappingListResponse(mappings []models.DiscordRoleMapping) []render.Renderer {
+	list := []render.Renderer{}
+	for _, m := range mappings {
+		list = append(list, NewMappingResponse(&m))
+	}
+	return list
+}
+
+type RolesResponse struct {
+	DiscordID string   `json:"discord_id" example:"1234567890"`
+	CID       uint     `json:"cid" example:"1293257"` // 0 if the account isn't linked
+	Roles     []string `json:"roles" example:"485491681903247362"`
+	Managed   []string `json:"managed" example:"485491681903247362,485491681903247363"` // Roles the bot should remove unless listed in roles
+}
+
+func (res *RolesResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// applyMapping checks the request and copies it onto the mapping, rendering the error response if it's rejected
+func applyMapping(w http.ResponseWriter, r *http.Request, mapping *models.DiscordRoleMapping) bool {
+	data := &MappingRequest{}
+	if err := render.Bind(r, data); err != nil {
+		render.Render(w, r, utils.ErrInvalidRequest(err))
+		return false
+	}
+
+	if err := data.Validate(); err != nil {
+		render.Render(w, r, utils.ErrInvalidRequest(err))
+		return false
+	}
+
+	if data.Facility != "" && !models.IsValidFacility(data.Facility) {
+		render.Render(w, r, utils.ErrInvalidFacility)
+		return false
+	}
+
+	if data.RoleID != "" && !data.RoleID.IsValidRole() {
+		render.Render(w, r, utils.ErrInvalidRole)
+		return false
+	}
+
+	if data.HomeOnly && (data.Facility == "" || data.RoleID != "") {
+		render.Render(w, r, utils.ErrInvalidRequest(errors.New("home_only needs a facility and no role")))
+		return false
+	}
+
+	mapping.GuildID = chi.URLParam(r, "GuildID")
+	mapping.DiscordRoleID = data.DiscordRoleID
+	mapping.Facility = data.Facility
+	mapping.HomeOnly = data.HomeOnly
+	mapping.RoleID = data.RoleID
+	mapping.Rating = data.Rating
+	return true
+}
+
+// ListMappings godoc
+// @Summary List Discord role mappings
+// @Description List the role mappings for a guild
+// @Tags discord
+// @Produce  json
+// @Param guild_id path string true "Guild ID"
+// @Success 200 {object} []MappingResponse
+// @Failure 401 {object} utils.ErrResponse
+// @Failure 403 {object} utils.ErrResponse
+// @Failure 500 {object} utils.ErrResponse
+// @Router /discord/guilds/{guild_id}/mappings [get]
+func ListMappings(w http.ResponseWriter, r *http.Request) {
+	mappings, err := models.GetDiscordRoleMappings(chi.URLParam(r, "GuildID"))
+	if err != nil {
+		render.Render(w, r, utils.ErrInternalServer)
+		return
+	}
+
+	if err := render.RenderList(w, r, NewMappingListResponse(mappings)); err != nil {
+		render.Render(w, r, utils.ErrRender(err))
+		return
+	}
+}
+
+// CreateMapping godoc
+// @Summary Create a Discord role mapping
+// @Description Grant a Discord role to linked users who meet the mapping's conditions: a roster facility, a role (optionally at a facility) and a controller rating. Conditions left empty aren't checked.
+// @Tags discord
+// @Accept  json
+// @Produce  json
+// @Param guild_id path string true "Guild ID"
+// @Param mapping body MappingRequest true "Role Mapping"
+// @Success 201 {object} MappingResponse
+// @Failure 400 {object} utils.ErrResponse
+// @Failure 401 {object} utils.ErrResponse
+// @Failure 403 {object} utils.ErrResponse
+// @Failure 500 {object} utils.ErrResponse
+// @Router /discord/guilds/{guild_id}/mappings [post]
+func CreateMapping(w http.ResponseWriter, r *http.Request) {
+	mapping := &models.DiscordRoleMapping{}
+	if !applyMapping(w, r, mapping) {
+		return
+	}
+
+	if err := mapping.Create(); err != nil {
+		render.Render(w, r, utils.ErrInternalServer)
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.Render(w, r, NewMappingResponse(mapping))
+}
+
+// UpdateMapping godoc
+// @Summary Update a Discord role mapping
+// @Description Update a Discord role mapping
+// @Tags discord
+// @Accept  json
+// @Produce  json
+// @Param guild_id path string true "Guild ID"
+// @Param id path int true "Mapping ID"
+// @Param mapping body MappingRequest true "Role Mapping"
+// @Success 200 {object} MappingResponse
+// @Failure 400 {object} utils.ErrResponse
+// @Failure 401 {object} utils.ErrResponse
+// @Failure 403 {object} utils.ErrResponse
+// @Failure 404 {object} utils.ErrResponse
+// @Failure 500 {object} utils.ErrResponse
+// @Router /discord/guilds/{guild_id}/mappings/{id} [put]
+func UpdateMapping(w http.ResponseWriter, r *http.Request) {
+	mapping := GetMappingCtx(r)
+	if !applyMapping(w, r, mapping) {
+		return
+	}
+
+	if err := mapping.Update(); err != nil {
+		render.Render(w, r, utils.ErrInternalServer)
+		return
+	}
+
+	render.Render(w, r, NewMappingResponse(mapping))
+}
+
+// DeleteMapping godoc
+// @Summary Delete a Discord role mapping
+// @Description Delete a Discord role mapping. The bot stops managing the role once no mapping uses it.
+// @Tags discord
+// @Param guild_id path string true "Guild ID"
+// @Param id path int true "Mapping ID"
+// @Success 204
+// @Failure 401 {object} utils.ErrResponse
+// @Failure 403 {object} utils.ErrResponse
+// @Failure 404 {object} utils.ErrResponse
+// @Failure 500 {object} utils.ErrResponse
+// @Router /discord/guilds/{guild_id}/mappings/{id} [delete]
+func DeleteMapping(w http.ResponseWriter, r *http.Request) {
+	mapping := GetMappingCtx(r)
+
+	if err := mapping.Delete(); err != nil {
+		render.Render(w, r, utils.ErrInternalServer)
+		return
+	}
+
+	render.Status(r, http.StatusNoContent)
+}
+
+// GetMemberRoles godoc
+// @Summary Get a Discord member's roles
+// @Description For the role bot: the roles a Discord user should hold in the guild, and every role the guild's mappings manage. Requires "Authorization: Bot <secret>".
+// @Tags discord
+// @Produce  json
+// @Param guild_id path string true "Guild ID"
+// @Param discord_id path string true "Discord user ID"
+// @Success 200 {object} RolesResponse
+// @Failure 401 {object} utils.ErrResponse
+// @Failure 500 {object} utils.ErrResponse
+// @Router /discord/guilds/{guild_id}/members/{discord_id}/roles [get]
+func GetMemberRoles(w http.ResponseWriter, r *http.Request) {
+	discordID := chi.URLParam(r, "DiscordID")
+
+	mappings, err := models.GetDiscordRoleMappings(chi.URLParam(r, "GuildID"))
+	if err != nil {
+		render.Render(w, r, utils.ErrInternalServer)
+		return
+	}
+
+	res := &RolesResponse{DiscordID: discordID}
+
+	var rosters []models.Roster
+	user := &models.User{DiscordID: discordID}
+	if err := user.Get(); errors.Is(err, gorm.ErrRecordNotFound) {
+		user = nil
+	} else if err != nil {
+		render.Render(w, r, utils.ErrInternalServer)
+		return
+	} else {
+		res.CID = user.CID
+		if rosters, err = models.GetAllRostersByCID(database.DB, user.CID); err != nil {
+			render.Render(w, r, utils.ErrInternalServer)
+			return
+		}
+	}
+
+	res.Roles, res.Managed = discord.Roles(mappings, user, rosters)
+	render.Render(w, r, res)
+}
@@ -0,0 +1,106 @@
+package discord
+
+import (
+	"context"
+	"crypto/subtle"
+	"github.com/VATUSA/primary-api/pkg/config"
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/VATUSA/primary-api/pkg/discord"
+	"github.com/VATUSA/primary-api/pkg/utils"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+func Router(r chi.Router, cfg *config.DiscordConfig) {
+	client := discord.NewClient(cfg)
+
+	// Without a secret the OAuth state could be forged to link someone else's account, so linking stays off
+	if cfg.StateSecret != "" {
+		r.Get("/link", func(w http.ResponseWriter, r *http.Request) {
+			StartLink(w, r, client, cfg.StateSecret)
+		})
+		r.Get("/callback", func(w http.ResponseWriter, r *http.Request) {
+			FinishLink(w, r, client, cfg.StateSecret)
+		})
+	} else {
+		log.Println("[Discord] DISCORD_STATE_SECRET is not set, account linking is disabled")
+	}
+	r.Delete("/link", Unlink)
+
+	r.Route("/guilds/{GuildID}", func(r chi.Router) {
+		r.Group(func(r chi.Router) {
+			r.Use(BotCtx(cfg.BotSecret))
+			r.Get("/members/{DiscordID}/roles", GetMemberRoles)
+		})
+
+		r.Route("/mappings", func(r chi.Router) {
+			r.Use(StaffCtx)
+			r.Get("/", ListMappings)
+			r.Post("/", CreateMapping)
+			r.Route("/{MappingID}", func(r chi.Router) {
+				r.Use(Ctx)
+				r.Put("/", UpdateMapping)
+				r.Delete("/", DeleteMapping)
+			})
+		})
+	})
+}
+
+// BotCtx only lets through requests carrying the bot's shared secret as "Authorization: Bot <secret>"
+func BotCtx(secret string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bot ")
+			if secret == "" || !ok || subtle.ConstantTimeCompare([]byte(token), []byte(secret)) != 1 {
+				render.Render(w, r, utils.ErrUnauthorized)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// StaffCtx limits role mappings to division staff
+func StaffCtx(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		self := utils.GetSelf(r)
+		if self == nil {
+			render.Render(w, r, utils.ErrUnauthorized)
+			return
+		}
+		if !self.IsDivisionStaff() {
+			render.Render(w, r, utils.ErrForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func Ctx(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		MappingID, err := strconv.ParseUint(chi.URLParam(r, "MappingID"), 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		mapping := &models.DiscordRoleMapping{ID: uint(MappingID)}
+		if err := mapping.Get(); err != nil || mapping.GuildID != chi.URLParam(r, "GuildID") {
+			http.Error(w, "Not found", http.StatusNotFound)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), "discordRoleMapping", mapping)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func GetMappingCtx(r *http.Request) *models.DiscordRoleMapping {
+	return r.Context().Value("discordRoleMapping").(*models.DiscordRoleMapping)
+}
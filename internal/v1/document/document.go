@@ -6,13 +6,14 @@ import (
 	"github.com/VATUSA/primary-api/pkg/database/models"
 	"github.com/VATUSA/primary-api/pkg/database/types"
 	"github.com/VATUSA/primary-api/pkg/storage"
+	"github.com/VATUSA/primary-api/pkg/upload"
 	"github.com/VATUSA/primary-api/pkg/utils"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/render"
 	"github.com/go-playground/validator/v10"
+	"log"
 	"net/http"
 	"path"
-	"strings"
 )
 
 type Request struct {
@@ -20,13 +21,33 @@ type Request struct {
 	Name        string `json:"name" example:"DP001" validate:"required"`
 	Description string `json:"description" example:"General Division Policy" validate:"required"`
 	Category    string `json:"category" example:"general" validate:"required,oneof=general training information_technology sops loas misc"`
+	Visibility  string `json:"visibility" example:"public" validate:"omitempty,oneof=public facility staff"`
+}
+
+// visibility returns the requested visibility, falling back to the category's default
+func (req *Request) visibility() types.DocumentVisibility {
+	if req.Visibility == "" {
+		return types.DocumentCategory(req.Category).DefaultVisibility()
+	}
+	return types.DocumentVisibility(req.Visibility)
 }
 
 func (req *Request) Validate() error {
 	return validator.New().Struct(req)
 }
 
+// Bind reads the fields of a multipart form once it has been parsed. JSON bodies are decoded by render.Bind
+// before it calls Bind, so there is nothing left to do for them.
 func (req *Request) Bind(r *http.Request) error {
+	if r.MultipartForm == nil {
+		return nil
+	}
+
+	req.Facility = r.FormValue("facility")
+	req.Name = r.FormValue("name")
+	req.Description = r.FormValue("description")
+	req.Category = r.FormValue("category")
+	req.Visibility = r.FormValue("visibility")
 	return nil
 }
 
@@ -57,15 +78,26 @@ func NewDocumentListResponse(d []models.Document) []render.Renderer {
 // @Summary Create a new document
 // @Description Create a new document
 // @Tags documents
-// @Accept  json
+// @Accept  multipart/form-data
 // @Produce  json
-// @Param document body Request true "Document"
-// @Param file formData file false "Document file"
+// @Param facility formData string true "Facility"
+// @Param name formData string true "Name"
+// @Param description formData string true "Description"
+// @Param category formData string true "Category"
+// @Param visibility formData string false "Visibility, defaulting to the category's"
+// @Param change_note formData string false "Description of the first version"
+// @Param file formData file true "Document file"
 // @Success 201 {object} Response
 // @Failure 400 {object} utils.ErrResponse
+// @Failure 413 {object} utils.ErrResponse
+// @Failure 415 {object} utils.ErrResponse
 // @Failure 500 {object} utils.ErrResponse
 // @Router /documents [post]
-func CreateDocument(w http.ResponseWriter, r *http.Request, endpoint string) {
+func (h *Handler) CreateDocument(w http.ResponseWriter, r *http.Request, store storage.Storage, validator *upload.Validator, endpoint string) {
+	if !validator.ParseForm(w, r) {
+		return
+	}
+
 	data := &Request{}
 	if err := data.Bind(r); err != nil {
 		render.Render(w, r, utils.ErrInvalidRequest(err))
@@ -77,34 +109,26 @@ func CreateDocument(w http.ResponseWriter, r *http.Request, endpoint string) {
 		return
 	}
 
-	currentDocs, err := models.GetAllDocumentsByFacilityAndCategory(data.Facility, types.DocumentCategory(data.Category))
+	taken, err := h.documents.NameTaken(&models.Document{Facility: data.Facility, Name: data.Name, Category: types.DocumentCategory(data.Category)})
 	if err != nil {
 		render.Render(w, r, utils.ErrInternalServer)
 		return
 	}
-
-	for _, doc := range currentDocs {
-		if doc.Name == data.Name {
-			render.Render(w, r, utils.ErrInvalidRequest(fmt.Errorf("document with name %s already exists", data.Name)))
-			return
-		}
+	if taken {
+		render.Render(w, r, utils.ErrInvalidRequest(fmt.Errorf("document with name %s already exists", data.Name)))
+		return
 	}
 
-	// Read the file from the request
-	file, fileHeader, err := r.FormFile("file")
-	if err != nil {
-		http.Error(w, "Error reading file", http.StatusBadRequest)
+	// Read and validate the file from the request
+	file, fileHeader, contentType, ok := validator.ReadFile(w, r, data.Category)
+	if !ok {
 		return
 	}
+	defer file.Close()
 
-	extension := path.Ext(fileHeader.Filename)
-	directory := path.Join(data.Facility, data.Category)
-	filename := strings.ReplaceAll(data.Name, " ", "-") + extension
-
-	// Put the file in the S3 bucket
-	if err := storage.PublicBucket.Upload(directory, filename, file); err != nil {
-		render.Render(w, r, utils.ErrInternalServer)
-		return
+	var cid uint
+	if self := utils.GetSelf(r); self != nil {
+		cid = self.CID
 	}
 
 	document := &models.Document{
@@ -112,15 +136,22 @@ func CreateDocument(w http.ResponseWriter, r *http.Request, endpoint string) {
 		Name:        data.Name,
 		Description: data.Description,
 		Category:    types.DocumentCategory(data.Category),
-		URL:         path.Join(endpoint, directory, filename),
+		Visibility:  data.visibility(),
+		CreatedBy:   cid,
+		UpdatedBy:   cid,
 	}
 
-	if err := document.Create(); err != nil {
+	if err := h.documents.Create(document); err != nil {
 		render.Render(w, r, utils.ErrInternalServer)
+		return
+	}
 
-		err := storage.PublicBucket.Delete(directory, filename)
-		if err != nil {
-			fmt.Println("Error deleting file from S3:", err)
+	// Put the file in the S3 bucket as the first version
+	if _, err := h.uploadVersion(store, document, file, path.Ext(fileHeader.Filename), contentType, r.FormValue("change_note"), cid, endpoint); err != nil {
+		render.Render(w, r, utils.ErrInternalServer)
+
+		if err := h.documents.Delete(document); err != nil {
+			log.Println("[Document] Error deleting document:", err)
 		}
 		return
 	}
@@ -141,7 +172,7 @@ func CreateDocument(w http.ResponseWriter, r *http.Request, endpoint string) {
 // @Failure 404 {object} utils.ErrResponse
 // @Failure 500 {object} utils.ErrResponse
 // @Router /documents/{id} [get]
-func GetDocument(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) GetDocument(w http.ResponseWriter, r *http.Request) {
 	doc := GetDocumentCtx(r)
 	render.Render(w, r, NewDocumentResponse(doc))
 }
@@ -156,8 +187,8 @@ func GetDocument(w http.ResponseWriter, r *http.Request) {
 // @Failure 422 {object} utils.ErrResponse
 // @Failure 500 {object} utils.ErrResponse
 // @Router /documents [get]
-func ListDocuments(w http.ResponseWriter, r *http.Request) {
-	docs, err := models.GetAllDocuments()
+func (h *Handler) ListDocuments(w http.ResponseWriter, r *http.Request) {
+	docs, err := h.documents.List()
 	if err != nil {
 		render.Render(w, r, utils.ErrInternalServer)
 		return
@@ -181,14 +212,14 @@ func ListDocuments(w http.ResponseWriter, r *http.Request) {
 // @Failure 422 {object} utils.ErrResponse
 // @Failure 500 {object} utils.ErrResponse
 // @Router /documents/facility/{Facility} [get]
-func ListDocumentsByFac(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) ListDocumentsByFac(w http.ResponseWriter, r *http.Request) {
 	facId := chi.URLParam(r, "Facility")
 	if facId == "" {
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
 
-	docs, err := models.GetAllDocumentsByFacility(facId)
+	docs, err := h.documents.ListByFacility(facId)
 	if err != nil {
 		render.Render(w, r, utils.ErrInternalServer)
 		return
@@ -213,7 +244,7 @@ func ListDocumentsByFac(w http.ResponseWriter, r *http.Request) {
 // @Failure 422 {object} utils.ErrResponse
 // @Failure 500 {object} utils.ErrResponse
 // @Router /documents/facility/{Facility}/category/{Category} [get]
-func ListDocumentsByFacByCat(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) ListDocumentsByFacByCat(w http.ResponseWriter, r *http.Request) {
 	facId := chi.URLParam(r, "Facility")
 	cat := chi.URLParam(r, "Category")
 	if facId == "" || cat == "" {
@@ -221,7 +252,7 @@ func ListDocumentsByFacByCat(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	docs, err := models.GetAllDocumentsByFacilityAndCategory(facId, types.DocumentCategory(cat))
+	docs, err := h.documents.ListByFacilityAndCategory(facId, types.DocumentCategory(cat))
 	if err != nil {
 		render.Render(w, r, utils.ErrInternalServer)
 		return
@@ -246,11 +277,11 @@ func ListDocumentsByFacByCat(w http.ResponseWriter, r *http.Request) {
 // @Failure 404 {object} utils.ErrResponse
 // @Failure 500 {object} utils.ErrResponse
 // @Router /documents/{id} [put]
-func UpdateDocument(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) UpdateDocument(w http.ResponseWriter, r *http.Request, store storage.Storage, endpoint string) {
 	doc := GetDocumentCtx(r)
 
 	data := &Request{}
-	if err := data.Bind(r); err != nil {
+	if err := render.Bind(r, data); err != nil {
 		render.Render(w, r, utils.ErrInvalidRequest(err))
 		return
 	}
@@ -260,15 +291,15 @@ func UpdateDocument(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// TODO - update the file in the S3 bucket
-
-	doc.Facility = data.Facility
-	doc.Name = data.Name
+	old := *doc
+	moved := applyLocation(doc, data.Facility, data.Name, types.DocumentCategory(data.Category))
 	doc.Description = data.Description
-	doc.Category = types.DocumentCategory(data.Category)
+	doc.Visibility = data.visibility()
+	if self := utils.GetSelf(r); self != nil {
+		doc.UpdatedBy = self.CID
+	}
 
-	if err := doc.Update(); err != nil {
-		render.Render(w, r, utils.ErrInternalServer)
+	if !h.updateDocument(w, r, store, old, doc, moved, endpoint) {
 		return
 	}
 
@@ -288,38 +319,65 @@ func UpdateDocument(w http.ResponseWriter, r *http.Request) {
 // @Failure 404 {object} utils.ErrResponse
 // @Failure 500 {object} utils.ErrResponse
 // @Router /documents/{id} [patch]
-func PatchDocument(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) PatchDocument(w http.ResponseWriter, r *http.Request, store storage.Storage, endpoint string) {
 	doc := GetDocumentCtx(r)
 
 	data := &Request{}
-	if err := data.Bind(r); err != nil {
+	if err := render.Bind(r, data); err != nil {
 		render.Render(w, r, utils.ErrInvalidRequest(err))
 		return
 	}
 
-	// TODO - update the file in the S3 bucket
-
-	if data.Facility != "" {
-		doc.Facility = data.Facility
-	}
-	if data.Name != "" {
-		doc.Name = data.Name
-	}
+	old := *doc
+	moved := applyLocation(doc, data.Facility, data.Name, types.DocumentCategory(data.Category))
 	if data.Description != "" {
 		doc.Description = data.Description
 	}
-	if data.Category != "" {
-		doc.Category = types.DocumentCategory(data.Category)
+	if data.Visibility != "" {
+		if err := validator.New().Var(data.Visibility, "oneof=public facility staff"); err != nil {
+			*doc = old
+			render.Render(w, r, utils.ErrInvalidRequest(err))
+			return
+		}
+		doc.Visibility = types.DocumentVisibility(data.Visibility)
+	}
+	if self := utils.GetSelf(r); self != nil {
+		doc.UpdatedBy = self.CID
 	}
 
-	if err := doc.Update(); err != nil {
-		render.Render(w, r, utils.ErrInternalServer)
+	if !h.updateDocument(w, r, store, old, doc, moved, endpoint) {
 		return
 	}
 
 	render.Render(w, r, NewDocumentResponse(doc))
 }
 
+// updateDocument saves doc, relocating its files if it moved, and renders an error if that fails
+func (h *Handler) updateDocument(w http.ResponseWriter, r *http.Request, store storage.Storage, old models.Document, doc *models.Document, moved bool, endpoint string) bool {
+	if moved {
+		taken, err := h.documents.NameTaken(doc)
+		if err != nil {
+			*doc = old
+			render.Render(w, r, utils.ErrInternalServer)
+			return false
+		}
+		if taken {
+			render.Render(w, r, utils.ErrInvalidRequest(fmt.Errorf("document with name %s already exists", doc.Name)))
+			*doc = old
+			return false
+		}
+	}
+
+	if err := h.relocate(store, old, doc, endpoint); err != nil {
+		log.Println("[Document] Error updating document:", err)
+		*doc = old
+		render.Render(w, r, utils.ErrInternalServer)
+		return false
+	}
+
+	return true
+}
+
 // DeleteDocument godoc
 // @Summary Delete a document
 // @Description Delete a document
@@ -331,18 +389,38 @@ func PatchDocument(w http.ResponseWriter, r *http.Request) {
 // @Failure 400 {object} utils.ErrResponse
 // @Failure 500 {object} utils.ErrResponse
 // @Router /documents/{id} [delete]
-func DeleteDocument(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) DeleteDocument(w http.ResponseWriter, r *http.Request, store storage.Storage) {
 	doc := GetDocumentCtx(r)
 
-	// Delete the file from the S3 bucket
-	directory := path.Join(doc.Facility, string(doc.Category))
-	filename := strings.ReplaceAll(doc.Name, " ", "-") + path.Ext(doc.URL)
-	if err := storage.PublicBucket.Delete(directory, filename); err != nil {
+	versions, err := h.documents.Versions(doc)
+	if err != nil {
 		render.Render(w, r, utils.ErrInternalServer)
 		return
 	}
 
-	if err := doc.Delete(); err != nil {
+	// Delete every version from the S3 bucket
+	for _, version := range versions {
+		if err := store.Delete(path.Dir(version.Key), path.Base(version.Key)); err != nil {
+			render.Render(w, r, utils.ErrInternalServer)
+			return
+		}
+
+		if err := h.documents.DeleteVersion(&version); err != nil {
+			render.Render(w, r, utils.ErrInternalServer)
+			return
+		}
+	}
+
+	// Documents uploaded before versioning keep a single object next to the category
+	if len(versions) == 0 && doc.URL != "" {
+		directory, filename := legacyLocation(doc)
+		if err := store.Delete(directory, filename); err != nil {
+			render.Render(w, r, utils.ErrInternalServer)
+			return
+		}
+	}
+
+	if err := h.documents.Delete(doc); err != nil {
 		render.Render(w, r, utils.ErrInternalServer)
 		return
 	}
@@ -353,51 +431,38 @@ func DeleteDocument(w http.ResponseWriter, r *http.Request) {
 
 // UploadDocument godoc
 // @Summary Upload a document
-// @Description Upload a document
+// @Description Upload a new version of a document. Previous versions are kept.
 // @Tags documents
-// @Accept  json
+// @Accept  multipart/form-data
 // @Produce  json
 // @Param id path int true "Document ID"
 // @Param file formData file true "Document file"
-// @Success 204
+// @Param change_note formData string false "Description of what changed"
+// @Success 201 {object} VersionResponse
 // @Failure 400 {object} utils.ErrResponse
 // @Failure 500 {object} utils.ErrResponse
 // @Router /documents/{id}/upload [post]
-func UploadDocument(w http.ResponseWriter, r *http.Request, endpoint string) {
+func (h *Handler) UploadDocument(w http.ResponseWriter, r *http.Request, store storage.Storage, validator *upload.Validator, endpoint string) {
 	data := GetDocumentCtx(r)
 
-	// Delete the file from the S3 bucket
-	directory := path.Join(data.Facility, string(data.Category))
-	filename := strings.ReplaceAll(data.Name, " ", "-") + path.Ext(data.URL)
-	if err := storage.PublicBucket.Delete(directory, filename); err != nil {
-		render.Render(w, r, utils.ErrInternalServer)
-		return
-
-	}
-
-	// Read the file from the request
-	file, fileHeader, err := r.FormFile("file")
-	if err != nil {
-		http.Error(w, "Error reading file", http.StatusBadRequest)
+	// Read and validate the file from the request
+	file, fileHeader, contentType, ok := validator.ReadFile(w, r, string(data.Category))
+	if !ok {
 		return
 	}
+	defer file.Close()
 
-	extension := path.Ext(fileHeader.Filename)
-	directory = path.Join(data.Facility, string(data.Category))
-	filename = strings.ReplaceAll(data.Name, " ", "-") + extension
-
-	// Put the file in the S3 bucket
-	if err := storage.PublicBucket.Upload(directory, filename, file); err != nil {
-		render.Render(w, r, utils.ErrInternalServer)
-		return
+	var cid uint
+	if self := utils.GetSelf(r); self != nil {
+		cid = self.CID
 	}
 
-	// Update the URL in the database
-	data.URL = path.Join(endpoint, directory, filename)
-	if err := data.Update(); err != nil {
+	version, err := h.uploadVersion(store, data, file, path.Ext(fileHeader.Filename), contentType, r.FormValue("change_note"), cid, endpoint)
+	if err != nil {
 		render.Render(w, r, utils.ErrInternalServer)
 		return
 	}
 
-	render.Status(r, http.StatusNoContent)
+	render.Status(r, http.StatusCreated)
+	render.Render(w, r, NewVersionResponse(version))
 }
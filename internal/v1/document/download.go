@@ -0,0 +1,91 @@
+package document
+
+import (
+	"errors"
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/VATUSA/primary-api/pkg/storage"
+	"github.com/VATUSA/primary-api/pkg/utils"
+	"github.com/go-chi/render"
+	"log"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+// DownloadExpiry is how long a presigned download URL stays valid
+var DownloadExpiry = 5 * time.Minute
+
+// objectURL joins the object's key onto the storage endpoint without collapsing the scheme's slashes
+func objectURL(endpoint, directory, filename string) string {
+	u, err := url.JoinPath(endpoint, directory, filename)
+	if err != nil {
+		return strings.TrimSuffix(endpoint, "/") + "/" + path.Join(directory, filename)
+	}
+	return u
+}
+
+// currentLocation is where the current version of the document is stored
+func (h *Handler) currentLocation(doc *models.Document) (string, string, error) {
+	if doc.Version == 0 {
+		if doc.URL == "" {
+			return "", "", errors.New("document has no file")
+		}
+		directory, filename := legacyLocation(doc)
+		return directory, filename, nil
+	}
+
+	version, err := h.documents.GetVersion(doc, doc.Version)
+	if err != nil {
+		return "", "", err
+	}
+	return path.Dir(version.Key), path.Base(version.Key), nil
+}
+
+// redirectToObject checks the caller may see doc and sends them to a short-lived URL for the object
+func redirectToObject(w http.ResponseWriter, r *http.Request, store storage.Storage, doc *models.Document, directory, filename string) {
+	self := utils.GetSelf(r)
+	if !doc.CanView(self) {
+		if self == nil {
+			render.Render(w, r, utils.ErrUnauthorized)
+			return
+		}
+		render.Render(w, r, utils.ErrForbidden)
+		return
+	}
+
+	presigned, err := store.PresignGet(directory, filename, DownloadExpiry)
+	if err != nil {
+		log.Println("[Document] Error presigning download:", err)
+		render.Render(w, r, utils.ErrInternalServer)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	http.Redirect(w, r, presigned, http.StatusFound)
+}
+
+// DownloadDocument godoc
+// @Summary Download a document
+// @Description Redirect to a short-lived URL for the current version of a document, if the caller may see it
+// @Tags documents
+// @Param id path int true "Document ID"
+// @Success 302
+// @Failure 400 {object} utils.ErrResponse
+// @Failure 401 {object} utils.ErrResponse
+// @Failure 403 {object} utils.ErrResponse
+// @Failure 404 {object} utils.ErrResponse
+// @Failure 500 {object} utils.ErrResponse
+// @Router /documents/{id}/download [get]
+func (h *Handler) DownloadDocument(w http.ResponseWriter, r *http.Request, store storage.Storage) {
+	doc := GetDocumentCtx(r)
+
+	directory, filename, err := h.currentLocation(doc)
+	if err != nil {
+		render.Render(w, r, utils.ErrNotFound)
+		return
+	}
+
+	redirectToObject(w, r, store, doc, directory, filename)
+}
@@ -0,0 +1,105 @@
+package document
+
+import (
+	"fmt"
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/VATUSA/primary-api/pkg/database/types"
+	"github.com/VATUSA/primary-api/pkg/storage"
+	"log"
+	"path"
+)
+
+type objectMove struct {
+	srcDirectory, srcFilename string
+	dstDirectory, dstFilename string
+}
+
+// legacyLocation is where documents uploaded before versioning keep their single object
+func legacyLocation(doc *models.Document) (string, string) {
+	return path.Join(storageRoot(doc), doc.Facility, string(doc.Category)), doc.ObjectName() + path.Ext(doc.URL)
+}
+
+// relocate saves doc, moving its objects from where old kept them if their keys changed. Objects are copied before
+// the database is touched and the originals are only removed once the update has committed, so a failure at any
+// step leaves the database pointing at objects that exist.
+func (h *Handler) relocate(store storage.Storage, old models.Document, doc *models.Document, endpoint string) error {
+	if versionDirectory(&old) == versionDirectory(doc) {
+		return h.documents.Update(doc)
+	}
+
+	versions, err := h.documents.Versions(doc)
+	if err != nil {
+		return err
+	}
+
+	var moves []objectMove
+	if len(versions) == 0 && doc.URL != "" {
+		srcDirectory, srcFilename := legacyLocation(&old)
+		dstDirectory, dstFilename := legacyLocation(doc)
+		if srcDirectory != dstDirectory || srcFilename != dstFilename {
+			moves = append(moves, objectMove{srcDirectory, srcFilename, dstDirectory, dstFilename})
+		}
+		doc.URL = objectURL(endpoint, dstDirectory, dstFilename)
+	}
+
+	directory := versionDirectory(doc)
+	for i := range versions {
+		v := &versions[i]
+		filename := path.Base(v.Key)
+		if path.Dir(v.Key) != directory {
+			moves = append(moves, objectMove{path.Dir(v.Key), filename, directory, filename})
+		}
+
+		v.Key = path.Join(directory, filename)
+		v.URL = objectURL(endpoint, directory, filename)
+		if v.Version == doc.Version {
+			doc.URL = v.URL
+		}
+	}
+
+	for i, m := range moves {
+		if err := store.Copy(m.srcDirectory, m.srcFilename, m.dstDirectory, m.dstFilename); err != nil {
+			removeCopies(store, moves[:i])
+			return fmt.Errorf("copying %s: %w", path.Join(m.srcDirectory, m.srcFilename), err)
+		}
+	}
+
+	if err := h.documents.UpdateWithVersions(doc, versions); err != nil {
+		removeCopies(store, moves)
+		return err
+	}
+
+	for _, m := range moves {
+		if err := store.Delete(m.srcDirectory, m.srcFilename); err != nil {
+			log.Println("[Document] Error deleting relocated file from storage:", err)
+		}
+	}
+
+	return nil
+}
+
+func removeCopies(store storage.Storage, moves []objectMove) {
+	for _, m := range moves {
+		if err := store.Delete(m.dstDirectory, m.dstFilename); err != nil {
+			log.Println("[Document] Error deleting copied file from storage:", err)
+		}
+	}
+}
+
+// applyLocation is a helper for the update handlers; it reports whether the document moved
+func applyLocation(doc *models.Document, facility, name string, category types.DocumentCategory) bool {
+	moved := false
+	if facility != "" && facility != doc.Facility {
+		doc.Facility = facility
+		moved = true
+	}
+	if name != "" && name != doc.Name {
+		doc.Name = name
+		moved = true
+	}
+	if category != "" && category != doc.Category {
+		doc.Category = category
+		moved = true
+	}
+	return moved
+}
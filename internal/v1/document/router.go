@@ -2,38 +2,69 @@ package document
 
 import (
 	"context"
-	"github.com/VATUSA/primary-api/pkg/config"
 	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/VATUSA/primary-api/pkg/service"
+	"github.com/VATUSA/primary-api/pkg/storage"
+	"github.com/VATUSA/primary-api/pkg/upload"
 	"github.com/go-chi/chi/v5"
 	"net/http"
 	"strconv"
 )
 
-func Router(r chi.Router, cfg *config.S3Config) {
-	r.Get("/", ListDocuments)
+// Handler serves the document endpoints through the services it is built with
+type Handler struct {
+	documents *service.DocumentService
+}
+
+func NewHandler(s *service.Services) *Handler {
+	return &Handler{documents: s.Documents}
+}
+
+func Router(r chi.Router, s *service.Services, store storage.Storage, validator *upload.Validator, endpoint string) {
+	h := NewHandler(s)
+	r.Get("/", h.ListDocuments)
 	r.Post("/", func(w http.ResponseWriter, r *http.Request) {
-		CreateDocument(w, r, cfg.Endpoint)
+		h.CreateDocument(w, r, store, validator, endpoint)
 	})
 
 	r.Route("/{Facility}", func(r chi.Router) {
-		r.Get("/", ListDocumentsByFac)
+		r.Get("/", h.ListDocumentsByFac)
 		r.Route("/{Category}", func(r chi.Router) {
-			r.Get("/", ListDocumentsByFacByCat)
+			r.Get("/", h.ListDocumentsByFacByCat)
 			r.Route("/{DocumentID}", func(r chi.Router) {
-				r.Use(Ctx)
-				r.Get("/", GetDocument)
-				r.Put("/", UpdateDocument)
+				r.Use(h.Ctx)
+				r.Get("/", h.GetDocument)
+				r.Put("/", func(w http.ResponseWriter, r *http.Request) {
+					h.UpdateDocument(w, r, store, endpoint)
+				})
 				r.Put("/upload", func(w http.ResponseWriter, r *http.Request) {
-					UploadDocument(w, r, cfg.Endpoint)
+					h.UploadDocument(w, r, store, validator, endpoint)
+				})
+				r.Patch("/", func(w http.ResponseWriter, r *http.Request) {
+					h.PatchDocument(w, r, store, endpoint)
+				})
+				r.Delete("/", func(w http.ResponseWriter, r *http.Request) {
+					h.DeleteDocument(w, r, store)
+				})
+				r.Get("/download", func(w http.ResponseWriter, r *http.Request) {
+					h.DownloadDocument(w, r, store)
+				})
+				r.Route("/versions", func(r chi.Router) {
+					r.Get("/", h.ListDocumentVersions)
+					r.Route("/{Version}", func(r chi.Router) {
+						r.Use(h.VersionCtx)
+						r.Get("/download", func(w http.ResponseWriter, r *http.Request) {
+							h.DownloadDocumentVersion(w, r, store)
+						})
+						r.Post("/promote", h.PromoteDocumentVersion)
+					})
 				})
-				r.Patch("/", PatchDocument)
-				r.Delete("/", DeleteDocument)
 			})
 		})
 	})
 }
 
-func Ctx(next http.Handler) http.Handler {
+func (h *Handler) Ctx(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		id := chi.URLParam(r, "DocumentID")
 		if id == "" {
@@ -47,8 +78,8 @@ func Ctx(next http.Handler) http.Handler {
 			return
 		}
 
-		document := &models.Document{ID: uint(DocumentID)}
-		if err = document.Get(); err != nil {
+		document, err := h.documents.Get(uint(DocumentID))
+		if err != nil {
 			http.Error(w, "Invalid request", http.StatusBadRequest)
 			return
 		}
@@ -61,3 +92,32 @@ func Ctx(next http.Handler) http.Handler {
 func GetDocumentCtx(r *http.Request) *models.Document {
 	return r.Context().Value("document").(*models.Document)
 }
+
+func (h *Handler) VersionCtx(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		v := chi.URLParam(r, "Version")
+		if v == "" {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		Version, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		version, err := h.documents.GetVersion(GetDocumentCtx(r), uint(Version))
+		if err != nil {
+			http.Error(w, "Not found", http.StatusNotFound)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), "documentVersion", version)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func GetDocumentVersionCtx(r *http.Request) *models.DocumentVersion {
+	return r.Context().Value("documentVersion").(*models.DocumentVersion)
+}
@@ -0,0 +1,196 @@
+package document
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/VATUSA/primary-api/pkg/database/types"
+	"github.com/VATUSA/primary-api/pkg/storage"
+	"github.com/VATUSA/primary-api/pkg/utils"
+	"github.com/go-chi/render"
+	"io"
+	"log"
+	"net/http"
+	"path"
+)
+
+type VersionResponse struct {
+	*models.DocumentVersion
+}
+
+func NewVersionResponse(v *models.DocumentVersion) *VersionResponse {
+	return &VersionResponse{DocumentVersion: v}
+}
+
+func (res *VersionResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	if res.DocumentVersion == nil {
+		return errors.New("missing required document version")
+	}
+	return nil
+}
+
+func NewVersionListResponse(v []models.DocumentVersion) []render.Renderer {
+	list := []render.Renderer{}
+	for _, version := range v {
+		list = append(list, NewVersionResponse(&version))
+	}
+	return list
+}
+
+// PrivatePrefix is where the objects of documents that aren't public are kept. The bucket must not allow
+// anonymous reads under it, so those objects can only be fetched through the download endpoints.
+const PrivatePrefix = "private"
+
+// storageRoot is the prefix the document's objects are kept under
+func storageRoot(doc *models.Document) string {
+	if doc.Visibility == types.PublicVisibility || doc.Visibility == "" {
+		return ""
+	}
+	return PrivatePrefix
+}
+
+// versionDirectory is where every version of the document is stored
+func versionDirectory(doc *models.Document) string {
+	return path.Join(storageRoot(doc), doc.Facility, string(doc.Category), doc.ObjectName())
+}
+
+// uploadVersion stores body as the next version of doc and makes it the current version. The upload is staged
+// under a name of its own and only moved to its versioned name once the version is numbered, so concurrent
+// uploads can't overwrite each other's objects.
+func (h *Handler) uploadVersion(store storage.Storage, doc *models.Document, body io.Reader, extension, contentType, note string, cid uint, endpoint string) (*models.DocumentVersion, error) {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return nil, err
+	}
+
+	directory := versionDirectory(doc)
+	staged := fmt.Sprintf("upload-%s%s", hex.EncodeToString(token), extension)
+
+	hash := sha256.New()
+	counter := &countingReader{r: io.TeeReader(body, hash)}
+	if err := store.Upload(directory, staged, counter); err != nil {
+		return nil, err
+	}
+
+	version := &models.DocumentVersion{
+		ContentType: contentType,
+		Checksum:    hex.EncodeToString(hash.Sum(nil)),
+		Size:        counter.n,
+		ChangeNote:  note,
+		CreatedBy:   cid,
+	}
+
+	filename := staged
+	err := h.documents.AddVersion(doc, version, func(v *models.DocumentVersion) error {
+		versioned := fmt.Sprintf("v%d%s", v.Version, extension)
+		if err := store.Move(directory, staged, directory, versioned); err != nil {
+			return err
+		}
+		filename = versioned
+
+		v.Key = path.Join(directory, versioned)
+		v.URL = objectURL(endpoint, directory, versioned)
+		return nil
+	})
+	if err != nil {
+		if err := store.Delete(directory, filename); err != nil {
+			log.Println("[Document] Error deleting file from storage:", err)
+		}
+		return nil, err
+	}
+
+	return version, nil
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// ListDocumentVersions godoc
+// @Summary List document versions
+// @Description List every version of a document, newest first
+// @Tags documents
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Document ID"
+// @Success 200 {object} []VersionResponse
+// @Failure 400 {object} utils.ErrResponse
+// @Failure 422 {object} utils.ErrResponse
+// @Failure 500 {object} utils.ErrResponse
+// @Router /documents/{id}/versions [get]
+func (h *Handler) ListDocumentVersions(w http.ResponseWriter, r *http.Request) {
+	doc := GetDocumentCtx(r)
+
+	versions, err := h.documents.Versions(doc)
+	if err != nil {
+		render.Render(w, r, utils.ErrInternalServer)
+		return
+	}
+
+	if err := render.RenderList(w, r, NewVersionListResponse(versions)); err != nil {
+		render.Render(w, r, utils.ErrRender(err))
+		return
+	}
+}
+
+// DownloadDocumentVersion godoc
+// @Summary Download a document version
+// @Description Redirect to a short-lived URL for a specific version of a document, if the caller may see it
+// @Tags documents
+// @Param id path int true "Document ID"
+// @Param version path int true "Version"
+// @Success 302
+// @Failure 400 {object} utils.ErrResponse
+// @Failure 401 {object} utils.ErrResponse
+// @Failure 403 {object} utils.ErrResponse
+// @Failure 404 {object} utils.ErrResponse
+// @Failure 500 {object} utils.ErrResponse
+// @Router /documents/{id}/versions/{version}/download [get]
+func (h *Handler) DownloadDocumentVersion(w http.ResponseWriter, r *http.Request, store storage.Storage) {
+	doc := GetDocumentCtx(r)
+	version := GetDocumentVersionCtx(r)
+	redirectToObject(w, r, store, doc, path.Dir(version.Key), path.Base(version.Key))
+}
+
+// PromoteDocumentVersion godoc
+// @Summary Promote a document version
+// @Description Make an older version of a document the current version
+// @Tags documents
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Document ID"
+// @Param version path int true "Version"
+// @Success 200 {object} Response
+// @Failure 400 {object} utils.ErrResponse
+// @Failure 404 {object} utils.ErrResponse
+// @Failure 500 {object} utils.ErrResponse
+// @Router /documents/{id}/versions/{version}/promote [post]
+func (h *Handler) PromoteDocumentVersion(w http.ResponseWriter, r *http.Request) {
+	doc := GetDocumentCtx(r)
+	version := GetDocumentVersionCtx(r)
+
+	doc.Version = version.Version
+	doc.URL = version.URL
+	doc.ContentType = version.ContentType
+	doc.SHA256 = version.Checksum
+	if self := utils.GetSelf(r); self != nil {
+		doc.UpdatedBy = self.CID
+	}
+
+	if err := h.documents.Update(doc); err != nil {
+		render.Render(w, r, utils.ErrInternalServer)
+		return
+	}
+
+	render.Render(w, r, NewDocumentResponse(doc))
+}
@@ -0,0 +1,520 @@
+package event
+
+import (
+	"errors"
+	"fmt"
+	"github.com/VATUSA/primary-api/pkg/config"
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/VATUSA/primary-api/pkg/storage"
+	"github.com/VATUSA/primary-api/pkg/upload"
+	"github.com/VATUSA/primary-api/pkg/utils"
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"log"
+	"net/http"
+	"path"
+	"strconv"
+	"time"
+)
+
+type Request struct {
+	Title        string   `json:"title" example:"Denver Nights" validate:"required"`
+	Description  string   `json:"description" example:"Join us for a night of traffic into Denver"`
+	HostFacility string   `json:"host_facility" example:"ZDV" validate:"required,len=3"`
+	Facilities   []string `json:"facilities" example:"ZLC,ZAB" validate:"dive,len=3"`
+	StartAt      string   `json:"start_at" example:"2021-01-01T00:00:00Z" validate:"required"`
+	EndAt        string   `json:"end_at" example:"2021-01-01T03:00:00Z" validate:"required"`
+}
+
+func (req *Request) Validate() error {
+	return validator.New().Struct(req)
+}
+
+func (req *Request) Bind(r *http.Request) error {
+	return nil
+}
+
+type Response struct {
+	*models.Event
+}
+
+func NewEventResponse(e *models.Event) *Response {
+	return &Response{Event: e}
+}
+
+func (res *Response) Render(w http.ResponseWriter, r *http.Request) error {
+	if res.Event == nil {
+		return errors.New("missing required event")
+	}
+	return nil
+}
+
+func NewEventListResponse(events []models.Event) []render.Renderer {
+	list := []render.Renderer{}
+	for _, e := range events {
+		list = append(list, NewEventResponse(&e))
+	}
+	return list
+}
+
+// canManage renders the error response and returns false unless the caller may manage the event
+func canManage(w http.ResponseWriter, r *http.Request, event *models.Event) bool {
+	self := utils.GetSelf(r)
+	if self == nil {
+		render.Render(w, r, utils.ErrUnauthorized)
+		return false
+	}
+	if !event.CanManage(self) {
+		render.Render(w, r, utils.ErrForbidden)
+		return false
+	}
+	return true
+}
+
+// parseTimes reads the start and end of an event, which must end after it starts
+func parseTimes(start, end string) (time.Time, time.Time, error) {
+	startAt, err := time.Parse(time.RFC3339, start)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	endAt, err := time.Parse(time.RFC3339, end)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	if !endAt.After(startAt) {
+		return time.Time{}, time.Time{}, errors.New("end_at must be after start_at")
+	}
+
+	return startAt, endAt, nil
+}
+
+// participating checks the facilities taking part in an event, dropping the host and duplicates
+func participating(host string, facilities []string) ([]string, error) {
+	seen := map[string]bool{host: true}
+	var valid []string
+	for _, f := range facilities {
+		if seen[f] {
+			continue
+		}
+		if !models.IsValidFacility(f) {
+			return nil, fmt.Errorf("invalid facility %s", f)
+		}
+		seen[f] = true
+		valid = append(valid, f)
+	}
+	return valid, nil
+}
+
+// CreateEvent godoc
+// @Summary Create a new event
+// @Description Create a new event. Facility events staff can create events their facility hosts.
+// @Tags event
+// @Accept  json
+// @Produce  json
+// @Param event body Request true "Event"
+// @Success 201 {object} Response
+// @Failure 400 {object} utils.ErrResponse
+// @Failure 401 {object} utils.ErrResponse
+// @Failure 403 {object} utils.ErrResponse
+// @Failure 500 {object} utils.ErrResponse
+// @Router /event [post]
+func CreateEvent(w http.ResponseWriter, r *http.Request) {
+	data := &Request{}
+	if err := render.Bind(r, data); err != nil {
+		render.Render(w, r, utils.ErrInvalidRequest(err))
+		return
+	}
+
+	if err := data.Validate(); err != nil {
+		render.Render(w, r, utils.ErrInvalidRequest(err))
+		return
+	}
+
+	if !models.IsValidFacility(data.HostFacility) {
+		render.Render(w, r, utils.ErrInvalidFacility)
+		return
+	}
+
+	facilities, err := participating(data.HostFacility, data.Facilities)
+	if err != nil {
+		render.Render(w, r, utils.ErrInvalidRequest(err))
+		return
+	}
+
+	startAt, endAt, err := parseTimes(data.StartAt, data.EndAt)
+	if err != nil {
+		render.Render(w, r, utils.ErrInvalidRequest(err))
+		return
+	}
+
+	event := &models.Event{
+		Title:        data.Title,
+		Description:  data.Description,
+		HostFacility: data.HostFacility,
+		StartAt:      startAt,
+		EndAt:        endAt,
+	}
+
+	if !canManage(w, r, event) {
+		return
+	}
+	event.CreatedBy = utils.GetSelf(r).CID
+	event.UpdatedBy = event.CreatedBy
+
+	if err := event.Create(); err != nil {
+		render.Render(w, r, utils.ErrInternalServer)
+		return
+	}
+
+	if err := event.SetFacilities(facilities); err != nil {
+		render.Render(w, r, utils.ErrInternalServer)
+		return
+	}
+	event.Positions = []models.EventPosition{}
+
+	render.Status(r, http.StatusCreated)
+	render.Render(w, r, NewEventResponse(event))
+}
+
+// GetEvent godoc
+// @Summary Get an event
+// @Description Get an event with its positions and signups
+// @Tags event
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Event ID"
+// @Success 200 {object} Response
+// @Failure 400 {object} utils.ErrResponse
+// @Failure 404 {object} utils.ErrResponse
+// @Router /event/{id} [get]
+func GetEvent(w http.ResponseWriter, r *http.Request) {
+	event := GetEventCtx(r)
+	render.Render(w, r, NewEventResponse(event))
+}
+
+// ListEvents godoc
+// @Summary List events
+// @Description List events that haven't ended, soonest first. Past events are included when after is given.
+// @Tags event
+// @Accept  json
+// @Produce  json
+// @Param facility query string false "Only events this facility hosts or takes part in"
+// @Param after query string false "Only events ending after this time (RFC3339), defaults to now"
+// @Success 200 {object} []Response
+// @Failure 400 {object} utils.ErrResponse
+// @Failure 500 {object} utils.ErrResponse
+// @Router /event [get]
+func ListEvents(w http.ResponseWriter, r *http.Request) {
+	facility := r.URL.Query().Get("facility")
+	if facility != "" && !models.IsValidFacility(facility) {
+		render.Render(w, r, utils.ErrInvalidFacility)
+		return
+	}
+
+	after := time.Now()
+	if value := r.URL.Query().Get("after"); value != "" {
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			render.Render(w, r, utils.ErrInvalidRequest(err))
+			return
+		}
+		after = t
+	}
+
+	events, err := models.GetEvents(facility, after)
+	if err != nil {
+		render.Render(w, r, utils.ErrInternalServer)
+		return
+	}
+
+	if err := render.RenderList(w, r, NewEventListResponse(events)); err != nil {
+		render.Render(w, r, utils.ErrRender(err))
+		return
+	}
+}
+
+// saveEvent saves an edited event and its facilities, rendering the response
+func saveEvent(w http.ResponseWriter, r *http.Request, event *models.Event, facilities []string, previousStart time.Time) {
+	// Reminders count down to the start, so start again if it moved
+	if !event.StartAt.Equal(previousStart) {
+		event.RemindersSent = 0
+	}
+	event.UpdatedBy = utils.GetSelf(r).CID
+
+	if err := event.Update(); err != nil {
+		render.Render(w, r, utils.ErrInternalServer)
+		return
+	}
+
+	if facilities != nil {
+		if err := event.SetFacilities(facilities); err != nil {
+			render.Render(w, r, utils.ErrInternalServer)
+			return
+		}
+	}
+
+	render.Render(w, r, NewEventResponse(event))
+}
+
+// UpdateEvent godoc
+// @Summary Update an event
+// @Description Update an event
+// @Tags event
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Event ID"
+// @Param event body Request true "Event"
+// @Success 200 {object} Response
+// @Failure 400 {object} utils.ErrResponse
+// @Failure 401 {object} utils.ErrResponse
+// @Failure 403 {object} utils.ErrResponse
+// @Failure 404 {object} utils.ErrResponse
+// @Failure 500 {object} utils.ErrResponse
+// @Router /event/{id} [put]
+func UpdateEvent(w http.ResponseWriter, r *http.Request) {
+	event := GetEventCtx(r)
+	if !canManage(w, r, event) {
+		return
+	}
+
+	data := &Request{}
+	if err := render.Bind(r, data); err != nil {
+		render.Render(w, r, utils.ErrInvalidRequest(err))
+		return
+	}
+
+	if err := data.Validate(); err != nil {
+		render.Render(w, r, utils.ErrInvalidRequest(err))
+		return
+	}
+
+	if !models.IsValidFacility(data.HostFacility) {
+		render.Render(w, r, utils.ErrInvalidFacility)
+		return
+	}
+
+	facilities, err := participating(data.HostFacility, data.Facilities)
+	if err != nil {
+		render.Render(w, r, utils.ErrInvalidRequest(err))
+		return
+	}
+
+	startAt, endAt, err := parseTimes(data.StartAt, data.EndAt)
+	if err != nil {
+		render.Render(w, r, utils.ErrInvalidRequest(err))
+		return
+	}
+
+	previousStart := event.StartAt
+	event.Title = data.Title
+	event.Description = data.Description
+	event.HostFacility = data.HostFacility
+	event.StartAt = startAt
+	event.EndAt = endAt
+
+	// Moving the event to another host needs rights there too
+	if !canManage(w, r, event) {
+		return
+	}
+
+	if facilities == nil {
+		facilities = []string{}
+	}
+	saveEvent(w, r, event, facilities, previousStart)
+}
+
+// PatchEvent godoc
+// @Summary Patch an event
+// @Description Patch an event
+// @Tags event
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Event ID"
+// @Param event body Request true "Event"
+// @Success 200 {object} Response
+// @Failure 400 {object} utils.ErrResponse
+// @Failure 401 {object} utils.ErrResponse
+// @Failure 403 {object} utils.ErrResponse
+// @Failure 404 {object} utils.ErrResponse
+// @Failure 500 {object} utils.ErrResponse
+// @Router /event/{id} [patch]
+func PatchEvent(w http.ResponseWriter, r *http.Request) {
+	event := GetEventCtx(r)
+	if !canManage(w, r, event) {
+		return
+	}
+
+	data := &Request{}
+	if err := render.Bind(r, data); err != nil {
+		render.Render(w, r, utils.ErrInvalidRequest(err))
+		return
+	}
+
+	previousStart := event.StartAt
+	if data.Title != "" {
+		event.Title = data.Title
+	}
+	if data.Description != "" {
+		event.Description = data.Description
+	}
+	if data.HostFacility != "" {
+		if !models.IsValidFacility(data.HostFacility) {
+			render.Render(w, r, utils.ErrInvalidFacility)
+			return
+		}
+		event.HostFacility = data.HostFacility
+	}
+
+	start, end := event.StartAt.Format(time.RFC3339), event.EndAt.Format(time.RFC3339)
+	if data.StartAt != "" {
+		start = data.StartAt
+	}
+	if data.EndAt != "" {
+		end = data.EndAt
+	}
+	startAt, endAt, err := parseTimes(start, end)
+	if err != nil {
+		render.Render(w, r, utils.ErrInvalidRequest(err))
+		return
+	}
+	event.StartAt = startAt
+	event.EndAt = endAt
+
+	var facilities []string
+	if data.Facilities != nil {
+		if facilities, err = participating(event.HostFacility, data.Facilities); err != nil {
+			render.Render(w, r, utils.ErrInvalidRequest(err))
+			return
+		}
+		if facilities == nil {
+			facilities = []string{}
+		}
+	}
+
+	if !canManage(w, r, event) {
+		return
+	}
+
+	saveEvent(w, r, event, facilities, previousStart)
+}
+
+// DeleteEvent godoc
+// @Summary Delete an event
+// @Description Delete an event with its positions, signups and banner
+// @Tags event
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Event ID"
+// @Success 204
+// @Failure 401 {object} utils.ErrResponse
+// @Failure 403 {object} utils.ErrResponse
+// @Failure 500 {object} utils.ErrResponse
+// @Router /event/{id} [delete]
+func DeleteEvent(w http.ResponseWriter, r *http.Request, store storage.Storage) {
+	event := GetEventCtx(r)
+	if !canManage(w, r, event) {
+		return
+	}
+
+	if err := event.Delete(); err != nil {
+		render.Render(w, r, utils.ErrInternalServer)
+		return
+	}
+
+	if event.BannerKey != "" {
+		if err := store.Delete(path.Dir(event.BannerKey), path.Base(event.BannerKey)); err != nil {
+			log.Println("[Event] Error deleting banner from storage:", err)
+		}
+	}
+
+	render.Status(r, http.StatusNoContent)
+}
+
+// BannerExpiry is how long the URL GetBanner redirects to stays valid
+var BannerExpiry = time.Hour
+
+// GetBanner godoc
+// @Summary Get an event banner
+// @Description Redirect to a short-lived URL for the event's banner image
+// @Tags event
+// @Param id path int true "Event ID"
+// @Success 302
+// @Failure 404 {object} utils.ErrResponse
+// @Failure 500 {object} utils.ErrResponse
+// @Router /event/{id}/banner [get]
+func GetBanner(w http.ResponseWriter, r *http.Request, store storage.Storage) {
+	event := GetEventCtx(r)
+	if event.BannerKey == "" {
+		render.Render(w, r, utils.ErrNotFound)
+		return
+	}
+
+	presigned, err := store.PresignGet(path.Dir(event.BannerKey), path.Base(event.BannerKey), BannerExpiry)
+	if err != nil {
+		log.Println("[Event] Error presigning banner:", err)
+		render.Render(w, r, utils.ErrInternalServer)
+		return
+	}
+
+	// Clients may reuse the redirect for a while, but not for as long as the URL it points to is valid
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(BannerExpiry.Seconds()/2)))
+	http.Redirect(w, r, presigned, http.StatusFound)
+}
+
+// UploadBanner godoc
+// @Summary Upload an event banner
+// @Description Upload or replace the banner image for an event
+// @Tags event
+// @Accept  multipart/form-data
+// @Produce  json
+// @Param id path int true "Event ID"
+// @Param file formData file true "Banner image"
+// @Success 200 {object} Response
+// @Failure 400 {object} utils.ErrResponse
+// @Failure 401 {object} utils.ErrResponse
+// @Failure 403 {object} utils.ErrResponse
+// @Failure 413 {object} utils.ErrResponse
+// @Failure 415 {object} utils.ErrResponse
+// @Failure 500 {object} utils.ErrResponse
+// @Router /event/{id}/banner [put]
+func UploadBanner(w http.ResponseWriter, r *http.Request, store storage.Storage, validator *upload.Validator) {
+	event := GetEventCtx(r)
+	if !canManage(w, r, event) {
+		return
+	}
+
+	file, _, contentType, ok := validator.ReadFile(w, r, config.EventBannerCategory)
+	if !ok {
+		return
+	}
+	defer file.Close()
+
+	directory := path.Join("events", strconv.FormatUint(uint64(event.ID), 10))
+	filename := "banner" + mimetype.Lookup(contentType).Extension()
+	if err := store.Upload(directory, filename, file); err != nil {
+		render.Render(w, r, utils.ErrInternalServer)
+		return
+	}
+
+	// The bucket isn't public, so the banner is linked through GetBanner, which is served on this same path
+	previous := event.BannerKey
+	event.BannerKey = path.Join(directory, filename)
+	event.BannerURL = r.URL.Path
+	event.UpdatedBy = utils.GetSelf(r).CID
+	if err := event.Update(); err != nil {
+		render.Render(w, r, utils.ErrInternalServer)
+		return
+	}
+
+	// A banner of another image type was stored under a different name
+	if previous != "" && previous != event.BannerKey {
+		if err := store.Delete(path.Dir(previous), path.Base(previous)); err != nil {
+			log.Println("[Event] Error deleting old banner from storage:", err)
+		}
+	}
+
+	render.Render(w, r, NewEventResponse(event))
+}
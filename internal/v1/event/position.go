@@ -0,0 +1,318 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/VATUSA/primary-api/internal/v1/notification"
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/VATUSA/primary-api/pkg/utils"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"log"
+	"net/http"
+	"time"
+)
+
+// NotificationCategory is the category of notifications about events
+const NotificationCategory = "Events"
+
+type PositionRequest struct {
+	Callsign string `json:"callsign" example:"DEN_APP" validate:"required"`
+}
+
+func (req *PositionRequest) Validate() error {
+	return validator.New().Struct(req)
+}
+
+func (req *PositionRequest) Bind(r *http.Request) error {
+	return nil
+}
+
+type SignupRequest struct {
+	Note string `json:"note" example:"Available from 0100z"`
+}
+
+func (req *SignupRequest) Bind(r *http.Request) error {
+	return nil
+}
+
+type AssignRequest struct {
+	CID *uint `json:"cid" example:"1293257"` // null to unassign
+}
+
+func (req *AssignRequest) Bind(r *http.Request) error {
+	return nil
+}
+
+type PositionResponse struct {
+	*models.EventPosition
+}
+
+func NewPositionResponse(p *models.EventPosition) *PositionResponse {
+	return &PositionResponse{EventPosition: p}
+}
+
+func (res *PositionResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	if res.EventPosition == nil {
+		return errors.New("missing required event position")
+	}
+	return nil
+}
+
+// notify sends a notification about the event to a controller, expiring when the event ends
+func notify(ctx context.Context, event *models.Event, cid uint, title, body string) {
+	n := &models.Notification{
+		CID:      cid,
+		Category: NotificationCategory,
+		Title:    title,
+		Body:     body,
+		ExpireAt: event.EndAt,
+	}
+	if err := n.Create(); err != nil {
+		log.Println("[Event] Error creating notification:", err)
+		return
+	}
+	notification.Publish(ctx, *n)
+}
+
+// CreatePosition godoc
+// @Summary Add an event position
+// @Description Add a position to be staffed for an event
+// @Tags event
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Event ID"
+// @Param position body PositionRequest true "Position"
+// @Success 201 {object} PositionResponse
+// @Failure 400 {object} utils.ErrResponse
+// @Failure 401 {object} utils.ErrResponse
+// @Failure 403 {object} utils.ErrResponse
+// @Failure 500 {object} utils.ErrResponse
+// @Router /event/{id}/positions [post]
+func CreatePosition(w http.ResponseWriter, r *http.Request) {
+	event := GetEventCtx(r)
+	if !canManage(w, r, event) {
+		return
+	}
+
+	data := &PositionRequest{}
+	if err := render.Bind(r, data); err != nil {
+		render.Render(w, r, utils.ErrInvalidRequest(err))
+		return
+	}
+
+	if err := data.Validate(); err != nil {
+		render.Render(w, r, utils.ErrInvalidRequest(err))
+		return
+	}
+
+	for _, p := range event.Positions {
+		if p.Callsign == data.Callsign {
+			render.Render(w, r, utils.ErrConflict(fmt.Errorf("position %s already exists", data.Callsign)))
+			return
+		}
+	}
+
+	position := &models.EventPosition{EventID: event.ID, Callsign: data.Callsign, Signups: []models.EventSignup{}}
+	if err := position.Create(); err != nil {
+		render.Render(w, r, utils.ErrInternalServer)
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.Render(w, r, NewPositionResponse(position))
+}
+
+// DeletePosition godoc
+// @Summary Remove an event position
+// @Description Remove a position and its signups from an event
+// @Tags event
+// @Param id path int true "Event ID"
+// @Param position path int true "Position ID"
+// @Success 204
+// @Failure 401 {object} utils.ErrResponse
+// @Failure 403 {object} utils.ErrResponse
+// @Failure 404 {object} utils.ErrResponse
+// @Failure 500 {object} utils.ErrResponse
+// @Router /event/{id}/positions/{position} [delete]
+func DeletePosition(w http.ResponseWriter, r *http.Request) {
+	event := GetEventCtx(r)
+	if !canManage(w, r, event) {
+		return
+	}
+
+	position := GetPositionCtx(r)
+	if err := position.Delete(); err != nil {
+		render.Render(w, r, utils.ErrInternalServer)
+		return
+	}
+
+	render.Status(r, http.StatusNoContent)
+}
+
+// SignUp godoc
+// @Summary Sign up for an event position
+// @Description Sign the caller up for a position at an event that hasn't ended
+// @Tags event
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Event ID"
+// @Param position path int true "Position ID"
+// @Param signup body SignupRequest false "Signup"
+// @Success 201 {object} PositionResponse
+// @Failure 400 {object} utils.ErrResponse
+// @Failure 401 {object} utils.ErrResponse
+// @Failure 404 {object} utils.ErrResponse
+// @Failure 409 {object} utils.ErrResponse
+// @Failure 500 {object} utils.ErrResponse
+// @Router /event/{id}/positions/{position}/signup [post]
+func SignUp(w http.ResponseWriter, r *http.Request) {
+	event := GetEventCtx(r)
+	position := GetPositionCtx(r)
+
+	self := utils.GetSelf(r)
+	if self == nil {
+		render.Render(w, r, utils.ErrUnauthorized)
+		return
+	}
+
+	data := &SignupRequest{}
+	if r.ContentLength != 0 {
+		if err := render.Bind(r, data); err != nil {
+			render.Render(w, r, utils.ErrInvalidRequest(err))
+			return
+		}
+	}
+
+	if !event.EndAt.After(time.Now()) {
+		render.Render(w, r, utils.ErrInvalidRequest(errors.New("event has ended")))
+		return
+	}
+
+	if signedUp(position, self.CID) {
+		render.Render(w, r, utils.ErrConflict(errors.New("already signed up for this position")))
+		return
+	}
+
+	signup := models.EventSignup{PositionID: position.ID, CID: self.CID, Note: data.Note}
+	if err := signup.Create(); err != nil {
+		render.Render(w, r, utils.ErrInternalServer)
+		return
+	}
+	position.Signups = append(position.Signups, signup)
+
+	render.Status(r, http.StatusCreated)
+	render.Render(w, r, NewPositionResponse(position))
+}
+
+// Withdraw godoc
+// @Summary Withdraw from an event position
+// @Description Remove the caller's signup for a position, and their assignment if they were assigned
+// @Tags event
+// @Param id path int true "Event ID"
+// @Param position path int true "Position ID"
+// @Success 204
+// @Failure 401 {object} utils.ErrResponse
+// @Failure 404 {object} utils.ErrResponse
+// @Failure 500 {object} utils.ErrResponse
+// @Router /event/{id}/positions/{position}/signup [delete]
+func Withdraw(w http.ResponseWriter, r *http.Request) {
+	position := GetPositionCtx(r)
+
+	self := utils.GetSelf(r)
+	if self == nil {
+		render.Render(w, r, utils.ErrUnauthorized)
+		return
+	}
+
+	signup := &models.EventSignup{PositionID: position.ID, CID: self.CID}
+	if err := signup.Get(); err != nil {
+		render.Render(w, r, utils.ErrNotFound)
+		return
+	}
+
+	if err := signup.Delete(); err != nil {
+		render.Render(w, r, utils.ErrInternalServer)
+		return
+	}
+
+	if position.AssignedCID != nil && *position.AssignedCID == self.CID {
+		position.AssignedCID = nil
+		if err := position.Update(); err != nil {
+			render.Render(w, r, utils.ErrInternalServer)
+			return
+		}
+	}
+
+	render.Status(r, http.StatusNoContent)
+}
+
+// signedUp reports whether the controller has signed up for the position
+func signedUp(position *models.EventPosition, cid uint) bool {
+	for _, s := range position.Signups {
+		if s.CID == cid {
+			return true
+		}
+	}
+	return false
+}
+
+// AssignPosition godoc
+// @Summary Assign an event position
+// @Description Assign a controller who has signed up for a position to it, or unassign it with a null cid. The controller is notified.
+// @Tags event
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Event ID"
+// @Param position path int true "Position ID"
+// @Param assignment body AssignRequest true "Assignment"
+// @Success 200 {object} PositionResponse
+// @Failure 400 {object} utils.ErrResponse
+// @Failure 401 {object} utils.ErrResponse
+// @Failure 403 {object} utils.ErrResponse
+// @Failure 404 {object} utils.ErrResponse
+// @Failure 500 {object} utils.ErrResponse
+// @Router /event/{id}/positions/{position}/assign [put]
+func AssignPosition(w http.ResponseWriter, r *http.Request) {
+	event := GetEventCtx(r)
+	if !canManage(w, r, event) {
+		return
+	}
+	position := GetPositionCtx(r)
+
+	data := &AssignRequest{}
+	if err := render.Bind(r, data); err != nil {
+		render.Render(w, r, utils.ErrInvalidRequest(err))
+		return
+	}
+
+	if data.CID != nil && !models.IsValidUser(*data.CID) {
+		render.Render(w, r, utils.ErrInvalidCID)
+		return
+	}
+
+	if data.CID != nil && !signedUp(position, *data.CID) {
+		render.Render(w, r, utils.ErrInvalidRequest(errors.New("controller has not signed up for this position")))
+		return
+	}
+
+	previous := position.AssignedCID
+	position.AssignedCID = data.CID
+	if err := position.Update(); err != nil {
+		render.Render(w, r, utils.ErrInternalServer)
+		return
+	}
+
+	when := event.StartAt.UTC().Format("Jan 2 15:04Z")
+	if previous != nil && (data.CID == nil || *previous != *data.CID) {
+		notify(r.Context(), event, *previous, "Event position unassigned",
+			fmt.Sprintf("You are no longer assigned to %s for %s on %s.", position.Callsign, event.Title, when))
+	}
+	if data.CID != nil && (previous == nil || *previous != *data.CID) {
+		notify(r.Context(), event, *data.CID, "Event position assigned",
+			fmt.Sprintf("You have been assigned to %s for %s on %s.", position.Callsign, event.Title, when))
+	}
+
+	render.Render(w, r, NewPositionResponse(position))
+}
@@ -0,0 +1,92 @@
+package event
+
+import (
+	"context"
+	"fmt"
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"log"
+	"time"
+)
+
+// ReminderLeadTimes are how long before an event starts its assigned controllers are reminded, longest first
+var ReminderLeadTimes = []time.Duration{24 * time.Hour, time.Hour}
+
+// reminderStage is the number of lead times that have been reached with until left before the start
+func reminderStage(until time.Duration) uint {
+	var stage uint
+	for _, lead := range ReminderLeadTimes {
+		if until <= lead {
+			stage++
+		}
+	}
+	return stage
+}
+
+// approximately describes a duration in whole hours, or minutes when it is under an hour
+func approximately(d time.Duration) string {
+	if hours := int(d.Round(time.Hour).Hours()); d >= time.Hour {
+		if hours == 1 {
+			return "1 hour"
+		}
+		return fmt.Sprintf("%d hours", hours)
+	}
+	if minutes := int(d.Round(time.Minute).Minutes()); minutes != 1 {
+		return fmt.Sprintf("%d minutes", minutes)
+	}
+	return "1 minute"
+}
+
+// SendReminders notifies the controllers assigned to events that are getting close. Each lead time is only
+// sent once per event, and lead times that were missed, such as for events created at short notice, are
+// skipped rather than sent together.
+func SendReminders(ctx context.Context, now time.Time) error {
+	events, err := models.GetEventsStartingBefore(now, now.Add(ReminderLeadTimes[0]))
+	if err != nil {
+		return err
+	}
+
+	for i := range events {
+		event := &events[i]
+		stage := reminderStage(event.StartAt.Sub(now))
+		if stage <= event.RemindersSent {
+			continue
+		}
+
+		// Claim the stage before notifying, so a run that loses the race doesn't send it again
+		claimed, err := event.ClaimReminderStage(stage)
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			continue
+		}
+
+		until := approximately(event.StartAt.Sub(now))
+		for _, p := range event.Positions {
+			if p.AssignedCID == nil {
+				continue
+			}
+			notify(ctx, event, *p.AssignedCID, "Upcoming event",
+				fmt.Sprintf("%s starts in %s. You are assigned to %s.", event.Title, until, p.Callsign))
+		}
+	}
+
+	return nil
+}
+
+// RunReminders sends reminders every interval until ctx is done
+func RunReminders(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := SendReminders(ctx, now); err != nil {
+				log.Println("[Event] Error sending reminders:", err)
+			}
+		}
+	}
+}
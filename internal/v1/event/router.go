@@ -0,0 +1,101 @@
+package event
+
+import (
+	"context"
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/VATUSA/primary-api/pkg/storage"
+	"github.com/VATUSA/primary-api/pkg/upload"
+	"github.com/go-chi/chi/v5"
+	"net/http"
+	"strconv"
+)
+
+func Router(r chi.Router, store storage.Storage, validator *upload.Validator) {
+	r.Get("/", ListEvents)
+	r.Post("/", CreateEvent)
+
+	r.Route("/{EventID}", func(r chi.Router) {
+		r.Use(Ctx)
+		r.Get("/", GetEvent)
+		r.Put("/", UpdateEvent)
+		r.Patch("/", PatchEvent)
+		r.Delete("/", func(w http.ResponseWriter, r *http.Request) {
+			DeleteEvent(w, r, store)
+		})
+		r.Get("/banner", func(w http.ResponseWriter, r *http.Request) {
+			GetBanner(w, r, store)
+		})
+		r.Put("/banner", func(w http.ResponseWriter, r *http.Request) {
+			UploadBanner(w, r, store, validator)
+		})
+
+		r.Route("/positions", func(r chi.Router) {
+			r.Post("/", CreatePosition)
+			r.Route("/{PositionID}", func(r chi.Router) {
+				r.Use(PositionCtx)
+				r.Delete("/", DeletePosition)
+				r.Post("/signup", SignUp)
+				r.Delete("/signup", Withdraw)
+				r.Put("/assign", AssignPosition)
+			})
+		})
+	})
+}
+
+func Ctx(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := chi.URLParam(r, "EventID")
+		if id == "" {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		EventID, err := strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		event := &models.Event{ID: uint(EventID)}
+		if err = event.Get(); err != nil {
+			http.Error(w, "Not found", http.StatusNotFound)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), "event", event)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func GetEventCtx(r *http.Request) *models.Event {
+	return r.Context().Value("event").(*models.Event)
+}
+
+func PositionCtx(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := chi.URLParam(r, "PositionID")
+		if id == "" {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		PositionID, err := strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		position := &models.EventPosition{ID: uint(PositionID), EventID: GetEventCtx(r).ID}
+		if err = position.Get(); err != nil {
+			http.Error(w, "Not found", http.StatusNotFound)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), "eventPosition", position)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func GetPositionCtx(r *http.Request) *models.EventPosition {
+	return r.Context().Value("eventPosition").(*models.EventPosition)
+}
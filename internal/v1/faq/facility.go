@@ -0,0 +1,216 @@
+package faq
+
+import (
+	"errors"
+	"fmt"
+	"github.com/VATUSA/primary-api/pkg/database"
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/VATUSA/primary-api/pkg/database/types"
+	"github.com/VATUSA/primary-api/pkg/utils"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"net/http"
+	"strings"
+)
+
+type CategoryGroup struct {
+	Category types.FAQCategory `json:"category" example:"membership"`
+	FAQs     []models.FAQ      `json:"faqs"`
+}
+
+type FacilityResponse struct {
+	Facility   string          `json:"facility" example:"ZDV"`
+	Categories []CategoryGroup `json:"categories"`
+}
+
+// NewFacilityResponse groups the FAQs by category, in category display order, leaving out empty categories
+func NewFacilityResponse(facility string, faqs []models.FAQ) *FacilityResponse {
+	res := &FacilityResponse{Facility: facility, Categories: []CategoryGroup{}}
+	for _, category := range types.FAQCategories {
+		group := CategoryGroup{Category: category}
+		for _, f := range faqs {
+			if f.Category == category {
+				group.FAQs = append(group.FAQs, f)
+			}
+		}
+		if len(group.FAQs) > 0 {
+			res.Categories = append(res.Categories, group)
+		}
+	}
+	return res
+}
+
+func (res *FacilityResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+type ReorderRequest struct {
+	IDs []uint `json:"ids" example:"3,1,2" validate:"required,min=1,unique"`
+}
+
+func (req *ReorderRequest) Validate() error {
+	return validator.New().Struct(req)
+}
+
+func (req *ReorderRequest) Bind(r *http.Request) error {
+	return nil
+}
+
+// canManage reports whether the caller manages the facility's FAQs, which lets them see its drafts
+func canManage(r *http.Request, facility string) bool {
+	self := utils.GetSelf(r)
+	return self != nil && self.ManagesFacility(facility)
+}
+
+// includeDrafts reports whether the caller asked for drafts and may see them
+func includeDrafts(r *http.Request, facility string) bool {
+	return r.URL.Query().Get("include_drafts") == "true" && canManage(r, facility)
+}
+
+// facilityFAQ loads the FAQs shown for the facility in the URL, rendering an error if it can't
+func facilityFAQ(w http.ResponseWriter, r *http.Request) (string, []models.FAQ, bool) {
+	facility := strings.ToUpper(chi.URLParam(r, "Facility"))
+	if !models.IsValidFacility(facility) {
+		render.Render(w, r, utils.ErrInvalidFacility)
+		return "", nil, false
+	}
+
+	faqs, err := models.GetFacilityFAQ(facility, includeDrafts(r, facility))
+	if err != nil {
+		render.Render(w, r, utils.ErrInternalServer)
+		return "", nil, false
+	}
+
+	return facility, faqs, true
+}
+
+// ListFAQByFacility godoc
+// @Summary List FAQs for a facility
+// @Description List the published FAQs for a facility grouped by category, including division FAQs the facility hasn't overridden. Facility management and division staff can include drafts.
+// @Tags faq
+// @Produce  json
+// @Param Facility path string true "Facility ID"
+// @Param include_drafts query bool false "Include drafts"
+// @Success 200 {object} FacilityResponse
+// @Failure 400 {object} utils.ErrResponse
+// @Failure 500 {object} utils.ErrResponse
+// @Router /faq/{Facility} [get]
+func ListFAQByFacility(w http.ResponseWriter, r *http.Request) {
+	facility, faqs, ok := facilityFAQ(w, r)
+	if !ok {
+		return
+	}
+
+	render.Render(w, r, NewFacilityResponse(facility, faqs))
+}
+
+// ListFAQByFacilityByCategory godoc
+// @Summary List FAQs for a facility and category
+// @Description List the published FAQs for a facility in a category, including division FAQs the facility hasn't overridden. Facility management and division staff can include drafts.
+// @Tags faq
+// @Produce  json
+// @Param Facility path string true "Facility ID"
+// @Param Category path string true "Category"
+// @Param include_drafts query bool false "Include drafts"
+// @Success 200 {object} []Response
+// @Failure 400 {object} utils.ErrResponse
+// @Failure 500 {object} utils.ErrResponse
+// @Router /faq/{Facility}/{Category} [get]
+func ListFAQByFacilityByCategory(w http.ResponseWriter, r *http.Request) {
+	category := types.FAQCategory(chi.URLParam(r, "Category"))
+	if !category.IsValid() {
+		render.Render(w, r, utils.ErrInvalidRequest(errors.New("invalid category")))
+		return
+	}
+
+	_, faqs, ok := facilityFAQ(w, r)
+	if !ok {
+		return
+	}
+
+	inCategory := []models.FAQ{}
+	for _, f := range faqs {
+		if f.Category == category {
+			inCategory = append(inCategory, f)
+		}
+	}
+
+	if err := render.RenderList(w, r, NewFAQListResponse(inCategory)); err != nil {
+		render.Render(w, r, utils.ErrRender(err))
+		return
+	}
+}
+
+// ReorderFAQ godoc
+// @Summary Reorder a facility's FAQs
+// @Description Set the sort order of a facility's FAQs to the order of the given IDs. Every ID must belong to the facility. Only the facility's management and division staff may reorder them.
+// @Tags faq
+// @Accept  json
+// @Produce  json
+// @Param Facility path string true "Facility ID"
+// @Param order body ReorderRequest true "FAQ IDs in order"
+// @Success 200 {object} FacilityResponse
+// @Failure 400 {object} utils.ErrResponse
+// @Failure 401 {object} utils.ErrResponse
+// @Failure 403 {object} utils.ErrResponse
+// @Failure 500 {object} utils.ErrResponse
+// @Router /faq/{Facility}/reorder [post]
+func ReorderFAQ(w http.ResponseWriter, r *http.Request) {
+	facility := strings.ToUpper(chi.URLParam(r, "Facility"))
+	if !models.IsValidFacility(facility) {
+		render.Render(w, r, utils.ErrInvalidFacility)
+		return
+	}
+
+	self := utils.GetSelf(r)
+	if self == nil {
+		render.Render(w, r, utils.ErrUnauthorized)
+		return
+	}
+	if !self.ManagesFacility(facility) {
+		render.Render(w, r, utils.ErrForbidden)
+		return
+	}
+
+	data := &ReorderRequest{}
+	if err := render.Bind(r, data); err != nil {
+		render.Render(w, r, utils.ErrInvalidRequest(err))
+		return
+	}
+
+	if err := data.Validate(); err != nil {
+		render.Render(w, r, utils.ErrInvalidRequest(err))
+		return
+	}
+
+	owned, err := models.GetAllFAQByFacility(database.DB, facility)
+	if err != nil {
+		render.Render(w, r, utils.ErrInternalServer)
+		return
+	}
+
+	ids := map[uint]bool{}
+	for _, f := range owned {
+		ids[f.ID] = true
+	}
+	for _, id := range data.IDs {
+		if !ids[id] {
+			render.Render(w, r, utils.ErrInvalidRequest(fmt.Errorf("FAQ %d does not belong to %s", id, facility)))
+			return
+		}
+	}
+
+	if err := models.ReorderFAQ(data.IDs); err != nil {
+		render.Render(w, r, utils.ErrInternalServer)
+		return
+	}
+
+	faqs, err := models.GetFacilityFAQ(facility, true)
+	if err != nil {
+		render.Render(w, r, utils.ErrInternalServer)
+		return
+	}
+
+	render.Render(w, r, NewFacilityResponse(facility, faqs))
+}
@@ -1,7 +1,10 @@
 package faq
 
 import (
+	"errors"
+	"fmt"
 	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/VATUSA/primary-api/pkg/database/types"
 	"github.com/VATUSA/primary-api/pkg/utils"
 	"github.com/go-chi/render"
 	"github.com/go-playground/validator/v10"
@@ -9,10 +12,13 @@ import (
 )
 
 type Request struct {
-	Facility string `json:"facility" validate:"required,len=3"`
-	Question string `json:"question" validate:"required"`
-	Answer   string `json:"answer" validate:"required"`
-	Category string `json:"category" validate:"required,oneof=membership training technology misc"`
+	Facility  string              `json:"facility" validate:"required,len=3"`
+	Question  string              `json:"question" validate:"required"`
+	Answer    string              `json:"answer" validate:"required"`
+	Category  types.FAQCategory   `json:"category" validate:"required,oneof=membership training technology misc"`
+	SortOrder *int                `json:"sort_order"`
+	Status    types.PublishStatus `json:"status" validate:"omitempty,oneof=draft published"`
+	Overrides *uint               `json:"overrides"`
 }
 
 func (req *Request) Validate() error {
@@ -23,6 +29,24 @@ func (req *Request) Bind(r *http.Request) error {
 	return nil
 }
 
+// validateOverride checks that a FAQ for the facility may override the division FAQ
+func validateOverride(facility string, overrides *uint) error {
+	if overrides == nil {
+		return nil
+	}
+
+	if facility == models.DivisionFacility {
+		return errors.New("division FAQs cannot override other FAQs")
+	}
+
+	division := &models.FAQ{ID: *overrides}
+	if err := division.Get(); err != nil || division.Facility != models.DivisionFacility {
+		return fmt.Errorf("FAQ %d is not a division FAQ", *overrides)
+	}
+
+	return nil
+}
+
 type Response struct {
 	*models.FAQ
 }
@@ -74,13 +98,27 @@ func CreateFAQ(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := validateOverride(data.Facility, data.Overrides); err != nil {
+		render.Render(w, r, utils.ErrInvalidRequest(err))
+		return
+	}
+
+	if data.Status == "" {
+		data.Status = types.Published
+	}
+
 	faq := &models.FAQ{
 		Facility:  data.Facility,
 		Question:  data.Question,
 		Answer:    data.Answer,
 		Category:  data.Category,
+		Status:    data.Status,
+		Overrides: data.Overrides,
 		CreatedBy: 1,
 	}
+	if data.SortOrder != nil {
+		faq.SortOrder = *data.SortOrder
+	}
 
 	if err := faq.Create(); err != nil {
 		render.Render(w, r, utils.ErrInternalServer)
@@ -94,7 +132,7 @@ func CreateFAQ(w http.ResponseWriter, r *http.Request) {
 
 // GetFAQ godoc
 // @Summary Get a FAQ
-// @Description Get a FAQ
+// @Description Get a FAQ. Drafts are only shown to the management of the FAQ's facility and division staff.
 // @Tags faq
 // @Accept  json
 // @Produce  json
@@ -106,13 +144,17 @@ func CreateFAQ(w http.ResponseWriter, r *http.Request) {
 // @Router /faq/{id} [get]
 func GetFAQ(w http.ResponseWriter, r *http.Request) {
 	faq := GetFAQCtx(r)
+	if faq.Status == types.Draft && !canManage(r, faq.Facility) {
+		render.Render(w, r, utils.ErrNotFound)
+		return
+	}
 
 	render.Render(w, r, NewFAQResponse(faq))
 }
 
 // ListFAQ godoc
 // @Summary List all FAQs
-// @Description List all FAQs
+// @Description List all FAQs. Drafts are only included for the facilities the caller manages.
 // @Tags faq
 // @Accept  json
 // @Produce  json
@@ -126,7 +168,14 @@ func ListFAQ(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := render.RenderList(w, r, NewFAQListResponse(faqs)); err != nil {
+	shown := []models.FAQ{}
+	for _, f := range faqs {
+		if f.Status != types.Draft || canManage(r, f.Facility) {
+			shown = append(shown, f)
+		}
+	}
+
+	if err := render.RenderList(w, r, NewFAQListResponse(shown)); err != nil {
 		render.Render(w, r, utils.ErrInternalServer)
 		return
 	}
@@ -164,10 +213,25 @@ func UpdateFAQ(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := validateOverride(data.Facility, data.Overrides); err != nil {
+		render.Render(w, r, utils.ErrInvalidRequest(err))
+		return
+	}
+
+	if data.Status == "" {
+		data.Status = types.Published
+	}
+
 	faq.Facility = data.Facility
 	faq.Question = data.Question
 	faq.Answer = data.Answer
 	faq.Category = data.Category
+	faq.SortOrder = 0
+	if data.SortOrder != nil {
+		faq.SortOrder = *data.SortOrder
+	}
+	faq.Status = data.Status
+	faq.Overrides = data.Overrides
 
 	if err := faq.Update(); err != nil {
 		render.Render(w, r, utils.ErrInternalServer)
@@ -213,8 +277,30 @@ func PatchFAQ(w http.ResponseWriter, r *http.Request) {
 		faq.Answer = data.Answer
 	}
 	if data.Category != "" {
+		if !data.Category.IsValid() {
+			render.Render(w, r, utils.ErrInvalidRequest(errors.New("invalid category")))
+			return
+		}
 		faq.Category = data.Category
 	}
+	if data.SortOrder != nil {
+		faq.SortOrder = *data.SortOrder
+	}
+	if data.Status != "" {
+		if err := validator.New().Var(data.Status, "oneof=draft published"); err != nil {
+			render.Render(w, r, utils.ErrInvalidRequest(err))
+			return
+		}
+		faq.Status = data.Status
+	}
+	if data.Overrides != nil {
+		faq.Overrides = data.Overrides
+	}
+
+	if err := validateOverride(faq.Facility, faq.Overrides); err != nil {
+		render.Render(w, r, utils.ErrInvalidRequest(err))
+		return
+	}
 
 	if err := faq.Update(); err != nil {
 		render.Render(w, r, utils.ErrInternalServer)
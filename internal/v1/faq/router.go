@@ -12,12 +12,18 @@ func Router(r chi.Router) {
 	r.Get("/", ListFAQ)
 	r.Post("/", CreateFAQ)
 
-	r.Route("/{FAQID}", func(r chi.Router) {
+	// chi can't mount two parameter subrouters at the same level, so the facility and ID routes are
+	// told apart by pattern and registered flat
+	r.Get("/{Facility:[A-Za-z]{3}}", ListFAQByFacility)
+	r.Post("/{Facility:[A-Za-z]{3}}/reorder", ReorderFAQ)
+	r.Get("/{Facility:[A-Za-z]{3}}/{Category}", ListFAQByFacilityByCategory)
+
+	r.Group(func(r chi.Router) {
 		r.Use(Ctx)
-		r.Get("/", GetFAQ)
-		r.Put("/", UpdateFAQ)
-		r.Patch("/", PatchFAQ)
-		r.Delete("/", DeleteFAQ)
+		r.Get("/{FAQID:[0-9]+}", GetFAQ)
+		r.Put("/{FAQID:[0-9]+}", UpdateFAQ)
+		r.Patch("/{FAQID:[0-9]+}", PatchFAQ)
+		r.Delete("/{FAQID:[0-9]+}", DeleteFAQ)
 	})
 }
 
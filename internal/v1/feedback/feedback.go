@@ -64,9 +64,9 @@ func NewFeedbackListResponse(f []models.Feedback) []render.Renderer {
 // @Failure 400 {object} utils.ErrResponse
 // @Failure 500 {object} utils.ErrResponse
 // @Router /feedback [post]
-func CreateFeedback(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) CreateFeedback(w http.ResponseWriter, r *http.Request) {
 	data := &Request{}
-	if err := data.Bind(r); err != nil {
+	if err := render.Bind(r, data); err != nil {
 		render.Render(w, r, utils.ErrInvalidRequest(err))
 		return
 	}
@@ -76,12 +76,12 @@ func CreateFeedback(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if !models.IsValidUser(data.ControllerCID) {
+	if !h.users.Exists(data.ControllerCID) {
 		render.Render(w, r, utils.ErrInvalidCID)
 		return
 	}
 
-	if !models.IsValidFacility(data.Facility) {
+	if !h.facilities.Exists(data.Facility) {
 		render.Render(w, r, utils.ErrInvalidFacility)
 		return
 	}
@@ -97,7 +97,7 @@ func CreateFeedback(w http.ResponseWriter, r *http.Request) {
 		Status:        data.Status,
 		Comment:       data.Comment,
 	}
-	if err := f.Create(); err != nil {
+	if err := h.feedback.Create(f); err != nil {
 		render.Render(w, r, utils.ErrInternalServer)
 		return
 	}
@@ -118,7 +118,7 @@ func CreateFeedback(w http.ResponseWriter, r *http.Request) {
 // @Failure 404 {object} utils.ErrResponse
 // @Failure 500 {object} utils.ErrResponse
 // @Router /feedback/{id} [get]
-func GetFeedback(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) GetFeedback(w http.ResponseWriter, r *http.Request) {
 	f := GetFeedbackCtx(r)
 	render.Render(w, r, NewFeedbackResponse(f))
 }
@@ -133,8 +133,8 @@ func GetFeedback(w http.ResponseWriter, r *http.Request) {
 // @Failure 422 {object} utils.ErrResponse
 // @Failure 500 {object} utils.ErrResponse
 // @Router /feedback [get]
-func ListFeedback(w http.ResponseWriter, r *http.Request) {
-	f, err := models.GetAllFeedback()
+func (h *Handler) ListFeedback(w http.ResponseWriter, r *http.Request) {
+	f, err := h.feedback.List()
 	if err != nil {
 		render.Render(w, r, utils.ErrInternalServer)
 		return
@@ -158,9 +158,9 @@ func ListFeedback(w http.ResponseWriter, r *http.Request) {
 // @Failure 400 {object} utils.ErrResponse
 // @Failure 500 {object} utils.ErrResponse
 // @Router /feedback/{id} [put]
-func UpdateFeedback(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) UpdateFeedback(w http.ResponseWriter, r *http.Request) {
 	data := &Request{}
-	if err := data.Bind(r); err != nil {
+	if err := render.Bind(r, data); err != nil {
 		render.Render(w, r, utils.ErrInvalidRequest(err))
 		return
 	}
@@ -170,12 +170,12 @@ func UpdateFeedback(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if !models.IsValidUser(data.ControllerCID) {
+	if !h.users.Exists(data.ControllerCID) {
 		render.Render(w, r, utils.ErrInvalidCID)
 		return
 	}
 
-	if !models.IsValidFacility(data.Facility) {
+	if !h.facilities.Exists(data.Facility) {
 		render.Render(w, r, utils.ErrInvalidFacility)
 		return
 	}
@@ -191,7 +191,7 @@ func UpdateFeedback(w http.ResponseWriter, r *http.Request) {
 	f.Status = data.Status
 	f.Comment = data.Comment
 
-	if err := f.Update(); err != nil {
+	if err := h.feedback.Update(f); err != nil {
 		render.Render(w, r, utils.ErrInternalServer)
 		return
 	}
@@ -211,10 +211,10 @@ func UpdateFeedback(w http.ResponseWriter, r *http.Request) {
 // @Failure 400 {object} utils.ErrResponse
 // @Failure 500 {object} utils.ErrResponse
 // @Router /feedback/{id} [patch]
-func PatchFeedback(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) PatchFeedback(w http.ResponseWriter, r *http.Request) {
 	f := GetFeedbackCtx(r)
 	data := &Request{}
-	if err := data.Bind(r); err != nil {
+	if err := render.Bind(r, data); err != nil {
 		render.Render(w, r, utils.ErrInvalidRequest(err))
 		return
 	}
@@ -226,7 +226,7 @@ func PatchFeedback(w http.ResponseWriter, r *http.Request) {
 		f.Callsign = data.Callsign
 	}
 	if data.ControllerCID != 0 {
-		if !models.IsValidUser(data.ControllerCID) {
+		if !h.users.Exists(data.ControllerCID) {
 			render.Render(w, r, utils.ErrInvalidCID)
 			return
 		}
@@ -236,7 +236,7 @@ func PatchFeedback(w http.ResponseWriter, r *http.Request) {
 		f.Position = data.Position
 	}
 	if data.Facility != "" {
-		if !models.IsValidFacility(data.Facility) {
+		if !h.facilities.Exists(data.Facility) {
 			render.Render(w, r, utils.ErrInvalidFacility)
 			return
 		}
@@ -255,7 +255,7 @@ func PatchFeedback(w http.ResponseWriter, r *http.Request) {
 		f.Comment = data.Comment
 	}
 
-	if err := f.Update(); err != nil {
+	if err := h.feedback.Update(f); err != nil {
 		render.Render(w, r, utils.ErrInternalServer)
 		return
 	}
@@ -274,9 +274,9 @@ func PatchFeedback(w http.ResponseWriter, r *http.Request) {
 // @Failure 400 {object} utils.ErrResponse
 // @Failure 500 {object} utils.ErrResponse
 // @Router /feedback/{id} [delete]
-func DeleteFeedback(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) DeleteFeedback(w http.ResponseWriter, r *http.Request) {
 	f := GetFeedbackCtx(r)
-	if err := f.Delete(); err != nil {
+	if err := h.feedback.Delete(f); err != nil {
 		render.Render(w, r, utils.ErrInternalServer)
 		return
 	}
@@ -3,25 +3,38 @@ package feedback
 import (
 	"context"
 	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/VATUSA/primary-api/pkg/service"
 	"github.com/go-chi/chi/v5"
 	"net/http"
 	"strconv"
 )
 
-func Router(r chi.Router) {
-	r.Get("/", ListFeedback)
-	r.Post("/", CreateFeedback)
+// Handler serves the feedback endpoints through the services it is built with
+type Handler struct {
+	feedback   *service.FeedbackService
+	users      *service.UserService
+	facilities *service.FacilityService
+}
+
+func NewHandler(s *service.Services) *Handler {
+	return &Handler{feedback: s.Feedback, users: s.Users, facilities: s.Facilities}
+}
+
+func Router(r chi.Router, s *service.Services) {
+	h := NewHandler(s)
+	r.Get("/", h.ListFeedback)
+	r.Post("/", h.CreateFeedback)
 
 	r.Route("/{FeedbackID}", func(r chi.Router) {
-		r.Use(Ctx)
-		r.Get("/", GetFeedback)
-		r.Put("/", UpdateFeedback)
-		r.Patch("/", PatchFeedback)
-		r.Delete("/", DeleteFeedback)
+		r.Use(h.Ctx)
+		r.Get("/", h.GetFeedback)
+		r.Put("/", h.UpdateFeedback)
+		r.Patch("/", h.PatchFeedback)
+		r.Delete("/", h.DeleteFeedback)
 	})
 }
 
-func Ctx(next http.Handler) http.Handler {
+func (h *Handler) Ctx(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		id := chi.URLParam(r, "FeedbackID")
 		if id == "" {
@@ -35,8 +48,8 @@ func Ctx(next http.Handler) http.Handler {
 			return
 		}
 
-		feedback := &models.Feedback{ID: uint(FeedbackID)}
-		if err = feedback.Get(); err != nil {
+		feedback, err := h.feedback.Get(uint(FeedbackID))
+		if err != nil {
 			http.Error(w, "Invalid request", http.StatusBadRequest)
 			return
 		}
@@ -0,0 +1,257 @@
+package news
+
+import (
+	"encoding/xml"
+	"fmt"
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/VATUSA/primary-api/pkg/utils"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// FeedSize is the number of news items in a feed
+const FeedSize = 50
+
+// Feed is the news shown for a facility, ready to be written as RSS or Atom
+type Feed struct {
+	Facility models.Facility
+	SelfURL  string
+	Items    []models.News
+}
+
+// Updated is when the feed last changed
+func (f *Feed) Updated() time.Time {
+	updated := f.Facility.UpdatedAt
+	for _, item := range f.Items {
+		if item.UpdatedAt.After(updated) {
+			updated = item.UpdatedAt
+		}
+	}
+	return updated
+}
+
+func (f *Feed) name() string {
+	if f.Facility.Name != "" {
+		return f.Facility.Name
+	}
+	return f.Facility.ID
+}
+
+func itemID(n models.News) string {
+	return fmt.Sprintf("urn:vatusa:news:%d", n.ID)
+}
+
+type RSS struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Atom    string     `xml:"xmlns:atom,attr"`
+	Channel RSSChannel `xml:"channel"`
+}
+
+type RSSChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	SelfLink      AtomLink  `xml:"atom:link"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Items         []RSSItem `xml:"item"`
+}
+
+type RSSItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link,omitempty"`
+	Description string  `xml:"description"`
+	GUID        RSSGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate"`
+	Category    string  `xml:"category,omitempty"`
+}
+
+type RSSGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+// NewRSS builds an RSS 2.0 document for the feed
+func NewRSS(f *Feed) *RSS {
+	rss := &RSS{
+		Version: "2.0",
+		Atom:    "http://www.w3.org/2005/Atom",
+		Channel: RSSChannel{
+			Title:         f.name() + " News",
+			Link:          f.Facility.URL,
+			Description:   "News from " + f.name(),
+			SelfLink:      AtomLink{Href: f.SelfURL, Rel: "self", Type: "application/rss+xml"},
+			LastBuildDate: f.Updated().UTC().Format(time.RFC1123Z),
+		},
+	}
+
+	for _, n := range f.Items {
+		item := RSSItem{
+			Title:       n.Title,
+			Link:        f.Facility.URL,
+			Description: n.Description,
+			GUID:        RSSGUID{Value: itemID(n)},
+			PubDate:     n.PublishedAt().UTC().Format(time.RFC1123Z),
+		}
+		if n.Pinned {
+			item.Category = "Pinned"
+		}
+		rss.Channel.Items = append(rss.Channel.Items, item)
+	}
+
+	return rss
+}
+
+type Atom struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Links   []AtomLink  `xml:"link"`
+	Entries []AtomEntry `xml:"entry"`
+}
+
+type AtomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type AtomEntry struct {
+	Title     string        `xml:"title"`
+	ID        string        `xml:"id"`
+	Updated   string        `xml:"updated"`
+	Published string        `xml:"published"`
+	Links     []AtomLink    `xml:"link,omitempty"`
+	Category  *AtomCategory `xml:"category,omitempty"`
+	Summary   string        `xml:"summary"`
+}
+
+type AtomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+// NewAtom builds an Atom document for the feed
+func NewAtom(f *Feed) *Atom {
+	atom := &Atom{
+		Title:   f.name() + " News",
+		ID:      f.SelfURL,
+		Updated: f.Updated().UTC().Format(time.RFC3339),
+		Links:   []AtomLink{{Href: f.SelfURL, Rel: "self", Type: "application/atom+xml"}},
+	}
+	if f.Facility.URL != "" {
+		atom.Links = append(atom.Links, AtomLink{Href: f.Facility.URL, Rel: "alternate"})
+	}
+
+	for _, n := range f.Items {
+		entry := AtomEntry{
+			Title:     n.Title,
+			ID:        itemID(n),
+			Updated:   n.UpdatedAt.UTC().Format(time.RFC3339),
+			Published: n.PublishedAt().UTC().Format(time.RFC3339),
+			Summary:   n.Description,
+		}
+		if f.Facility.URL != "" {
+			entry.Links = []AtomLink{{Href: f.Facility.URL, Rel: "alternate"}}
+		}
+		if n.Pinned {
+			entry.Category = &AtomCategory{Term: "Pinned"}
+		}
+		atom.Entries = append(atom.Entries, entry)
+	}
+
+	return atom
+}
+
+// requestURL rebuilds the URL the request was made to, honouring the proxy's scheme
+func requestURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+	return scheme + "://" + r.Host + r.URL.Path
+}
+
+// liveFacilityNews loads the facility in the URL and its live news, rendering an error if it can't
+func liveFacilityNews(w http.ResponseWriter, r *http.Request, limit int) (*models.Facility, []models.News, bool) {
+	facility := &models.Facility{ID: strings.ToUpper(chi.URLParam(r, "Facility"))}
+	if err := facility.Get(); err != nil {
+		render.Render(w, r, utils.ErrInvalidFacility)
+		return nil, nil, false
+	}
+
+	news, err := models.GetLiveNews(facility.ID, time.Now(), limit)
+	if err != nil {
+		render.Render(w, r, utils.ErrInternalServer)
+		return nil, nil, false
+	}
+
+	return facility, news, true
+}
+
+// ListNewsByFacility godoc
+// @Summary List news for a facility
+// @Description List the live news for a facility, including division news, pinned news first and then newest first
+// @Tags news
+// @Produce  json
+// @Param Facility path string true "Facility ID"
+// @Success 200 {object} []Response
+// @Failure 400 {object} utils.ErrResponse
+// @Failure 500 {object} utils.ErrResponse
+// @Router /news/{Facility} [get]
+func ListNewsByFacility(w http.ResponseWriter, r *http.Request) {
+	_, news, ok := liveFacilityNews(w, r, 0)
+	if !ok {
+		return
+	}
+
+	if err := render.RenderList(w, r, NewNewsListResponse(news)); err != nil {
+		render.Render(w, r, utils.ErrRender(err))
+		return
+	}
+}
+
+// GetFeed godoc
+// @Summary Get a facility news feed
+// @Description Get the live news for a facility, including division news, as RSS 2.0. Use format=atom, or atom.xml, for Atom.
+// @Tags news
+// @Produce  xml
+// @Param Facility path string true "Facility ID"
+// @Param format query string false "rss or atom"
+// @Success 200
+// @Failure 400 {object} utils.ErrResponse
+// @Failure 500 {object} utils.ErrResponse
+// @Router /news/{Facility}/feed.xml [get]
+// @Router /news/{Facility}/atom.xml [get]
+func GetFeed(w http.ResponseWriter, r *http.Request) {
+	facility, news, ok := liveFacilityNews(w, r, FeedSize)
+	if !ok {
+		return
+	}
+
+	feed := &Feed{Facility: *facility, SelfURL: requestURL(r), Items: news}
+
+	var doc interface{}
+	if r.URL.Query().Get("format") == "atom" || strings.HasSuffix(r.URL.Path, "/atom.xml") {
+		w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+		doc = NewAtom(feed)
+	} else {
+		w.Header().Set("Content-Type", "application/rss+xml; charset=utf-8")
+		doc = NewRSS(feed)
+	}
+
+	out, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		render.Render(w, r, utils.ErrInternalServer)
+		return
+	}
+
+	w.Write([]byte(xml.Header))
+	w.Write(out)
+}
@@ -4,16 +4,22 @@ import (
 	"errors"
 	"fmt"
 	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/VATUSA/primary-api/pkg/database/types"
 	"github.com/VATUSA/primary-api/pkg/utils"
 	"github.com/go-chi/render"
 	"github.com/go-playground/validator/v10"
 	"net/http"
+	"time"
 )
 
 type Request struct {
-	Facility    string `json:"facility" example:"ZDV" validate:"required,len=3"`
-	Title       string `json:"title" example:"DP001 Revision 3 Released" validate:"required"`
-	Description string `json:"description" example:"DP001 has been revised to include new information regarding the new VATSIM Code of Conduct" validate:"required"`
+	Facility    string              `json:"facility" example:"ZDV" validate:"required,len=3"`
+	Title       string              `json:"title" example:"DP001 Revision 3 Released" validate:"required"`
+	Description string              `json:"description" example:"DP001 has been revised to include new information regarding the new VATSIM Code of Conduct" validate:"required"`
+	Status      types.PublishStatus `json:"status" example:"published" validate:"omitempty,oneof=draft scheduled published"`
+	PublishAt   string              `json:"publish_at" example:"2021-01-01T00:00:00Z"`
+	ExpireAt    string              `json:"expire_at" example:"2021-02-01T00:00:00Z"`
+	Pinned      *bool               `json:"pinned" example:"false"`
 }
 
 func (req *Request) Validate() error {
@@ -24,6 +30,78 @@ func (req *Request) Bind(r *http.Request) error {
 	return nil
 }
 
+// applyPublishing sets the publishing fields of news from the request. When patching, fields missing from the
+// request are left alone; otherwise a missing status means published and missing times are cleared.
+func applyPublishing(news *models.News, req *Request, patch bool) error {
+	if req.Status != "" {
+		if err := validator.New().Var(req.Status, "oneof=draft scheduled published"); err != nil {
+			return err
+		}
+	}
+
+	previous := news.Status
+	if req.Status != "" {
+		news.Status = req.Status
+	} else if !patch {
+		news.Status = types.Published
+	}
+
+	if req.PublishAt != "" {
+		publishAt, err := time.Parse(time.RFC3339, req.PublishAt)
+		if err != nil {
+			return err
+		}
+		news.PublishAt = &publishAt
+	} else if !patch {
+		news.PublishAt = nil
+	}
+
+	if req.ExpireAt != "" {
+		expireAt, err := time.Parse(time.RFC3339, req.ExpireAt)
+		if err != nil {
+			return err
+		}
+		news.ExpireAt = &expireAt
+	} else if !patch {
+		news.ExpireAt = nil
+	}
+
+	if req.Pinned != nil {
+		news.Pinned = *req.Pinned
+	} else if !patch {
+		news.Pinned = false
+	}
+
+	switch news.Status {
+	case types.Scheduled:
+		if news.PublishAt == nil {
+			return errors.New("publish_at is required for scheduled news")
+		}
+	case types.Published:
+		// News published by hand goes out now unless it is backdated
+		if req.PublishAt == "" && (news.PublishAt == nil || previous != types.Published) {
+			now := time.Now()
+			news.PublishAt = &now
+		}
+	}
+
+	if news.ExpireAt != nil && news.PublishAt != nil && !news.ExpireAt.After(*news.PublishAt) {
+		return errors.New("expire_at must be after publish_at")
+	}
+
+	return nil
+}
+
+// canView reports whether the caller may see the news: anyone can once it's live, and the facility's staff can
+// see it before and after
+func canView(r *http.Request, news *models.News, now time.Time) bool {
+	if news.IsLive(now) {
+		return true
+	}
+	self := utils.GetSelf(r)
+	return self != nil && self.IsFacilityStaff(news.Facility)
+}
+
 type Response struct {
 	*models.News
 }
@@ -83,6 +161,11 @@ func CreateNews(w http.ResponseWriter, r *http.Request) {
 		CreatedBy:   "System",
 	}
 
+	if err := applyPublishing(news, data, false); err != nil {
+		render.Render(w, r, utils.ErrInvalidRequest(err))
+		return
+	}
+
 	if err := news.Create(); err != nil {
 		render.Render(w, r, utils.ErrInternalServer)
 		return
@@ -94,7 +177,7 @@ func CreateNews(w http.ResponseWriter, r *http.Request) {
 
 // GetNews godoc
 // @Summary Get a news entry
-// @Description Get a news entry
+// @Description Get a news entry. News that isn't live is only shown to the staff of its facility.
 // @Tags news
 // @Accept  json
 // @Produce  json
@@ -106,12 +189,16 @@ func CreateNews(w http.ResponseWriter, r *http.Request) {
 // @Router /news/{id} [get]
 func GetNews(w http.ResponseWriter, r *http.Request) {
 	news := GetNewsCtx(r)
+	if !canView(r, news, time.Now()) {
+		render.Render(w, r, utils.ErrNotFound)
+		return
+	}
 	render.Render(w, r, NewNewsResponse(news))
 }
 
 // ListNews godoc
 // @Summary List all news entries
-// @Description List all news entries
+// @Description List all news entries. Drafts, scheduled and expired news are only included for the facilities the caller is staff of.
 // @Tags news
 // @Accept  json
 // @Produce  json
@@ -126,7 +213,15 @@ func ListNews(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := render.RenderList(w, r, NewNewsListResponse(news)); err != nil {
+	now := time.Now()
+	shown := []models.News{}
+	for _, n := range news {
+		if canView(r, &n, now) {
+			shown = append(shown, n)
+		}
+	}
+
+	if err := render.RenderList(w, r, NewNewsListResponse(shown)); err != nil {
 		render.Render(w, r, utils.ErrRender(err))
 		return
 	}
@@ -169,6 +264,11 @@ func UpdateNews(w http.ResponseWriter, r *http.Request) {
 	news.Description = req.Description
 	news.UpdatedBy = "System"
 
+	if err := applyPublishing(news, req, false); err != nil {
+		render.Render(w, r, utils.ErrInvalidRequest(err))
+		return
+	}
+
 	if err := news.Update(); err != nil {
 		render.Render(w, r, utils.ErrInternalServer)
 		return
@@ -214,6 +314,11 @@ func PatchNews(w http.ResponseWriter, r *http.Request) {
 		news.Description = req.Description
 	}
 
+	if err := applyPublishing(news, req, true); err != nil {
+		render.Render(w, r, utils.ErrInvalidRequest(err))
+		return
+	}
+
 	news.UpdatedBy = "System"
 
 	if err := news.Update(); err != nil {
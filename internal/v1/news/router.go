@@ -14,12 +14,18 @@ func Router(r chi.Router) {
 	r.Get("/", ListNews)
 	r.Post("/", CreateNews)
 
-	r.Route("/{NewsID}", func(r chi.Router) {
+	// Facility and ID routes are told apart by pattern, as chi can't mount two parameter subrouters at the
+	// same level
+	r.Get("/{Facility:[A-Za-z]{3}}", ListNewsByFacility)
+	r.Get("/{Facility:[A-Za-z]{3}}/feed.xml", GetFeed)
+	r.Get("/{Facility:[A-Za-z]{3}}/atom.xml", GetFeed)
+
+	r.Group(func(r chi.Router) {
 		r.Use(Ctx)
-		r.Get("/", GetNews)
-		r.Put("/", UpdateNews)
-		r.Patch("/", PatchNews)
-		r.Delete("/", DeleteNews)
+		r.Get("/{NewsID:[0-9]+}", GetNews)
+		r.Put("/{NewsID:[0-9]+}", UpdateNews)
+		r.Patch("/{NewsID:[0-9]+}", PatchNews)
+		r.Delete("/{NewsID:[0-9]+}", DeleteNews)
 	})
 }
 
@@ -0,0 +1,179 @@
+package notification
+
+import (
+	"errors"
+	"github.com/VATUSA/primary-api/pkg/constants"
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/VATUSA/primary-api/pkg/database/types"
+	"github.com/VATUSA/primary-api/pkg/utils"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"net/http"
+	"time"
+)
+
+type BroadcastRequest struct {
+	AudienceType types.AudienceType `json:"audience_type" example:"facility" validate:"required,oneof=facility role group"`
+	Audience     string             `json:"audience" example:"ZDV" validate:"required"`
+	Facility     string             `json:"facility" example:"ZDV" validate:"omitempty,len=3"`
+	HomeOnly     bool               `json:"home_only" example:"true"`
+	Category     string             `json:"category" example:"Training" validate:"required"`
+	Title        string             `json:"title" example:"Upcoming Training Session" validate:"required"`
+	Body         string             `json:"body" example:"You have a training session coming up." validate:"required"`
+	ExpireAt     string             `json:"expire_at" example:"Fri, 01 Jan 2021 00:00:00 GMT" validate:"required"`
+}
+
+func (req *BroadcastRequest) Validate() error {
+	return validator.New().Struct(req)
+}
+
+func (req *BroadcastRequest) Bind(r *http.Request) error {
+	return nil
+}
+
+type BroadcastResponse struct {
+	*models.NotificationBroadcast
+}
+
+func NewBroadcastResponse(b *models.NotificationBroadcast) *BroadcastResponse {
+	return &BroadcastResponse{NotificationBroadcast: b}
+}
+
+func (res *BroadcastResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	if res.NotificationBroadcast == nil {
+		return errors.New("broadcast not found")
+	}
+	return nil
+}
+
+func NewBroadcastListResponse(b []models.NotificationBroadcast) []render.Renderer {
+	list := []render.Renderer{}
+	for _, d := range b {
+		list = append(list, NewBroadcastResponse(&d))
+	}
+	return list
+}
+
+// CreateBroadcast godoc
+// @Summary Broadcast a notification
+// @Description Send a notification to every member of a facility, role or group audience
+// @Tags notification
+// @Accept  json
+// @Produce  json
+// @Param broadcast body BroadcastRequest true "Broadcast"
+// @Success 201 {object} BroadcastResponse
+// @Failure 400 {object} utils.ErrResponse
+// @Failure 500 {object} utils.ErrResponse
+// @Router /notification/broadcast [post]
+func CreateBroadcast(w http.ResponseWriter, r *http.Request) {
+	data := &BroadcastRequest{}
+	if err := render.Bind(r, data); err != nil {
+		render.Render(w, r, utils.ErrInvalidRequest(err))
+		return
+	}
+
+	if err := data.Validate(); err != nil {
+		render.Render(w, r, utils.ErrInvalidRequest(err))
+		return
+	}
+
+	switch data.AudienceType {
+	case types.FacilityAudience:
+		if !models.IsValidFacility(data.Audience) {
+			render.Render(w, r, utils.ErrInvalidFacility)
+			return
+		}
+	case types.RoleAudience:
+		if !constants.RoleID(data.Audience).IsValidRole() {
+			render.Render(w, r, utils.ErrInvalidRole)
+			return
+		}
+	case types.GroupAudience:
+		if !constants.GroupID(data.Audience).IsValidGroup() {
+			render.Render(w, r, utils.ErrInvalidRequest(errors.New("invalid group")))
+			return
+		}
+	}
+
+	if data.Facility != "" && !models.IsValidFacility(data.Facility) {
+		render.Render(w, r, utils.ErrInvalidFacility)
+		return
+	}
+
+	expireAt, err := http.ParseTime(data.ExpireAt)
+	if err != nil {
+		render.Render(w, r, utils.ErrInvalidRequest(err))
+		return
+	}
+
+	// Make sure expireAt is in the future
+	if expireAt.Before(time.Now()) {
+		render.Render(w, r, utils.ErrInvalidRequest(errors.New("expire_at must be in the future")))
+		return
+	}
+
+	b := &models.NotificationBroadcast{
+		AudienceType: data.AudienceType,
+		Audience:     data.Audience,
+		Facility:     data.Facility,
+		HomeOnly:     data.HomeOnly,
+		Category:     data.Category,
+		Title:        data.Title,
+		Body:         data.Body,
+		ExpireAt:     expireAt,
+	}
+
+	if self := utils.GetSelf(r); self != nil {
+		b.CreatedBy = self.CID
+	}
+
+	notifications, err := b.Send()
+	if err != nil {
+		render.Render(w, r, utils.ErrInternalServer)
+		return
+	}
+
+	Publish(r.Context(), notifications...)
+
+	render.Status(r, http.StatusCreated)
+	render.Render(w, r, NewBroadcastResponse(b))
+}
+
+// GetBroadcast godoc
+// @Summary Get a broadcast
+// @Description Get a broadcast
+// @Tags notification
+// @Param id path int true "Broadcast ID"
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} BroadcastResponse
+// @Failure 400 {object} utils.ErrResponse
+// @Failure 404 {object} utils.ErrResponse
+// @Router /notification/broadcast/{id} [get]
+func GetBroadcast(w http.ResponseWriter, r *http.Request) {
+	b := GetBroadcastCtx(r)
+	render.Render(w, r, NewBroadcastResponse(b))
+}
+
+// ListBroadcasts godoc
+// @Summary List all broadcasts
+// @Description List all broadcasts, newest first
+// @Tags notification
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} []BroadcastResponse
+// @Failure 422 {object} utils.ErrResponse
+// @Failure 500 {object} utils.ErrResponse
+// @Router /notification/broadcast [get]
+func ListBroadcasts(w http.ResponseWriter, r *http.Request) {
+	broadcasts, err := models.GetAllNotificationBroadcasts()
+	if err != nil {
+		render.Render(w, r, utils.ErrInternalServer)
+		return
+	}
+
+	if err := render.RenderList(w, r, NewBroadcastListResponse(broadcasts)); err != nil {
+		render.Render(w, r, utils.ErrRender(err))
+		return
+	}
+}
@@ -0,0 +1,61 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/VATUSA/primary-api/pkg/database/types"
+	"strconv"
+	"time"
+)
+
+// RosterCategory is the category of notifications about a member's rosters, roles and rating
+const RosterCategory = "Roster"
+
+// Consume is the outbox consumer that tells members about changes to their rosters, roles and rating
+func Consume(ctx context.Context, e *models.OutboxEvent) error {
+	if e.AggregateType != models.UserAggregate {
+		return nil
+	}
+
+	cid, err := strconv.ParseUint(e.AggregateID, 10, 64)
+	if err != nil {
+		return err
+	}
+
+	var title, body string
+	switch e.Type {
+	case types.RosterAdded:
+		title = fmt.Sprintf("Welcome to %s", e.Facility)
+		body = fmt.Sprintf("You have been added to the %s roster.", e.Facility)
+	case types.RosterRemoved:
+		title = fmt.Sprintf("Removed from %s", e.Facility)
+		body = fmt.Sprintf("You have been removed from the %s roster.", e.Facility)
+	case types.RoleGranted:
+		role := &models.UserRole{}
+		if err := json.Unmarshal([]byte(e.Payload), role); err != nil {
+			return err
+		}
+		title = "New Role"
+		body = fmt.Sprintf("You have been granted the %s role at %s.", role.RoleID, role.FacilityID)
+	case types.RatingChanged:
+		title = "Rating Changed"
+		body = "Your controller rating has been updated."
+	default:
+		return nil
+	}
+
+	n := &models.Notification{
+		CID:      uint(cid),
+		Category: RosterCategory,
+		Title:    title,
+		Body:     body,
+		ExpireAt: time.Now().Add(30 * 24 * time.Hour),
+	}
+	if err := n.Create(); err != nil {
+		return err
+	}
+	Publish(ctx, *n)
+	return nil
+}
@@ -62,7 +62,7 @@ func NewNotificationListResponse(n []models.Notification) []render.Renderer {
 // @Router /notification [post]
 func CreateNotification(w http.ResponseWriter, r *http.Request) {
 	data := &Request{}
-	if err := data.Bind(r); err != nil {
+	if err := render.Bind(r, data); err != nil {
 		render.Render(w, r, utils.ErrInvalidRequest(err))
 		return
 	}
@@ -102,6 +102,8 @@ func CreateNotification(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	Publish(r.Context(), *n)
+
 	render.Status(r, http.StatusCreated)
 	render.Render(w, r, NewNotificationResponse(n))
 }
@@ -160,7 +162,7 @@ func ListNotifications(w http.ResponseWriter, r *http.Request) {
 func UpdateNotification(w http.ResponseWriter, r *http.Request) {
 	n := GetNotificationCtx(r)
 	data := &Request{}
-	if err := data.Bind(r); err != nil {
+	if err := render.Bind(r, data); err != nil {
 		render.Render(w, r, utils.ErrInvalidRequest(err))
 		return
 	}
@@ -216,7 +218,7 @@ func UpdateNotification(w http.ResponseWriter, r *http.Request) {
 func PatchNotification(w http.ResponseWriter, r *http.Request) {
 	n := GetNotificationCtx(r)
 	data := &Request{}
-	if err := data.Bind(r); err != nil {
+	if err := render.Bind(r, data); err != nil {
 		render.Render(w, r, utils.ErrInvalidRequest(err))
 		return
 	}
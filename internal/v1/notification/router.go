@@ -11,6 +11,14 @@ import (
 func Router(r chi.Router) {
 	r.Get("/", ListNotifications)
 	r.Post("/", CreateNotification)
+	r.Route("/broadcast", func(r chi.Router) {
+		r.Get("/", ListBroadcasts)
+		r.Post("/", CreateBroadcast)
+		r.Route("/{BroadcastID}", func(r chi.Router) {
+			r.Use(BroadcastCtx)
+			r.Get("/", GetBroadcast)
+		})
+	})
 	r.Route("/{NotificationID}", func(r chi.Router) {
 		r.Use(Ctx)
 		r.Get("/", GetNotification)
@@ -48,3 +56,32 @@ func Ctx(next http.Handler) http.Handler {
 func GetNotificationCtx(r *http.Request) *models.Notification {
 	return r.Context().Value("notification").(*models.Notification)
 }
+
+func BroadcastCtx(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := chi.URLParam(r, "BroadcastID")
+		if id == "" {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		BroadcastID, err := strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		broadcast := &models.NotificationBroadcast{ID: uint(BroadcastID)}
+		if err = broadcast.Get(); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), "broadcast", broadcast)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func GetBroadcastCtx(r *http.Request) *models.NotificationBroadcast {
+	return r.Context().Value("broadcast").(*models.NotificationBroadcast)
+}
@@ -0,0 +1,155 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/VATUSA/primary-api/pkg/pubsub"
+	"github.com/VATUSA/primary-api/pkg/utils"
+	"github.com/go-chi/render"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// HeartbeatInterval is how often a comment is sent on idle streams to keep proxies from closing the connection
+var HeartbeatInterval = 15 * time.Second
+
+var (
+	streamsClosed = make(chan struct{})
+	closeStreams  sync.Once
+)
+
+// CloseStreams ends every open stream, so server shutdown isn't held up by them. Clients reconnect with
+// Last-Event-ID and pick up where they left off.
+func CloseStreams() {
+	closeStreams.Do(func() {
+		close(streamsClosed)
+	})
+}
+
+func Topic(cid uint) string {
+	return fmt.Sprintf("notifications:%d", cid)
+}
+
+// Publish pushes the notifications to any stream their recipient has open
+func Publish(ctx context.Context, notifications ...models.Notification) {
+	if pubsub.DefaultHub == nil {
+		return
+	}
+
+	for _, n := range notifications {
+		data, err := json.Marshal(n)
+		if err != nil {
+			log.Println("[Notification] Error encoding notification:", err)
+			continue
+		}
+
+		msg := pubsub.Message{ID: uint64(n.ID), Event: "notification", Data: data}
+		if err := pubsub.DefaultHub.Publish(ctx, Topic(n.CID), msg); err != nil {
+			log.Println("[Notification] Error publishing notification:", err)
+		}
+	}
+}
+
+// StreamNotifications godoc
+// @Summary Stream notifications
+// @Description Server-sent events stream of new notifications for the current user. Send Last-Event-ID to replay notifications missed since that ID.
+// @Tags notification
+// @Produce  text/event-stream
+// @Param Last-Event-ID header int false "ID of the last notification received"
+// @Success 200
+// @Failure 401 {object} utils.ErrResponse
+// @Failure 500 {object} utils.ErrResponse
+// @Router /me/notifications/stream [get]
+func StreamNotifications(w http.ResponseWriter, r *http.Request) {
+	self := utils.GetSelf(r)
+	if self == nil {
+		render.Render(w, r, utils.ErrUnauthorized)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok || pubsub.DefaultHub == nil {
+		render.Render(w, r, utils.ErrInternalServer)
+		return
+	}
+
+	var lastID uint64
+	if id := r.Header.Get("Last-Event-ID"); id != "" {
+		parsed, err := strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			render.Render(w, r, utils.ErrInvalidRequest(err))
+			return
+		}
+		lastID = parsed
+	}
+
+	// Subscribe before replaying so nothing created in between is missed
+	messages, cancel, err := pubsub.DefaultHub.Subscribe(r.Context(), Topic(self.CID))
+	if err != nil {
+		render.Render(w, r, utils.ErrInternalServer)
+		return
+	}
+	defer cancel()
+
+	var missed []models.Notification
+	if lastID != 0 {
+		missed, err = models.GetActiveNotificationsByCIDSince(self.CID, uint(lastID))
+		if err != nil {
+			render.Render(w, r, utils.ErrInternalServer)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	// Notifications created after subscribing can be both replayed and published; IDs aren't published in order,
+	// so only those sent during the replay are skipped
+	replayed := map[uint64]bool{}
+	for _, n := range missed {
+		data, err := json.Marshal(n)
+		if err != nil {
+			continue
+		}
+		writeEvent(w, pubsub.Message{ID: uint64(n.ID), Event: "notification", Data: data})
+		replayed[uint64(n.ID)] = true
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(HeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-streamsClosed:
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+			flusher.Flush()
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			if replayed[msg.ID] {
+				delete(replayed, msg.ID)
+				continue
+			}
+			writeEvent(w, msg)
+			flusher.Flush()
+		}
+	}
+}
+
+func writeEvent(w http.ResponseWriter, msg pubsub.Message) {
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", msg.ID, msg.Event, msg.Data)
+}
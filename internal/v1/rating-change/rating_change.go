@@ -60,7 +60,7 @@ func NewRatingChangeListResponse(rc []models.RatingChange) []render.Renderer {
 // @Router /rating-change [post]
 func CreateRatingChange(w http.ResponseWriter, r *http.Request) {
 	data := &Request{}
-	if err := data.Bind(r); err != nil {
+	if err := render.Bind(r, data); err != nil {
 		render.Render(w, r, utils.ErrInvalidRequest(err))
 		return
 	}
@@ -147,7 +147,7 @@ func ListRatingChanges(w http.ResponseWriter, r *http.Request) {
 // @Router /rating-change/{id} [put]
 func UpdateRatingChange(w http.ResponseWriter, r *http.Request) {
 	data := &Request{}
-	if err := data.Bind(r); err != nil {
+	if err := render.Bind(r, data); err != nil {
 		render.Render(w, r, utils.ErrInvalidRequest(err))
 		return
 	}
@@ -193,7 +193,7 @@ func UpdateRatingChange(w http.ResponseWriter, r *http.Request) {
 func PatchRatingChange(w http.ResponseWriter, r *http.Request) {
 	rc := GetRatingChangeCtx(r)
 	data := &Request{}
-	if err := data.Bind(r); err != nil {
+	if err := render.Bind(r, data); err != nil {
 		render.Render(w, r, utils.ErrInvalidRequest(err))
 		return
 	}
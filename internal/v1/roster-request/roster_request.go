@@ -62,7 +62,7 @@ func NewRosterRequestListResponse(r []models.RosterRequest) []render.Renderer {
 // @Router /roster-request [post]
 func CreateRosterRequest(w http.ResponseWriter, r *http.Request) {
 	req := &Request{}
-	if err := req.Bind(r); err != nil {
+	if err := render.Bind(r, req); err != nil {
 		render.Render(w, r, utils.ErrInvalidRequest(err))
 		return
 	}
@@ -156,7 +156,7 @@ func ListRosterRequest(w http.ResponseWriter, r *http.Request) {
 func UpdateRosterRequest(w http.ResponseWriter, r *http.Request) {
 	req := GetRosterRequestCtx(r)
 	data := &Request{}
-	if err := data.Bind(r); err != nil {
+	if err := render.Bind(r, data); err != nil {
 		render.Render(w, r, utils.ErrInvalidRequest(err))
 		return
 	}
@@ -176,8 +176,9 @@ func UpdateRosterRequest(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	var roster *models.Roster
 	if req.Status == types.Pending && data.Status == types.Accepted {
-		roster := &models.Roster{
+		roster = &models.Roster{
 			CID:        data.CID,
 			Facility:   data.Facility,
 			OIs:        "",
@@ -193,11 +194,6 @@ func UpdateRosterRequest(w http.ResponseWriter, r *http.Request) {
 		} else {
 			roster.Home = true
 		}
-
-		if err := roster.Create(); err != nil {
-			render.Render(w, r, utils.ErrInvalidRequest(err))
-			return
-		}
 	}
 
 	req.CID = data.CID
@@ -206,7 +202,13 @@ func UpdateRosterRequest(w http.ResponseWriter, r *http.Request) {
 	req.Status = data.Status
 	req.Reason = data.Reason
 
-	if err := req.Update(); err != nil {
+	if roster != nil {
+		// Add them to the roster and accept the request together, so neither happens without the other
+		if err := req.Accept(roster); err != nil {
+			render.Render(w, r, utils.ErrInvalidRequest(err))
+			return
+		}
+	} else if err := req.Update(); err != nil {
 		render.Render(w, r, utils.ErrInvalidRequest(err))
 		return
 	}
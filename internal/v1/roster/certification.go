@@ -0,0 +1,249 @@
+package roster
+
+import (
+	"errors"
+	"fmt"
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/VATUSA/primary-api/pkg/database/types"
+	"github.com/VATUSA/primary-api/pkg/service"
+	"github.com/VATUSA/primary-api/pkg/utils"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// MaxSoloDuration is the longest a solo certification may be granted for at once
+const MaxSoloDuration = 90 * 24 * time.Hour
+
+type CertificationRequest struct {
+	Type      types.CertificationType `json:"type" example:"TWR" validate:"required,oneof=DEL GND TWR APP CTR tier1 special"`
+	Position  string                  `json:"position" example:"DEN" validate:"max=32"`
+	Solo      bool                    `json:"solo" example:"false"`
+	ExpiresAt string                  `json:"expires_at" example:"2021-02-01T00:00:00Z"` // Required for solo certifications
+}
+
+func (req *CertificationRequest) Validate() error {
+	return validator.New().Struct(req)
+}
+
+func (req *CertificationRequest) Bind(r *http.Request) error {
+	return nil
+}
+
+type CertificationResponse struct {
+	*models.Certification
+}
+
+func NewCertificationResponse(c *models.Certification) *CertificationResponse {
+	return &CertificationResponse{Certification: c}
+}
+
+func (res *CertificationResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	if res.Certification == nil {
+		return errors.New("certification not found")
+	}
+	return nil
+}
+
+func NewCertificationListResponse(c []models.Certification) []render.Renderer {
+	list := []render.Renderer{}
+	for _, d := range c {
+		list = append(list, NewCertificationResponse(&d))
+	}
+	return list
+}
+
+// canCertify renders the error response and returns false unless the caller may manage certifications at the facility
+func canCertify(w http.ResponseWriter, r *http.Request, facility string) bool {
+	self := utils.GetSelf(r)
+	if self == nil {
+		render.Render(w, r, utils.ErrUnauthorized)
+		return false
+	}
+	if !self.CanCertify(facility) {
+		render.Render(w, r, utils.ErrForbidden)
+		return false
+	}
+	return true
+}
+
+// applyCertification checks the request and copies it onto the certification
+func applyCertification(cert *models.Certification, data *CertificationRequest, now time.Time) error {
+	if err := data.Validate(); err != nil {
+		return err
+	}
+
+	position := strings.ToUpper(strings.TrimSpace(data.Position))
+	if data.Type.IsEndorsement() && position == "" {
+		return fmt.Errorf("%s endorsements must name the airport or airspace they cover", data.Type)
+	}
+
+	var expiresAt *time.Time
+	if data.Solo {
+		if !data.Type.AllowsSolo() {
+			return fmt.Errorf("%s can't be granted as a solo certification", data.Type)
+		}
+		if data.ExpiresAt == "" {
+			return errors.New("solo certifications require expires_at")
+		}
+		t, err := time.Parse(time.RFC3339, data.ExpiresAt)
+		if err != nil {
+			return err
+		}
+		if !t.After(now) {
+			return errors.New("expires_at must be in the future")
+		}
+		if t.Sub(now) > MaxSoloDuration {
+			return fmt.Errorf("solo certifications can't last longer than %d days", int(MaxSoloDuration.Hours()/24))
+		}
+		expiresAt = &t
+	} else if data.ExpiresAt != "" {
+		return errors.New("only solo certifications expire")
+	}
+
+	cert.Type = data.Type
+	cert.Position = position
+	cert.Solo = data.Solo
+	cert.ExpiresAt = expiresAt
+	return nil
+}
+
+// ListCertifications godoc
+// @Summary List a roster member's certifications
+// @Description List the certifications and endorsements held through a roster entry
+// @Tags roster
+// @Produce  json
+// @Param id path int true "Roster ID"
+// @Success 200 {object} []CertificationResponse
+// @Failure 400 {object} utils.ErrResponse
+// @Failure 500 {object} utils.ErrResponse
+// @Router /roster/{id}/certifications [get]
+func (h *Handler) ListCertifications(w http.ResponseWriter, r *http.Request) {
+	roster := GetRosterCtx(r)
+
+	if err := render.RenderList(w, r, NewCertificationListResponse(roster.Certifications)); err != nil {
+		render.Render(w, r, utils.ErrRender(err))
+		return
+	}
+}
+
+// CreateCertification godoc
+// @Summary Grant a certification
+// @Description Grant a position certification or endorsement to a roster member. Limited to the facility's TA and instructors.
+// @Tags roster
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Roster ID"
+// @Param certification body CertificationRequest true "Certification"
+// @Success 201 {object} CertificationResponse
+// @Failure 400 {object} utils.ErrResponse
+// @Failure 401 {object} utils.ErrResponse
+// @Failure 403 {object} utils.ErrResponse
+// @Failure 409 {object} utils.ErrResponse
+// @Failure 500 {object} utils.ErrResponse
+// @Router /roster/{id}/certifications [post]
+func (h *Handler) CreateCertification(w http.ResponseWriter, r *http.Request) {
+	roster := GetRosterCtx(r)
+	if !canCertify(w, r, roster.Facility) {
+		return
+	}
+
+	data := &CertificationRequest{}
+	if err := render.Bind(r, data); err != nil {
+		render.Render(w, r, utils.ErrInvalidRequest(err))
+		return
+	}
+
+	cert := &models.Certification{GrantedBy: utils.GetSelf(r).CID}
+	if err := applyCertification(cert, data, time.Now()); err != nil {
+		render.Render(w, r, utils.ErrInvalidRequest(err))
+		return
+	}
+
+	if err := h.roster.CreateCertification(roster, cert); errors.Is(err, service.ErrCertificationHeld) {
+		render.Render(w, r, utils.ErrConflict(err))
+		return
+	} else if err != nil {
+		render.Render(w, r, utils.ErrInternalServer)
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.Render(w, r, NewCertificationResponse(cert))
+}
+
+// UpdateCertification godoc
+// @Summary Update a certification
+// @Description Update a roster member's certification, such as to extend a solo certification or make it permanent
+// @Tags roster
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Roster ID"
+// @Param certification_id path int true "Certification ID"
+// @Param certification body CertificationRequest true "Certification"
+// @Success 200 {object} CertificationResponse
+// @Failure 400 {object} utils.ErrResponse
+// @Failure 401 {object} utils.ErrResponse
+// @Failure 403 {object} utils.ErrResponse
+// @Failure 404 {object} utils.ErrResponse
+// @Failure 409 {object} utils.ErrResponse
+// @Failure 500 {object} utils.ErrResponse
+// @Router /roster/{id}/certifications/{certification_id} [put]
+func (h *Handler) UpdateCertification(w http.ResponseWriter, r *http.Request) {
+	roster := GetRosterCtx(r)
+	cert := GetCertificationCtx(r)
+	if !canCertify(w, r, roster.Facility) {
+		return
+	}
+
+	data := &CertificationRequest{}
+	if err := render.Bind(r, data); err != nil {
+		render.Render(w, r, utils.ErrInvalidRequest(err))
+		return
+	}
+
+	if err := applyCertification(cert, data, time.Now()); err != nil {
+		render.Render(w, r, utils.ErrInvalidRequest(err))
+		return
+	}
+
+	cert.GrantedBy = utils.GetSelf(r).CID
+	if err := h.roster.UpdateCertification(roster, cert); errors.Is(err, service.ErrCertificationHeld) {
+		render.Render(w, r, utils.ErrConflict(err))
+		return
+	} else if err != nil {
+		render.Render(w, r, utils.ErrInternalServer)
+		return
+	}
+
+	render.Render(w, r, NewCertificationResponse(cert))
+}
+
+// DeleteCertification godoc
+// @Summary Revoke a certification
+// @Description Revoke a roster member's certification
+// @Tags roster
+// @Param id path int true "Roster ID"
+// @Param certification_id path int true "Certification ID"
+// @Success 204
+// @Failure 401 {object} utils.ErrResponse
+// @Failure 403 {object} utils.ErrResponse
+// @Failure 404 {object} utils.ErrResponse
+// @Failure 500 {object} utils.ErrResponse
+// @Router /roster/{id}/certifications/{certification_id} [delete]
+func (h *Handler) DeleteCertification(w http.ResponseWriter, r *http.Request) {
+	roster := GetRosterCtx(r)
+	cert := GetCertificationCtx(r)
+	if !canCertify(w, r, roster.Facility) {
+		return
+	}
+
+	if err := h.roster.DeleteCertification(cert); err != nil {
+		render.Render(w, r, utils.ErrInternalServer)
+		return
+	}
+
+	render.Status(r, http.StatusNoContent)
+}
@@ -64,9 +64,9 @@ func NewRosterListResponse(r []models.Roster) []render.Renderer {
 // @Failure 400 {object} utils.ErrResponse
 // @Failure 500 {object} utils.ErrResponse
 // @Router /roster [post]
-func CreateRoster(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) CreateRoster(w http.ResponseWriter, r *http.Request) {
 	data := &Request{}
-	if err := data.Bind(r); err != nil {
+	if err := render.Bind(r, data); err != nil {
 		render.Render(w, r, utils.ErrInvalidRequest(err))
 		return
 	}
@@ -76,12 +76,12 @@ func CreateRoster(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if !models.IsValidUser(data.CID) {
+	if !h.users.Exists(data.CID) {
 		render.Render(w, r, utils.ErrInvalidCID)
 		return
 	}
 
-	if !models.IsValidFacility(data.Facility) {
+	if !h.facilities.Exists(data.Facility) {
 		render.Render(w, r, utils.ErrInvalidFacility)
 		return
 	}
@@ -107,7 +107,7 @@ func CreateRoster(w http.ResponseWriter, r *http.Request) {
 		Instructor: data.Instructor,
 	}
 
-	if err := roster.Create(); err != nil {
+	if err := h.roster.Create(roster); err != nil {
 		render.Render(w, r, utils.ErrInvalidRequest(err))
 		return
 	}
@@ -128,7 +128,7 @@ func CreateRoster(w http.ResponseWriter, r *http.Request) {
 // @Failure 404 {object} utils.ErrResponse
 // @Failure 500 {object} utils.ErrResponse
 // @Router /roster/{id} [get]
-func GetRoster(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) GetRoster(w http.ResponseWriter, r *http.Request) {
 	roster := GetRosterCtx(r)
 
 	render.Render(w, r, NewRosterResponse(roster))
@@ -144,8 +144,8 @@ func GetRoster(w http.ResponseWriter, r *http.Request) {
 // @Failure 422 {object} utils.ErrResponse
 // @Failure 500 {object} utils.ErrResponse
 // @Router /roster [get]
-func ListRoster(w http.ResponseWriter, r *http.Request) {
-	rosters, err := models.GetAllRosters()
+func (h *Handler) ListRoster(w http.ResponseWriter, r *http.Request) {
+	rosters, err := h.roster.List()
 	if err != nil {
 		render.Render(w, r, utils.ErrInternalServer)
 		return
@@ -170,10 +170,10 @@ func ListRoster(w http.ResponseWriter, r *http.Request) {
 // @Failure 404 {object} utils.ErrResponse
 // @Failure 500 {object} utils.ErrResponse
 // @Router /roster/{id} [put]
-func UpdateRoster(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) UpdateRoster(w http.ResponseWriter, r *http.Request) {
 	roster := GetRosterCtx(r)
 	data := &Request{}
-	if err := data.Bind(r); err != nil {
+	if err := render.Bind(r, data); err != nil {
 		render.Render(w, r, utils.ErrInvalidRequest(err))
 		return
 	}
@@ -183,12 +183,12 @@ func UpdateRoster(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if !models.IsValidUser(data.CID) {
+	if !h.users.Exists(data.CID) {
 		render.Render(w, r, utils.ErrInvalidCID)
 		return
 	}
 
-	if !models.IsValidFacility(data.Facility) {
+	if !h.facilities.Exists(data.Facility) {
 		render.Render(w, r, utils.ErrInvalidFacility)
 		return
 	}
@@ -212,7 +212,7 @@ func UpdateRoster(w http.ResponseWriter, r *http.Request) {
 	roster.Mentor = data.Mentor
 	roster.Instructor = data.Instructor
 
-	if err := roster.Update(); err != nil {
+	if err := h.roster.Update(roster); err != nil {
 		render.Render(w, r, utils.ErrInternalServer)
 		return
 	}
@@ -231,10 +231,10 @@ func UpdateRoster(w http.ResponseWriter, r *http.Request) {
 // @Failure 400 {object} utils.ErrResponse
 // @Failure 500 {object} utils.ErrResponse
 // @Router /roster/{id} [delete]
-func DeleteRoster(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) DeleteRoster(w http.ResponseWriter, r *http.Request) {
 	roster := GetRosterCtx(r)
 
-	if err := roster.Delete(); err != nil {
+	if err := h.roster.Delete(roster); err != nil {
 		render.Render(w, r, utils.ErrInternalServer)
 		return
 	}
@@ -3,23 +3,46 @@ package roster
 import (
 	"context"
 	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/VATUSA/primary-api/pkg/service"
 	"github.com/go-chi/chi/v5"
 	"net/http"
 	"strconv"
 )
 
-func Router(r chi.Router) {
-	r.Get("/", ListRoster)
-	r.Post("/", CreateRoster)
+// Handler serves the roster endpoints through the services it is built with
+type Handler struct {
+	roster     *service.RosterService
+	users      *service.UserService
+	facilities *service.FacilityService
+}
+
+func NewHandler(s *service.Services) *Handler {
+	return &Handler{roster: s.Roster, users: s.Users, facilities: s.Facilities}
+}
+
+func Router(r chi.Router, s *service.Services) {
+	h := NewHandler(s)
+	r.Get("/", h.ListRoster)
+	r.Post("/", h.CreateRoster)
 	r.Route("/{RosterID}", func(r chi.Router) {
-		r.Use(Ctx)
-		r.Get("/", GetRoster)
-		r.Put("/", UpdateRoster)
-		r.Delete("/", DeleteRoster)
+		r.Use(h.Ctx)
+		r.Get("/", h.GetRoster)
+		r.Put("/", h.UpdateRoster)
+		r.Delete("/", h.DeleteRoster)
+
+		r.Route("/certifications", func(r chi.Router) {
+			r.Get("/", h.ListCertifications)
+			r.Post("/", h.CreateCertification)
+			r.Route("/{CertificationID}", func(r chi.Router) {
+				r.Use(h.CertificationCtx)
+				r.Put("/", h.UpdateCertification)
+				r.Delete("/", h.DeleteCertification)
+			})
+		})
 	})
 }
 
-func Ctx(next http.Handler) http.Handler {
+func (h *Handler) Ctx(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		id := chi.URLParam(r, "RosterID")
 		if id == "" {
@@ -33,8 +56,8 @@ func Ctx(next http.Handler) http.Handler {
 			return
 		}
 
-		roster := &models.Roster{ID: uint(RosterID)}
-		if err = roster.Get(); err != nil {
+		roster, err := h.roster.Get(uint(RosterID))
+		if err != nil {
 			http.Error(w, "Invalid request", http.StatusBadRequest)
 			return
 		}
@@ -47,3 +70,27 @@ func Ctx(next http.Handler) http.Handler {
 func GetRosterCtx(r *http.Request) *models.Roster {
 	return r.Context().Value("roster").(*models.Roster)
 }
+
+// CertificationCtx loads a certification held through the roster entry in the context
+func (h *Handler) CertificationCtx(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		CertificationID, err := strconv.ParseUint(chi.URLParam(r, "CertificationID"), 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		cert, err := h.roster.GetCertification(GetRosterCtx(r), uint(CertificationID))
+		if err != nil {
+			http.Error(w, "Not found", http.StatusNotFound)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), "certification", cert)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func GetCertificationCtx(r *http.Request) *models.Certification {
+	return r.Context().Value("certification").(*models.Certification)
+}
@@ -0,0 +1,61 @@
+package roster
+
+import (
+	"context"
+	"fmt"
+	"github.com/VATUSA/primary-api/internal/v1/notification"
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/VATUSA/primary-api/pkg/service"
+	"log"
+	"time"
+)
+
+const NotificationCategory = "Training"
+
+// SweepSoloCertifications removes solo certifications that have expired, lets their holders know and returns the
+// certifications it removed
+func SweepSoloCertifications(ctx context.Context, roster *service.RosterService, now time.Time) ([]models.Certification, error) {
+	expired, err := roster.RemoveExpiredSolos(now)
+	for i := range expired {
+		cert := &expired[i]
+		n := &models.Notification{
+			CID:      cert.CID,
+			Category: NotificationCategory,
+			Title:    "Solo certification expired",
+			Body:     fmt.Sprintf("Your %s solo certification at %s has expired.", describe(cert), cert.Facility),
+			ExpireAt: now.Add(30 * 24 * time.Hour),
+		}
+		if err := n.Create(); err != nil {
+			log.Println("[Roster] Error creating notification:", err)
+			continue
+		}
+		notification.Publish(ctx, *n)
+	}
+
+	return expired, err
+}
+
+// describe names the certification, such as "DEN TWR"
+func describe(cert *models.Certification) string {
+	if cert.Position == "" {
+		return string(cert.Type)
+	}
+	return cert.Position + " " + string(cert.Type)
+}
+
+// RunCertificationSweep sweeps expired solo certifications every interval until ctx is done
+func RunCertificationSweep(ctx context.Context, roster *service.RosterService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if _, err := SweepSoloCertifications(ctx, roster, now); err != nil {
+				log.Println("[Roster] Error sweeping solo certifications:", err)
+			}
+		}
+	}
+}
@@ -2,8 +2,12 @@ package v1
 
 import (
 	action_log "github.com/VATUSA/primary-api/internal/v1/action-log"
+	"github.com/VATUSA/primary-api/internal/v1/activity"
+	"github.com/VATUSA/primary-api/internal/v1/admin"
 	disciplinary_log "github.com/VATUSA/primary-api/internal/v1/disciplinary-log"
+	"github.com/VATUSA/primary-api/internal/v1/discord"
 	"github.com/VATUSA/primary-api/internal/v1/document"
+	"github.com/VATUSA/primary-api/internal/v1/event"
 	facility_log "github.com/VATUSA/primary-api/internal/v1/facility-log"
 	"github.com/VATUSA/primary-api/internal/v1/faq"
 	"github.com/VATUSA/primary-api/internal/v1/feedback"
@@ -12,25 +16,49 @@ import (
 	rating_change "github.com/VATUSA/primary-api/internal/v1/rating-change"
 	"github.com/VATUSA/primary-api/internal/v1/roster"
 	roster_request "github.com/VATUSA/primary-api/internal/v1/roster-request"
+	"github.com/VATUSA/primary-api/internal/v1/search"
+	"github.com/VATUSA/primary-api/internal/v1/training"
 	"github.com/VATUSA/primary-api/internal/v1/user"
 	user_flag "github.com/VATUSA/primary-api/internal/v1/user-flag"
 	user_role "github.com/VATUSA/primary-api/internal/v1/user-role"
+	"github.com/VATUSA/primary-api/internal/v1/webhook"
 	"github.com/VATUSA/primary-api/pkg/config"
+	searchindex "github.com/VATUSA/primary-api/pkg/search"
+	"github.com/VATUSA/primary-api/pkg/service"
+	"github.com/VATUSA/primary-api/pkg/storage"
+	"github.com/VATUSA/primary-api/pkg/upload"
+	"github.com/VATUSA/primary-api/pkg/vatsim"
 	"github.com/go-chi/chi/v5"
 )
 
-func Router(r chi.Router, cfg *config.Config) {
+func Router(r chi.Router, cfg *config.Config, services *service.Services, store storage.Storage, searcher searchindex.Searcher, syncer *vatsim.Syncer) {
 	r.Route("/v1", func(r chi.Router) {
 		r.Route("/action-log", func(r chi.Router) {
 			action_log.Router(r)
 		})
 
+		r.Route("/activity", func(r chi.Router) {
+			activity.Router(r, cfg.Activity)
+		})
+
+		r.Route("/admin", func(r chi.Router) {
+			admin.Router(r, syncer)
+		})
+
 		r.Route("/disciplinary-log", func(r chi.Router) {
 			disciplinary_log.Router(r)
 		})
 
+		r.Route("/discord", func(r chi.Router) {
+			discord.Router(r, cfg.Discord)
+		})
+
 		r.Route("/document", func(r chi.Router) {
-			document.Router(r, cfg.S3)
+			document.Router(r, services, store, upload.NewValidator(cfg.Upload), storage.PublicEndpoint(cfg))
+		})
+
+		r.Route("/event", func(r chi.Router) {
+			event.Router(r, store, upload.NewValidator(cfg.Upload))
 		})
 
 		r.Route("/faq", func(r chi.Router) {
@@ -42,7 +70,7 @@ func Router(r chi.Router, cfg *config.Config) {
 		})
 
 		r.Route("/feedback", func(r chi.Router) {
-			feedback.Router(r)
+			feedback.Router(r, services)
 		})
 
 		r.Route("/news", func(r chi.Router) {
@@ -53,20 +81,32 @@ func Router(r chi.Router, cfg *config.Config) {
 			notification.Router(r)
 		})
 
+		r.Route("/me", func(r chi.Router) {
+			r.Get("/notifications/stream", notification.StreamNotifications)
+		})
+
 		r.Route("/rating-change", func(r chi.Router) {
 			rating_change.Router(r)
 		})
 
 		r.Route("/roster", func(r chi.Router) {
-			roster.Router(r)
+			roster.Router(r, services)
 		})
 
 		r.Route("/roster-request", func(r chi.Router) {
 			roster_request.Router(r)
 		})
 
+		r.Route("/search", func(r chi.Router) {
+			search.Router(r, services, searcher)
+		})
+
+		r.Route("/training", func(r chi.Router) {
+			training.Router(r)
+		})
+
 		r.Route("/user", func(r chi.Router) {
-			user.Router(r)
+			user.Router(r, services)
 		})
 
 		r.Route("/user-flag", func(r chi.Router) {
@@ -74,7 +114,11 @@ func Router(r chi.Router, cfg *config.Config) {
 		})
 
 		r.Route("/user-role", func(r chi.Router) {
-			user_role.Router(r)
+			user_role.Router(r, services)
+		})
+
+		r.Route("/webhook", func(r chi.Router) {
+			webhook.Router(r)
 		})
 	})
 }
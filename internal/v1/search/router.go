@@ -0,0 +1,14 @@
+package search
+
+import (
+	searchindex "github.com/VATUSA/primary-api/pkg/search"
+	"github.com/VATUSA/primary-api/pkg/service"
+	"github.com/go-chi/chi/v5"
+	"net/http"
+)
+
+func Router(r chi.Router, services *service.Services, searcher searchindex.Searcher) {
+	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		Search(w, r, searcher, services.Documents)
+	})
+}
@@ -0,0 +1,178 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	searchindex "github.com/VATUSA/primary-api/pkg/search"
+	"github.com/VATUSA/primary-api/pkg/service"
+	"github.com/VATUSA/primary-api/pkg/utils"
+	"github.com/go-chi/render"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+	// MaxFetch bounds how many results are asked for while filling a page, so a search matching mostly hidden
+	// documents stops growing
+	MaxFetch = 1000
+)
+
+type Response struct {
+	*searchindex.Result
+}
+
+func NewResultResponse(result *searchindex.Result) *Response {
+	return &Response{Result: result}
+}
+
+func (res *Response) Render(w http.ResponseWriter, r *http.Request) error {
+	if res.Result == nil {
+		return errors.New("missing required search result")
+	}
+	return nil
+}
+
+func NewResultListResponse(results []searchindex.Result) []render.Renderer {
+	list := []render.Renderer{}
+	for i := range results {
+		list = append(list, NewResultResponse(&results[i]))
+	}
+	return list
+}
+
+// parseQuery reads the search query from the request parameters
+func parseQuery(r *http.Request) (searchindex.Query, error) {
+	params := r.URL.Query()
+	q := searchindex.Query{
+		Text:     strings.TrimSpace(params.Get("q")),
+		Facility: strings.ToUpper(params.Get("facility")),
+		Limit:    DefaultLimit,
+	}
+
+	if len(searchindex.Terms(q.Text)) == 0 {
+		return q, errors.New("q is required")
+	}
+
+	if q.Facility != "" && !models.IsValidFacility(q.Facility) {
+		return q, errors.New("invalid facility")
+	}
+
+	if types := params.Get("type"); types != "" {
+		for _, t := range strings.Split(types, ",") {
+			switch t {
+			case searchindex.DocumentType, searchindex.FAQType, searchindex.NewsType:
+				q.Types = append(q.Types, t)
+			default:
+				return q, errors.New("invalid type " + t)
+			}
+		}
+	}
+
+	if limit := params.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > MaxLimit {
+			return q, errors.New("limit must be between 1 and " + strconv.Itoa(MaxLimit))
+		}
+		q.Limit = n
+	}
+
+	if offset := params.Get("offset"); offset != "" {
+		n, err := strconv.Atoi(offset)
+		if err != nil || n < 0 {
+			return q, errors.New("offset must not be negative")
+		}
+		q.Offset = n
+	}
+
+	return q, nil
+}
+
+// Search godoc
+// @Summary Search documents, FAQs and news
+// @Description Full-text search across documents, FAQs and news, best matches first. Scoping to a facility also includes division-wide content. Documents the caller may not view are left out.
+// @Tags search
+// @Produce  json
+// @Param q query string true "Search text"
+// @Param facility query string false "Facility"
+// @Param type query string false "Comma separated types: document, faq, news"
+// @Param limit query int false "Limit (default 20, max 100)"
+// @Param offset query int false "Offset"
+// @Success 200 {object} []Response
+// @Failure 400 {object} utils.ErrResponse
+// @Failure 500 {object} utils.ErrResponse
+// @Router /search [get]
+func Search(w http.ResponseWriter, r *http.Request, searcher searchindex.Searcher, documents *service.DocumentService) {
+	q, err := parseQuery(r)
+	if err != nil {
+		render.Render(w, r, utils.ErrInvalidRequest(err))
+		return
+	}
+
+	results, err := visiblePage(r.Context(), searcher, documents, q, utils.GetSelf(r))
+	if err != nil {
+		render.Render(w, r, utils.ErrInternalServer)
+		return
+	}
+
+	if err := render.RenderList(w, r, NewResultListResponse(results)); err != nil {
+		render.Render(w, r, utils.ErrRender(err))
+		return
+	}
+}
+
+// visiblePage returns the requested page of the results the user may see. Hidden documents are dropped before
+// paging, so the searcher is asked for every result up to the end of the page, and then for more until the page
+// is full, the searcher runs out or MaxFetch is reached.
+func visiblePage(ctx context.Context, searcher searchindex.Searcher, documents *service.DocumentService, q searchindex.Query, user *models.User) ([]searchindex.Result, error) {
+	end := q.Offset + q.Limit
+	fetch := q
+	fetch.Offset, fetch.Limit = 0, end
+
+	for {
+		results, err := searcher.Search(ctx, fetch)
+		if err != nil {
+			return nil, err
+		}
+
+		shown, err := visible(documents, results, user)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(shown) >= end || len(results) < fetch.Limit || fetch.Limit >= MaxFetch {
+			if q.Offset >= len(shown) {
+				return []searchindex.Result{}, nil
+			}
+			return shown[q.Offset:min(end, len(shown))], nil
+		}
+		fetch.Limit = min(2*fetch.Limit, MaxFetch)
+	}
+}
+
+// visible drops documents the user may not view, loading them together
+func visible(documents *service.DocumentService, results []searchindex.Result, user *models.User) ([]searchindex.Result, error) {
+	var ids []uint
+	for _, result := range results {
+		if result.Type == searchindex.DocumentType {
+			ids = append(ids, result.ID)
+		}
+	}
+
+	viewable, err := documents.Viewable(ids, user)
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := []searchindex.Result{}
+	for _, result := range results {
+		if result.Type == searchindex.DocumentType && !viewable[result.ID] {
+			continue
+		}
+		filtered = append(filtered, result)
+	}
+	return filtered, nil
+}
@@ -0,0 +1,135 @@
+package training
+
+import (
+	"github.com/VATUSA/primary-api/pkg/database"
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/VATUSA/primary-api/pkg/utils"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"net/http"
+	"strconv"
+)
+
+// Progress is how far a student is through a progression
+type Progress struct {
+	Progression    models.TrainingProgression `json:"progression"`
+	CompletedSteps []uint                     `json:"completed_steps" example:"1,2"`
+	Complete       bool                       `json:"complete" example:"false"`
+}
+
+type HistoryResponse struct {
+	CID          uint                     `json:"cid" example:"1293257"`
+	TotalMinutes uint                     `json:"total_minutes" example:"540"`
+	Sessions     []models.TrainingSession `json:"sessions"`
+	Progress     []Progress               `json:"progress"`
+}
+
+func (res *HistoryResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// GetHistory godoc
+// @Summary Get a student's training history
+// @Description Get a student's training sessions, newest first, and their progress through the progressions of their facilities. Students see their whole history; training staff see the parts belonging to facilities they train for.
+// @Tags training
+// @Produce  json
+// @Param cid path int true "Student CID"
+// @Success 200 {object} HistoryResponse
+// @Failure 400 {object} utils.ErrResponse
+// @Failure 401 {object} utils.ErrResponse
+// @Failure 403 {object} utils.ErrResponse
+// @Failure 500 {object} utils.ErrResponse
+// @Router /training/students/{cid}/history [get]
+func GetHistory(w http.ResponseWriter, r *http.Request) {
+	cid, err := strconv.ParseUint(chi.URLParam(r, "CID"), 10, 64)
+	if err != nil {
+		render.Render(w, r, utils.ErrInvalidCID)
+		return
+	}
+
+	self := utils.GetSelf(r)
+	if self == nil {
+		render.Render(w, r, utils.ErrUnauthorized)
+		return
+	}
+
+	sessions, err := models.GetTrainingSessionsByCID(uint(cid))
+	if err != nil {
+		render.Render(w, r, utils.ErrInternalServer)
+		return
+	}
+
+	rosters, err := models.GetAllRostersByCID(database.DB, uint(cid))
+	if err != nil {
+		render.Render(w, r, utils.ErrInternalServer)
+		return
+	}
+
+	// The facilities the student trains at, and whether the caller may see each one
+	visible := map[string]bool{}
+	var facilities []string
+	see := func(facility string) {
+		if _, ok := visible[facility]; ok {
+			return
+		}
+		visible[facility] = self.CID == uint(cid) || self.CanTrain(facility)
+		facilities = append(facilities, facility)
+	}
+	for _, roster := range rosters {
+		see(roster.Facility)
+	}
+	for _, s := range sessions {
+		see(s.Facility)
+	}
+
+	history := &HistoryResponse{CID: uint(cid), Sessions: []models.TrainingSession{}, Progress: []Progress{}}
+	for _, s := range sessions {
+		if visible[s.Facility] {
+			history.Sessions = append(history.Sessions, s)
+			history.TotalMinutes += s.DurationMinutes
+		}
+	}
+
+	completions, err := models.GetTrainingStepCompletionsByCID(uint(cid))
+	if err != nil {
+		render.Render(w, r, utils.ErrInternalServer)
+		return
+	}
+	completed := map[uint]bool{}
+	for _, c := range completions {
+		completed[c.StepID] = true
+	}
+
+	anyVisible := self.CID == uint(cid)
+	for _, facility := range facilities {
+		if !visible[facility] {
+			continue
+		}
+		anyVisible = true
+
+		progressions, err := models.GetTrainingProgressions(facility)
+		if err != nil {
+			render.Render(w, r, utils.ErrInternalServer)
+			return
+		}
+
+		for _, p := range progressions {
+			progress := Progress{Progression: p, CompletedSteps: []uint{}, Complete: len(p.Steps) > 0}
+			for _, step := range p.Steps {
+				if completed[step.ID] {
+					progress.CompletedSteps = append(progress.CompletedSteps, step.ID)
+				} else {
+					progress.Complete = false
+				}
+			}
+			history.Progress = append(history.Progress, progress)
+		}
+	}
+
+	if !anyVisible {
+		render.Render(w, r, utils.ErrForbidden)
+		return
+	}
+
+	render.Render(w, r, history)
+}
@@ -0,0 +1,251 @@
+package training
+
+import (
+	"errors"
+	"fmt"
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/VATUSA/primary-api/pkg/utils"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"net/http"
+)
+
+type StepRequest struct {
+	ID       uint   `json:"id" example:"1"` // Set to keep an existing step, and its completions
+	Name     string `json:"name" example:"Ground control basics" validate:"required"`
+	Position string `json:"position" example:"DEN_GND"`
+}
+
+// ProgressionRequest describes a progression. Steps are taken in the order given.
+type ProgressionRequest struct {
+	Facility    string        `json:"facility" example:"ZDV" validate:"required,len=3"`
+	Name        string        `json:"name" example:"S2 Tower" validate:"required"`
+	Description string        `json:"description" example:"From S1 to a tower certification at DEN"`
+	Steps       []StepRequest `json:"steps" validate:"dive"`
+}
+
+func (req *ProgressionRequest) Validate() error {
+	return validator.New().Struct(req)
+}
+
+func (req *ProgressionRequest) Bind(r *http.Request) error {
+	return nil
+}
+
+type ProgressionResponse struct {
+	*models.TrainingProgression
+}
+
+func NewProgressionResponse(p *models.TrainingProgression) *ProgressionResponse {
+	return &ProgressionResponse{TrainingProgression: p}
+}
+
+func (res *ProgressionResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	if res.TrainingProgression == nil {
+		return errors.New("missing required training progression")
+	}
+	return nil
+}
+
+func NewProgressionListResponse(progressions []models.TrainingProgression) []render.Renderer {
+	list := []render.Renderer{}
+	for _, p := range progressions {
+		list = append(list, NewProgressionResponse(&p))
+	}
+	return list
+}
+
+// canManageTraining renders the error response and returns false unless the caller may change the
+// facility's progressions
+func canManageTraining(w http.ResponseWriter, r *http.Request, facility string) bool {
+	self := utils.GetSelf(r)
+	if self == nil {
+		render.Render(w, r, utils.ErrUnauthorized)
+		return false
+	}
+	if !self.CanManageTraining(facility) {
+		render.Render(w, r, utils.ErrForbidden)
+		return false
+	}
+	return true
+}
+
+// applyProgression copies the request onto the progression, rendering the error response and returning false
+// if it is rejected
+func applyProgression(w http.ResponseWriter, r *http.Request, progression *models.TrainingProgression, data *ProgressionRequest) bool {
+	if err := data.Validate(); err != nil {
+		render.Render(w, r, utils.ErrInvalidRequest(err))
+		return false
+	}
+
+	if !models.IsValidFacility(data.Facility) {
+		render.Render(w, r, utils.ErrInvalidFacility)
+		return false
+	}
+
+	if !canManageTraining(w, r, data.Facility) {
+		return false
+	}
+
+	existing := map[uint]bool{}
+	for _, s := range progression.Steps {
+		existing[s.ID] = true
+	}
+
+	steps := []models.TrainingStep{}
+	for i, s := range data.Steps {
+		if s.ID != 0 && !existing[s.ID] {
+			render.Render(w, r, utils.ErrInvalidRequest(fmt.Errorf("step %d is not part of this progression", s.ID)))
+			return false
+		}
+		steps = append(steps, models.TrainingStep{ID: s.ID, SortOrder: i, Name: s.Name, Position: s.Position})
+	}
+
+	progression.Facility = data.Facility
+	progression.Name = data.Name
+	progression.Description = data.Description
+	progression.Steps = steps
+	return true
+}
+
+// ListProgressions godoc
+// @Summary List training progressions
+// @Description List training progressions with their steps
+// @Tags training
+// @Accept  json
+// @Produce  json
+// @Param facility query string false "Only this facility's progressions"
+// @Success 200 {object} []ProgressionResponse
+// @Failure 400 {object} utils.ErrResponse
+// @Failure 500 {object} utils.ErrResponse
+// @Router /training/progressions [get]
+func ListProgressions(w http.ResponseWriter, r *http.Request) {
+	facility := r.URL.Query().Get("facility")
+	if facility != "" && !models.IsValidFacility(facility) {
+		render.Render(w, r, utils.ErrInvalidFacility)
+		return
+	}
+
+	progressions, err := models.GetTrainingProgressions(facility)
+	if err != nil {
+		render.Render(w, r, utils.ErrInternalServer)
+		return
+	}
+
+	if err := render.RenderList(w, r, NewProgressionListResponse(progressions)); err != nil {
+		render.Render(w, r, utils.ErrRender(err))
+		return
+	}
+}
+
+// CreateProgression godoc
+// @Summary Create a training progression
+// @Description Create a training progression for a facility
+// @Tags training
+// @Accept  json
+// @Produce  json
+// @Param progression body ProgressionRequest true "Training Progression"
+// @Success 201 {object} ProgressionResponse
+// @Failure 400 {object} utils.ErrResponse
+// @Failure 401 {object} utils.ErrResponse
+// @Failure 403 {object} utils.ErrResponse
+// @Failure 500 {object} utils.ErrResponse
+// @Router /training/progressions [post]
+func CreateProgression(w http.ResponseWriter, r *http.Request) {
+	data := &ProgressionRequest{}
+	if err := render.Bind(r, data); err != nil {
+		render.Render(w, r, utils.ErrInvalidRequest(err))
+		return
+	}
+
+	progression := &models.TrainingProgression{}
+	if !applyProgression(w, r, progression, data) {
+		return
+	}
+
+	if err := progression.Create(); err != nil {
+		render.Render(w, r, utils.ErrInternalServer)
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.Render(w, r, NewProgressionResponse(progression))
+}
+
+// GetProgression godoc
+// @Summary Get a training progression
+// @Description Get a training progression with its steps
+// @Tags training
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Progression ID"
+// @Success 200 {object} ProgressionResponse
+// @Failure 404 {object} utils.ErrResponse
+// @Router /training/progressions/{id} [get]
+func GetProgression(w http.ResponseWriter, r *http.Request) {
+	render.Render(w, r, NewProgressionResponse(GetProgressionCtx(r)))
+}
+
+// UpdateProgression godoc
+// @Summary Update a training progression
+// @Description Update a training progression. Steps given with an ID are kept along with their completions; steps left out are removed.
+// @Tags training
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Progression ID"
+// @Param progression body ProgressionRequest true "Training Progression"
+// @Success 200 {object} ProgressionResponse
+// @Failure 400 {object} utils.ErrResponse
+// @Failure 401 {object} utils.ErrResponse
+// @Failure 403 {object} utils.ErrResponse
+// @Failure 404 {object} utils.ErrResponse
+// @Failure 500 {object} utils.ErrResponse
+// @Router /training/progressions/{id} [put]
+func UpdateProgression(w http.ResponseWriter, r *http.Request) {
+	progression := GetProgressionCtx(r)
+	if !canManageTraining(w, r, progression.Facility) {
+		return
+	}
+
+	data := &ProgressionRequest{}
+	if err := render.Bind(r, data); err != nil {
+		render.Render(w, r, utils.ErrInvalidRequest(err))
+		return
+	}
+
+	if !applyProgression(w, r, progression, data) {
+		return
+	}
+
+	if err := progression.Update(); err != nil {
+		render.Render(w, r, utils.ErrInternalServer)
+		return
+	}
+
+	render.Render(w, r, NewProgressionResponse(progression))
+}
+
+// DeleteProgression godoc
+// @Summary Delete a training progression
+// @Description Delete a training progression with its steps and their completions
+// @Tags training
+// @Param id path int true "Progression ID"
+// @Success 204
+// @Failure 401 {object} utils.ErrResponse
+// @Failure 403 {object} utils.ErrResponse
+// @Failure 404 {object} utils.ErrResponse
+// @Failure 500 {object} utils.ErrResponse
+// @Router /training/progressions/{id} [delete]
+func DeleteProgression(w http.ResponseWriter, r *http.Request) {
+	progression := GetProgressionCtx(r)
+	if !canManageTraining(w, r, progression.Facility) {
+		return
+	}
+
+	if err := progression.Delete(); err != nil {
+		render.Render(w, r, utils.ErrInternalServer)
+		return
+	}
+
+	render.Status(r, http.StatusNoContent)
+}
@@ -0,0 +1,583 @@
+package training
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/VATUSA/primary-api/internal/v1/notification"
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/VATUSA/primary-api/pkg/database/types"
+	"github.com/VATUSA/primary-api/pkg/utils"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"log"
+	"net/http"
+	"time"
+)
+
+const (
+	NotificationCategory = "Training"
+
+	// QueueStatsWindow is how far back queue statistics look when working out wait times
+	QueueStatsWindow = 90 * 24 * time.Hour
+)
+
+type AvailabilityRequest struct {
+	StartAt string `json:"start_at" example:"2021-01-03T18:00:00Z" validate:"required"`
+	EndAt   string `json:"end_at" example:"2021-01-03T22:00:00Z" validate:"required"`
+}
+
+type TrainingRequestRequest struct {
+	Facility     string                `json:"facility" example:"ZDV" validate:"required,len=3"`
+	Position     string                `json:"position" example:"DEN_TWR" validate:"required"`
+	Notes        string                `json:"notes" example:"Looking to start tower training"`
+	Availability []AvailabilityRequest `json:"availability" validate:"required,min=1,max=20,dive"`
+}
+
+func (req *TrainingRequestRequest) Validate() error {
+	return validator.New().Struct(req)
+}
+
+func (req *TrainingRequestRequest) Bind(r *http.Request) error {
+	return nil
+}
+
+type TrainingRequestResponse struct {
+	*models.TrainingRequest
+}
+
+func NewTrainingRequestResponse(t *models.TrainingRequest) *TrainingRequestResponse {
+	return &TrainingRequestResponse{TrainingRequest: t}
+}
+
+func (res *TrainingRequestResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	if res.TrainingRequest == nil {
+		return errors.New("missing required training request")
+	}
+	return nil
+}
+
+func NewTrainingRequestListResponse(requests []models.TrainingRequest) []render.Renderer {
+	list := []render.Renderer{}
+	for _, t := range requests {
+		list = append(list, NewTrainingRequestResponse(&t))
+	}
+	return list
+}
+
+type QueueStatsResponse struct {
+	*models.TrainingQueueStats
+}
+
+func (res *QueueStatsResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// parseAvailability turns the requested windows into availability, rejecting empty windows and windows
+// that have already passed
+func parseAvailability(windows []AvailabilityRequest, now time.Time) ([]models.TrainingAvailability, error) {
+	var availability []models.TrainingAvailability
+	for _, window := range windows {
+		startAt, err := time.Parse(time.RFC3339, window.StartAt)
+		if err != nil {
+			return nil, err
+		}
+		endAt, err := time.Parse(time.RFC3339, window.EndAt)
+		if err != nil {
+			return nil, err
+		}
+		if !endAt.After(startAt) {
+			return nil, errors.New("availability must end after it starts")
+		}
+		if !endAt.After(now) {
+			return nil, errors.New("availability must not be in the past")
+		}
+		availability = append(availability, models.TrainingAvailability{StartAt: startAt, EndAt: endAt})
+	}
+	return availability, nil
+}
+
+// canQueue renders the error response and returns false unless the student may be in the facility's queue
+func canQueue(w http.ResponseWriter, r *http.Request, cid uint, facility string) bool {
+	blocked, err := models.IsTrainingBlocked(cid)
+	if err != nil {
+		render.Render(w, r, utils.ErrInternalServer)
+		return false
+	}
+	if blocked {
+		render.Render(w, r, utils.ErrInvalidRequest(fmt.Errorf("%d is not allowed to receive training", cid)))
+		return false
+	}
+
+	if !models.IsOnRoster(cid, facility) {
+		render.Render(w, r, utils.ErrInvalidRequest(fmt.Errorf("%d is not on the %s roster", cid, facility)))
+		return false
+	}
+
+	return true
+}
+
+// canSeeRequest reports whether the caller is the request's student or trains at its facility
+func canSeeRequest(self *models.User, request *models.TrainingRequest) bool {
+	return self.CID == request.CID || self.CanTrain(request.Facility)
+}
+
+func notifyStudent(ctx context.Context, request *models.TrainingRequest, title, body string) {
+	n := &models.Notification{
+		CID:      request.CID,
+		Category: NotificationCategory,
+		Title:    title,
+		Body:     body,
+		ExpireAt: time.Now().Add(30 * 24 * time.Hour),
+	}
+	if err := n.Create(); err != nil {
+		log.Println("[Training] Error creating notification:", err)
+		return
+	}
+	notification.Publish(ctx, *n)
+}
+
+// CreateTrainingRequest godoc
+// @Summary Request training
+// @Description Join a facility's training queue for a position or certification. The student must be on the facility's roster and not be blocked from training.
+// @Tags training
+// @Accept  json
+// @Produce  json
+// @Param request body TrainingRequestRequest true "Training Request"
+// @Success 201 {object} TrainingRequestResponse
+// @Failure 400 {object} utils.ErrResponse
+// @Failure 401 {object} utils.ErrResponse
+// @Failure 409 {object} utils.ErrResponse
+// @Failure 500 {object} utils.ErrResponse
+// @Router /training/requests [post]
+func CreateTrainingRequest(w http.ResponseWriter, r *http.Request) {
+	self := utils.GetSelf(r)
+	if self == nil {
+		render.Render(w, r, utils.ErrUnauthorized)
+		return
+	}
+
+	data := &TrainingRequestRequest{}
+	if err := render.Bind(r, data); err != nil {
+		render.Render(w, r, utils.ErrInvalidRequest(err))
+		return
+	}
+
+	if err := data.Validate(); err != nil {
+		render.Render(w, r, utils.ErrInvalidRequest(err))
+		return
+	}
+
+	if !models.IsValidFacility(data.Facility) {
+		render.Render(w, r, utils.ErrInvalidFacility)
+		return
+	}
+
+	availability, err := parseAvailability(data.Availability, time.Now())
+	if err != nil {
+		render.Render(w, r, utils.ErrInvalidRequest(err))
+		return
+	}
+
+	if !canQueue(w, r, self.CID, data.Facility) {
+		return
+	}
+
+	active, err := models.HasActiveTrainingRequest(self.CID, data.Facility, data.Position)
+	if err != nil {
+		render.Render(w, r, utils.ErrInternalServer)
+		return
+	}
+	if active {
+		render.Render(w, r, utils.ErrConflict(fmt.Errorf("already in the %s queue for %s", data.Facility, data.Position)))
+		return
+	}
+
+	request := &models.TrainingRequest{
+		CID:          self.CID,
+		Facility:     data.Facility,
+		Position:     data.Position,
+		Notes:        data.Notes,
+		Status:       types.OpenTrainingRequest,
+		Availability: availability,
+	}
+
+	if err := request.Create(); err != nil {
+		render.Render(w, r, utils.ErrInternalServer)
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.Render(w, r, NewTrainingRequestResponse(request))
+}
+
+// ListTrainingRequests godoc
+// @Summary List training requests
+// @Description With a facility, list its queue oldest first; only its mentors and instructors may do so. Without one, list the caller's own requests.
+// @Tags training
+// @Produce  json
+// @Param facility query string false "Facility queue"
+// @Param status query string false "Queue status (open, assigned, completed or cancelled), defaults to open"
+// @Success 200 {object} []TrainingRequestResponse
+// @Failure 400 {object} utils.ErrResponse
+// @Failure 401 {object} utils.ErrResponse
+// @Failure 403 {object} utils.ErrResponse
+// @Failure 500 {object} utils.ErrResponse
+// @Router /training/requests [get]
+func ListTrainingRequests(w http.ResponseWriter, r *http.Request) {
+	self := utils.GetSelf(r)
+	if self == nil {
+		render.Render(w, r, utils.ErrUnauthorized)
+		return
+	}
+
+	var requests []models.TrainingRequest
+	var err error
+
+	if facility := r.URL.Query().Get("facility"); facility != "" {
+		if !models.IsValidFacility(facility) {
+			render.Render(w, r, utils.ErrInvalidFacility)
+			return
+		}
+
+		if !canTrain(w, r, facility) {
+			return
+		}
+
+		status := types.TrainingRequestStatus(r.URL.Query().Get("status"))
+		if status == "" {
+			status = types.OpenTrainingRequest
+		}
+		if err := validator.New().Var(status, "oneof=open assigned completed cancelled"); err != nil {
+			render.Render(w, r, utils.ErrInvalidRequest(err))
+			return
+		}
+
+		requests, err = models.GetTrainingQueue(facility, status)
+	} else {
+		requests, err = models.GetTrainingRequestsByCID(self.CID)
+	}
+	if err != nil {
+		render.Render(w, r, utils.ErrInternalServer)
+		return
+	}
+
+	if err := render.RenderList(w, r, NewTrainingRequestListResponse(requests)); err != nil {
+		render.Render(w, r, utils.ErrRender(err))
+		return
+	}
+}
+
+// GetTrainingQueueStats godoc
+// @Summary Get training queue statistics
+// @Description Get a facility's queue depth and how long recently picked up requests waited
+// @Tags training
+// @Produce  json
+// @Param facility query string true "Facility"
+// @Success 200 {object} QueueStatsResponse
+// @Failure 400 {object} utils.ErrResponse
+// @Failure 401 {object} utils.ErrResponse
+// @Failure 403 {object} utils.ErrResponse
+// @Failure 500 {object} utils.ErrResponse
+// @Router /training/requests/stats [get]
+func GetTrainingQueueStats(w http.ResponseWriter, r *http.Request) {
+	facility := r.URL.Query().Get("facility")
+	if !models.IsValidFacility(facility) {
+		render.Render(w, r, utils.ErrInvalidFacility)
+		return
+	}
+
+	if !canManageTraining(w, r, facility) {
+		return
+	}
+
+	stats, err := models.GetTrainingQueueStats(facility, time.Now(), QueueStatsWindow)
+	if err != nil {
+		render.Render(w, r, utils.ErrInternalServer)
+		return
+	}
+
+	render.Render(w, r, &QueueStatsResponse{TrainingQueueStats: stats})
+}
+
+// GetTrainingRequest godoc
+// @Summary Get a training request
+// @Description Get a training request. Visible to the student and the facility's mentors and instructors.
+// @Tags training
+// @Produce  json
+// @Param id path int true "Training Request ID"
+// @Success 200 {object} TrainingRequestResponse
+// @Failure 401 {object} utils.ErrResponse
+// @Failure 403 {object} utils.ErrResponse
+// @Failure 404 {object} utils.ErrResponse
+// @Router /training/requests/{id} [get]
+func GetTrainingRequest(w http.ResponseWriter, r *http.Request) {
+	request := GetTrainingRequestCtx(r)
+
+	self := utils.GetSelf(r)
+	if self == nil {
+		render.Render(w, r, utils.ErrUnauthorized)
+		return
+	}
+	if !canSeeRequest(self, request) {
+		render.Render(w, r, utils.ErrForbidden)
+		return
+	}
+
+	render.Render(w, r, NewTrainingRequestResponse(request))
+}
+
+// UpdateTrainingRequest godoc
+// @Summary Update a training request
+// @Description Change the notes or availability of an open request. Only the student may do so, and the facility and position can't change.
+// @Tags training
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Training Request ID"
+// @Param request body TrainingRequestRequest true "Training Request"
+// @Success 200 {object} TrainingRequestResponse
+// @Failure 400 {object} utils.ErrResponse
+// @Failure 401 {object} utils.ErrResponse
+// @Failure 403 {object} utils.ErrResponse
+// @Failure 404 {object} utils.ErrResponse
+// @Failure 409 {object} utils.ErrResponse
+// @Failure 500 {object} utils.ErrResponse
+// @Router /training/requests/{id} [put]
+func UpdateTrainingRequest(w http.ResponseWriter, r *http.Request) {
+	request := GetTrainingRequestCtx(r)
+
+	self := utils.GetSelf(r)
+	if self == nil {
+		render.Render(w, r, utils.ErrUnauthorized)
+		return
+	}
+	if self.CID != request.CID {
+		render.Render(w, r, utils.ErrForbidden)
+		return
+	}
+
+	data := &TrainingRequestRequest{}
+	if err := render.Bind(r, data); err != nil {
+		render.Render(w, r, utils.ErrInvalidRequest(err))
+		return
+	}
+
+	if err := data.Validate(); err != nil {
+		render.Render(w, r, utils.ErrInvalidRequest(err))
+		return
+	}
+
+	if data.Facility != request.Facility || data.Position != request.Position {
+		render.Render(w, r, utils.ErrInvalidRequest(errors.New("facility and position can't be changed; cancel and request again")))
+		return
+	}
+
+	if request.Status != types.OpenTrainingRequest {
+		render.Render(w, r, utils.ErrConflict(fmt.Errorf("request is %s", request.Status)))
+		return
+	}
+
+	availability, err := parseAvailability(data.Availability, time.Now())
+	if err != nil {
+		render.Render(w, r, utils.ErrInvalidRequest(err))
+		return
+	}
+
+	request.Notes = data.Notes
+	request.Availability = availability
+
+	if err := request.Update(); err != nil {
+		render.Render(w, r, utils.ErrInternalServer)
+		return
+	}
+
+	render.Render(w, r, NewTrainingRequestResponse(request))
+}
+
+// CancelTrainingRequest godoc
+// @Summary Cancel a training request
+// @Description Take a request out of the queue. The student and the facility's training staff may cancel it.
+// @Tags training
+// @Produce  json
+// @Param id path int true "Training Request ID"
+// @Success 200 {object} TrainingRequestResponse
+// @Failure 401 {object} utils.ErrResponse
+// @Failure 403 {object} utils.ErrResponse
+// @Failure 404 {object} utils.ErrResponse
+// @Failure 409 {object} utils.ErrResponse
+// @Failure 500 {object} utils.ErrResponse
+// @Router /training/requests/{id} [delete]
+func CancelTrainingRequest(w http.ResponseWriter, r *http.Request) {
+	request := GetTrainingRequestCtx(r)
+
+	self := utils.GetSelf(r)
+	if self == nil {
+		render.Render(w, r, utils.ErrUnauthorized)
+		return
+	}
+	if self.CID != request.CID && !self.CanManageTraining(request.Facility) {
+		render.Render(w, r, utils.ErrForbidden)
+		return
+	}
+
+	if request.Status != types.OpenTrainingRequest && request.Status != types.AssignedTrainingRequest {
+		render.Render(w, r, utils.ErrConflict(fmt.Errorf("request is %s", request.Status)))
+		return
+	}
+
+	ok, err := request.SetStatus(types.CancelledTrainingRequest)
+	if err != nil {
+		render.Render(w, r, utils.ErrInternalServer)
+		return
+	}
+	if !ok {
+		render.Render(w, r, utils.ErrConflict(errChanged))
+		return
+	}
+
+	if self.CID != request.CID {
+		notifyStudent(r.Context(), request, "Training Request Cancelled",
+			fmt.Sprintf("Your %s training request for %s was cancelled by training staff.", request.Facility, request.Position))
+	}
+
+	render.Render(w, r, NewTrainingRequestResponse(request))
+}
+
+// PickUpTrainingRequest godoc
+// @Summary Pick up a training request
+// @Description Assign an open request to the calling mentor or instructor and notify the student
+// @Tags training
+// @Produce  json
+// @Param id path int true "Training Request ID"
+// @Success 200 {object} TrainingRequestResponse
+// @Failure 400 {object} utils.ErrResponse
+// @Failure 401 {object} utils.ErrResponse
+// @Failure 403 {object} utils.ErrResponse
+// @Failure 404 {object} utils.ErrResponse
+// @Failure 409 {object} utils.ErrResponse
+// @Failure 500 {object} utils.ErrResponse
+// @Router /training/requests/{id}/pickup [post]
+func PickUpTrainingRequest(w http.ResponseWriter, r *http.Request) {
+	request := GetTrainingRequestCtx(r)
+	if !canTrain(w, r, request.Facility) {
+		return
+	}
+	self := utils.GetSelf(r)
+
+	if self.CID == request.CID {
+		render.Render(w, r, utils.ErrInvalidRequest(errors.New("you can't pick up your own request")))
+		return
+	}
+
+	// The student may have been flagged or left the roster while they waited
+	if !canQueue(w, r, request.CID, request.Facility) {
+		return
+	}
+
+	ok, err := request.Assign(self.CID, time.Now())
+	if err != nil {
+		render.Render(w, r, utils.ErrInternalServer)
+		return
+	}
+	if !ok {
+		render.Render(w, r, utils.ErrConflict(errors.New("request is no longer open")))
+		return
+	}
+
+	notifyStudent(r.Context(), request, "Training Request Picked Up",
+		fmt.Sprintf("%s %s will be working with you on your %s training request for %s.",
+			self.FirstName, self.LastName, request.Facility, request.Position))
+
+	render.Render(w, r, NewTrainingRequestResponse(request))
+}
+
+// ReleaseTrainingRequest godoc
+// @Summary Release a training request
+// @Description Put an assigned request back in the queue in its original place. The assigned instructor and the facility's training staff may release it.
+// @Tags training
+// @Produce  json
+// @Param id path int true "Training Request ID"
+// @Success 200 {object} TrainingRequestResponse
+// @Failure 401 {object} utils.ErrResponse
+// @Failure 403 {object} utils.ErrResponse
+// @Failure 404 {object} utils.ErrResponse
+// @Failure 409 {object} utils.ErrResponse
+// @Failure 500 {object} utils.ErrResponse
+// @Router /training/requests/{id}/release [post]
+func ReleaseTrainingRequest(w http.ResponseWriter, r *http.Request) {
+	request := GetTrainingRequestCtx(r)
+	if !canAssigned(w, r, request) {
+		return
+	}
+
+	ok, err := request.Release()
+	if err != nil {
+		render.Render(w, r, utils.ErrInternalServer)
+		return
+	}
+	if !ok {
+		render.Render(w, r, utils.ErrConflict(errChanged))
+		return
+	}
+
+	render.Render(w, r, NewTrainingRequestResponse(request))
+}
+
+// CompleteTrainingRequest godoc
+// @Summary Complete a training request
+// @Description Mark an assigned request as done. The assigned instructor and the facility's training staff may complete it.
+// @Tags training
+// @Produce  json
+// @Param id path int true "Training Request ID"
+// @Success 200 {object} TrainingRequestResponse
+// @Failure 401 {object} utils.ErrResponse
+// @Failure 403 {object} utils.ErrResponse
+// @Failure 404 {object} utils.ErrResponse
+// @Failure 409 {object} utils.ErrResponse
+// @Failure 500 {object} utils.ErrResponse
+// @Router /training/requests/{id}/complete [post]
+func CompleteTrainingRequest(w http.ResponseWriter, r *http.Request) {
+	request := GetTrainingRequestCtx(r)
+	if !canAssigned(w, r, request) {
+		return
+	}
+
+	ok, err := request.SetStatus(types.CompletedTrainingRequest)
+	if err != nil {
+		render.Render(w, r, utils.ErrInternalServer)
+		return
+	}
+	if !ok {
+		render.Render(w, r, utils.ErrConflict(errChanged))
+		return
+	}
+
+	render.Render(w, r, NewTrainingRequestResponse(request))
+}
+
+// errChanged is returned when a request was picked up, released, completed or cancelled by someone else while the
+// caller was acting on it
+var errChanged = errors.New("request was changed by someone else; reload it and try again")
+
+// canAssigned renders the error response and returns false unless the request is assigned and the caller is
+// its instructor or manages the facility's training
+func canAssigned(w http.ResponseWriter, r *http.Request, request *models.TrainingRequest) bool {
+	self := utils.GetSelf(r)
+	if self == nil {
+		render.Render(w, r, utils.ErrUnauthorized)
+		return false
+	}
+
+	assigned := request.AssignedCID != nil && *request.AssignedCID == self.CID
+	if !assigned && !self.CanManageTraining(request.Facility) {
+		render.Render(w, r, utils.ErrForbidden)
+		return false
+	}
+
+	if request.Status != types.AssignedTrainingRequest {
+		render.Render(w, r, utils.ErrConflict(fmt.Errorf("request is %s", request.Status)))
+		return false
+	}
+
+	return true
+}
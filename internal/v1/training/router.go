@@ -0,0 +1,136 @@
+package training
+
+import (
+	"context"
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/go-chi/chi/v5"
+	"net/http"
+	"strconv"
+)
+
+func Router(r chi.Router) {
+	r.Route("/sessions", func(r chi.Router) {
+		r.Post("/", CreateSession)
+		r.Route("/{SessionID}", func(r chi.Router) {
+			r.Use(SessionCtx)
+			r.Get("/", GetSession)
+			r.Put("/", UpdateSession)
+			r.Delete("/", DeleteSession)
+		})
+	})
+
+	r.Route("/progressions", func(r chi.Router) {
+		r.Get("/", ListProgressions)
+		r.Post("/", CreateProgression)
+		r.Route("/{ProgressionID}", func(r chi.Router) {
+			r.Use(ProgressionCtx)
+			r.Get("/", GetProgression)
+			r.Put("/", UpdateProgression)
+			r.Delete("/", DeleteProgression)
+		})
+	})
+
+	r.Route("/requests", func(r chi.Router) {
+		r.Get("/", ListTrainingRequests)
+		r.Post("/", CreateTrainingRequest)
+		r.Get("/stats", GetTrainingQueueStats)
+		r.Route("/{RequestID}", func(r chi.Router) {
+			r.Use(TrainingRequestCtx)
+			r.Get("/", GetTrainingRequest)
+			r.Put("/", UpdateTrainingRequest)
+			r.Delete("/", CancelTrainingRequest)
+			r.Post("/pickup", PickUpTrainingRequest)
+			r.Post("/release", ReleaseTrainingRequest)
+			r.Post("/complete", CompleteTrainingRequest)
+		})
+	})
+
+	r.Get("/students/{CID}/history", GetHistory)
+}
+
+func SessionCtx(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := chi.URLParam(r, "SessionID")
+		if id == "" {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		SessionID, err := strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		session := &models.TrainingSession{ID: uint(SessionID)}
+		if err = session.Get(); err != nil {
+			http.Error(w, "Not found", http.StatusNotFound)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), "trainingSession", session)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func GetSessionCtx(r *http.Request) *models.TrainingSession {
+	return r.Context().Value("trainingSession").(*models.TrainingSession)
+}
+
+func ProgressionCtx(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := chi.URLParam(r, "ProgressionID")
+		if id == "" {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		ProgressionID, err := strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		progression := &models.TrainingProgression{ID: uint(ProgressionID)}
+		if err = progression.Get(); err != nil {
+			http.Error(w, "Not found", http.StatusNotFound)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), "trainingProgression", progression)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func GetProgressionCtx(r *http.Request) *models.TrainingProgression {
+	return r.Context().Value("trainingProgression").(*models.TrainingProgression)
+}
+
+func TrainingRequestCtx(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := chi.URLParam(r, "RequestID")
+		if id == "" {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		RequestID, err := strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		request := &models.TrainingRequest{ID: uint(RequestID)}
+		if err = request.Get(); err != nil {
+			http.Error(w, "Not found", http.StatusNotFound)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), "trainingRequest", request)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func GetTrainingRequestCtx(r *http.Request) *models.TrainingRequest {
+	return r.Context().Value("trainingRequest").(*models.TrainingRequest)
+}
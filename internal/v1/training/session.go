@@ -0,0 +1,276 @@
+package training
+
+import (
+	"errors"
+	"fmt"
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/VATUSA/primary-api/pkg/database/types"
+	"github.com/VATUSA/primary-api/pkg/utils"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"net/http"
+	"time"
+)
+
+type SessionRequest struct {
+	CID             uint              `json:"cid" example:"1293257" validate:"required"`
+	Facility        string            `json:"facility" example:"ZDV" validate:"required,len=3"`
+	Position        string            `json:"position" example:"DEN_APP" validate:"required"`
+	Type            types.SessionType `json:"type" example:"sweatbox" validate:"required,oneof=classroom sweatbox live ots"`
+	StartAt         string            `json:"start_at" example:"2021-01-01T00:00:00Z" validate:"required"`
+	DurationMinutes uint              `json:"duration_minutes" example:"90" validate:"required,min=1,max=1440"`
+	Score           *uint             `json:"score" example:"4" validate:"omitempty,min=1,max=5"`
+	Notes           string            `json:"notes" example:"Worked on sequencing into 17R"`
+	CompletedSteps  []uint            `json:"completed_steps" example:"1,2"`
+}
+
+func (req *SessionRequest) Validate() error {
+	return validator.New().Struct(req)
+}
+
+func (req *SessionRequest) Bind(r *http.Request) error {
+	return nil
+}
+
+type SessionResponse struct {
+	*models.TrainingSession
+}
+
+func NewSessionResponse(s *models.TrainingSession) *SessionResponse {
+	return &SessionResponse{TrainingSession: s}
+}
+
+func (res *SessionResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	if res.TrainingSession == nil {
+		return errors.New("missing required training session")
+	}
+	return nil
+}
+
+// canTrain renders the error response and returns false unless the caller may record training at the facility
+func canTrain(w http.ResponseWriter, r *http.Request, facility string) bool {
+	self := utils.GetSelf(r)
+	if self == nil {
+		render.Render(w, r, utils.ErrUnauthorized)
+		return false
+	}
+	if !self.CanTrain(facility) {
+		render.Render(w, r, utils.ErrForbidden)
+		return false
+	}
+	return true
+}
+
+// checkSteps makes sure every step belongs to one of the facility's progressions
+func checkSteps(facility string, steps []uint) error {
+	if len(steps) == 0 {
+		return nil
+	}
+
+	known, err := models.GetTrainingStepsForFacility(facility)
+	if err != nil {
+		return err
+	}
+
+	ids := map[uint]bool{}
+	for _, s := range known {
+		ids[s.ID] = true
+	}
+	for _, id := range steps {
+		if !ids[id] {
+			return fmt.Errorf("step %d is not part of a %s progression", id, facility)
+		}
+	}
+	return nil
+}
+
+// applySession validates the request against the facility and student and copies it onto the session,
+// rendering the error response and returning false if it is rejected. The caller can't train themselves, and a
+// student with a no training flag can't be given a session.
+func applySession(w http.ResponseWriter, r *http.Request, session *models.TrainingSession, data *SessionRequest) bool {
+	if err := data.Validate(); err != nil {
+		render.Render(w, r, utils.ErrInvalidRequest(err))
+		return false
+	}
+
+	if !models.IsValidFacility(data.Facility) {
+		render.Render(w, r, utils.ErrInvalidFacility)
+		return false
+	}
+
+	if !canTrain(w, r, data.Facility) {
+		return false
+	}
+
+	if !models.IsValidUser(data.CID) {
+		render.Render(w, r, utils.ErrInvalidCID)
+		return false
+	}
+
+	if !models.IsOnRoster(data.CID, data.Facility) {
+		render.Render(w, r, utils.ErrInvalidRequest(fmt.Errorf("%d is not on the %s roster", data.CID, data.Facility)))
+		return false
+	}
+
+	if data.CID == utils.GetSelf(r).CID {
+		render.Render(w, r, utils.ErrInvalidRequest(errors.New("cannot record training for yourself")))
+		return false
+	}
+
+	// Giving a session to a student, whether a new one or by moving an existing one, needs them to be allowed training
+	if data.CID != session.CID {
+		blocked, err := models.IsTrainingBlocked(data.CID)
+		if err != nil {
+			render.Render(w, r, utils.ErrInternalServer)
+			return false
+		}
+		if blocked {
+			render.Render(w, r, utils.ErrInvalidRequest(fmt.Errorf("%d is not allowed to receive training", data.CID)))
+			return false
+		}
+	}
+
+	startAt, err := time.Parse(time.RFC3339, data.StartAt)
+	if err != nil {
+		render.Render(w, r, utils.ErrInvalidRequest(err))
+		return false
+	}
+
+	if err := checkSteps(data.Facility, data.CompletedSteps); err != nil {
+		render.Render(w, r, utils.ErrInvalidRequest(err))
+		return false
+	}
+
+	session.CID = data.CID
+	session.Facility = data.Facility
+	session.Position = data.Position
+	session.Type = data.Type
+	session.StartAt = startAt
+	session.DurationMinutes = data.DurationMinutes
+	session.Score = data.Score
+	session.Notes = data.Notes
+	return true
+}
+
+// CreateSession godoc
+// @Summary Record a training session
+// @Description Record a training session given by the caller, who must be training staff at the facility. Students with a no training flag can't be given new sessions.
+// @Tags training
+// @Accept  json
+// @Produce  json
+// @Param session body SessionRequest true "Training Session"
+// @Success 201 {object} SessionResponse
+// @Failure 400 {object} utils.ErrResponse
+// @Failure 401 {object} utils.ErrResponse
+// @Failure 403 {object} utils.ErrResponse
+// @Failure 500 {object} utils.ErrResponse
+// @Router /training/sessions [post]
+func CreateSession(w http.ResponseWriter, r *http.Request) {
+	data := &SessionRequest{}
+	if err := render.Bind(r, data); err != nil {
+		render.Render(w, r, utils.ErrInvalidRequest(err))
+		return
+	}
+
+	session := &models.TrainingSession{Completions: []models.TrainingStepCompletion{}}
+	if !applySession(w, r, session, data) {
+		return
+	}
+
+	session.InstructorCID = utils.GetSelf(r).CID
+	if err := session.CreateWithCompletions(data.CompletedSteps); err != nil {
+		render.Render(w, r, utils.ErrInternalServer)
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.Render(w, r, NewSessionResponse(session))
+}
+
+// GetSession godoc
+// @Summary Get a training session
+// @Description Get a training session. Students can see their own sessions and training staff those of their facility.
+// @Tags training
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Session ID"
+// @Success 200 {object} SessionResponse
+// @Failure 401 {object} utils.ErrResponse
+// @Failure 403 {object} utils.ErrResponse
+// @Failure 404 {object} utils.ErrResponse
+// @Router /training/sessions/{id} [get]
+func GetSession(w http.ResponseWriter, r *http.Request) {
+	session := GetSessionCtx(r)
+
+	if self := utils.GetSelf(r); self == nil || self.CID != session.CID {
+		if !canTrain(w, r, session.Facility) {
+			return
+		}
+	}
+
+	render.Render(w, r, NewSessionResponse(session))
+}
+
+// UpdateSession godoc
+// @Summary Update a training session
+// @Description Update a training session. Steps already completed by the session stay complete.
+// @Tags training
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Session ID"
+// @Param session body SessionRequest true "Training Session"
+// @Success 200 {object} SessionResponse
+// @Failure 400 {object} utils.ErrResponse
+// @Failure 401 {object} utils.ErrResponse
+// @Failure 403 {object} utils.ErrResponse
+// @Failure 404 {object} utils.ErrResponse
+// @Failure 500 {object} utils.ErrResponse
+// @Router /training/sessions/{id} [put]
+func UpdateSession(w http.ResponseWriter, r *http.Request) {
+	session := GetSessionCtx(r)
+	if !canTrain(w, r, session.Facility) {
+		return
+	}
+
+	data := &SessionRequest{}
+	if err := render.Bind(r, data); err != nil {
+		render.Render(w, r, utils.ErrInvalidRequest(err))
+		return
+	}
+
+	if !applySession(w, r, session, data) {
+		return
+	}
+
+	if err := session.UpdateWithCompletions(data.CompletedSteps); err != nil {
+		render.Render(w, r, utils.ErrInternalServer)
+		return
+	}
+
+	render.Render(w, r, NewSessionResponse(session))
+}
+
+// DeleteSession godoc
+// @Summary Delete a training session
+// @Description Delete a training session. Steps it completed stay complete.
+// @Tags training
+// @Param id path int true "Session ID"
+// @Success 204
+// @Failure 401 {object} utils.ErrResponse
+// @Failure 403 {object} utils.ErrResponse
+// @Failure 404 {object} utils.ErrResponse
+// @Failure 500 {object} utils.ErrResponse
+// @Router /training/sessions/{id} [delete]
+func DeleteSession(w http.ResponseWriter, r *http.Request) {
+	session := GetSessionCtx(r)
+	if !canTrain(w, r, session.Facility) {
+		return
+	}
+
+	if err := session.Delete(); err != nil {
+		render.Render(w, r, utils.ErrInternalServer)
+		return
+	}
+
+	render.Status(r, http.StatusNoContent)
+}
@@ -16,7 +16,7 @@ type Request struct {
 	NoVisiting               bool `json:"no_visiting" example:"false"`
 	NoVisitingLogEntryID     uint `json:"no_visiting_log_entry_id" example:"1"`
 	NoTransferring           bool `json:"no_transferring" example:"false"`
-	NoTransferringLogEntryID uint `json:"no_transferring_log_entry_id" example:"`
+	NoTransferringLogEntryID uint `json:"no_transferring_log_entry_id" example:"1"`
 	NoTraining               bool `json:"no_training" example:"false"`
 	NoTrainingLogEntryID     uint `json:"no_training_log_entry_id" example:"1"`
 }
@@ -67,7 +67,7 @@ func NewUserFlagListResponse(r []models.UserFlag) []render.Renderer {
 // @Router /user-flag [post]
 func CreateUserFlag(w http.ResponseWriter, r *http.Request) {
 	req := &Request{}
-	if err := req.Bind(r); err != nil {
+	if err := render.Bind(r, req); err != nil {
 		render.Render(w, r, utils.ErrInvalidRequest(err))
 		return
 	}
@@ -156,7 +156,7 @@ func ListUserFlag(w http.ResponseWriter, r *http.Request) {
 // @Failure 500 {object} utils.ErrResponse
 func UpdateUserFlag(w http.ResponseWriter, r *http.Request) {
 	req := &Request{}
-	if err := req.Bind(r); err != nil {
+	if err := render.Bind(r, req); err != nil {
 		render.Render(w, r, utils.ErrInvalidRequest(err))
 		return
 	}
@@ -205,7 +205,7 @@ func UpdateUserFlag(w http.ResponseWriter, r *http.Request) {
 // @Router /user-flag/{cid} [patch]
 func PatchUserFlag(w http.ResponseWriter, r *http.Request) {
 	req := &Request{}
-	if err := req.Bind(r); err != nil {
+	if err := render.Bind(r, req); err != nil {
 		render.Render(w, r, utils.ErrInvalidRequest(err))
 		return
 	}
@@ -3,23 +3,35 @@ package user_role
 import (
 	"context"
 	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/VATUSA/primary-api/pkg/service"
 	"github.com/go-chi/chi/v5"
 	"net/http"
 	"strconv"
 )
 
-func Router(r chi.Router) {
-	r.Get("/", ListUserRoles)
-	r.Post("/", CreateUserRoles)
+// Handler serves the user role endpoints through the services it is built with
+type Handler struct {
+	roles *service.RoleService
+	users *service.UserService
+}
+
+func NewHandler(s *service.Services) *Handler {
+	return &Handler{roles: s.Roles, users: s.Users}
+}
+
+func Router(r chi.Router, s *service.Services) {
+	h := NewHandler(s)
+	r.Get("/", h.ListUserRoles)
+	r.Post("/", h.CreateUserRoles)
 	r.Route("/{UserRoleID}", func(r chi.Router) {
-		r.Use(Ctx)
-		r.Get("/", GetUserRole)
-		r.Put("/", UpdateUserRole)
-		r.Delete("/", DeleteUserRole)
+		r.Use(h.Ctx)
+		r.Get("/", h.GetUserRole)
+		r.Put("/", h.UpdateUserRole)
+		r.Delete("/", h.DeleteUserRole)
 	})
 }
 
-func Ctx(next http.Handler) http.Handler {
+func (h *Handler) Ctx(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		id := chi.URLParam(r, "UserRoleID")
 		if id == "" {
@@ -33,8 +45,8 @@ func Ctx(next http.Handler) http.Handler {
 			return
 		}
 
-		userRole := &models.UserRole{ID: uint(UserRoleID)}
-		if err = userRole.Get(); err != nil {
+		userRole, err := h.roles.Get(uint(UserRoleID))
+		if err != nil {
 			http.Error(w, "Invalid request", http.StatusBadRequest)
 			return
 		}
@@ -20,7 +20,7 @@ func (req *Request) Validate() error {
 	return validator.New().Struct(req)
 }
 
-func (req *Request) Bind(r http.Request) error {
+func (req *Request) Bind(r *http.Request) error {
 	return nil
 }
 
@@ -59,9 +59,9 @@ func NewUserRoleListResponse(r []models.UserRole) []render.Renderer {
 // @Failure 400 {object} utils.ErrResponse
 // @Failure 500 {object} utils.ErrResponse
 // @Router /user-roles [post]
-func CreateUserRoles(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) CreateUserRoles(w http.ResponseWriter, r *http.Request) {
 	req := &Request{}
-	if err := req.Bind(*r); err != nil {
+	if err := render.Bind(r, req); err != nil {
 		render.Render(w, r, utils.ErrInvalidRequest(err))
 		return
 	}
@@ -71,7 +71,7 @@ func CreateUserRoles(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if !models.IsValidUser(req.CID) {
+	if !h.users.Exists(req.CID) {
 		render.Render(w, r, utils.ErrInvalidCID)
 		return
 	}
@@ -87,7 +87,7 @@ func CreateUserRoles(w http.ResponseWriter, r *http.Request) {
 		FacilityID: req.FacilityID,
 	}
 
-	if err := userRole.Create(); err != nil {
+	if err := h.roles.Create(userRole); err != nil {
 		render.Render(w, r, utils.ErrInvalidRequest(err))
 		return
 	}
@@ -107,7 +107,7 @@ func CreateUserRoles(w http.ResponseWriter, r *http.Request) {
 // @Failure 400 {object} utils.ErrResponse
 // @Failure 500 {object} utils.ErrResponse
 // @Router /user-roles/{id} [get]
-func GetUserRole(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) GetUserRole(w http.ResponseWriter, r *http.Request) {
 	userRole := GetUserRoleCtx(r)
 
 	render.Render(w, r, NewUserRoleResponse(userRole))
@@ -123,8 +123,8 @@ func GetUserRole(w http.ResponseWriter, r *http.Request) {
 // @Failure 422 {object} utils.ErrResponse
 // @Failure 500 {object} utils.ErrResponse
 // @Router /user-roles [get]
-func ListUserRoles(w http.ResponseWriter, r *http.Request) {
-	userRoles, err := models.GetAllUserRoles()
+func (h *Handler) ListUserRoles(w http.ResponseWriter, r *http.Request) {
+	userRoles, err := h.roles.List()
 	if err != nil {
 		render.Render(w, r, utils.ErrInvalidRequest(err))
 		return
@@ -147,11 +147,11 @@ func ListUserRoles(w http.ResponseWriter, r *http.Request) {
 // @Failure 400 {object} utils.ErrResponse
 // @Failure 500 {object} utils.ErrResponse
 // @Router /user-roles [put]
-func UpdateUserRole(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) UpdateUserRole(w http.ResponseWriter, r *http.Request) {
 	userRole := GetUserRoleCtx(r)
 
 	req := &Request{}
-	if err := req.Bind(*r); err != nil {
+	if err := render.Bind(r, req); err != nil {
 		render.Render(w, r, utils.ErrInvalidRequest(err))
 		return
 	}
@@ -161,7 +161,7 @@ func UpdateUserRole(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if !models.IsValidUser(req.CID) {
+	if !h.users.Exists(req.CID) {
 		render.Render(w, r, utils.ErrInvalidCID)
 		return
 	}
@@ -175,7 +175,7 @@ func UpdateUserRole(w http.ResponseWriter, r *http.Request) {
 	userRole.RoleID = req.RoleID
 	userRole.FacilityID = req.FacilityID
 
-	if err := userRole.Update(); err != nil {
+	if err := h.roles.Update(userRole); err != nil {
 		render.Render(w, r, utils.ErrInvalidRequest(err))
 		return
 	}
@@ -194,17 +194,17 @@ func UpdateUserRole(w http.ResponseWriter, r *http.Request) {
 // @Failure 400 {object} utils.ErrResponse
 // @Failure 500 {object} utils.ErrResponse
 // @Router /user-roles [patch]
-func PatchUserRole(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) PatchUserRole(w http.ResponseWriter, r *http.Request) {
 	userRole := GetUserRoleCtx(r)
 
 	req := &Request{}
-	if err := req.Bind(*r); err != nil {
+	if err := render.Bind(r, req); err != nil {
 		render.Render(w, r, utils.ErrInvalidRequest(err))
 		return
 	}
 
 	if req.CID != 0 {
-		if !models.IsValidUser(req.CID) {
+		if !h.users.Exists(req.CID) {
 			render.Render(w, r, utils.ErrInvalidCID)
 			return
 		}
@@ -221,7 +221,7 @@ func PatchUserRole(w http.ResponseWriter, r *http.Request) {
 		userRole.FacilityID = req.FacilityID
 	}
 
-	if err := userRole.Update(); err != nil {
+	if err := h.roles.Update(userRole); err != nil {
 		render.Render(w, r, utils.ErrInvalidRequest(err))
 		return
 	}
@@ -239,10 +239,10 @@ func PatchUserRole(w http.ResponseWriter, r *http.Request) {
 // @Failure 400 {object} utils.ErrResponse
 // @Failure 500 {object} utils.ErrResponse
 // @Router /user-roles [delete]
-func DeleteUserRole(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) DeleteUserRole(w http.ResponseWriter, r *http.Request) {
 	userRole := GetUserRoleCtx(r)
 
-	if err := userRole.Delete(); err != nil {
+	if err := h.roles.Delete(userRole); err != nil {
 		render.Render(w, r, utils.ErrInvalidRequest(err))
 		return
 	}
@@ -3,6 +3,7 @@ package user
 import (
 	"context"
 	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/VATUSA/primary-api/pkg/service"
 	"github.com/VATUSA/primary-api/pkg/utils"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/render"
@@ -10,20 +11,32 @@ import (
 	"strconv"
 )
 
-func Router(r chi.Router) {
-	r.Get("/", ListUsers)
-	r.Post("/", CreateUser)
+// Handler serves the user endpoints through the services it is built with
+type Handler struct {
+	users      *service.UserService
+	facilities *service.FacilityService
+}
+
+func NewHandler(s *service.Services) *Handler {
+	return &Handler{users: s.Users, facilities: s.Facilities}
+}
+
+func Router(r chi.Router, s *service.Services) {
+	h := NewHandler(s)
+	r.Get("/", h.ListUsers)
+	r.Post("/", h.CreateUser)
+	r.Get("/search", h.SearchUsers)
 
 	r.Route("/{CID}", func(r chi.Router) {
-		r.Use(Ctx)
-		r.Get("/", GetUser)
-		r.Put("/", UpdateUser)
-		r.Patch("/", PatchUser)
-		r.Delete("/", DeleteUser)
+		r.Use(h.Ctx)
+		r.Get("/", h.GetUser)
+		r.Put("/", h.UpdateUser)
+		r.Patch("/", h.PatchUser)
+		r.Delete("/", h.DeleteUser)
 	})
 }
 
-func Ctx(next http.Handler) http.Handler {
+func (h *Handler) Ctx(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		cid := chi.URLParam(r, "CID")
 		if cid == "" {
@@ -37,8 +50,7 @@ func Ctx(next http.Handler) http.Handler {
 			return
 		}
 
-		user := &models.User{CID: uint(CID)}
-		err = user.Get()
+		user, err := h.users.Get(uint(CID))
 		if err != nil {
 			render.Render(w, r, utils.ErrNotFound)
 			return
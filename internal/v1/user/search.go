@@ -0,0 +1,102 @@
+package user
+
+import (
+	"errors"
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/VATUSA/primary-api/pkg/utils"
+	"github.com/go-chi/render"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultSearchLimit = 25
+	MaxSearchLimit     = 100
+)
+
+// redact hides the contact details, flags and activity of users the caller isn't allowed to see them for
+func redact(users []models.User) {
+	for i := range users {
+		users[i].Email = ""
+		users[i].DiscordID = ""
+		users[i].LastLogin = time.Time{}
+		users[i].Flags = nil
+	}
+}
+
+// SearchUsers godoc
+// @Summary Search users
+// @Description Search users by CID prefix, name, preferred name and operating initials, best matches first. Division staff, and facility staff searching their own facility, can also match on email and see contact details. The total number of matches is returned in X-Total-Count.
+// @Tags user
+// @Produce  json
+// @Param q query string true "Search text"
+// @Param facility query string false "Only users on this facility's roster"
+// @Param limit query int false "Limit (default 25, max 100)"
+// @Param offset query int false "Offset"
+// @Success 200 {object} []Response
+// @Failure 400 {object} utils.ErrResponse
+// @Failure 401 {object} utils.ErrResponse
+// @Failure 500 {object} utils.ErrResponse
+// @Router /user/search [get]
+func (h *Handler) SearchUsers(w http.ResponseWriter, r *http.Request) {
+	self := utils.GetSelf(r)
+	if self == nil {
+		render.Render(w, r, utils.ErrUnauthorized)
+		return
+	}
+
+	params := r.URL.Query()
+	search := models.UserSearch{
+		Query:    strings.TrimSpace(params.Get("q")),
+		Facility: strings.ToUpper(params.Get("facility")),
+		Limit:    DefaultSearchLimit,
+	}
+
+	if search.Query == "" {
+		render.Render(w, r, utils.ErrInvalidRequest(errors.New("q is required")))
+		return
+	}
+
+	if search.Facility != "" && !h.facilities.Exists(search.Facility) {
+		render.Render(w, r, utils.ErrInvalidFacility)
+		return
+	}
+
+	if limit := params.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > MaxSearchLimit {
+			render.Render(w, r, utils.ErrInvalidRequest(errors.New("limit must be between 1 and "+strconv.Itoa(MaxSearchLimit))))
+			return
+		}
+		search.Limit = n
+	}
+
+	if offset := params.Get("offset"); offset != "" {
+		n, err := strconv.Atoi(offset)
+		if err != nil || n < 0 {
+			render.Render(w, r, utils.ErrInvalidRequest(errors.New("offset must not be negative")))
+			return
+		}
+		search.Offset = n
+	}
+
+	search.IncludeEmail = self.IsDivisionStaff() || (search.Facility != "" && self.HasFacilityRole(search.Facility))
+
+	users, total, err := h.users.Search(search)
+	if err != nil {
+		render.Render(w, r, utils.ErrInternalServer)
+		return
+	}
+
+	if !search.IncludeEmail {
+		redact(users)
+	}
+
+	w.Header().Set("X-Total-Count", strconv.FormatInt(total, 10))
+	if err := render.RenderList(w, r, NewUserListResponse(users)); err != nil {
+		render.Render(w, r, utils.ErrRender(err))
+		return
+	}
+}
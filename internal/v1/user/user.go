@@ -49,8 +49,8 @@ func (res *Response) Render(w http.ResponseWriter, r *http.Request) error {
 
 func NewUserListResponse(users []models.User) []render.Renderer {
 	list := []render.Renderer{}
-	for _, user := range users {
-		list = append(list, NewUserResponse(&user))
+	for i := range users {
+		list = append(list, NewUserResponse(&users[i]))
 	}
 	return list
 }
@@ -66,7 +66,7 @@ func NewUserListResponse(users []models.User) []render.Renderer {
 // @Failure 400 {object} utils.ErrResponse
 // @Failure 500 {object} utils.ErrResponse
 // @Router /user [post]
-func CreateUser(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) CreateUser(w http.ResponseWriter, r *http.Request) {
 	req := &Request{}
 	if err := render.Bind(r, req); err != nil {
 		render.Render(w, r, utils.ErrInvalidRequest(err))
@@ -89,7 +89,7 @@ func CreateUser(w http.ResponseWriter, r *http.Request) {
 		ControllerRating: req.ControllerRating,
 		DiscordID:        req.DiscordID,
 	}
-	if err := user.Create(); err != nil {
+	if err := h.users.Create(user); err != nil {
 		render.Render(w, r, utils.ErrInternalServer)
 		return
 	}
@@ -109,7 +109,7 @@ func CreateUser(w http.ResponseWriter, r *http.Request) {
 // @Failure 400 {object} utils.ErrResponse
 // @Failure 500 {object} utils.ErrResponse
 // @Router /user/{cid} [get]
-func GetUser(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) GetUser(w http.ResponseWriter, r *http.Request) {
 	user := GetUserCtx(r)
 
 	render.Render(w, r, NewUserResponse(user))
@@ -126,8 +126,8 @@ func GetUser(w http.ResponseWriter, r *http.Request) {
 // @Failure 422 {object} utils.ErrResponse
 // @Failure 500 {object} utils.ErrResponse
 // @Router /user [get]
-func ListUsers(w http.ResponseWriter, r *http.Request) {
-	users, err := models.GetAllUsers()
+func (h *Handler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	users, err := h.users.List()
 	if err != nil {
 		render.Render(w, r, utils.ErrInternalServer)
 		return
@@ -151,7 +151,7 @@ func ListUsers(w http.ResponseWriter, r *http.Request) {
 // @Failure 400 {object} utils.ErrResponse
 // @Failure 500 {object} utils.ErrResponse
 // @Router /user/{cid} [put]
-func UpdateUser(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 	user := GetUserCtx(r)
 
 	req := &Request{}
@@ -175,7 +175,7 @@ func UpdateUser(w http.ResponseWriter, r *http.Request) {
 	user.ControllerRating = req.ControllerRating
 	user.DiscordID = req.DiscordID
 
-	if err := user.Update(); err != nil {
+	if err := h.users.Update(user); err != nil {
 		render.Render(w, r, utils.ErrInternalServer)
 		return
 	}
@@ -195,7 +195,7 @@ func UpdateUser(w http.ResponseWriter, r *http.Request) {
 // @Failure 400 {object} utils.ErrResponse
 // @Failure 500 {object} utils.ErrResponse
 // @Router /user/{cid} [patch]
-func PatchUser(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) PatchUser(w http.ResponseWriter, r *http.Request) {
 	user := GetUserCtx(r)
 
 	req := &Request{}
@@ -223,7 +223,7 @@ func PatchUser(w http.ResponseWriter, r *http.Request) {
 		user.DiscordID = req.DiscordID
 	}
 
-	if err := user.Update(); err != nil {
+	if err := h.users.Update(user); err != nil {
 		render.Render(w, r, utils.ErrInternalServer)
 		return
 	}
@@ -242,10 +242,10 @@ func PatchUser(w http.ResponseWriter, r *http.Request) {
 // @Failure 400 {object} utils.ErrResponse
 // @Failure 500 {object} utils.ErrResponse
 // @Router /user/{cid} [delete]
-func DeleteUser(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 	user := GetUserCtx(r)
 
-	if err := user.Delete(); err != nil {
+	if err := h.users.Delete(user); err != nil {
 		render.Render(w, r, utils.ErrInternalServer)
 		return
 	}
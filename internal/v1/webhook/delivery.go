@@ -0,0 +1,129 @@
+package webhook
+
+import (
+	"errors"
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/VATUSA/primary-api/pkg/database/types"
+	"github.com/VATUSA/primary-api/pkg/utils"
+	"github.com/go-chi/render"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// DeliveryLimit is the most deliveries listed at once
+const DeliveryLimit = 100
+
+type DeliveryResponse struct {
+	*models.WebhookDelivery
+}
+
+func NewDeliveryResponse(d *models.WebhookDelivery) *DeliveryResponse {
+	return &DeliveryResponse{WebhookDelivery: d}
+}
+
+func (res *DeliveryResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	if res.WebhookDelivery == nil {
+		return errors.New("webhook delivery not found")
+	}
+	return nil
+}
+
+func NewDeliveryListResponse(deliveries []models.WebhookDelivery) []render.Renderer {
+	list := []render.Renderer{}
+	for _, d := range deliveries {
+		list = append(list, NewDeliveryResponse(&d))
+	}
+	return list
+}
+
+// ListDeliveries godoc
+// @Summary List webhook deliveries
+// @Description List a subscription's most recent deliveries, newest first
+// @Tags webhook
+// @Produce  json
+// @Param id path int true "Webhook ID"
+// @Param limit query int false "Deliveries to list, at most 100"
+// @Success 200 {object} []DeliveryResponse
+// @Failure 400 {object} utils.ErrResponse
+// @Failure 401 {object} utils.ErrResponse
+// @Failure 403 {object} utils.ErrResponse
+// @Failure 404 {object} utils.ErrResponse
+// @Failure 500 {object} utils.ErrResponse
+// @Router /webhook/{id}/deliveries [get]
+func ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	sub := GetSubscriptionCtx(r)
+
+	limit := DeliveryLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			render.Render(w, r, utils.ErrInvalidRequest(errors.New("limit must be a positive number")))
+			return
+		}
+		limit = min(n, DeliveryLimit)
+	}
+
+	deliveries, err := models.GetWebhookDeliveries(sub.ID, limit)
+	if err != nil {
+		render.Render(w, r, utils.ErrInternalServer)
+		return
+	}
+
+	if err := render.RenderList(w, r, NewDeliveryListResponse(deliveries)); err != nil {
+		render.Render(w, r, utils.ErrRender(err))
+		return
+	}
+}
+
+// GetDelivery godoc
+// @Summary Get a webhook delivery
+// @Description Get a webhook delivery, including its payload
+// @Tags webhook
+// @Produce  json
+// @Param id path int true "Webhook ID"
+// @Param delivery_id path int true "Delivery ID"
+// @Success 200 {object} DeliveryResponse
+// @Failure 401 {object} utils.ErrResponse
+// @Failure 403 {object} utils.ErrResponse
+// @Failure 404 {object} utils.ErrResponse
+// @Router /webhook/{id}/deliveries/{delivery_id} [get]
+func GetDelivery(w http.ResponseWriter, r *http.Request) {
+	render.Render(w, r, NewDeliveryResponse(GetDeliveryCtx(r)))
+}
+
+// Redeliver godoc
+// @Summary Redeliver a webhook
+// @Description Queue the delivery's payload to be sent again. The new delivery keeps the event ID so receivers can tell it apart from a new event.
+// @Tags webhook
+// @Produce  json
+// @Param id path int true "Webhook ID"
+// @Param delivery_id path int true "Delivery ID"
+// @Success 201 {object} DeliveryResponse
+// @Failure 401 {object} utils.ErrResponse
+// @Failure 403 {object} utils.ErrResponse
+// @Failure 404 {object} utils.ErrResponse
+// @Failure 500 {object} utils.ErrResponse
+// @Router /webhook/{id}/deliveries/{delivery_id}/redeliver [post]
+func Redeliver(w http.ResponseWriter, r *http.Request) {
+	original := GetDeliveryCtx(r)
+
+	now := time.Now()
+	delivery := &models.WebhookDelivery{
+		SubscriptionID: original.SubscriptionID,
+		EventID:        original.EventID,
+		Event:          original.Event,
+		Payload:        original.Payload,
+		Status:         types.PendingDelivery,
+		NextAttemptAt:  &now,
+		RedeliveryOf:   &original.ID,
+	}
+
+	if err := delivery.Create(); err != nil {
+		render.Render(w, r, utils.ErrInternalServer)
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.Render(w, r, NewDeliveryResponse(delivery))
+}
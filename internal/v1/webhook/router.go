@@ -0,0 +1,97 @@
+package webhook
+
+import (
+	"context"
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/VATUSA/primary-api/pkg/utils"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"net/http"
+	"strconv"
+)
+
+func Router(r chi.Router) {
+	r.Get("/", ListSubscriptions)
+	r.Post("/", CreateSubscription)
+
+	r.Route("/{WebhookID}", func(r chi.Router) {
+		r.Use(Ctx)
+		r.Get("/", GetSubscription)
+		r.Put("/", UpdateSubscription)
+		r.Delete("/", DeleteSubscription)
+
+		r.Route("/deliveries", func(r chi.Router) {
+			r.Get("/", ListDeliveries)
+			r.Route("/{DeliveryID}", func(r chi.Router) {
+				r.Use(DeliveryCtx)
+				r.Get("/", GetDelivery)
+				r.Post("/redeliver", Redeliver)
+			})
+		})
+	})
+}
+
+// Ctx loads the subscription, which only those who manage the facility's webhooks may see
+func Ctx(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		WebhookID, err := strconv.ParseUint(chi.URLParam(r, "WebhookID"), 10, 64)
+		if err != nil {
+			render.Render(w, r, utils.ErrInvalidRequest(err))
+			return
+		}
+
+		sub := &models.WebhookSubscription{ID: uint(WebhookID)}
+		if err := sub.Get(); err != nil {
+			render.Render(w, r, utils.ErrNotFound)
+			return
+		}
+
+		if !canManage(w, r, sub.Facility) {
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), "webhookSubscription", sub)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func GetSubscriptionCtx(r *http.Request) *models.WebhookSubscription {
+	return r.Context().Value("webhookSubscription").(*models.WebhookSubscription)
+}
+
+func DeliveryCtx(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		DeliveryID, err := strconv.ParseUint(chi.URLParam(r, "DeliveryID"), 10, 64)
+		if err != nil {
+			render.Render(w, r, utils.ErrInvalidRequest(err))
+			return
+		}
+
+		delivery := &models.WebhookDelivery{ID: uint(DeliveryID)}
+		if err := delivery.Get(); err != nil || delivery.SubscriptionID != GetSubscriptionCtx(r).ID {
+			render.Render(w, r, utils.ErrNotFound)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), "webhookDelivery", delivery)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func GetDeliveryCtx(r *http.Request) *models.WebhookDelivery {
+	return r.Context().Value("webhookDelivery").(*models.WebhookDelivery)
+}
+
+// canManage renders the error response unless the caller may manage the facility's webhooks
+func canManage(w http.ResponseWriter, r *http.Request, facility string) bool {
+	self := utils.GetSelf(r)
+	if self == nil {
+		render.Render(w, r, utils.ErrUnauthorized)
+		return false
+	}
+	if !self.CanManageWebhooks(facility) {
+		render.Render(w, r, utils.ErrForbidden)
+		return false
+	}
+	return true
+}
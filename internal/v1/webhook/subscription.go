@@ -0,0 +1,262 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/VATUSA/primary-api/pkg/database/types"
+	"github.com/VATUSA/primary-api/pkg/utils"
+	"github.com/VATUSA/primary-api/pkg/webhook"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"net/http"
+)
+
+type Request struct {
+	Facility string               `json:"facility" example:"ZDV" validate:"required,len=3"`
+	URL      string               `json:"url" example:"https://zdvartcc.org/api/vatusa/webhook" validate:"required,url,startswith=https://"`
+	Events   []types.WebhookEvent `json:"events" example:"roster.added,roster.removed" validate:"required,min=1,dive,required"`
+	Active   *bool                `json:"active" example:"true"`
+	Secret   string               `json:"secret" example:"" validate:"omitempty,min=16,max=255"` // Generated if left empty on create, kept if left empty on update
+}
+
+func (req *Request) Validate() error {
+	if err := validator.New().Struct(req); err != nil {
+		return err
+	}
+	for _, event := range req.Events {
+		if !event.IsValid() {
+			return fmt.Errorf("unknown event %s", event)
+		}
+	}
+	return nil
+}
+
+func (req *Request) Bind(r *http.Request) error {
+	return nil
+}
+
+type Response struct {
+	*models.WebhookSubscription
+	Secret string `json:"secret,omitempty" example:"5f4dcc3b5aa765d61d8327deb882cf99"` // Only returned when the secret is set
+}
+
+func NewSubscriptionResponse(s *models.WebhookSubscription) *Response {
+	return &Response{WebhookSubscription: s}
+}
+
+func (res *Response) Render(w http.ResponseWriter, r *http.Request) error {
+	if res.WebhookSubscription == nil {
+		return errors.New("webhook subscription not found")
+	}
+	return nil
+}
+
+func NewSubscriptionListResponse(subs []models.WebhookSubscription) []render.Renderer {
+	list := []render.Renderer{}
+	for _, s := range subs {
+		list = append(list, NewSubscriptionResponse(&s))
+	}
+	return list
+}
+
+// applySubscription checks the request and copies it onto the subscription, rendering the error response if it's
+// rejected. It reports whether the secret was changed.
+func applySubscription(w http.ResponseWriter, r *http.Request, sub *models.WebhookSubscription) (bool, bool) {
+	data := &Request{}
+	if err := render.Bind(r, data); err != nil {
+		render.Render(w, r, utils.ErrInvalidRequest(err))
+		return false, false
+	}
+
+	if err := data.Validate(); err != nil {
+		render.Render(w, r, utils.ErrInvalidRequest(err))
+		return false, false
+	}
+
+	if !models.IsValidFacility(data.Facility) {
+		render.Render(w, r, utils.ErrInvalidFacility)
+		return false, false
+	}
+
+	if !canManage(w, r, data.Facility) {
+		return false, false
+	}
+
+	sub.Facility = data.Facility
+	sub.URL = data.URL
+	if data.Active != nil {
+		sub.Active = *data.Active
+	}
+
+	seen := map[types.WebhookEvent]bool{}
+	sub.Events = []models.WebhookSubscriptionEvent{}
+	for _, event := range data.Events {
+		if seen[event] {
+			continue
+		}
+		seen[event] = true
+		sub.Events = append(sub.Events, models.WebhookSubscriptionEvent{Event: event})
+	}
+
+	if data.Secret != "" {
+		sub.Secret = data.Secret
+		return true, true
+	}
+	if sub.Secret == "" {
+		secret, err := webhook.NewSecret()
+		if err != nil {
+			render.Render(w, r, utils.ErrInternalServer)
+			return false, false
+		}
+		sub.Secret = secret
+		return true, true
+	}
+	return true, false
+}
+
+// ListSubscriptions godoc
+// @Summary List webhook subscriptions
+// @Description List a facility's webhook subscriptions. Division staff may leave out the facility to list every subscription.
+// @Tags webhook
+// @Produce  json
+// @Param facility query string false "Facility"
+// @Success 200 {object} []Response
+// @Failure 401 {object} utils.ErrResponse
+// @Failure 403 {object} utils.ErrResponse
+// @Failure 500 {object} utils.ErrResponse
+// @Router /webhook [get]
+func ListSubscriptions(w http.ResponseWriter, r *http.Request) {
+	facility := r.URL.Query().Get("facility")
+	if facility != "" {
+		if !canManage(w, r, facility) {
+			return
+		}
+	} else {
+		self := utils.GetSelf(r)
+		if self == nil {
+			render.Render(w, r, utils.ErrUnauthorized)
+			return
+		}
+		if !self.IsDivisionStaff() {
+			render.Render(w, r, utils.ErrForbidden)
+			return
+		}
+	}
+
+	subs, err := models.GetWebhookSubscriptions(facility)
+	if err != nil {
+		render.Render(w, r, utils.ErrInternalServer)
+		return
+	}
+
+	if err := render.RenderList(w, r, NewSubscriptionListResponse(subs)); err != nil {
+		render.Render(w, r, utils.ErrRender(err))
+		return
+	}
+}
+
+// CreateSubscription godoc
+// @Summary Create a webhook subscription
+// @Description Send a facility's events to a URL. Deliveries are signed with HMAC-SHA256 over "<X-VATUSA-Timestamp>.<body>" and the signature is sent as X-VATUSA-Signature. The secret is generated if not given and is only returned here.
+// @Tags webhook
+// @Accept  json
+// @Produce  json
+// @Param subscription body Request true "Webhook Subscription"
+// @Success 201 {object} Response
+// @Failure 400 {object} utils.ErrResponse
+// @Failure 401 {object} utils.ErrResponse
+// @Failure 403 {object} utils.ErrResponse
+// @Failure 500 {object} utils.ErrResponse
+// @Router /webhook [post]
+func CreateSubscription(w http.ResponseWriter, r *http.Request) {
+	sub := &models.WebhookSubscription{Active: true}
+	if ok, _ := applySubscription(w, r, sub); !ok {
+		return
+	}
+
+	if self := utils.GetSelf(r); self != nil {
+		sub.CreatedBy = self.CID
+	}
+
+	if err := sub.Create(); err != nil {
+		render.Render(w, r, utils.ErrInternalServer)
+		return
+	}
+
+	res := NewSubscriptionResponse(sub)
+	res.Secret = sub.Secret
+	render.Status(r, http.StatusCreated)
+	render.Render(w, r, res)
+}
+
+// GetSubscription godoc
+// @Summary Get a webhook subscription
+// @Description Get a webhook subscription
+// @Tags webhook
+// @Produce  json
+// @Param id path int true "Webhook ID"
+// @Success 200 {object} Response
+// @Failure 401 {object} utils.ErrResponse
+// @Failure 403 {object} utils.ErrResponse
+// @Failure 404 {object} utils.ErrResponse
+// @Router /webhook/{id} [get]
+func GetSubscription(w http.ResponseWriter, r *http.Request) {
+	render.Render(w, r, NewSubscriptionResponse(GetSubscriptionCtx(r)))
+}
+
+// UpdateSubscription godoc
+// @Summary Update a webhook subscription
+// @Description Update a webhook subscription. The secret is kept unless a new one is given, in which case it is returned.
+// @Tags webhook
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Webhook ID"
+// @Param subscription body Request true "Webhook Subscription"
+// @Success 200 {object} Response
+// @Failure 400 {object} utils.ErrResponse
+// @Failure 401 {object} utils.ErrResponse
+// @Failure 403 {object} utils.ErrResponse
+// @Failure 404 {object} utils.ErrResponse
+// @Failure 500 {object} utils.ErrResponse
+// @Router /webhook/{id} [put]
+func UpdateSubscription(w http.ResponseWriter, r *http.Request) {
+	sub := GetSubscriptionCtx(r)
+	ok, rotated := applySubscription(w, r, sub)
+	if !ok {
+		return
+	}
+
+	if err := sub.Update(); err != nil {
+		render.Render(w, r, utils.ErrInternalServer)
+		return
+	}
+
+	res := NewSubscriptionResponse(sub)
+	if rotated {
+		res.Secret = sub.Secret
+	}
+	render.Render(w, r, res)
+}
+
+// DeleteSubscription godoc
+// @Summary Delete a webhook subscription
+// @Description Delete a webhook subscription along with its delivery log
+// @Tags webhook
+// @Param id path int true "Webhook ID"
+// @Success 204
+// @Failure 401 {object} utils.ErrResponse
+// @Failure 403 {object} utils.ErrResponse
+// @Failure 404 {object} utils.ErrResponse
+// @Failure 500 {object} utils.ErrResponse
+// @Router /webhook/{id} [delete]
+func DeleteSubscription(w http.ResponseWriter, r *http.Request) {
+	sub := GetSubscriptionCtx(r)
+
+	if err := sub.Delete(); err != nil {
+		render.Render(w, r, utils.ErrInternalServer)
+		return
+	}
+
+	render.Status(r, http.StatusNoContent)
+}
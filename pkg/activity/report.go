@@ -0,0 +1,107 @@
+package activity
+
+import (
+	"github.com/VATUSA/primary-api/pkg/database"
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Hours totals each controller's time on position between since and now. Sessions that started before since
+// only count from since.
+func Hours(sessions []models.ControllingSession, since, now time.Time) map[uint]time.Duration {
+	hours := map[uint]time.Duration{}
+	for _, s := range sessions {
+		start := s.StartAt
+		if start.Before(since) {
+			start = since
+		}
+
+		end := s.LastSeenAt
+		if s.EndAt != nil {
+			end = *s.EndAt
+		}
+		if end.After(now) {
+			end = now
+		}
+
+		if end.After(start) {
+			hours[s.CID] += end.Sub(start)
+		}
+	}
+	return hours
+}
+
+// Thresholds decide who the inactive members report lists
+type Thresholds struct {
+	Window          time.Duration // How far back to look
+	MinHours        float64       // Members with fewer hours than this in the window are inactive
+	IncludeVisiting bool          // Also hold visiting controllers to the thresholds
+}
+
+// Member is a roster member with the hours they controlled in the window
+type Member struct {
+	RosterID         uint       `json:"roster_id" example:"1"`
+	CID              uint       `json:"cid" example:"1293257"`
+	Home             bool       `json:"home" example:"true"`
+	Visiting         bool       `json:"visiting" example:"false"`
+	JoinedAt         time.Time  `json:"joined_at" example:"2021-01-01T00:00:00Z"`
+	Hours            float64    `json:"hours" example:"1.5"`
+	LastControlledAt *time.Time `json:"last_controlled_at" example:"2021-01-01T00:00:00Z"`
+}
+
+// Inactive lists the roster members who controlled for less than the thresholds allow, least active first.
+// Members on a leave of absence, and members who joined within the window and so haven't had a full window
+// to meet the thresholds, are left out.
+func Inactive(rosters []models.Roster, sessions []models.ControllingSession, last map[uint]time.Time, th Thresholds, now time.Time) []Member {
+	since := now.Add(-th.Window)
+	hours := Hours(sessions, since, now)
+
+	inactive := []Member{}
+	for _, r := range rosters {
+		if strings.EqualFold(r.Status, "loa") || r.CreatedAt.After(since) {
+			continue
+		}
+		if r.Visiting && !th.IncludeVisiting {
+			continue
+		}
+
+		h := hours[r.CID].Hours()
+		if h >= th.MinHours {
+			continue
+		}
+
+		m := Member{RosterID: r.ID, CID: r.CID, Home: r.Home, Visiting: r.Visiting, JoinedAt: r.CreatedAt, Hours: h}
+		if t, ok := last[r.CID]; ok {
+			m.LastControlledAt = &t
+		}
+		inactive = append(inactive, m)
+	}
+
+	sort.SliceStable(inactive, func(i, j int) bool {
+		return inactive[i].Hours < inactive[j].Hours
+	})
+	return inactive
+}
+
+// FindInactive builds the inactive members report for the facility. It returns the facility's roster along with
+// the members the report lists.
+func FindInactive(facility string, th Thresholds, now time.Time) ([]models.Roster, []Member, error) {
+	rosters, err := models.GetAllRostersByFacility(database.DB, facility)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sessions, err := models.GetControllingSessionsSince(facility, now.Add(-th.Window))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	last, err := models.GetLastControllingSessions(facility)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return rosters, Inactive(rosters, sessions, last, th, now), nil
+}
@@ -0,0 +1,116 @@
+package activity
+
+import (
+	"context"
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/VATUSA/primary-api/pkg/datafeed"
+	"log"
+	"strings"
+	"time"
+)
+
+// Tracker turns data feed snapshots into controlling sessions. It keeps no state of its own, so sessions
+// carry on across restarts.
+type Tracker struct {
+	Feed datafeed.Feed
+}
+
+func NewTracker(feed datafeed.Feed) *Tracker {
+	return &Tracker{Feed: feed}
+}
+
+// Facility returns the facility a callsign belongs to, or "" if no prefix matches
+func Facility(callsign string, prefixes map[string]string) string {
+	prefix, _, _ := strings.Cut(strings.ToUpper(callsign), "_")
+	return prefixes[prefix]
+}
+
+type sessionKey struct {
+	cid      uint
+	callsign string
+	logon    int64
+}
+
+// Poll takes a snapshot of the feed. Controllers seen for the first time start a session, those still
+// online are marked as seen, and sessions whose controller is gone are closed. A controller who comes back with
+// the same logon reopens their closed session rather than starting another.
+func (t *Tracker) Poll(ctx context.Context, now time.Time) error {
+	controllers, err := t.Feed.Controllers(ctx)
+	if err != nil {
+		return err
+	}
+
+	prefixes, err := models.GetCallsignPrefixes()
+	if err != nil {
+		return err
+	}
+
+	open, err := models.GetOpenControllingSessions()
+	if err != nil {
+		return err
+	}
+
+	sessions := map[sessionKey]*models.ControllingSession{}
+	for i := range open {
+		s := &open[i]
+		sessions[sessionKey{s.CID, s.Callsign, s.StartAt.Unix()}] = s
+	}
+
+	var seen []uint
+	online := map[uint]bool{}
+	for _, c := range controllers {
+		facility := Facility(c.Callsign, prefixes)
+		if facility == "" {
+			continue
+		}
+
+		if s, ok := sessions[sessionKey{c.CID, c.Callsign, c.LogonTime.Unix()}]; ok {
+			seen = append(seen, s.ID)
+			online[s.ID] = true
+			continue
+		}
+
+		s := &models.ControllingSession{
+			CID:        c.CID,
+			Callsign:   c.Callsign,
+			Facility:   facility,
+			StartAt:    c.LogonTime,
+			LastSeenAt: now,
+		}
+		if err := s.Open(); err != nil {
+			return err
+		}
+	}
+
+	if err := models.TouchControllingSessions(seen, now); err != nil {
+		return err
+	}
+
+	for i := range open {
+		if online[open[i].ID] {
+			continue
+		}
+		if err := open[i].Close(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Run polls every interval until ctx is done
+func (t *Tracker) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := t.Poll(ctx, now); err != nil {
+				log.Println("[Activity] Error polling data feed:", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,120 @@
+// Package admin holds the operational tasks run from the vatusa-admin command. Each task records what it
+// changed in a Summary, and can be run as a dry run that works out the changes and then rolls them back.
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"github.com/VATUSA/primary-api/pkg/database"
+	"gorm.io/gorm"
+	"io"
+	"sort"
+)
+
+// Actions recorded in a summary
+const (
+	Created   = "created"
+	Updated   = "updated"
+	Unchanged = "unchanged"
+	Removed   = "removed"
+	Exported  = "exported"
+	Issued    = "issued"
+)
+
+var errDryRun = errors.New("dry run")
+
+// Change is one thing a task changed, or would change, or a number of like things
+type Change struct {
+	Action  string
+	Subject string
+	Count   int
+}
+
+// Summary collects the changes a task made
+type Summary struct {
+	Changes []Change
+}
+
+func (s *Summary) Add(action, format string, args ...interface{}) {
+	s.Changes = append(s.Changes, Change{Action: action, Subject: fmt.Sprintf(format, args...), Count: 1})
+}
+
+// AddCount records n like changes as one, for tasks that only know how many records they changed. Nothing is
+// recorded when n is 0.
+func (s *Summary) AddCount(action string, n int, format string, args ...interface{}) {
+	if n == 0 {
+		return
+	}
+	s.Changes = append(s.Changes, Change{Action: action, Subject: fmt.Sprintf(format, args...), Count: n})
+}
+
+// Count is the number of changes with the action
+func (s *Summary) Count(action string) int {
+	n := 0
+	for _, c := range s.Changes {
+		if c.Action == action {
+			n += c.Count
+		}
+	}
+	return n
+}
+
+// Print writes each change, leaving out unchanged records, followed by the totals for each action
+func (s *Summary) Print(w io.Writer, dryRun bool) {
+	if dryRun {
+		fmt.Fprintln(w, "Dry run, nothing was saved. These changes would be made:")
+	}
+
+	totals := map[string]int{}
+	for _, c := range s.Changes {
+		totals[c.Action] += c.Count
+		if c.Action != Unchanged {
+			fmt.Fprintf(w, "  %-9s %s\n", c.Action, c.Subject)
+		}
+	}
+
+	if len(totals) == 0 {
+		fmt.Fprintln(w, "No changes")
+		return
+	}
+
+	actions := []string{}
+	for action := range totals {
+		actions = append(actions, action)
+	}
+	sort.Strings(actions)
+
+	line := ""
+	for i, action := range actions {
+		if i > 0 {
+			line += ", "
+		}
+		line += fmt.Sprintf("%d %s", totals[action], action)
+	}
+	fmt.Fprintln(w, line)
+}
+
+// Run runs task against db. A dry run runs it in a transaction that is rolled back once it finishes. database.DB
+// is pointed at the transaction while the task runs, so model methods working through it are rolled back too.
+func Run(db *gorm.DB, dryRun bool, task func(tx *gorm.DB) error) error {
+	if !dryRun {
+		return task(db)
+	}
+
+	previous := database.DB
+	defer func() {
+		database.DB = previous
+	}()
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		database.DB = tx
+		if err := task(tx); err != nil {
+			return err
+		}
+		return errDryRun
+	})
+	if errors.Is(err, errDryRun) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,43 @@
+package admin
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"gorm.io/gorm"
+)
+
+// RotateAPIKey issues the facility a new API key, replacing its current one, and returns the key
+func RotateAPIKey(db *gorm.DB, facility string, s *Summary) (string, error) {
+	if err := db.Where("id = ?", facility).First(&models.Facility{}).Error; err != nil {
+		return "", fmt.Errorf("unknown facility %s: %w", facility, err)
+	}
+
+	previous, err := models.GetAPIKeyByFacility(db, facility)
+	replacing := err == nil
+
+	key, err := newAPIKey()
+	if err != nil {
+		return "", err
+	}
+	k, err := models.SaveAPIKey(db, facility, key)
+	if err != nil {
+		return "", err
+	}
+
+	if replacing {
+		s.Add(Updated, "API key for %s (%s..., replacing %s...)", facility, k.Prefix, previous.Prefix)
+	} else {
+		s.Add(Issued, "API key for %s (%s...)", facility, k.Prefix)
+	}
+	return key, nil
+}
+
+func newAPIKey() (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(key), nil
+}
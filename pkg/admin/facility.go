@@ -0,0 +1,61 @@
+package admin
+
+import (
+	"errors"
+	"github.com/VATUSA/primary-api/pkg/constants"
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"sort"
+)
+
+// DefaultFacilities are the division's facilities, named as in constants.FacilityDisplayNameMap
+func DefaultFacilities() []models.Facility {
+	facilities := []models.Facility{}
+	for id, name := range constants.FacilityDisplayNameMap {
+		facilities = append(facilities, models.Facility{ID: string(id), Name: name})
+	}
+	sort.Slice(facilities, func(i, j int) bool {
+		return facilities[i].ID < facilities[j].ID
+	})
+	return facilities
+}
+
+// SeedFacilities creates the facilities that are missing and renames those whose name has changed. A URL is only
+// set when one is given, so URLs set through the API are kept.
+func SeedFacilities(db *gorm.DB, facilities []models.Facility, s *Summary) error {
+	for _, f := range facilities {
+		if len(f.ID) != 3 || f.Name == "" {
+			return errors.New("facilities need a three letter id and a name")
+		}
+
+		existing := &models.Facility{}
+		err := db.Where("id = ?", f.ID).First(existing).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			f := f
+			if err := db.Create(&f).Error; err != nil {
+				return err
+			}
+			s.Add(Created, "facility %s (%s)", f.ID, f.Name)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if existing.Name == f.Name && (f.URL == "" || existing.URL == f.URL) {
+			s.Add(Unchanged, "facility %s", f.ID)
+			continue
+		}
+
+		existing.Name = f.Name
+		if f.URL != "" {
+			existing.URL = f.URL
+		}
+		if err := db.Omit(clause.Associations).Save(existing).Error; err != nil {
+			return err
+		}
+		s.Add(Updated, "facility %s (%s)", f.ID, f.Name)
+	}
+	return nil
+}
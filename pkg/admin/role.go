@@ -0,0 +1,50 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"github.com/VATUSA/primary-api/pkg/constants"
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"gorm.io/gorm"
+)
+
+// GrantRole gives cid the role at facility. A user who isn't known yet is created with only their CID, for the
+// VATSIM sync to fill in, so the first division staff can be set up on an empty database.
+func GrantRole(db *gorm.DB, cid uint, role constants.RoleID, facility string, s *Summary) error {
+	if _, ok := constants.Roles[role]; !ok {
+		return fmt.Errorf("unknown role: %s", role)
+	}
+	if err := db.Where("id = ?", facility).First(&models.Facility{}).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("unknown facility: %s", facility)
+		}
+		return err
+	}
+
+	user := &models.User{}
+	err := db.Where("c_id = ?", cid).First(user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		user = &models.User{CID: cid}
+		if err := db.Create(user).Error; err != nil {
+			return err
+		}
+		s.Add(Created, "user %d", cid)
+	} else if err != nil {
+		return err
+	}
+
+	var held int64
+	if err := db.Model(&models.UserRole{}).Where("c_id = ? AND role_id = ? AND facility_id = ?", cid, role, facility).Count(&held).Error; err != nil {
+		return err
+	}
+	if held > 0 {
+		s.Add(Unchanged, "role %s at %s for %d", role, facility, cid)
+		return nil
+	}
+
+	if err := models.CreateUserRole(db, &models.UserRole{CID: cid, RoleID: role, FacilityID: facility}); err != nil {
+		return err
+	}
+	s.Add(Created, "role %s at %s for %d", role, facility, cid)
+	return nil
+}
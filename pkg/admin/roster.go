@@ -0,0 +1,100 @@
+package admin
+
+import (
+	"encoding/csv"
+	"github.com/VATUSA/primary-api/pkg/activity"
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"gorm.io/gorm"
+	"io"
+	"strconv"
+	"time"
+)
+
+// RosterColumns are the columns written by ExportRoster
+var RosterColumns = []string{
+	"cid",
+	"first_name",
+	"last_name",
+	"operating_initials",
+	"controller_rating",
+	"membership",
+	"status",
+	"mentor",
+	"instructor",
+	"joined_at",
+}
+
+// ExportRoster writes the facility's roster to w as CSV, ordered by CID
+func ExportRoster(db *gorm.DB, facility string, w io.Writer, s *Summary) error {
+	var rosters []models.Roster
+	if err := db.Where("facility = ?", facility).Order("c_id").Find(&rosters).Error; err != nil {
+		return err
+	}
+
+	cids := []uint{}
+	for _, roster := range rosters {
+		cids = append(cids, roster.CID)
+	}
+	var users []models.User
+	if err := db.Where("c_id IN ?", cids).Find(&users).Error; err != nil {
+		return err
+	}
+	byCID := map[uint]models.User{}
+	for _, user := range users {
+		byCID[user.CID] = user
+	}
+
+	out := csv.NewWriter(w)
+	if err := out.Write(RosterColumns); err != nil {
+		return err
+	}
+	for _, roster := range rosters {
+		user := byCID[roster.CID]
+		membership := "home"
+		if roster.Visiting {
+			membership = "visiting"
+		}
+
+		err := out.Write([]string{
+			strconv.FormatUint(uint64(roster.CID), 10),
+			user.FirstName,
+			user.LastName,
+			roster.OIs,
+			strconv.FormatUint(uint64(user.ControllerRating), 10),
+			membership,
+			roster.Status,
+			strconv.FormatBool(roster.Mentor),
+			strconv.FormatBool(roster.Instructor),
+			roster.CreatedAt.UTC().Format(time.RFC3339),
+		})
+		if err != nil {
+			return err
+		}
+		s.Add(Exported, "%d %s %s (%s)", roster.CID, user.FirstName, user.LastName, membership)
+	}
+
+	out.Flush()
+	return out.Error()
+}
+
+// PurgeInactive removes every member the facility's inactive members report lists
+func PurgeInactive(facility string, th activity.Thresholds, now time.Time, s *Summary) error {
+	rosters, members, err := activity.FindInactive(facility, th, now)
+	if err != nil {
+		return err
+	}
+
+	byID := map[uint]models.Roster{}
+	for _, roster := range rosters {
+		byID[roster.ID] = roster
+	}
+
+	for _, m := range members {
+		roster := byID[m.RosterID]
+		if err := roster.Delete(); err != nil {
+			return err
+		}
+		s.Add(Removed, "%d from the %s roster (%s hours)", m.CID, facility, strconv.FormatFloat(m.Hours, 'f', 1, 64))
+	}
+	return nil
+}
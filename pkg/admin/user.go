@@ -0,0 +1,188 @@
+package admin
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// UserColumns are the columns ImportUsers understands. Only cid is required; other fields are left alone when
+// their column is missing.
+var UserColumns = []string{
+	"cid",
+	"first_name",
+	"last_name",
+	"preferred_name",
+	"email",
+	"preferred_ois",
+	"controller_rating",
+	"pilot_rating",
+	"region",
+	"division",
+	"discord_id",
+}
+
+type userRow struct {
+	line   int
+	cid    uint
+	values map[string]string
+}
+
+// ImportUsers creates or updates a user for each row of a CSV file with a header row naming UserColumns. Every
+// row is checked before any are saved, and they are saved in one transaction, so a bad file changes nothing.
+func ImportUsers(db *gorm.DB, r io.Reader, s *Summary) error {
+	rows, err := readUsers(r)
+	if err != nil {
+		return err
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, row := range rows {
+			user := &models.User{}
+			err := tx.Where("c_id = ?", row.cid).First(user).Error
+			created := errors.Is(err, gorm.ErrRecordNotFound)
+			if err != nil && !created {
+				return err
+			}
+
+			before := *user
+			user.CID = row.cid
+			for column, value := range row.values {
+				setUserField(user, column, value)
+			}
+
+			switch {
+			case created:
+				if err := tx.Create(user).Error; err != nil {
+					return fmt.Errorf("line %d: %w", row.line, err)
+				}
+				s.Add(Created, "user %d (%s %s)", user.CID, user.FirstName, user.LastName)
+			case sameUser(&before, user):
+				s.Add(Unchanged, "user %d", user.CID)
+			default:
+				if err := tx.Omit(clause.Associations).Save(user).Error; err != nil {
+					return fmt.Errorf("line %d: %w", row.line, err)
+				}
+				s.Add(Updated, "user %d (%s %s)", user.CID, user.FirstName, user.LastName)
+			}
+		}
+		return nil
+	})
+}
+
+func readUsers(r io.Reader) ([]userRow, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading header: %w", err)
+	}
+
+	known := map[string]bool{}
+	for _, column := range UserColumns {
+		known[column] = true
+	}
+
+	columns := map[int]string{}
+	hasCID := false
+	for i, column := range header {
+		column = strings.ToLower(strings.TrimSpace(column))
+		if !known[column] {
+			return nil, fmt.Errorf("unknown column: %q", column)
+		}
+		columns[i] = column
+		hasCID = hasCID || column == "cid"
+	}
+	if !hasCID {
+		return nil, errors.New("missing cid column")
+	}
+
+	rows := []userRow{}
+	seen := map[uint]int{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		line, _ := reader.FieldPos(0)
+		row := userRow{line: line, values: map[string]string{}}
+		for i, value := range record {
+			value = strings.TrimSpace(value)
+			switch column := columns[i]; column {
+			case "cid":
+				cid, err := strconv.ParseUint(value, 10, 32)
+				if err != nil || cid == 0 {
+					return nil, fmt.Errorf("line %d: invalid cid %q", line, value)
+				}
+				row.cid = uint(cid)
+			case "controller_rating", "pilot_rating":
+				if _, err := strconv.ParseUint(value, 10, 32); err != nil {
+					return nil, fmt.Errorf("line %d: invalid %s %q", line, column, value)
+				}
+				row.values[column] = value
+			default:
+				row.values[column] = value
+			}
+		}
+
+		if previous, ok := seen[row.cid]; ok {
+			return nil, fmt.Errorf("line %d: cid %d is already on line %d", line, row.cid, previous)
+		}
+		seen[row.cid] = line
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// setUserField sets the field for a column of an already validated row
+func setUserField(u *models.User, column, value string) {
+	switch column {
+	case "first_name":
+		u.FirstName = value
+	case "last_name":
+		u.LastName = value
+	case "preferred_name":
+		u.PreferredName = value
+	case "email":
+		u.Email = value
+	case "preferred_ois":
+		u.PreferredOIs = value
+	case "controller_rating":
+		rating, _ := strconv.ParseUint(value, 10, 32)
+		u.ControllerRating = uint(rating)
+	case "pilot_rating":
+		rating, _ := strconv.ParseUint(value, 10, 32)
+		u.PilotRating = uint(rating)
+	case "region":
+		u.Region = value
+	case "division":
+		u.Division = value
+	case "discord_id":
+		u.DiscordID = value
+	}
+}
+
+func sameUser(a, b *models.User) bool {
+	return a.FirstName == b.FirstName &&
+		a.LastName == b.LastName &&
+		a.PreferredName == b.PreferredName &&
+		a.Email == b.Email &&
+		a.PreferredOIs == b.PreferredOIs &&
+		a.ControllerRating == b.ControllerRating &&
+		a.PilotRating == b.PilotRating &&
+		a.Region == b.Region &&
+		a.Division == b.Division &&
+		a.DiscordID == b.DiscordID
+}
@@ -0,0 +1,20 @@
+package admin
+
+import (
+	"context"
+	"github.com/VATUSA/primary-api/pkg/vatsim"
+	"time"
+)
+
+// SyncVATSIM runs a sync with VATSIM and records how many users it changed. The sync saves as it goes, so what
+// it changed before failing is recorded too.
+func SyncVATSIM(ctx context.Context, syncer *vatsim.Syncer, now time.Time, s *Summary) error {
+	err := syncer.Sync(ctx, now)
+
+	status := syncer.Status()
+	s.AddCount(Created, status.Created, "users new to the division: %d", status.Created)
+	s.AddCount(Updated, status.Updated, "users refreshed from the division listing: %d", status.Updated)
+	s.AddCount(Created, status.RatingChanges, "rating changes: %d", status.RatingChanges)
+	s.AddCount(Updated, status.Moved, "members moved off facility rosters: %d", status.Moved)
+	return err
+}
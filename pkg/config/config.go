@@ -1,13 +1,44 @@
 package config
 
-import "os"
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
 
 type Config struct {
+	Server   *ServerConfig
 	Database *DBConfig
 	Cors     *CorsConfig
 	S3       *S3Config
+	Storage  *StorageConfig
+	Upload   *UploadConfig
+	Activity *ActivityConfig
+	VATSIM   *VATSIMConfig
+	Discord  *DiscordConfig
 }
 
+// ServerConfig controls the HTTP server. ReadTimeout and WriteTimeout apply to each request; WriteTimeout is off
+// by default so notification streams can stay open. On SIGTERM /readyz reports the instance as draining for
+// DrainDelay, giving load balancers time to stop sending it traffic, then the server stops accepting connections
+// and waits up to ShutdownTimeout for requests in flight and background workers to finish.
+type ServerConfig struct {
+	Addr            string
+	ReadTimeout     time.Duration
+	WriteTimeout    time.Duration
+	IdleTimeout     time.Duration
+	DrainDelay      time.Duration
+	ShutdownTimeout time.Duration
+}
+
+const (
+	DefaultServerAddr      = ":8080"
+	DefaultReadTimeout     = 30 * time.Second
+	DefaultIdleTimeout     = 2 * time.Minute
+	DefaultShutdownTimeout = 30 * time.Second
+)
+
 type DBConfig struct {
 	Host        string
 	Port        string
@@ -29,6 +60,85 @@ type S3Config struct {
 	Bucket    string
 }
 
+// StorageConfig selects the document storage backend. Backend is either "s3" (the default), which uses S3Config,
+// or "local", which keeps objects under LocalPath and serves them from LocalURL.
+type StorageConfig struct {
+	Backend   string
+	LocalPath string
+	LocalURL  string
+	Secret    string
+}
+
+// UploadConfig limits the files accepted for documents and event banners. AllowedTypes maps a document category to the MIME
+// types it accepts, with "*" used for categories that have no entry of their own. Uploads are scanned
+// through the ClamAV socket at ScannerSocket when it is set.
+type UploadConfig struct {
+	MaxSize       int64
+	AllowedTypes  map[string][]string
+	ScannerSocket string
+}
+
+// DefaultUploadTypes are PDF, DOCX and common image formats
+var DefaultUploadTypes = []string{
+	"application/pdf",
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	"image/png",
+	"image/jpeg",
+}
+
+// EventBannerCategory is the upload category for event banners, which only accept images
+const EventBannerCategory = "event_banner"
+
+// DefaultBannerTypes are the image formats accepted for event banners unless configured otherwise
+var DefaultBannerTypes = []string{
+	"image/png",
+	"image/jpeg",
+	"image/webp",
+}
+
+const DefaultUploadMaxSize = 25 << 20
+
+// ActivityConfig controls controller activity tracking. The VATSIM data feed at FeedURL is polled every
+// PollInterval, unless FeedFile is set, in which case that file is read instead. InactiveDays and
+// InactiveHours are the default thresholds for the inactive members report.
+type ActivityConfig struct {
+	FeedURL       string
+	FeedFile      string
+	PollInterval  time.Duration
+	InactiveDays  int
+	InactiveHours float64
+}
+
+const (
+	DefaultActivityPollInterval = time.Minute
+	DefaultInactiveDays         = 90
+	DefaultInactiveHours        = 3
+)
+
+func NewServerConfig() *ServerConfig {
+	cfg := &ServerConfig{
+		Addr:            os.Getenv("SERVER_ADDR"),
+		ReadTimeout:     parseDuration(os.Getenv("SERVER_READ_TIMEOUT"), DefaultReadTimeout),
+		WriteTimeout:    parseDuration(os.Getenv("SERVER_WRITE_TIMEOUT"), 0),
+		IdleTimeout:     parseDuration(os.Getenv("SERVER_IDLE_TIMEOUT"), DefaultIdleTimeout),
+		DrainDelay:      parseDuration(os.Getenv("SERVER_DRAIN_DELAY"), 0),
+		ShutdownTimeout: parseDuration(os.Getenv("SERVER_SHUTDOWN_TIMEOUT"), DefaultShutdownTimeout),
+	}
+	if cfg.Addr == "" {
+		cfg.Addr = DefaultServerAddr
+	}
+	return cfg
+}
+
+// parseDuration reads a duration such as "30s", falling back to def when it is missing or not positive
+func parseDuration(value string, def time.Duration) time.Duration {
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return def
+	}
+	return d
+}
+
 func NewDBConfig() *DBConfig {
 	return &DBConfig{
 		Host:        os.Getenv("DB_HOST"),
@@ -56,10 +166,149 @@ func NewS3Config() *S3Config {
 	}
 }
 
+func NewStorageConfig() *StorageConfig {
+	return &StorageConfig{
+		Backend:   os.Getenv("STORAGE_BACKEND"),
+		LocalPath: os.Getenv("STORAGE_LOCAL_PATH"),
+		LocalURL:  os.Getenv("STORAGE_LOCAL_URL"),
+		Secret:    os.Getenv("STORAGE_SECRET"),
+	}
+}
+
+func NewUploadConfig() *UploadConfig {
+	maxSize, err := strconv.ParseInt(os.Getenv("UPLOAD_MAX_SIZE"), 10, 64)
+	if err != nil || maxSize <= 0 {
+		maxSize = DefaultUploadMaxSize
+	}
+
+	return &UploadConfig{
+		MaxSize:       maxSize,
+		AllowedTypes:  parseAllowedTypes(os.Getenv("UPLOAD_ALLOWED_TYPES")),
+		ScannerSocket: os.Getenv("UPLOAD_SCANNER_SOCKET"),
+	}
+}
+
+// parseAllowedTypes reads "category=type|type;category=type". Categories that aren't listed accept the "*" entry,
+// or DefaultUploadTypes when there is none. Event banners accept DefaultBannerTypes unless they are configured.
+func parseAllowedTypes(value string) map[string][]string {
+	allowed := map[string][]string{}
+	for _, entry := range strings.Split(value, ";") {
+		category, types, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok {
+			continue
+		}
+		allowed[strings.TrimSpace(category)] = strings.Split(types, "|")
+	}
+
+	if _, ok := allowed["*"]; !ok {
+		allowed["*"] = DefaultUploadTypes
+	}
+	if _, ok := allowed[EventBannerCategory]; !ok {
+		allowed[EventBannerCategory] = DefaultBannerTypes
+	}
+	return allowed
+}
+
+// VATSIMConfig points the membership sync at a VATSIM API compatible endpoint. The sync only runs when an
+// APIKey is set; members of Division are synced every SyncInterval.
+type VATSIMConfig struct {
+	APIURL       string
+	APIKey       string
+	Division     string
+	SyncInterval time.Duration
+}
+
+const (
+	DefaultVATSIMAPIURL       = "https://api.vatsim.net"
+	DefaultVATSIMDivision     = "USA"
+	DefaultVATSIMSyncInterval = 6 * time.Hour
+)
+
+func NewVATSIMConfig() *VATSIMConfig {
+	interval, err := time.ParseDuration(os.Getenv("VATSIM_SYNC_INTERVAL"))
+	if err != nil || interval <= 0 {
+		interval = DefaultVATSIMSyncInterval
+	}
+
+	cfg := &VATSIMConfig{
+		APIURL:       strings.TrimSuffix(os.Getenv("VATSIM_API_URL"), "/"),
+		APIKey:       os.Getenv("VATSIM_API_KEY"),
+		Division:     os.Getenv("VATSIM_DIVISION"),
+		SyncInterval: interval,
+	}
+	if cfg.APIURL == "" {
+		cfg.APIURL = DefaultVATSIMAPIURL
+	}
+	if cfg.Division == "" {
+		cfg.Division = DefaultVATSIMDivision
+	}
+	return cfg
+}
+
+// DiscordConfig holds the OAuth application used to link Discord accounts. StateSecret signs the OAuth state
+// so a callback can be tied to the user who started it, and BotSecret is the shared secret the role bot
+// presents to read role sets.
+type DiscordConfig struct {
+	APIURL       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	StateSecret  string
+	BotSecret    string
+}
+
+const DefaultDiscordAPIURL = "https://discord.com/api"
+
+func NewDiscordConfig() *DiscordConfig {
+	cfg := &DiscordConfig{
+		APIURL:       strings.TrimSuffix(os.Getenv("DISCORD_API_URL"), "/"),
+		ClientID:     os.Getenv("DISCORD_CLIENT_ID"),
+		ClientSecret: os.Getenv("DISCORD_CLIENT_SECRET"),
+		RedirectURL:  os.Getenv("DISCORD_REDIRECT_URL"),
+		StateSecret:  os.Getenv("DISCORD_STATE_SECRET"),
+		BotSecret:    os.Getenv("DISCORD_BOT_SECRET"),
+	}
+	if cfg.APIURL == "" {
+		cfg.APIURL = DefaultDiscordAPIURL
+	}
+	return cfg
+}
+
+func NewActivityConfig() *ActivityConfig {
+	interval, err := time.ParseDuration(os.Getenv("ACTIVITY_POLL_INTERVAL"))
+	if err != nil || interval <= 0 {
+		interval = DefaultActivityPollInterval
+	}
+
+	days, err := strconv.Atoi(os.Getenv("ACTIVITY_INACTIVE_DAYS"))
+	if err != nil || days <= 0 {
+		days = DefaultInactiveDays
+	}
+
+	hours, err := strconv.ParseFloat(os.Getenv("ACTIVITY_INACTIVE_HOURS"), 64)
+	if err != nil || hours < 0 {
+		hours = DefaultInactiveHours
+	}
+
+	return &ActivityConfig{
+		FeedURL:       os.Getenv("ACTIVITY_FEED_URL"),
+		FeedFile:      os.Getenv("ACTIVITY_FEED_FILE"),
+		PollInterval:  interval,
+		InactiveDays:  days,
+		InactiveHours: hours,
+	}
+}
+
 func New() *Config {
 	return &Config{
+		Server:   NewServerConfig(),
 		Database: NewDBConfig(),
 		Cors:     NewCorsConfig(),
 		S3:       NewS3Config(),
+		Storage:  NewStorageConfig(),
+		Upload:   NewUploadConfig(),
+		Activity: NewActivityConfig(),
+		VATSIM:   NewVATSIMConfig(),
+		Discord:  NewDiscordConfig(),
 	}
 }
@@ -457,3 +457,18 @@ func (r RoleID) InGroup(group GroupID) bool {
 	}
 	return false
 }
+
+func (g GroupID) IsValidGroup() bool {
+	return len(g.Roles()) > 0
+}
+
+// Roles returns every role that is a member of the group
+func (g GroupID) Roles() []RoleID {
+	var roles []RoleID
+	for id := range Roles {
+		if id.InGroup(g) {
+			roles = append(roles, id)
+		}
+	}
+	return roles
+}
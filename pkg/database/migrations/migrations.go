@@ -0,0 +1,405 @@
+// Package migrations manages the database schema through numbered SQL migrations. Each migration is a pair of
+// files, NNNN_name.up.sql and NNNN_name.down.sql, embedded from the sql directory. Applied versions are kept in
+// the schema_migrations table, and on MySQL migrations run under an advisory lock so API replicas starting
+// together apply them once.
+package migrations
+
+import (
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Files are the migrations built into the API
+//
+//go:embed sql/*.sql
+var Files embed.FS
+
+// LockName is the MySQL advisory lock held while migrations run
+const LockName = "vatusa_schema_migrations"
+
+// DefaultLockTimeout is how long to wait for another process to finish migrating
+const DefaultLockTimeout = time.Minute
+
+var (
+	ErrLocked         = errors.New("timed out waiting for the migration lock")
+	ErrUnknownVersion = errors.New("database has a migration applied that this build doesn't know")
+)
+
+var (
+	filename  = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+	validName = regexp.MustCompile(`^[a-z0-9_]+$`)
+)
+
+// Migration is one version of the schema
+type Migration struct {
+	Version uint
+	Name    string
+	Up      string
+	Down    string
+}
+
+func (m Migration) String() string {
+	return fmt.Sprintf("%04d_%s", m.Version, m.Name)
+}
+
+// Record is a row of schema_migrations
+type Record struct {
+	Version   uint      `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"size:255"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+func (Record) TableName() string {
+	return "schema_migrations"
+}
+
+// Status is a migration and when it was applied, which is nil while it is pending
+type Status struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// Load reads the migrations in the sql directory of fsys, ordered by version. Every version needs both an up and
+// a down file.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, "sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[uint]*Migration{}
+	for _, entry := range entries {
+		match := filename.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+
+		version, err := strconv.ParseUint(match[1], 10, 32)
+		if err != nil || version == 0 {
+			return nil, fmt.Errorf("invalid migration version: %s", entry.Name())
+		}
+
+		data, err := fs.ReadFile(fsys, path.Join("sql", entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[uint(version)]
+		if !ok {
+			m = &Migration{Version: uint(version), Name: match[2]}
+			byVersion[uint(version)] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, m.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			m.Up = string(data)
+		} else {
+			m.Down = string(data)
+		}
+	}
+
+	migrations := []Migration{}
+	for _, m := range byVersion {
+		if strings.TrimSpace(m.Up) == "" || strings.TrimSpace(m.Down) == "" {
+			return nil, fmt.Errorf("migration %s needs both an up and a down file", m)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Migrator applies and rolls back the migrations in FS against DB. Unless AllowUnknown is set, it refuses to touch
+// a database that has versions applied it doesn't know, which a newer build left behind.
+type Migrator struct {
+	DB           *gorm.DB
+	FS           fs.FS
+	LockTimeout  time.Duration
+	AllowUnknown bool
+}
+
+// New returns a Migrator for the embedded migrations
+func New(db *gorm.DB) *Migrator {
+	return &Migrator{DB: db, FS: Files, LockTimeout: DefaultLockTimeout}
+}
+
+// Up applies every pending migration in order and returns the ones it applied. It stops at the first failure.
+// MySQL commits schema changes as it goes, so a migration that fails part way may need repairing by hand
+// before it can be run again.
+func (m *Migrator) Up() ([]Migration, error) {
+	migrations, err := Load(m.FS)
+	if err != nil {
+		return nil, err
+	}
+
+	applied := []Migration{}
+	err = m.locked(func(conn *gorm.DB) error {
+		done, err := appliedVersions(conn)
+		if err != nil {
+			return err
+		}
+		if err := checkKnown(migrations, done); err != nil && !m.AllowUnknown {
+			return err
+		}
+
+		for _, migration := range migrations {
+			if _, ok := done[migration.Version]; ok {
+				continue
+			}
+
+			err := conn.Transaction(func(tx *gorm.DB) error {
+				if err := run(tx, migration.Up); err != nil {
+					return err
+				}
+				return tx.Create(&Record{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now().UTC()}).Error
+			})
+			if err != nil {
+				return fmt.Errorf("migration %s: %w", migration, err)
+			}
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+
+	return applied, err
+}
+
+// Down rolls back the last steps applied migrations, newest first, and returns the ones it rolled back
+func (m *Migrator) Down(steps int) ([]Migration, error) {
+	migrations, err := Load(m.FS)
+	if err != nil {
+		return nil, err
+	}
+
+	rolledBack := []Migration{}
+	err = m.locked(func(conn *gorm.DB) error {
+		done, err := appliedVersions(conn)
+		if err != nil {
+			return err
+		}
+		if err := checkKnown(migrations, done); err != nil {
+			return err
+		}
+
+		for i := len(migrations) - 1; i >= 0 && len(rolledBack) < steps; i-- {
+			migration := migrations[i]
+			if _, ok := done[migration.Version]; !ok {
+				continue
+			}
+
+			err := conn.Transaction(func(tx *gorm.DB) error {
+				if err := run(tx, migration.Down); err != nil {
+					return err
+				}
+				return tx.Delete(&Record{Version: migration.Version}).Error
+			})
+			if err != nil {
+				return fmt.Errorf("migration %s: %w", migration, err)
+			}
+			rolledBack = append(rolledBack, migration)
+		}
+		return nil
+	})
+
+	return rolledBack, err
+}
+
+// Status lists every known migration with when it was applied
+func (m *Migrator) Status() ([]Status, error) {
+	migrations, err := Load(m.FS)
+	if err != nil {
+		return nil, err
+	}
+
+	done := map[uint]Record{}
+	if m.DB.Migrator().HasTable(&Record{}) {
+		if done, err = appliedVersions(m.DB); err != nil {
+			return nil, err
+		}
+	}
+
+	statuses := []Status{}
+	for _, migration := range migrations {
+		status := Status{Migration: migration}
+		if record, ok := done[migration.Version]; ok {
+			status.AppliedAt = &record.AppliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Pending is the number of known migrations that have not been applied
+func (m *Migrator) Pending() (int, error) {
+	statuses, err := m.Status()
+	if err != nil {
+		return 0, err
+	}
+
+	pending := 0
+	for _, status := range statuses {
+		if status.AppliedAt == nil {
+			pending++
+		}
+	}
+	return pending, nil
+}
+
+// Unknown lists the applied versions that aren't in FS, oldest first
+func (m *Migrator) Unknown() ([]Record, error) {
+	migrations, err := Load(m.FS)
+	if err != nil {
+		return nil, err
+	}
+	if !m.DB.Migrator().HasTable(&Record{}) {
+		return nil, nil
+	}
+	done, err := appliedVersions(m.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, migration := range migrations {
+		delete(done, migration.Version)
+	}
+	unknown := []Record{}
+	for _, record := range done {
+		unknown = append(unknown, record)
+	}
+	sort.Slice(unknown, func(i, j int) bool {
+		return unknown[i].Version < unknown[j].Version
+	})
+	return unknown, nil
+}
+
+// Create writes empty up and down files for a new migration to dir, numbered after the highest version there,
+// and returns their paths
+func Create(dir, name string) (string, string, error) {
+	name = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(name), " ", "_"))
+	if !validName.MatchString(name) {
+		return "", "", fmt.Errorf("invalid migration name: %q", name)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", "", err
+	}
+
+	var version uint64
+	for _, entry := range entries {
+		if match := filename.FindStringSubmatch(entry.Name()); match != nil {
+			if v, _ := strconv.ParseUint(match[1], 10, 32); v > version {
+				version = v
+			}
+		}
+	}
+
+	base := filepath.Join(dir, fmt.Sprintf("%04d_%s", version+1, name))
+	up, down := base+".up.sql", base+".down.sql"
+	if err := os.WriteFile(up, []byte("-- "+name+"\n"), 0644); err != nil {
+		return "", "", err
+	}
+	if err := os.WriteFile(down, []byte("-- Reverts "+name+"\n"), 0644); err != nil {
+		return "", "", err
+	}
+	return up, down, nil
+}
+
+// locked runs fn on a single connection. On MySQL the connection holds LockName for the duration, which is
+// released when fn returns or the connection drops.
+func (m *Migrator) locked(fn func(conn *gorm.DB) error) error {
+	return m.DB.Connection(func(conn *gorm.DB) error {
+		if conn.Dialector.Name() == "mysql" {
+			var acquired sql.NullInt64
+			if err := conn.Raw("SELECT GET_LOCK(?, ?)", LockName, int(m.LockTimeout.Seconds())).Row().Scan(&acquired); err != nil {
+				return err
+			}
+			if !acquired.Valid || acquired.Int64 != 1 {
+				return ErrLocked
+			}
+			defer conn.Exec("SELECT RELEASE_LOCK(?)", LockName)
+		}
+
+		if err := conn.AutoMigrate(&Record{}); err != nil {
+			return err
+		}
+		return fn(conn)
+	})
+}
+
+func appliedVersions(db *gorm.DB) (map[uint]Record, error) {
+	var records []Record
+	if err := db.Find(&records).Error; err != nil {
+		return nil, err
+	}
+
+	done := map[uint]Record{}
+	for _, record := range records {
+		done[record.Version] = record
+	}
+	return done, nil
+}
+
+// checkKnown refuses to migrate a database that a newer build has already migrated further
+func checkKnown(migrations []Migration, done map[uint]Record) error {
+	known := map[uint]bool{}
+	for _, migration := range migrations {
+		known[migration.Version] = true
+	}
+	for version, record := range done {
+		if !known[version] {
+			return fmt.Errorf("%w: %04d_%s", ErrUnknownVersion, version, record.Name)
+		}
+	}
+	return nil
+}
+
+// run executes script one statement at a time, as the MySQL driver doesn't accept several in one query.
+// Statements end with a semicolon at the end of a line, and lines starting with -- are comments.
+func run(tx *gorm.DB, script string) error {
+	for _, statement := range Statements(script) {
+		if err := tx.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Statements splits a migration script into its statements
+func Statements(script string) []string {
+	statements := []string{}
+	var current strings.Builder
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+
+		current.WriteString(line)
+		current.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			statements = append(statements, strings.TrimSuffix(strings.TrimSpace(current.String()), ";"))
+			current.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(current.String()); rest != "" {
+		statements = append(statements, rest)
+	}
+	return statements
+}
@@ -0,0 +1,15 @@
+DROP TABLE IF EXISTS `user_roles`;
+DROP TABLE IF EXISTS `user_flags`;
+DROP TABLE IF EXISTS `roster_requests`;
+DROP TABLE IF EXISTS `rosters`;
+DROP TABLE IF EXISTS `rating_changes`;
+DROP TABLE IF EXISTS `notifications`;
+DROP TABLE IF EXISTS `news`;
+DROP TABLE IF EXISTS `feedbacks`;
+DROP TABLE IF EXISTS `faqs`;
+DROP TABLE IF EXISTS `facility_log_entries`;
+DROP TABLE IF EXISTS `documents`;
+DROP TABLE IF EXISTS `disciplinary_log_entries`;
+DROP TABLE IF EXISTS `action_log_entries`;
+DROP TABLE IF EXISTS `users`;
+DROP TABLE IF EXISTS `facilities`;
@@ -0,0 +1,212 @@
+-- Baseline schema, exactly what models.AutoMigrate created before migrations were introduced. Tables are only
+-- created when missing, so databases set up by AutoMigrate adopt this migration without changes. Everything
+-- added since is in the migrations that follow.
+
+CREATE TABLE IF NOT EXISTS `facilities` (
+    `id` varchar(3),
+    `name` longtext,
+    `url` longtext,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    PRIMARY KEY (`id`)
+);
+
+CREATE TABLE IF NOT EXISTS `users` (
+    `c_id` bigint unsigned AUTO_INCREMENT,
+    `first_name` varchar(191),
+    `last_name` varchar(191),
+    `preferred_name` varchar(191),
+    `pref_name_enabled` boolean,
+    `email` longtext,
+    `preferred_o_is` longtext,
+    `pilot_rating` bigint unsigned,
+    `controller_rating` bigint unsigned,
+    `discord_id` longtext,
+    `last_login` datetime(3) NULL,
+    `last_cert_sync` datetime(3) NULL,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    PRIMARY KEY (`c_id`),
+    INDEX `idx_first_name` (`first_name`),
+    INDEX `idx_last_name` (`last_name`),
+    INDEX `idx_pref_name` (`preferred_name`)
+);
+
+CREATE TABLE IF NOT EXISTS `action_log_entries` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `c_id` bigint unsigned,
+    `entry` longtext,
+    `created_at` datetime(3) NULL,
+    `created_by` longtext,
+    `updated_at` datetime(3) NULL,
+    `updated_by` longtext,
+    PRIMARY KEY (`id`),
+    CONSTRAINT `fk_users_action_log_entry` FOREIGN KEY (`c_id`) REFERENCES `users`(`c_id`)
+);
+
+CREATE TABLE IF NOT EXISTS `disciplinary_log_entries` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `c_id` bigint unsigned,
+    `entry` longtext,
+    `vatusa_only` boolean,
+    `created_at` datetime(3) NULL,
+    `created_by` longtext,
+    `updated_at` datetime(3) NULL,
+    `updated_by` longtext,
+    PRIMARY KEY (`id`),
+    CONSTRAINT `fk_users_disciplinary_log_entry` FOREIGN KEY (`c_id`) REFERENCES `users`(`c_id`)
+);
+
+CREATE TABLE IF NOT EXISTS `documents` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `facility` varchar(3),
+    `name` longtext,
+    `description` longtext,
+    `category` enum('general', 'training', 'information_technology', 'sops', 'loas', 'misc'),
+    `url` longtext,
+    `created_at` datetime(3) NULL,
+    `created_by` bigint unsigned,
+    `updated_at` datetime(3) NULL,
+    `updated_by` bigint unsigned,
+    PRIMARY KEY (`id`),
+    CONSTRAINT `fk_facilities_document` FOREIGN KEY (`facility`) REFERENCES `facilities`(`id`)
+);
+
+CREATE TABLE IF NOT EXISTS `facility_log_entries` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `facility` varchar(3),
+    `entry` longtext,
+    `created_at` datetime(3) NULL,
+    `created_by` longtext,
+    `updated_at` datetime(3) NULL,
+    `updated_by` longtext,
+    PRIMARY KEY (`id`),
+    CONSTRAINT `fk_facilities_facility_log_entry` FOREIGN KEY (`facility`) REFERENCES `facilities`(`id`)
+);
+
+CREATE TABLE IF NOT EXISTS `faqs` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `facility` varchar(3),
+    `question` longtext,
+    `answer` longtext,
+    `category` enum('membership', 'training', 'technology', 'misc'),
+    `created_at` datetime(3) NULL,
+    `created_by` bigint unsigned,
+    `updated_at` datetime(3) NULL,
+    `updated_by` bigint unsigned,
+    PRIMARY KEY (`id`),
+    CONSTRAINT `fk_facilities_faq` FOREIGN KEY (`facility`) REFERENCES `facilities`(`id`)
+);
+
+CREATE TABLE IF NOT EXISTS `feedbacks` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `pilot_c_id` bigint unsigned,
+    `callsign` longtext,
+    `controller_c_id` bigint unsigned,
+    `position` longtext,
+    `facility` longtext,
+    `rating` enum('unsatisfactory', 'poor', 'fair', 'good', 'excellent'),
+    `notes` longtext,
+    `status` enum('pending', 'approved', 'denied'),
+    `comment` longtext,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    CONSTRAINT `fk_feedbacks_pilot` FOREIGN KEY (`pilot_c_id`) REFERENCES `users`(`c_id`),
+    CONSTRAINT `fk_users_feedback` FOREIGN KEY (`controller_c_id`) REFERENCES `users`(`c_id`)
+);
+
+CREATE TABLE IF NOT EXISTS `news` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `facility` longtext,
+    `title` longtext,
+    `description` longtext,
+    `created_at` datetime(3) NULL,
+    `created_by` longtext,
+    `updated_at` datetime(3) NULL,
+    `updated_by` longtext,
+    PRIMARY KEY (`id`)
+);
+
+CREATE TABLE IF NOT EXISTS `notifications` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `c_id` bigint unsigned,
+    `category` longtext,
+    `title` longtext,
+    `body` longtext,
+    `expire_at` datetime(3) NULL,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    CONSTRAINT `fk_users_notifications` FOREIGN KEY (`c_id`) REFERENCES `users`(`c_id`)
+);
+
+CREATE TABLE IF NOT EXISTS `rating_changes` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `c_id` bigint unsigned,
+    `old_rating` bigint unsigned,
+    `new_rating` bigint unsigned,
+    `created_at` datetime(3) NULL,
+    `created_by_c_id` longtext,
+    `updated_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    CONSTRAINT `fk_users_rating_changes` FOREIGN KEY (`c_id`) REFERENCES `users`(`c_id`)
+);
+
+CREATE TABLE IF NOT EXISTS `rosters` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `c_id` bigint unsigned,
+    `facility` longtext,
+    `o_is` longtext,
+    `home` boolean,
+    `visiting` boolean,
+    `status` longtext,
+    `mentor` boolean,
+    `instructor` boolean,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    CONSTRAINT `fk_users_roster` FOREIGN KEY (`c_id`) REFERENCES `users`(`c_id`)
+);
+
+CREATE TABLE IF NOT EXISTS `roster_requests` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `c_id` bigint unsigned,
+    `facility` longtext,
+    `request_type` enum('visiting', 'transferring'),
+    `status` enum('pending', 'accepted', 'rejected'),
+    `reason` longtext,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    CONSTRAINT `fk_users_roster_request` FOREIGN KEY (`c_id`) REFERENCES `users`(`c_id`)
+);
+
+CREATE TABLE IF NOT EXISTS `user_flags` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `c_id` bigint unsigned,
+    `no_staff_role` boolean,
+    `no_staff_log_entry_id` bigint unsigned,
+    `no_visiting` boolean,
+    `no_visiting_log_entry_id` bigint unsigned,
+    `no_transferring` boolean,
+    `no_transferring_log_entry_id` bigint unsigned,
+    `no_training` boolean,
+    `no_training_log_entry_id` bigint unsigned,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    CONSTRAINT `fk_users_flags` FOREIGN KEY (`c_id`) REFERENCES `users`(`c_id`)
+);
+
+CREATE TABLE IF NOT EXISTS `user_roles` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `c_id` bigint unsigned,
+    `role_id` varchar(10),
+    `facility_id` longtext,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    CONSTRAINT `fk_users_roles` FOREIGN KEY (`c_id`) REFERENCES `users`(`c_id`)
+);
@@ -0,0 +1,5 @@
+ALTER TABLE `notifications`
+    DROP FOREIGN KEY `fk_notification_broadcasts_notifications`,
+    DROP COLUMN `broadcast_id`;
+
+DROP TABLE `notification_broadcasts`;
@@ -0,0 +1,21 @@
+-- Audience broadcasts for notifications
+
+CREATE TABLE `notification_broadcasts` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `audience_type` enum('facility', 'role', 'group'),
+    `audience` longtext,
+    `facility` longtext,
+    `home_only` boolean,
+    `category` longtext,
+    `title` longtext,
+    `body` longtext,
+    `expire_at` datetime(3) NULL,
+    `recipient_count` bigint,
+    `created_at` datetime(3) NULL,
+    `created_by` bigint unsigned,
+    PRIMARY KEY (`id`)
+);
+
+ALTER TABLE `notifications`
+    ADD COLUMN `broadcast_id` bigint unsigned AFTER `c_id`,
+    ADD CONSTRAINT `fk_notification_broadcasts_notifications` FOREIGN KEY (`broadcast_id`) REFERENCES `notification_broadcasts`(`id`);
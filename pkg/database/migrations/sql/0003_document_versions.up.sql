@@ -0,0 +1,20 @@
+-- Document version history
+
+ALTER TABLE `documents`
+    ADD COLUMN `version` bigint unsigned AFTER `url`;
+
+CREATE TABLE `document_versions` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `document_id` bigint unsigned,
+    `version` bigint unsigned,
+    `key` longtext,
+    `url` longtext,
+    `checksum` longtext,
+    `size` bigint,
+    `change_note` longtext,
+    `created_at` datetime(3) NULL,
+    `created_by` bigint unsigned,
+    PRIMARY KEY (`id`),
+    INDEX `idx_document_versions_document_id` (`document_id`),
+    CONSTRAINT `fk_documents_versions` FOREIGN KEY (`document_id`) REFERENCES `documents`(`id`)
+);
@@ -0,0 +1,4 @@
+-- Document visibility. Existing documents stay public.
+
+ALTER TABLE `documents`
+    ADD COLUMN `visibility` enum('public', 'facility', 'staff') DEFAULT 'public' AFTER `url`;
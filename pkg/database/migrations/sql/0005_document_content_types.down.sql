@@ -0,0 +1,6 @@
+ALTER TABLE `document_versions`
+    DROP COLUMN `content_type`;
+
+ALTER TABLE `documents`
+    DROP COLUMN `sha256`,
+    DROP COLUMN `content_type`;
@@ -0,0 +1,8 @@
+-- Content type and checksum of validated uploads
+
+ALTER TABLE `documents`
+    ADD COLUMN `content_type` longtext AFTER `url`,
+    ADD COLUMN `sha256` longtext AFTER `content_type`;
+
+ALTER TABLE `document_versions`
+    ADD COLUMN `content_type` longtext AFTER `url`;
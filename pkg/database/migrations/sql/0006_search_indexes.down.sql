@@ -0,0 +1,5 @@
+DROP INDEX `idx_news_search` ON `news`;
+
+DROP INDEX `idx_faq_search` ON `faqs`;
+
+DROP INDEX `idx_document_search` ON `documents`;
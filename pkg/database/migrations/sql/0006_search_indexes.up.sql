@@ -0,0 +1,7 @@
+-- Full-text indexes used by search
+
+CREATE FULLTEXT INDEX `idx_document_search` ON `documents` (`name`, `description`);
+
+CREATE FULLTEXT INDEX `idx_faq_search` ON `faqs` (`question`, `answer`);
+
+CREATE FULLTEXT INDEX `idx_news_search` ON `news` (`title`, `description`);
@@ -0,0 +1,4 @@
+ALTER TABLE `faqs`
+    DROP COLUMN `overrides`,
+    DROP COLUMN `status`,
+    DROP COLUMN `sort_order`;
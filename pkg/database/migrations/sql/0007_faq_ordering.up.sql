@@ -0,0 +1,6 @@
+-- FAQ ordering, drafts and division overrides. Existing FAQs stay published.
+
+ALTER TABLE `faqs`
+    ADD COLUMN `sort_order` bigint AFTER `category`,
+    ADD COLUMN `status` enum('draft', 'published') DEFAULT 'published' AFTER `sort_order`,
+    ADD COLUMN `overrides` bigint unsigned AFTER `status`;
@@ -0,0 +1,5 @@
+ALTER TABLE `news`
+    DROP COLUMN `pinned`,
+    DROP COLUMN `expire_at`,
+    DROP COLUMN `publish_at`,
+    DROP COLUMN `status`;
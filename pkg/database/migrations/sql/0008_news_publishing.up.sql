@@ -0,0 +1,7 @@
+-- News publishing workflow. Existing news stays published.
+
+ALTER TABLE `news`
+    ADD COLUMN `status` enum('draft', 'scheduled', 'published') DEFAULT 'published' AFTER `description`,
+    ADD COLUMN `publish_at` datetime(3) NULL AFTER `status`,
+    ADD COLUMN `expire_at` datetime(3) NULL AFTER `publish_at`,
+    ADD COLUMN `pinned` boolean AFTER `expire_at`;
@@ -0,0 +1,4 @@
+DROP TABLE `event_signups`;
+DROP TABLE `event_positions`;
+DROP TABLE `event_facilities`;
+DROP TABLE `events`;
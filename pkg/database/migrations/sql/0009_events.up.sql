@@ -0,0 +1,51 @@
+-- Events with positions and signups
+
+CREATE TABLE `events` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `title` longtext,
+    `description` longtext,
+    `banner_key` longtext,
+    `banner_url` longtext,
+    `host_facility` longtext,
+    `start_at` datetime(3) NULL,
+    `end_at` datetime(3) NULL,
+    `reminders_sent` bigint unsigned,
+    `created_at` datetime(3) NULL,
+    `created_by` bigint unsigned,
+    `updated_at` datetime(3) NULL,
+    `updated_by` bigint unsigned,
+    PRIMARY KEY (`id`),
+    INDEX `idx_events_start_at` (`start_at`)
+);
+
+CREATE TABLE `event_facilities` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `event_id` bigint unsigned,
+    `facility` varchar(3),
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_event_facility` (`event_id`,`facility`),
+    CONSTRAINT `fk_events_facilities` FOREIGN KEY (`event_id`) REFERENCES `events`(`id`)
+);
+
+CREATE TABLE `event_positions` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `event_id` bigint unsigned,
+    `callsign` longtext,
+    `assigned_c_id` bigint unsigned,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_event_positions_event_id` (`event_id`),
+    CONSTRAINT `fk_events_positions` FOREIGN KEY (`event_id`) REFERENCES `events`(`id`)
+);
+
+CREATE TABLE `event_signups` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `position_id` bigint unsigned,
+    `c_id` bigint unsigned,
+    `note` longtext,
+    `created_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_event_signup` (`position_id`,`c_id`),
+    CONSTRAINT `fk_event_positions_signups` FOREIGN KEY (`position_id`) REFERENCES `event_positions`(`id`)
+);
@@ -0,0 +1,4 @@
+DROP TABLE `training_step_completions`;
+DROP TABLE `training_sessions`;
+DROP TABLE `training_steps`;
+DROP TABLE `training_progressions`;
@@ -0,0 +1,55 @@
+-- Training sessions, progressions and completed steps
+
+CREATE TABLE `training_progressions` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `facility` varchar(3),
+    `name` longtext,
+    `description` longtext,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_training_progressions_facility` (`facility`)
+);
+
+CREATE TABLE `training_steps` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `progression_id` bigint unsigned,
+    `sort_order` bigint,
+    `name` longtext,
+    `position` longtext,
+    PRIMARY KEY (`id`),
+    INDEX `idx_training_steps_progression_id` (`progression_id`),
+    CONSTRAINT `fk_training_progressions_steps` FOREIGN KEY (`progression_id`) REFERENCES `training_progressions`(`id`)
+);
+
+CREATE TABLE `training_sessions` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `c_id` bigint unsigned,
+    `instructor_c_id` bigint unsigned,
+    `facility` varchar(3),
+    `position` longtext,
+    `type` enum('classroom', 'sweatbox', 'live', 'ots'),
+    `start_at` datetime(3) NULL,
+    `duration_minutes` bigint unsigned,
+    `score` bigint unsigned,
+    `notes` text,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_training_sessions_c_id` (`c_id`),
+    INDEX `idx_training_sessions_instructor_c_id` (`instructor_c_id`),
+    INDEX `idx_training_sessions_facility` (`facility`)
+);
+
+CREATE TABLE `training_step_completions` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `c_id` bigint unsigned,
+    `step_id` bigint unsigned,
+    `session_id` bigint unsigned,
+    `completed_by` bigint unsigned,
+    `created_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_training_completion` (`c_id`,`step_id`),
+    INDEX `idx_training_step_completions_session_id` (`session_id`),
+    CONSTRAINT `fk_training_sessions_completions` FOREIGN KEY (`session_id`) REFERENCES `training_sessions`(`id`)
+);
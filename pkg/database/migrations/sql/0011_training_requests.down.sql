@@ -0,0 +1,2 @@
+DROP TABLE `training_availabilities`;
+DROP TABLE `training_requests`;
@@ -0,0 +1,28 @@
+-- Training request queue
+
+CREATE TABLE `training_requests` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `c_id` bigint unsigned,
+    `facility` varchar(3),
+    `position` longtext,
+    `notes` text,
+    `status` enum('open', 'assigned', 'completed', 'cancelled') DEFAULT 'open',
+    `assigned_c_id` bigint unsigned,
+    `assigned_at` datetime(3) NULL,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_training_requests_c_id` (`c_id`),
+    INDEX `idx_training_requests_facility` (`facility`),
+    INDEX `idx_training_requests_status` (`status`)
+);
+
+CREATE TABLE `training_availabilities` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `request_id` bigint unsigned,
+    `start_at` datetime(3) NULL,
+    `end_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_training_availabilities_request_id` (`request_id`),
+    CONSTRAINT `fk_training_requests_availability` FOREIGN KEY (`request_id`) REFERENCES `training_requests`(`id`)
+);
@@ -0,0 +1,20 @@
+-- Roster certifications and endorsements
+
+CREATE TABLE `certifications` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `roster_id` bigint unsigned,
+    `c_id` bigint unsigned,
+    `facility` varchar(3),
+    `type` enum('DEL', 'GND', 'TWR', 'APP', 'CTR', 'tier1', 'special'),
+    `position` varchar(32),
+    `solo` boolean,
+    `expires_at` datetime(3) NULL,
+    `granted_by` bigint unsigned,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_certification` (`roster_id`,`type`,`position`),
+    INDEX `idx_certifications_c_id` (`c_id`),
+    INDEX `idx_certifications_expires_at` (`expires_at`),
+    CONSTRAINT `fk_rosters_certifications` FOREIGN KEY (`roster_id`) REFERENCES `rosters`(`id`)
+);
@@ -0,0 +1,2 @@
+DROP TABLE `controlling_sessions`;
+DROP TABLE `callsign_prefixes`;
@@ -0,0 +1,23 @@
+-- Controlling sessions from the data feed
+
+CREATE TABLE `callsign_prefixes` (
+    `prefix` varchar(8),
+    `facility` varchar(3),
+    PRIMARY KEY (`prefix`),
+    INDEX `idx_callsign_prefixes_facility` (`facility`)
+);
+
+CREATE TABLE `controlling_sessions` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `c_id` bigint unsigned,
+    `callsign` longtext,
+    `facility` varchar(3),
+    `start_at` datetime(3) NULL,
+    `end_at` datetime(3) NULL,
+    `last_seen_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_controlling_sessions_facility` (`facility`),
+    INDEX `idx_controlling_sessions_start_at` (`start_at`),
+    INDEX `idx_controlling_sessions_end_at` (`end_at`),
+    INDEX `idx_controlling_sessions_c_id` (`c_id`)
+);
@@ -0,0 +1,3 @@
+ALTER TABLE `users`
+    DROP COLUMN `division`,
+    DROP COLUMN `region`;
@@ -0,0 +1,5 @@
+-- VATSIM region and division, kept up to date by the membership sync
+
+ALTER TABLE `users`
+    ADD COLUMN `region` longtext AFTER `discord_id`,
+    ADD COLUMN `division` longtext AFTER `region`;
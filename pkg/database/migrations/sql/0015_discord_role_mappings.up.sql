@@ -0,0 +1,15 @@
+-- Discord guild role mappings
+
+CREATE TABLE `discord_role_mappings` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `guild_id` varchar(32),
+    `discord_role_id` varchar(32),
+    `facility` varchar(3),
+    `home_only` boolean,
+    `role_id` varchar(10),
+    `rating` bigint unsigned,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_discord_role_mappings_guild_id` (`guild_id`)
+);
@@ -0,0 +1,46 @@
+-- Outbound webhook subscriptions and their delivery log
+
+CREATE TABLE `webhook_subscriptions` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `facility` varchar(3),
+    `url` longtext,
+    `secret` longtext,
+    `active` boolean,
+    `created_by` bigint unsigned,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_webhook_subscriptions_facility` (`facility`)
+);
+
+CREATE TABLE `webhook_subscription_events` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `subscription_id` bigint unsigned,
+    `event` varchar(32),
+    PRIMARY KEY (`id`),
+    INDEX `idx_webhook_subscription_events_subscription_id` (`subscription_id`),
+    INDEX `idx_webhook_subscription_events_event` (`event`),
+    CONSTRAINT `fk_webhook_subscriptions_events` FOREIGN KEY (`subscription_id`) REFERENCES `webhook_subscriptions`(`id`)
+);
+
+CREATE TABLE `webhook_deliveries` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `subscription_id` bigint unsigned,
+    `event_id` varchar(32),
+    `event` varchar(32),
+    `payload` text,
+    `status` enum('pending', 'delivered', 'failed') DEFAULT 'pending',
+    `attempts` bigint,
+    `next_attempt_at` datetime(3) NULL,
+    `last_status_code` bigint,
+    `last_error` varchar(512),
+    `delivered_at` datetime(3) NULL,
+    `redelivery_of` bigint unsigned,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_webhook_deliveries_subscription_id` (`subscription_id`),
+    INDEX `idx_webhook_deliveries_event_id` (`event_id`),
+    INDEX `idx_webhook_deliveries_status` (`status`),
+    INDEX `idx_webhook_deliveries_next_attempt_at` (`next_attempt_at`)
+);
@@ -0,0 +1,19 @@
+-- Transactional outbox for domain events
+
+CREATE TABLE `outbox_events` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `aggregate_type` varchar(32),
+    `aggregate_id` varchar(64),
+    `type` varchar(64),
+    `facility` varchar(3),
+    `payload` text,
+    `handled_by` longtext,
+    `attempts` bigint,
+    `next_attempt_at` datetime(3) NULL,
+    `last_error` varchar(512),
+    `processed_at` datetime(3) NULL,
+    `created_at` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_outbox_aggregate` (`aggregate_type`,`aggregate_id`),
+    INDEX `idx_outbox_events_processed_at` (`processed_at`)
+);
@@ -0,0 +1,3 @@
+ALTER TABLE `controlling_sessions`
+    DROP INDEX `idx_controlling_sessions_key`,
+    MODIFY COLUMN `callsign` longtext;
@@ -0,0 +1,20 @@
+-- One row per controlling session. Sessions recorded more than once by overlapping polls are merged into the
+-- first, which is kept open if any of them were.
+
+UPDATE `controlling_sessions` AS `s`
+    JOIN (
+        SELECT MIN(`id`) AS `id`, MAX(`last_seen_at`) AS `last_seen_at`, MIN(`end_at` IS NOT NULL) AS `closed`
+        FROM `controlling_sessions`
+        GROUP BY `c_id`, `callsign`, `start_at`
+        HAVING COUNT(*) > 1
+    ) AS `d` ON `d`.`id` = `s`.`id`
+    SET `s`.`last_seen_at` = `d`.`last_seen_at`,
+        `s`.`end_at` = IF(`d`.`closed`, `d`.`last_seen_at`, NULL);
+
+DELETE `s` FROM `controlling_sessions` AS `s`
+    JOIN `controlling_sessions` AS `k`
+        ON `k`.`c_id` = `s`.`c_id` AND `k`.`callsign` = `s`.`callsign` AND `k`.`start_at` = `s`.`start_at` AND `k`.`id` < `s`.`id`;
+
+ALTER TABLE `controlling_sessions`
+    MODIFY COLUMN `callsign` varchar(16),
+    ADD UNIQUE INDEX `idx_controlling_sessions_key` (`c_id`, `callsign`, `start_at`);
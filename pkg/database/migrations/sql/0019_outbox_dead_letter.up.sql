@@ -0,0 +1,4 @@
+-- Outbox events the dispatcher gives up on are set aside instead of blocking their aggregate
+
+ALTER TABLE `outbox_events`
+    ADD COLUMN `dead_at` datetime(3) NULL AFTER `processed_at`;
@@ -0,0 +1,11 @@
+-- Facility API keys, stored as SHA-256 hashes
+
+CREATE TABLE `api_keys` (
+    `facility` varchar(3),
+    `hash` varchar(64),
+    `prefix` varchar(8),
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    PRIMARY KEY (`facility`),
+    UNIQUE INDEX `idx_api_keys_hash` (`hash`)
+);
@@ -45,5 +45,5 @@ func GetAllActionLogEntries() ([]ActionLogEntry, error) {
 
 func GetAllActionLogEntriesByCID(db *gorm.DB, cid uint) ([]ActionLogEntry, error) {
 	var ale []ActionLogEntry
-	return ale, database.DB.Where("cid = ?", cid).Find(&ale).Error
+	return ale, db.Where("c_id = ?", cid).Find(&ale).Error
 }
@@ -0,0 +1,158 @@
+package models
+
+import (
+	"fmt"
+	"github.com/VATUSA/primary-api/pkg/database"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"strings"
+	"time"
+)
+
+// ControllingSession is a stretch of time a controller spent connected on a position. Sessions still in
+// progress have no EndAt; LastSeenAt is the last time the data feed showed them online. A session is identified
+// by the controller, callsign and logon time, which are unique.
+type ControllingSession struct {
+	ID         uint       `json:"id" gorm:"primaryKey" example:"1"`
+	CID        uint       `json:"cid" gorm:"index;uniqueIndex:idx_controlling_sessions_key" example:"1293257"`
+	Callsign   string     `json:"callsign" gorm:"size:16;uniqueIndex:idx_controlling_sessions_key" example:"DEN_APP"`
+	Facility   string     `json:"facility" gorm:"size:3;index" example:"ZDV"`
+	StartAt    time.Time  `json:"start_at" gorm:"index;uniqueIndex:idx_controlling_sessions_key" example:"2021-01-01T00:00:00Z"`
+	EndAt      *time.Time `json:"end_at" gorm:"index" example:"2021-01-01T02:00:00Z"`
+	LastSeenAt time.Time  `json:"last_seen_at" example:"2021-01-01T02:00:00Z"`
+}
+
+// CallsignPrefix maps the first part of a callsign, such as DEN in DEN_APP, to the facility it belongs to
+type CallsignPrefix struct {
+	Prefix   string `json:"prefix" gorm:"size:8;primaryKey" example:"DEN"`
+	Facility string `json:"facility" gorm:"size:3;index" example:"ZDV"`
+}
+
+func (s *ControllingSession) Create() error {
+	return database.DB.Create(s).Error
+}
+
+// Open records the session as online. If it was recorded before, because another poll got there first or the
+// feed briefly lost the controller and it was closed, that session is reopened instead.
+func (s *ControllingSession) Open() error {
+	return database.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "c_id"}, {Name: "callsign"}, {Name: "start_at"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"end_at": nil, "last_seen_at": s.LastSeenAt}),
+	}).Create(s).Error
+}
+
+// Close ends the session at the last time it was seen online
+func (s *ControllingSession) Close() error {
+	end := s.LastSeenAt
+	s.EndAt = &end
+	return database.DB.Model(s).Update("end_at", end).Error
+}
+
+// Duration is how long the session has lasted so far
+func (s *ControllingSession) Duration() time.Duration {
+	if s.EndAt != nil {
+		return s.EndAt.Sub(s.StartAt)
+	}
+	return s.LastSeenAt.Sub(s.StartAt)
+}
+
+func GetOpenControllingSessions() ([]ControllingSession, error) {
+	var sessions []ControllingSession
+	return sessions, database.DB.Where("end_at IS NULL").Find(&sessions).Error
+}
+
+// TouchControllingSessions marks the sessions as seen online at now
+func TouchControllingSessions(ids []uint, now time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return database.DB.Model(&ControllingSession{}).Where("id IN ?", ids).Update("last_seen_at", now).Error
+}
+
+// GetControllingSessionsSince returns the facility's sessions that were still going at or after since
+func GetControllingSessionsSince(facility string, since time.Time) ([]ControllingSession, error) {
+	var sessions []ControllingSession
+	return sessions, database.DB.Where("facility = ? AND (end_at IS NULL OR end_at >= ?)", facility, since).
+		Order("start_at").Find(&sessions).Error
+}
+
+// GetLastControllingSessions returns when each of the facility's controllers was last seen on position
+func GetLastControllingSessions(facility string) (map[uint]time.Time, error) {
+	// Select the latest rows rather than MAX() so the column keeps its type on every driver
+	latest := database.DB.Table("controlling_sessions AS latest").Select("MAX(latest.last_seen_at)").
+		Where("latest.facility = ? AND latest.c_id = controlling_sessions.c_id", facility)
+
+	var sessions []ControllingSession
+	err := database.DB.Select("c_id, last_seen_at").
+		Where("facility = ? AND last_seen_at = (?)", facility, latest).Find(&sessions).Error
+	if err != nil {
+		return nil, err
+	}
+
+	last := map[uint]time.Time{}
+	for _, s := range sessions {
+		last[s.CID] = s.LastSeenAt
+	}
+	return last, nil
+}
+
+// GetCallsignPrefixes returns every prefix mapped to its facility
+func GetCallsignPrefixes() (map[string]string, error) {
+	var prefixes []CallsignPrefix
+	if err := database.DB.Find(&prefixes).Error; err != nil {
+		return nil, err
+	}
+
+	m := map[string]string{}
+	for _, p := range prefixes {
+		m[p.Prefix] = p.Facility
+	}
+	return m, nil
+}
+
+func GetCallsignPrefixesByFacility(facility string) ([]CallsignPrefix, error) {
+	var prefixes []CallsignPrefix
+	return prefixes, database.DB.Where("facility = ?", facility).Order("prefix").Find(&prefixes).Error
+}
+
+// PrefixTakenError is returned by SetCallsignPrefixes when a prefix belongs to another facility
+type PrefixTakenError struct {
+	Prefix   string
+	Facility string
+}
+
+func (e *PrefixTakenError) Error() string {
+	return fmt.Sprintf("prefix %s belongs to %s", e.Prefix, e.Facility)
+}
+
+// SetCallsignPrefixes replaces the facility's prefixes. Prefixes that belong to another facility only move to this
+// one if takeOver is set; otherwise nothing changes and a *PrefixTakenError is returned.
+func SetCallsignPrefixes(facility string, prefixes []string, takeOver bool) error {
+	upper := make([]string, len(prefixes))
+	for i, p := range prefixes {
+		upper[i] = strings.ToUpper(p)
+	}
+
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if !takeOver && len(upper) > 0 {
+			var taken []CallsignPrefix
+			if err := tx.Where("prefix IN ? AND facility <> ?", upper, facility).Limit(1).Find(&taken).Error; err != nil {
+				return err
+			}
+			if len(taken) > 0 {
+				return &PrefixTakenError{Prefix: taken[0].Prefix, Facility: taken[0].Facility}
+			}
+		}
+
+		if err := tx.Where("facility = ?", facility).Delete(&CallsignPrefix{}).Error; err != nil {
+			return err
+		}
+		for _, p := range upper {
+			prefix := CallsignPrefix{Prefix: p, Facility: facility}
+			if err := tx.Save(&prefix).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
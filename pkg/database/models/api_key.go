@@ -0,0 +1,47 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"gorm.io/gorm"
+	"time"
+)
+
+// APIKey is a facility's key for requests made with the x-api-key header. Keys are issued but not yet checked by
+// any route. Only a hash of the key is kept; the key itself is shown once, when it is issued.
+type APIKey struct {
+	Facility  string    `json:"facility" gorm:"size:3;primaryKey" example:"ZDV"`
+	Hash      string    `json:"-" gorm:"size:64;uniqueIndex"`
+	Prefix    string    `json:"prefix" gorm:"size:8" example:"3f9a1c2b"` // Start of the key, to tell keys apart
+	CreatedAt time.Time `json:"created_at" example:"2021-01-01T00:00:00Z"`
+	UpdatedAt time.Time `json:"updated_at" example:"2021-01-01T00:00:00Z"`
+}
+
+// HashAPIKey is the hash stored for key
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// SaveAPIKey stores key as the facility's API key, replacing any key it had
+func SaveAPIKey(db *gorm.DB, facility, key string) (*APIKey, error) {
+	k := &APIKey{Facility: facility}
+	if err := db.Where("facility = ?", facility).Limit(1).Find(k).Error; err != nil {
+		return nil, err
+	}
+
+	k.Hash = HashAPIKey(key)
+	k.Prefix = key[:8]
+	return k, db.Save(k).Error
+}
+
+func GetAPIKeyByFacility(db *gorm.DB, facility string) (*APIKey, error) {
+	k := &APIKey{}
+	return k, db.Where("facility = ?", facility).First(k).Error
+}
+
+// GetAPIKeyByKey finds the API key matching key
+func GetAPIKeyByKey(db *gorm.DB, key string) (*APIKey, error) {
+	k := &APIKey{}
+	return k, db.Where("hash = ?", HashAPIKey(key)).First(k).Error
+}
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"github.com/VATUSA/primary-api/pkg/constants"
+	"github.com/VATUSA/primary-api/pkg/database"
+	"github.com/VATUSA/primary-api/pkg/database/types"
+	"gorm.io/gorm"
+	"time"
+)
+
+// Certification is a position certification or endorsement held by a roster member. Solo certifications
+// expire and are removed once they do.
+type Certification struct {
+	ID        uint                    `json:"id" gorm:"primaryKey" example:"1"`
+	RosterID  uint                    `json:"roster_id" gorm:"uniqueIndex:idx_certification" example:"1"`
+	CID       uint                    `json:"cid" gorm:"index" example:"1293257"`
+	Facility  string                  `json:"facility" gorm:"size:3" example:"ZDV"`
+	Type      types.CertificationType `json:"type" gorm:"type:enum('DEL', 'GND', 'TWR', 'APP', 'CTR', 'tier1', 'special');uniqueIndex:idx_certification" example:"TWR"`
+	Position  string                  `json:"position" gorm:"size:32;uniqueIndex:idx_certification" example:"DEN"` // Airport or airspace covered, if limited to one
+	Solo      bool                    `json:"solo" example:"false"`
+	ExpiresAt *time.Time              `json:"expires_at" gorm:"index" example:"2021-02-01T00:00:00Z"`
+	GrantedBy uint                    `json:"granted_by" example:"1000001"`
+	CreatedAt time.Time               `json:"created_at" example:"2021-01-01T00:00:00Z"`
+	UpdatedAt time.Time               `json:"updated_at" example:"2021-01-01T00:00:00Z"`
+}
+
+func (c *Certification) Create() error {
+	return database.DB.Create(c).Error
+}
+
+func (c *Certification) Update() error {
+	return database.DB.Save(c).Error
+}
+
+func (c *Certification) Delete() error {
+	return database.DB.Delete(c).Error
+}
+
+func (c *Certification) Get() error {
+	return database.DB.Where("id = ?", c.ID).First(c).Error
+}
+
+func GetCertificationsByRoster(db *gorm.DB, rosterID uint) ([]Certification, error) {
+	var certifications []Certification
+	return certifications, db.Where("roster_id = ?", rosterID).Scopes(orderCertifications).Find(&certifications).Error
+}
+
+// GetExpiredSoloCertifications returns the solo certifications that have expired by now
+func GetExpiredSoloCertifications(db *gorm.DB, now time.Time) ([]Certification, error) {
+	var certifications []Certification
+	return certifications, db.Where("solo = ? AND expires_at <= ?", true, now).Find(&certifications).Error
+}
+
+// DeleteExpiredSoloCertification deletes cert if it is still a solo certification that has expired by now and
+// reports whether this call removed it. When sweeps overlap, only the one that removes the row sees true.
+func DeleteExpiredSoloCertification(db *gorm.DB, cert *Certification, now time.Time) (bool, error) {
+	result := db.Where("id = ? AND solo = ? AND expires_at <= ?", cert.ID, true, now).Delete(&Certification{})
+	return result.RowsAffected == 1, result.Error
+}
+
+// CanCertify reports whether the user may grant and revoke certifications at the facility. That is limited to
+// the facility's TA and instructors, and division staff.
+func (u *User) CanCertify(facility string) bool {
+	if u.IsDivisionStaff() {
+		return true
+	}
+
+	for _, role := range u.Roles {
+		if role.FacilityID != facility {
+			continue
+		}
+		if role.RoleID == constants.TrainingAdministratorRole || role.RoleID == constants.InstructorRole {
+			return true
+		}
+	}
+
+	var count int64
+	database.DB.Model(&Roster{}).Where("c_id = ? AND facility = ? AND instructor = ?", u.CID, facility, true).Count(&count)
+	return count > 0
+}
@@ -43,5 +43,5 @@ func GetAllDisciplinaryLogEntries(VATUSAOnly bool) ([]DisciplinaryLogEntry, erro
 
 func GetAllDisciplinaryLogEntriesByCID(cid uint, VATUSAOnly bool) ([]DisciplinaryLogEntry, error) {
 	var dle []DisciplinaryLogEntry
-	return dle, database.DB.Where("cid = ? AND vatusa_only = ?", cid, VATUSAOnly).Find(&dle).Error
+	return dle, database.DB.Where("c_id = ? AND vatusa_only = ?", cid, VATUSAOnly).Find(&dle).Error
 }
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"github.com/VATUSA/primary-api/pkg/constants"
+	"github.com/VATUSA/primary-api/pkg/database"
+	"time"
+)
+
+// DiscordRoleMapping grants a Discord role in a guild to the linked users who meet every condition it sets.
+// A mapping that sets no conditions applies to every linked user.
+type DiscordRoleMapping struct {
+	ID            uint             `json:"id" gorm:"primaryKey" example:"1"`
+	GuildID       string           `json:"guild_id" gorm:"size:32;index" example:"485491681903247361"`
+	DiscordRoleID string           `json:"discord_role_id" gorm:"size:32" example:"485491681903247362"`
+	Facility      string           `json:"facility" gorm:"size:3" example:"ZDV"`          // On the facility's roster, or holding RoleID there
+	HomeOnly      bool             `json:"home_only" example:"false"`                     // With Facility, only home controllers
+	RoleID        constants.RoleID `json:"role_id" gorm:"type:varchar(10)" example:"ATM"` // Holding the role
+	Rating        *uint            `json:"rating" example:"5"`                            // With this controller rating
+	CreatedAt     time.Time        `json:"created_at" example:"2021-01-01T00:00:00Z"`
+	UpdatedAt     time.Time        `json:"updated_at" example:"2021-01-01T00:00:00Z"`
+}
+
+func (m *DiscordRoleMapping) Create() error {
+	return database.DB.Create(m).Error
+}
+
+func (m *DiscordRoleMapping) Update() error {
+	return database.DB.Save(m).Error
+}
+
+func (m *DiscordRoleMapping) Delete() error {
+	return database.DB.Delete(m).Error
+}
+
+func (m *DiscordRoleMapping) Get() error {
+	return database.DB.Where("id = ?", m.ID).First(m).Error
+}
+
+func GetDiscordRoleMappings(guildID string) ([]DiscordRoleMapping, error) {
+	var mappings []DiscordRoleMapping
+	return mappings, database.DB.Where("guild_id = ?", guildID).Order("id").Find(&mappings).Error
+}
+
+// IsDiscordLinked reports whether another user has already linked the Discord account
+func IsDiscordLinked(discordID string, except uint) (bool, error) {
+	var count int64
+	err := database.DB.Model(&User{}).Where("discord_id = ? AND c_id <> ?", discordID, except).Count(&count).Error
+	return count > 0, err
+}
@@ -3,28 +3,169 @@ package models
 import (
 	"github.com/VATUSA/primary-api/pkg/database"
 	"github.com/VATUSA/primary-api/pkg/database/types"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"strconv"
+	"strings"
 	"time"
 )
 
 type Document struct {
-	ID          uint                   `json:"id" gorm:"primaryKey" example:"1"`
-	Facility    string                 `json:"facility" example:"ZDV"`
-	Name        string                 `json:"name" example:"DP001"`
-	Description string                 `json:"description" example:"General Division Policy"`
-	Category    types.DocumentCategory `gorm:"type:enum('general', 'training', 'information_technology', 'sops', 'loas', 'misc');" json:"category" example:"general"`
-	URL         string                 `json:"url" example:"https://zdvartcc.org"`
-	CreatedAt   time.Time              `json:"created_at" example:"2021-01-01T00:00:00Z"`
-	CreatedBy   uint                   `json:"created_by" example:"1293257"`
-	UpdatedAt   time.Time              `json:"updated_at" example:"2021-01-01T00:00:00Z"`
-	UpdatedBy   uint                   `json:"updated_by" example:"1293257"`
+	ID          uint                     `json:"id" gorm:"primaryKey" example:"1"`
+	Facility    string                   `json:"facility" example:"ZDV"`
+	Name        string                   `json:"name" example:"DP001"`
+	Description string                   `json:"description" example:"General Division Policy"`
+	Category    types.DocumentCategory   `gorm:"type:enum('general', 'training', 'information_technology', 'sops', 'loas', 'misc');" json:"category" example:"general"`
+	URL         string                   `json:"-"`
+	ContentType string                   `json:"content_type" example:"application/pdf"`
+	SHA256      string                   `json:"sha256" example:"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"`
+	Visibility  types.DocumentVisibility `gorm:"type:enum('public', 'facility', 'staff');default:'public'" json:"visibility" example:"public"`
+	Version     uint                     `json:"version" example:"3"`
+	Versions    []DocumentVersion        `json:"-" gorm:"foreignKey:DocumentID"`
+	CreatedAt   time.Time                `json:"created_at" example:"2021-01-01T00:00:00Z"`
+	CreatedBy   uint                     `json:"created_by" example:"1293257"`
+	UpdatedAt   time.Time                `json:"updated_at" example:"2021-01-01T00:00:00Z"`
+	UpdatedBy   uint                     `json:"updated_by" example:"1293257"`
 }
 
 func (d *Document) Create() error {
 	return database.DB.Create(d).Error
 }
 
+// Update saves the document and raises document.updated
 func (d *Document) Update() error {
-	return database.DB.Save(d).Error
+	return d.UpdateWithVersions(nil)
+}
+
+// UpdateWithVersions saves the document and its versions in a single transaction
+func (d *Document) UpdateWithVersions(versions []DocumentVersion) error {
+	return UpdateDocumentWithVersions(database.DB, d, versions)
+}
+
+// UpdateDocumentWithVersions is UpdateWithVersions through db
+func UpdateDocumentWithVersions(db *gorm.DB, d *Document, versions []DocumentVersion) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for i := range versions {
+			if err := tx.Save(&versions[i]).Error; err != nil {
+				return err
+			}
+		}
+		return d.save(tx)
+	})
+}
+
+// AddVersion records a newly uploaded version as the next version of the document and makes it the current version
+func (d *Document) AddVersion(v *DocumentVersion) error {
+	return AddDocumentVersion(database.DB, d, v, nil)
+}
+
+// AddDocumentVersion is AddVersion through db. The document's row is locked while the version is numbered, so
+// concurrent uploads get consecutive numbers. If place is set it is called once v.Version is assigned, before
+// anything is saved, to put the upload where the version will point.
+func AddDocumentVersion(db *gorm.DB, d *Document, v *DocumentVersion, place func(v *DocumentVersion) error) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").Where("id = ?", d.ID).First(&Document{}).Error; err != nil {
+			return err
+		}
+
+		next, err := NextDocumentVersion(tx, d.ID)
+		if err != nil {
+			return err
+		}
+		v.DocumentID = d.ID
+		v.Version = next
+
+		if place != nil {
+			if err := place(v); err != nil {
+				return err
+			}
+		}
+		if err := tx.Create(v).Error; err != nil {
+			return err
+		}
+
+		d.Version = v.Version
+		d.URL = v.URL
+		d.ContentType = v.ContentType
+		d.SHA256 = v.Checksum
+		d.UpdatedBy = v.CreatedBy
+		return d.save(tx)
+	})
+}
+
+// ObjectName is the name the document's objects are stored under, which is its name with spaces replaced by
+// dashes. Two documents whose object names match would share storage keys.
+func (d *Document) ObjectName() string {
+	return strings.ReplaceAll(d.Name, " ", "-")
+}
+
+func (d *Document) save(tx *gorm.DB) error {
+	if err := tx.Save(d).Error; err != nil {
+		return err
+	}
+	return emit(tx, DocumentAggregate, strconv.FormatUint(uint64(d.ID), 10), types.DocumentUpdated, d.Facility, d)
+}
+
+// CanView reports whether the user may download the document. Division staff and the facility's staff can see
+// every document, and facility documents are also visible to anyone on the facility's roster.
+func (d *Document) CanView(user *User) bool {
+	return d.canView(user, func(facility string) bool {
+		var count int64
+		database.DB.Model(&Roster{}).Where("c_id = ? AND facility = ?", user.CID, facility).Count(&count)
+		return count > 0
+	})
+}
+
+func (d *Document) canView(user *User, rostered func(facility string) bool) bool {
+	if d.Visibility == types.PublicVisibility || d.Visibility == "" {
+		return true
+	}
+
+	if user == nil {
+		return false
+	}
+
+	if user.IsFacilityStaff(d.Facility) {
+		return true
+	}
+
+	if d.Visibility == types.StaffVisibility {
+		return false
+	}
+
+	return rostered(d.Facility)
+}
+
+// GetViewableDocumentIDs returns which of the documents the user may download, as CanView would decide, loading
+// the documents and the user's rosters once
+func GetViewableDocumentIDs(db *gorm.DB, ids []uint, user *User) (map[uint]bool, error) {
+	viewable := map[uint]bool{}
+	if len(ids) == 0 {
+		return viewable, nil
+	}
+
+	var docs []Document
+	if err := db.Select("id, facility, visibility").Where("id IN ?", ids).Find(&docs).Error; err != nil {
+		return nil, err
+	}
+
+	rosters := map[string]bool{}
+	if user != nil {
+		var facilities []string
+		if err := db.Model(&Roster{}).Where("c_id = ?", user.CID).Pluck("facility", &facilities).Error; err != nil {
+			return nil, err
+		}
+		for _, f := range facilities {
+			rosters[f] = true
+		}
+	}
+
+	for i := range docs {
+		viewable[docs[i].ID] = docs[i].canView(user, func(facility string) bool {
+			return rosters[facility]
+		})
+	}
+	return viewable, nil
 }
 
 func (d *Document) Delete() error {
@@ -35,22 +176,22 @@ func (d *Document) Get() error {
 	return database.DB.Where("id = ?", d.ID).First(d).Error
 }
 
-func GetAllDocuments() ([]Document, error) {
+func GetAllDocuments(db *gorm.DB) ([]Document, error) {
 	var documents []Document
-	return documents, database.DB.Find(&documents).Error
+	return documents, db.Find(&documents).Error
 }
 
-func GetAllDocumentsByCategory(category types.DocumentCategory) ([]Document, error) {
+func GetAllDocumentsByCategory(db *gorm.DB, category types.DocumentCategory) ([]Document, error) {
 	var documents []Document
-	return documents, database.DB.Where("category = ?", category).Find(&documents).Error
+	return documents, db.Where("category = ?", category).Find(&documents).Error
 }
 
-func GetAllDocumentsByFacility(facility string) ([]Document, error) {
+func GetAllDocumentsByFacility(db *gorm.DB, facility string) ([]Document, error) {
 	var documents []Document
-	return documents, database.DB.Where("facility = ?", facility).Find(&documents).Error
+	return documents, db.Where("facility = ?", facility).Find(&documents).Error
 }
 
-func GetAllDocumentsByFacilityAndCategory(facility string, category types.DocumentCategory) ([]Document, error) {
+func GetAllDocumentsByFacilityAndCategory(db *gorm.DB, facility string, category types.DocumentCategory) ([]Document, error) {
 	var documents []Document
-	return documents, database.DB.Where("facility = ? AND category = ?", facility, category).Find(&documents).Error
+	return documents, db.Where("facility = ? AND category = ?", facility, category).Find(&documents).Error
 }
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"github.com/VATUSA/primary-api/pkg/database"
+	"gorm.io/gorm"
+	"time"
+)
+
+// DocumentVersion is a single uploaded revision of a Document. Every revision keeps its own object in
+// storage so older revisions can be downloaded or promoted back to current.
+type DocumentVersion struct {
+	ID          uint      `json:"id" gorm:"primaryKey" example:"1"`
+	DocumentID  uint      `json:"document_id" gorm:"index" example:"1"`
+	Version     uint      `json:"version" example:"3"`
+	Key         string    `json:"-"`
+	URL         string    `json:"-"`
+	ContentType string    `json:"content_type" example:"application/pdf"`
+	Checksum    string    `json:"checksum" example:"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"`
+	Size        int64     `json:"size" example:"102400"`
+	ChangeNote  string    `json:"change_note" example:"Updated for the new VATSIM Code of Conduct"`
+	CreatedAt   time.Time `json:"created_at" example:"2021-01-01T00:00:00Z"`
+	CreatedBy   uint      `json:"created_by" example:"1293257"`
+}
+
+func (v *DocumentVersion) Create() error {
+	return database.DB.Create(v).Error
+}
+
+func (v *DocumentVersion) Delete() error {
+	return database.DB.Delete(v).Error
+}
+
+func (v *DocumentVersion) Get() error {
+	return database.DB.Where("document_id = ? AND version = ?", v.DocumentID, v.Version).First(v).Error
+}
+
+func GetAllDocumentVersions(db *gorm.DB, documentID uint) ([]DocumentVersion, error) {
+	var versions []DocumentVersion
+	return versions, db.Where("document_id = ?", documentID).Order("version desc").Find(&versions).Error
+}
+
+// NextDocumentVersion returns the version number the next upload of the document should use. It is only safe to
+// rely on while the document is locked, as AddDocumentVersion does.
+func NextDocumentVersion(db *gorm.DB, documentID uint) (uint, error) {
+	var latest uint
+	err := db.Model(&DocumentVersion{}).Where("document_id = ?", documentID).Select("COALESCE(MAX(version), 0)").Scan(&latest).Error
+	return latest + 1, err
+}
@@ -0,0 +1,193 @@
+package models
+
+import (
+	"github.com/VATUSA/primary-api/pkg/constants"
+	"github.com/VATUSA/primary-api/pkg/database"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type Event struct {
+	ID            uint            `json:"id" gorm:"primaryKey" example:"1"`
+	Title         string          `json:"title" example:"Denver Nights"`
+	Description   string          `json:"description" example:"Join us for a night of traffic into Denver"`
+	BannerKey     string          `json:"-"`
+	BannerURL     string          `json:"banner_url" example:"/internal/v1/event/1/banner"`
+	HostFacility  string          `json:"host_facility" example:"ZDV"`
+	Facilities    []EventFacility `json:"facilities" gorm:"foreignKey:EventID"`
+	Positions     []EventPosition `json:"positions" gorm:"foreignKey:EventID"`
+	StartAt       time.Time       `json:"start_at" gorm:"index" example:"2021-01-01T00:00:00Z"`
+	EndAt         time.Time       `json:"end_at" example:"2021-01-01T03:00:00Z"`
+	RemindersSent uint            `json:"-"`
+	CreatedAt     time.Time       `json:"created_at" example:"2021-01-01T00:00:00Z"`
+	CreatedBy     uint            `json:"created_by" example:"1293257"`
+	UpdatedAt     time.Time       `json:"updated_at" example:"2021-01-01T00:00:00Z"`
+	UpdatedBy     uint            `json:"updated_by" example:"1293257"`
+}
+
+// EventFacility is a facility taking part in an event alongside the host
+type EventFacility struct {
+	ID       uint   `json:"-" gorm:"primaryKey"`
+	EventID  uint   `json:"-" gorm:"uniqueIndex:idx_event_facility"`
+	Facility string `json:"facility" gorm:"size:3;uniqueIndex:idx_event_facility" example:"ZLC"`
+}
+
+// EventPosition is a position staffed for an event. Controllers sign up and an event coordinator assigns one.
+type EventPosition struct {
+	ID          uint          `json:"id" gorm:"primaryKey" example:"1"`
+	EventID     uint          `json:"event_id" gorm:"index" example:"1"`
+	Callsign    string        `json:"callsign" example:"DEN_APP"`
+	AssignedCID *uint         `json:"assigned_cid" example:"1293257"`
+	Signups     []EventSignup `json:"signups" gorm:"foreignKey:PositionID"`
+	CreatedAt   time.Time     `json:"created_at" example:"2021-01-01T00:00:00Z"`
+	UpdatedAt   time.Time     `json:"updated_at" example:"2021-01-01T00:00:00Z"`
+}
+
+type EventSignup struct {
+	ID         uint      `json:"id" gorm:"primaryKey" example:"1"`
+	PositionID uint      `json:"position_id" gorm:"uniqueIndex:idx_event_signup" example:"1"`
+	CID        uint      `json:"cid" gorm:"uniqueIndex:idx_event_signup" example:"1293257"`
+	Note       string    `json:"note" example:"Available from 0100z"`
+	CreatedAt  time.Time `json:"created_at" example:"2021-01-01T00:00:00Z"`
+}
+
+func (e *Event) Create() error {
+	return database.DB.Create(e).Error
+}
+
+// Update saves the event itself; facilities and positions are managed separately
+func (e *Event) Update() error {
+	return database.DB.Omit(clause.Associations).Save(e).Error
+}
+
+// ClaimReminderStage records that the reminders up to stage are going out for the event and reports whether this
+// call claimed them. It fails to claim a stage already reached, so overlapping runs send each stage once.
+func (e *Event) ClaimReminderStage(stage uint) (bool, error) {
+	result := database.DB.Model(&Event{}).Where("id = ? AND reminders_sent < ?", e.ID, stage).Update("reminders_sent", stage)
+	if result.Error != nil || result.RowsAffected != 1 {
+		return false, result.Error
+	}
+	e.RemindersSent = stage
+	return true, nil
+}
+
+// SetFacilities replaces the facilities taking part in the event
+func (e *Event) SetFacilities(facilities []string) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("event_id = ?", e.ID).Delete(&EventFacility{}).Error; err != nil {
+			return err
+		}
+
+		e.Facilities = []EventFacility{}
+		for _, f := range facilities {
+			e.Facilities = append(e.Facilities, EventFacility{EventID: e.ID, Facility: f})
+		}
+		if len(e.Facilities) == 0 {
+			return nil
+		}
+		return tx.Create(&e.Facilities).Error
+	})
+}
+
+// Delete removes the event with its facilities, positions and signups
+func (e *Event) Delete() error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		positions := tx.Model(&EventPosition{}).Select("id").Where("event_id = ?", e.ID)
+		if err := tx.Where("position_id IN (?)", positions).Delete(&EventSignup{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("event_id = ?", e.ID).Delete(&EventPosition{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("event_id = ?", e.ID).Delete(&EventFacility{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(e).Error
+	})
+}
+
+func (e *Event) Get() error {
+	return database.DB.Where("id = ?", e.ID).
+		Preload("Facilities").
+		Preload("Positions", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Preload("Positions.Signups", func(db *gorm.DB) *gorm.DB { return db.Order("created_at") }).
+		First(e).Error
+}
+
+// CanManage reports whether the user may edit the event and assign its positions. Division events staff can
+// manage every event; facility events staff and management can manage events their facility hosts.
+func (e *Event) CanManage(user *User) bool {
+	if user == nil {
+		return false
+	}
+
+	if user.IsDivisionStaff() {
+		return true
+	}
+
+	for _, role := range user.Roles {
+		if role.RoleID.InGroup(constants.DivisionEvents) {
+			return true
+		}
+		if role.FacilityID == e.HostFacility && (role.RoleID.InGroup(constants.FacilityEvents) || role.RoleID.InGroup(constants.FacilityManagement)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetEvents returns events ending after the given time, soonest first. With a facility, only events it hosts
+// or takes part in are returned.
+func GetEvents(facility string, after time.Time) ([]Event, error) {
+	var events []Event
+	query := database.DB.Where("end_at > ?", after).Preload("Facilities").Order("start_at")
+	if facility != "" {
+		query = query.Where("host_facility = ? OR id IN (?)", facility,
+			database.DB.Model(&EventFacility{}).Select("event_id").Where("facility = ?", facility))
+	}
+	return events, query.Find(&events).Error
+}
+
+// GetEventsStartingBefore returns events that haven't started by now but will by the given time, with positions
+func GetEventsStartingBefore(now, before time.Time) ([]Event, error) {
+	var events []Event
+	return events, database.DB.Where("start_at > ? AND start_at <= ?", now, before).Preload("Positions").Find(&events).Error
+}
+
+func (p *EventPosition) Create() error {
+	return database.DB.Create(p).Error
+}
+
+func (p *EventPosition) Update() error {
+	return database.DB.Omit(clause.Associations).Save(p).Error
+}
+
+// Delete removes the position and its signups
+func (p *EventPosition) Delete() error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("position_id = ?", p.ID).Delete(&EventSignup{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(p).Error
+	})
+}
+
+func (p *EventPosition) Get() error {
+	return database.DB.Where("id = ? AND event_id = ?", p.ID, p.EventID).
+		Preload("Signups", func(db *gorm.DB) *gorm.DB { return db.Order("created_at") }).
+		First(p).Error
+}
+
+func (s *EventSignup) Create() error {
+	return database.DB.Create(s).Error
+}
+
+func (s *EventSignup) Delete() error {
+	return database.DB.Delete(s).Error
+}
+
+func (s *EventSignup) Get() error {
+	return database.DB.Where("position_id = ? AND c_id = ?", s.PositionID, s.CID).First(s).Error
+}
@@ -5,6 +5,9 @@ import (
 	"time"
 )
 
+// DivisionFacility holds division-wide content such as FAQs and news, which every facility inherits
+const DivisionFacility = "ZHQ"
+
 type Facility struct {
 	ID               string             `json:"id" gorm:"size:3;primaryKey" example:"ZDV"`
 	Name             string             `json:"name" example:"Denver ARTCC"`
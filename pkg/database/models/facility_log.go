@@ -46,5 +46,5 @@ func GetAllFacilityLogEntries() ([]FacilityLogEntry, error) {
 
 func GetAllFacilityLogEntriesByFacility(db *gorm.DB, facility string) ([]FacilityLogEntry, error) {
 	var fle []FacilityLogEntry
-	return fle, database.DB.Where("facility = ?", facility).Find(&fle).Error
+	return fle, db.Where("facility = ?", facility).Find(&fle).Error
 }
@@ -2,20 +2,25 @@ package models
 
 import (
 	"github.com/VATUSA/primary-api/pkg/database"
+	"github.com/VATUSA/primary-api/pkg/database/types"
 	"gorm.io/gorm"
+	"sort"
 	"time"
 )
 
 type FAQ struct {
-	ID        uint      `json:"id" gorm:"primaryKey" example:"1"`
-	Facility  string    `json:"facility" example:"ZDV"`
-	Question  string    `json:"question" example:"Why shouldn't I join ZDV?'"`
-	Answer    string    `json:"answer" example:"There are no reasons not to join ZDV!"`
-	Category  string    `gorm:"type:enum('membership', 'training', 'technology', 'misc');" json:"category" example:"membership"`
-	CreatedAt time.Time `json:"created_at" example:"2021-01-01T00:00:00Z"`
-	CreatedBy uint      `json:"created_by" example:"1293257"`
-	UpdatedAt time.Time `json:"updated_at" example:"2021-01-01T00:00:00Z"`
-	UpdatedBy uint      `json:"updated_by" example:"1293257"`
+	ID        uint                `json:"id" gorm:"primaryKey" example:"1"`
+	Facility  string              `json:"facility" example:"ZDV"`
+	Question  string              `json:"question" example:"Why shouldn't I join ZDV?'"`
+	Answer    string              `json:"answer" example:"There are no reasons not to join ZDV!"`
+	Category  types.FAQCategory   `gorm:"type:enum('membership', 'training', 'technology', 'misc');" json:"category" example:"membership"`
+	SortOrder int                 `json:"sort_order" example:"1"`
+	Status    types.PublishStatus `gorm:"type:enum('draft', 'published');default:'published'" json:"status" example:"published"`
+	Overrides *uint               `json:"overrides,omitempty" example:"1"` // Division FAQ this facility FAQ replaces
+	CreatedAt time.Time           `json:"created_at" example:"2021-01-01T00:00:00Z"`
+	CreatedBy uint                `json:"created_by" example:"1293257"`
+	UpdatedAt time.Time           `json:"updated_at" example:"2021-01-01T00:00:00Z"`
+	UpdatedBy uint                `json:"updated_by" example:"1293257"`
 }
 
 func (f *FAQ) Create() error {
@@ -36,15 +41,71 @@ func (f *FAQ) Get() error {
 
 func GetAllFAQ() ([]FAQ, error) {
 	var faq []FAQ
-	return faq, database.DB.Find(&faq).Error
+	return faq, database.DB.Order("facility, category, sort_order, id").Find(&faq).Error
 }
 
 func GetAllFAQByCategory(db *gorm.DB, category string) ([]FAQ, error) {
 	var faq []FAQ
-	return faq, database.DB.Where("category = ?", category).Find(&faq).Error
+	return faq, db.Where("category = ?", category).Order("sort_order, id").Find(&faq).Error
 }
 
 func GetAllFAQByFacility(db *gorm.DB, facility string) ([]FAQ, error) {
 	var faq []FAQ
-	return faq, database.DB.Where("facility = ?", facility).Find(&faq).Error
+	return faq, db.Where("facility = ?", facility).Order("sort_order, id").Find(&faq).Error
+}
+
+// GetFacilityFAQ returns the FAQs shown for a facility in display order: its own FAQs plus every division FAQ
+// it hasn't overridden. An override hides the division FAQ even while the override is a draft, so a facility
+// can drop a division FAQ by overriding it with a draft. Drafts are left out unless includeDrafts is set.
+func GetFacilityFAQ(facility string, includeDrafts bool) ([]FAQ, error) {
+	var faqs []FAQ
+	if err := database.DB.Where("facility IN ?", []string{facility, DivisionFacility}).Find(&faqs).Error; err != nil {
+		return nil, err
+	}
+
+	overridden := map[uint]bool{}
+	if facility != DivisionFacility {
+		for _, f := range faqs {
+			if f.Facility == facility && f.Overrides != nil {
+				overridden[*f.Overrides] = true
+			}
+		}
+	}
+
+	shown := []FAQ{}
+	for _, f := range faqs {
+		if overridden[f.ID] {
+			continue
+		}
+		if f.Status == types.Draft && !includeDrafts {
+			continue
+		}
+		shown = append(shown, f)
+	}
+
+	sort.SliceStable(shown, func(i, j int) bool {
+		if shown[i].SortOrder != shown[j].SortOrder {
+			return shown[i].SortOrder < shown[j].SortOrder
+		}
+		return shown[i].ID < shown[j].ID
+	})
+
+	return shown, nil
+}
+
+// ReorderFAQ sets the sort order of the FAQs to their position in ids
+func ReorderFAQ(ids []uint) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		for i, id := range ids {
+			if err := tx.Model(&FAQ{}).Where("id = ?", id).Update("sort_order", i).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// PublishedFAQ limits a query to published FAQs
+func PublishedFAQ(db *gorm.DB) *gorm.DB {
+	return db.Where("status = ?", types.Published)
 }
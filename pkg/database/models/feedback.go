@@ -3,6 +3,8 @@ package models
 import (
 	"github.com/VATUSA/primary-api/pkg/database"
 	"github.com/VATUSA/primary-api/pkg/database/types"
+	"gorm.io/gorm"
+	"strconv"
 	"time"
 )
 
@@ -25,12 +27,44 @@ type Feedback struct {
 	UpdatedAt     time.Time            `json:"updated_at" example:"2021-01-01T00:00:00Z"`
 }
 
+// Create saves the feedback, raising feedback.approved if it is entered already approved
 func (f *Feedback) Create() error {
-	return database.DB.Create(f).Error
+	return CreateFeedback(database.DB, f)
 }
 
+// CreateFeedback is Create through db
+func CreateFeedback(db *gorm.DB, f *Feedback) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(f).Error; err != nil {
+			return err
+		}
+		if f.Status != types.Approved {
+			return nil
+		}
+		return emit(tx, FeedbackAggregate, strconv.FormatUint(uint64(f.ID), 10), types.FeedbackApproved, f.Facility, f)
+	})
+}
+
+// Update saves the feedback, raising feedback.approved when this change approves it
 func (f *Feedback) Update() error {
-	return database.DB.Save(f).Error
+	return UpdateFeedback(database.DB, f)
+}
+
+// UpdateFeedback is Update through db
+func UpdateFeedback(db *gorm.DB, f *Feedback) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var previous types.StatusType
+		if err := tx.Model(&Feedback{}).Where("id = ?", f.ID).Select("status").Scan(&previous).Error; err != nil {
+			return err
+		}
+		if err := tx.Save(f).Error; err != nil {
+			return err
+		}
+		if previous == types.Approved || f.Status != types.Approved {
+			return nil
+		}
+		return emit(tx, FeedbackAggregate, strconv.FormatUint(uint64(f.ID), 10), types.FeedbackApproved, f.Facility, f)
+	})
 }
 
 func (f *Feedback) Delete() error {
@@ -41,7 +75,7 @@ func (f *Feedback) Get() error {
 	return database.DB.Where("id = ?", f.ID).First(f).Error
 }
 
-func GetAllFeedback() ([]Feedback, error) {
+func GetAllFeedback(db *gorm.DB) ([]Feedback, error) {
 	var feedback []Feedback
-	return feedback, database.DB.Find(&feedback).Error
+	return feedback, db.Find(&feedback).Error
 }
@@ -2,18 +2,24 @@ package models
 
 import (
 	"github.com/VATUSA/primary-api/pkg/database"
+	"github.com/VATUSA/primary-api/pkg/database/types"
+	"gorm.io/gorm"
 	"time"
 )
 
 type News struct {
-	ID          uint      `json:"id" gorm:"primaryKey" example:"1"`
-	Facility    string    `json:"facility" example:"ZDV"`
-	Title       string    `json:"news" example:"DP001 Revision 3 Released"`
-	Description string    `json:"answer" example:"DP001 has been revised to include new information regarding the new VATSIM Code of Conduct"`
-	CreatedAt   time.Time `json:"created_at" example:"2021-01-01T00:00:00Z"`
-	CreatedBy   string    `json:"created_by" example:"'1293257' or 'System'"`
-	UpdatedAt   time.Time `json:"updated_at" example:"2021-01-01T00:00:00Z"`
-	UpdatedBy   string    `json:"updated_by" example:"1293257"`
+	ID          uint                `json:"id" gorm:"primaryKey" example:"1"`
+	Facility    string              `json:"facility" example:"ZDV"`
+	Title       string              `json:"title" example:"DP001 Revision 3 Released"`
+	Description string              `json:"description" example:"DP001 has been revised to include new information regarding the new VATSIM Code of Conduct"`
+	Status      types.PublishStatus `gorm:"type:enum('draft', 'scheduled', 'published');default:'published'" json:"status" example:"published"`
+	PublishAt   *time.Time          `json:"publish_at" example:"2021-01-01T00:00:00Z"`
+	ExpireAt    *time.Time          `json:"expire_at" example:"2021-02-01T00:00:00Z"`
+	Pinned      bool                `json:"pinned" example:"false"`
+	CreatedAt   time.Time           `json:"created_at" example:"2021-01-01T00:00:00Z"`
+	CreatedBy   string              `json:"created_by" example:"'1293257' or 'System'"`
+	UpdatedAt   time.Time           `json:"updated_at" example:"2021-01-01T00:00:00Z"`
+	UpdatedBy   string              `json:"updated_by" example:"1293257"`
 }
 
 func (n *News) Create() error {
@@ -32,7 +38,50 @@ func (n *News) Get() error {
 	return database.DB.Where("id = ?", n.ID).First(n).Error
 }
 
+// PublishedAt is when the news was, or will be, published. News from before scheduling uses its creation time.
+func (n *News) PublishedAt() time.Time {
+	if n.PublishAt != nil {
+		return *n.PublishAt
+	}
+	return n.CreatedAt
+}
+
+// IsLive reports whether the news is published and not yet expired at the given time
+func (n *News) IsLive(now time.Time) bool {
+	switch n.Status {
+	case types.Published:
+	case types.Scheduled:
+		if n.PublishAt == nil || n.PublishAt.After(now) {
+			return false
+		}
+	default:
+		return false
+	}
+	return n.ExpireAt == nil || n.ExpireAt.After(now)
+}
+
+// LiveNews limits a query to news that is published and not yet expired at the given time, matching IsLive
+func LiveNews(now time.Time) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("(status = ? OR (status = ? AND publish_at <= ?)) AND (expire_at IS NULL OR expire_at > ?)",
+			types.Published, types.Scheduled, now, now)
+	}
+}
+
 func GetAllNews() ([]News, error) {
 	var news []News
 	return news, database.DB.Find(&news).Error
 }
+
+// GetLiveNews returns the live news for a facility, including division news, pinned news first and then newest
+// first. A limit of zero returns everything.
+func GetLiveNews(facility string, now time.Time, limit int) ([]News, error) {
+	var news []News
+	query := database.DB.Scopes(LiveNews(now)).
+		Where("facility IN ?", []string{facility, DivisionFacility}).
+		Order("pinned DESC, COALESCE(publish_at, created_at) DESC, id DESC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	return news, query.Find(&news).Error
+}
@@ -9,14 +9,15 @@ import (
 // Expire Time can be the time of the session, or the time of the event
 
 type Notification struct {
-	ID        uint      `json:"id" gorm:"primaryKey" example:"1"`
-	CID       uint      `json:"cid" example:"1293257"`
-	Category  string    `json:"category" example:"Training"`
-	Title     string    `json:"title" example:"Upcoming Training Session"`
-	Body      string    `json:"body" example:"You have a training session coming up."`
-	ExpireAt  time.Time `json:"expire_at" example:"2021-01-01T00:00:00Z"`
-	CreatedAt time.Time `json:"created_at" example:"2021-01-01T00:00:00Z"`
-	UpdatedAt time.Time `json:"updated_at" example:"2021-01-01T00:00:00Z"`
+	ID          uint      `json:"id" gorm:"primaryKey" example:"1"`
+	CID         uint      `json:"cid" example:"1293257"`
+	BroadcastID *uint     `json:"broadcast_id,omitempty" example:"1"`
+	Category    string    `json:"category" example:"Training"`
+	Title       string    `json:"title" example:"Upcoming Training Session"`
+	Body        string    `json:"body" example:"You have a training session coming up."`
+	ExpireAt    time.Time `json:"expire_at" example:"2021-01-01T00:00:00Z"`
+	CreatedAt   time.Time `json:"created_at" example:"2021-01-01T00:00:00Z"`
+	UpdatedAt   time.Time `json:"updated_at" example:"2021-01-01T00:00:00Z"`
 }
 
 func (n *Notification) Create() error {
@@ -42,5 +43,11 @@ func GetAllNotifications() ([]Notification, error) {
 
 func GetAllActiveNotificationsByCID(db *gorm.DB, cid uint) ([]Notification, error) {
 	var notifications []Notification
-	return notifications, database.DB.Where("cid = ? AND expire_at > ?", cid, time.Now()).Find(&notifications).Error
+	return notifications, db.Where("c_id = ? AND expire_at > ?", cid, time.Now()).Find(&notifications).Error
+}
+
+// GetActiveNotificationsByCIDSince returns the unexpired notifications for cid created after the notification with the given ID
+func GetActiveNotificationsByCIDSince(cid uint, id uint) ([]Notification, error) {
+	var notifications []Notification
+	return notifications, database.DB.Where("c_id = ? AND id > ? AND expire_at > ?", cid, id, time.Now()).Order("id").Find(&notifications).Error
 }
@@ -0,0 +1,114 @@
+package models
+
+import (
+	"errors"
+	"github.com/VATUSA/primary-api/pkg/constants"
+	"github.com/VATUSA/primary-api/pkg/database"
+	"github.com/VATUSA/primary-api/pkg/database/types"
+	"gorm.io/gorm"
+	"time"
+)
+
+// BroadcastBatchSize is the number of per-user notifications inserted per statement when fanning out a broadcast
+const BroadcastBatchSize = 500
+
+// NotificationBroadcast records a notification sent to an audience rather than a single CID.
+// Audience holds the facility ID, role ID or group ID depending on AudienceType. Facility optionally
+// scopes role and group audiences to a single facility.
+type NotificationBroadcast struct {
+	ID             uint               `json:"id" gorm:"primaryKey" example:"1"`
+	AudienceType   types.AudienceType `gorm:"type:enum('facility', 'role', 'group');" json:"audience_type" example:"facility"`
+	Audience       string             `json:"audience" example:"ZDV"`
+	Facility       string             `json:"facility" example:"ZDV"`
+	HomeOnly       bool               `json:"home_only" example:"true"`
+	Category       string             `json:"category" example:"Training"`
+	Title          string             `json:"title" example:"Upcoming Training Session"`
+	Body           string             `json:"body" example:"You have a training session coming up."`
+	ExpireAt       time.Time          `json:"expire_at" example:"2021-01-01T00:00:00Z"`
+	RecipientCount int                `json:"recipient_count" example:"42"`
+	Notifications  []Notification     `json:"-" gorm:"foreignKey:BroadcastID"`
+	CreatedAt      time.Time          `json:"created_at" example:"2021-01-01T00:00:00Z"`
+	CreatedBy      uint               `json:"created_by" example:"1293257"`
+}
+
+// Send resolves the broadcast's recipients and records the broadcast together with one notification
+// per recipient in a single transaction.
+func (b *NotificationBroadcast) Send() ([]Notification, error) {
+	cids, err := b.Recipients(database.DB)
+	if err != nil {
+		return nil, err
+	}
+
+	notifications := make([]Notification, 0, len(cids))
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		b.RecipientCount = len(cids)
+		if err := tx.Create(b).Error; err != nil {
+			return err
+		}
+
+		for _, cid := range cids {
+			notifications = append(notifications, Notification{
+				CID:         cid,
+				BroadcastID: &b.ID,
+				Category:    b.Category,
+				Title:       b.Title,
+				Body:        b.Body,
+				ExpireAt:    b.ExpireAt,
+			})
+		}
+
+		if len(notifications) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(&notifications, BroadcastBatchSize).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return notifications, nil
+}
+
+// Recipients returns the distinct CIDs that make up the broadcast's audience
+func (b *NotificationBroadcast) Recipients(db *gorm.DB) ([]uint, error) {
+	var cids []uint
+
+	switch b.AudienceType {
+	case types.FacilityAudience:
+		query := db.Model(&Roster{}).Where("facility = ?", b.Audience)
+		if b.HomeOnly {
+			query = query.Where("home = ?", true)
+		}
+		return cids, query.Distinct().Pluck("c_id", &cids).Error
+	case types.RoleAudience:
+		return recipientsByRoles(db, []constants.RoleID{constants.RoleID(b.Audience)}, b.Facility)
+	case types.GroupAudience:
+		roles := constants.GroupID(b.Audience).Roles()
+		if len(roles) == 0 {
+			return cids, nil
+		}
+		return recipientsByRoles(db, roles, b.Facility)
+	}
+
+	return nil, errors.New("invalid audience type")
+}
+
+func recipientsByRoles(db *gorm.DB, roles []constants.RoleID, facility string) ([]uint, error) {
+	var cids []uint
+
+	query := db.Model(&UserRole{}).Where("role_id IN ?", roles)
+	if facility != "" {
+		query = query.Where("facility_id = ?", facility)
+	}
+
+	return cids, query.Distinct().Pluck("c_id", &cids).Error
+}
+
+func (b *NotificationBroadcast) Get() error {
+	return database.DB.Where("id = ?", b.ID).First(b).Error
+}
+
+func GetAllNotificationBroadcasts() ([]NotificationBroadcast, error) {
+	var broadcasts []NotificationBroadcast
+	return broadcasts, database.DB.Order("created_at desc").Find(&broadcasts).Error
+}
@@ -0,0 +1,109 @@
+package models
+
+import (
+	"encoding/json"
+	"github.com/VATUSA/primary-api/pkg/database"
+	"github.com/VATUSA/primary-api/pkg/database/types"
+	"gorm.io/gorm"
+	"time"
+)
+
+// Aggregates that domain events are ordered within. Roster, role and rating events belong to the member.
+const (
+	UserAggregate     = "user"
+	DocumentAggregate = "document"
+	FeedbackAggregate = "feedback"
+)
+
+// OutboxEvent is a domain event written in the same transaction as the change that raised it. The dispatcher
+// hands it to every consumer, at least once and in order within its aggregate, unless it keeps failing and is
+// set aside as dead.
+type OutboxEvent struct {
+	ID            uint            `json:"id" gorm:"primaryKey" example:"1"`
+	AggregateType string          `json:"aggregate_type" gorm:"size:32;index:idx_outbox_aggregate" example:"user"`
+	AggregateID   string          `json:"aggregate_id" gorm:"size:64;index:idx_outbox_aggregate" example:"1293257"`
+	Type          types.EventType `json:"type" gorm:"type:varchar(64)" example:"roster.added"`
+	Facility      string          `json:"facility" gorm:"size:3" example:"ZDV"` // Empty when the event isn't tied to one facility
+	Payload       string          `json:"payload" gorm:"type:text"`
+	HandledBy     string          `json:"handled_by" example:"webhook,notification"` // Consumers that have handled it, comma separated
+	Attempts      int             `json:"attempts" example:"0"`
+	NextAttemptAt time.Time       `json:"next_attempt_at" example:"2021-01-01T00:00:00Z"`
+	LastError     string          `json:"last_error" gorm:"size:512" example:""`
+	ProcessedAt   *time.Time      `json:"processed_at" gorm:"index" example:"2021-01-01T00:00:01Z"`
+	DeadAt        *time.Time      `json:"dead_at" example:"2021-01-02T00:00:00Z"` // When the dispatcher gave up on it
+	CreatedAt     time.Time       `json:"created_at" example:"2021-01-01T00:00:00Z"`
+}
+
+// NewOutboxEvent encodes data as the payload of an event
+func NewOutboxEvent(aggregateType, aggregateID string, eventType types.EventType, facility string, data interface{}) (OutboxEvent, error) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return OutboxEvent{}, err
+	}
+
+	return OutboxEvent{
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		Type:          eventType,
+		Facility:      facility,
+		Payload:       string(payload),
+		NextAttemptAt: time.Now(),
+	}, nil
+}
+
+// WriteOutboxEvents adds the events to the outbox through tx, which should be the transaction making the change
+func WriteOutboxEvents(tx *gorm.DB, events ...OutboxEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	return tx.Create(&events).Error
+}
+
+// emit is a helper for model methods that raise a single event inside their transaction
+func emit(tx *gorm.DB, aggregateType, aggregateID string, eventType types.EventType, facility string, data interface{}) error {
+	event, err := NewOutboxEvent(aggregateType, aggregateID, eventType, facility, data)
+	if err != nil {
+		return err
+	}
+	return WriteOutboxEvents(tx, event)
+}
+
+func (e *OutboxEvent) Update() error {
+	return database.DB.Save(e).Error
+}
+
+// Claim pushes a due event's next attempt back by lease so no other dispatcher picks it up while it is being
+// handled. It returns false if another dispatcher claimed it first.
+func (e *OutboxEvent) Claim(now time.Time, lease time.Duration) (bool, error) {
+	until := now.Add(lease)
+	result := database.DB.Model(&OutboxEvent{}).Scopes(pendingOutboxEvents).
+		Where("id = ? AND next_attempt_at <= ?", e.ID, now).
+		Update("next_attempt_at", until)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return false, result.Error
+	}
+	e.NextAttemptAt = until
+	return true, nil
+}
+
+// pendingOutboxEvents limits a query to events that are neither processed nor dead
+func pendingOutboxEvents(db *gorm.DB) *gorm.DB {
+	return db.Where("processed_at IS NULL AND dead_at IS NULL")
+}
+
+// GetDueOutboxEvents returns the oldest pending event of each aggregate, if it is due, oldest first. Later events
+// wait behind their aggregate's head, so an aggregate with a backlog doesn't fill the batch.
+func GetDueOutboxEvents(now time.Time, limit int) ([]OutboxEvent, error) {
+	heads := database.DB.Model(&OutboxEvent{}).Scopes(pendingOutboxEvents).
+		Select("MIN(id)").Group("aggregate_type, aggregate_id")
+
+	var events []OutboxEvent
+	return events, database.DB.Where("id IN (?) AND next_attempt_at <= ?", heads, now).
+		Order("id").Limit(limit).Find(&events).Error
+}
+
+// DeleteProcessedOutboxEvents removes events processed before the given time
+func DeleteProcessedOutboxEvents(before time.Time) (int64, error) {
+	result := database.DB.Where("processed_at < ?", before).Delete(&OutboxEvent{})
+	return result.RowsAffected, result.Error
+}
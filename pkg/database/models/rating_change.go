@@ -2,7 +2,9 @@ package models
 
 import (
 	"github.com/VATUSA/primary-api/pkg/database"
+	"github.com/VATUSA/primary-api/pkg/database/types"
 	"gorm.io/gorm"
+	"strconv"
 	"time"
 )
 
@@ -16,8 +18,19 @@ type RatingChange struct {
 	UpdatedAt    time.Time `json:"updated_at" example:"2021-01-01T00:00:00Z"`
 }
 
+// Create records the rating change and raises rating.changed for the member
 func (rc *RatingChange) Create() error {
-	return database.DB.Create(rc).Error
+	return CreateRatingChange(database.DB, rc)
+}
+
+// CreateRatingChange is Create through db, so the change can be saved along with the caller's other writes
+func CreateRatingChange(db *gorm.DB, rc *RatingChange) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(rc).Error; err != nil {
+			return err
+		}
+		return emit(tx, UserAggregate, strconv.FormatUint(uint64(rc.CID), 10), types.RatingChanged, "", rc)
+	})
 }
 
 func (rc *RatingChange) Update() error {
@@ -39,5 +52,5 @@ func GetAllRatingChanges() ([]RatingChange, error) {
 
 func GetAllRatingChangesByCID(db *gorm.DB, cid uint) ([]RatingChange, error) {
 	var ratingChanges []RatingChange
-	return ratingChanges, database.DB.Where("cid = ?", cid).Find(&ratingChanges).Error
+	return ratingChanges, db.Where("c_id = ?", cid).Find(&ratingChanges).Error
 }
@@ -3,7 +3,10 @@ package models
 import (
 	"errors"
 	"github.com/VATUSA/primary-api/pkg/database"
+	"github.com/VATUSA/primary-api/pkg/database/types"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"strconv"
 	"time"
 )
 
@@ -20,57 +23,154 @@ type Roster struct {
 	CreatedAt  time.Time `json:"created_at" example:"2021-01-01T00:00:00Z"`
 	UpdatedAt  time.Time `json:"updated_at" example:"2021-01-01T00:00:00Z"`
 	DeletedAt  time.Time `json:"deleted_at" example:"2021-01-01T00:00:00Z"` // Soft Deletes for logging
+
+	Certifications []Certification `json:"certifications" gorm:"foreignKey:RosterID"`
 }
 
+// Create adds the member to the facility roster and raises roster.added
 func (r *Roster) Create() error {
+	return CreateRoster(database.DB, r)
+}
+
+// CreateRoster adds the member to the facility roster through db and raises roster.added
+func CreateRoster(db *gorm.DB, r *Roster) error {
+	return db.Transaction(r.create)
+}
+
+func (r *Roster) create(tx *gorm.DB) error {
 	// Check and see if user is already on the roster\
-	if err := database.DB.Where("cid = ? AND facility = ?", r.CID, r.Facility).First(&User{}).Error; err == nil {
+	if err := tx.Where("c_id = ? AND facility = ?", r.CID, r.Facility).First(&Roster{}).Error; err == nil {
 		return errors.New("user already exists on facility roster")
 	}
 
-	user := &User{CID: r.CID}
-	if err := user.Get(); err != nil {
+	user := &User{}
+	if err := tx.Where("c_id = ?", r.CID).First(user).Error; err != nil {
 		return errors.New("user not found")
 	}
 
 	// See if preferred OIs are already taken
-	if err := database.DB.Where("ois = ? AND facility = ?", user.PreferredOIs, r.Facility).First(&User{}).Error; err == nil {
+	if err := tx.Where("o_is = ? AND facility = ?", user.PreferredOIs, r.Facility).First(&Roster{}).Error; err == nil {
 		// OIs are taken so try first and last initial
-		if err := database.DB.Where("ois = ? AND facility = ?", user.FirstName[:1]+user.LastName[:1], r.Facility).First(&User{}).Error; err == nil {
-			// First and last initial are taken so just use first available OIs
-			return database.DB.Create(r).Error
+		if err := tx.Where("o_is = ? AND facility = ?", user.FirstName[:1]+user.LastName[:1], r.Facility).First(&Roster{}).Error; err != nil {
+			r.OIs = user.FirstName[:1] + user.LastName[:1]
 		}
-		r.OIs = user.FirstName[:1] + user.LastName[:1]
-		return database.DB.Create(r).Error
+		// Otherwise first and last initial are taken so just use first available OIs
+	} else {
+		r.OIs = user.PreferredOIs
 	}
 
-	r.OIs = user.PreferredOIs
-	return database.DB.Create(r).Error
+	if err := tx.Create(r).Error; err != nil {
+		return err
+	}
+	return r.emit(tx, types.RosterAdded)
+}
+
+func (r *Roster) emit(tx *gorm.DB, eventType types.EventType) error {
+	return emit(tx, UserAggregate, strconv.FormatUint(uint64(r.CID), 10), eventType, r.Facility, r)
 }
 
+// Update saves the roster entry, keeping its certifications in step with the member and facility
 func (r *Roster) Update() error {
-	return database.DB.Save(r).Error
+	return UpdateRoster(database.DB, r)
 }
 
+// UpdateRoster is Update through db
+func UpdateRoster(db *gorm.DB, r *Roster) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Save(r).Error; err != nil {
+			return err
+		}
+		return tx.Model(&Certification{}).Where("roster_id = ?", r.ID).
+			Updates(map[string]interface{}{"c_id": r.CID, "facility": r.Facility}).Error
+	})
+}
+
+// Delete removes the roster entry along with the certifications held through it and raises roster.removed
 func (r *Roster) Delete() error {
-	return database.DB.Delete(r).Error
+	return DeleteRoster(database.DB, r)
+}
+
+// DeleteRoster is Delete through db
+func DeleteRoster(db *gorm.DB, r *Roster) error {
+	return db.Transaction(r.delete)
+}
+
+func (r *Roster) delete(tx *gorm.DB) error {
+	if err := tx.Where("roster_id = ?", r.ID).Delete(&Certification{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Delete(r).Error; err != nil {
+		return err
+	}
+	return r.emit(tx, types.RosterRemoved)
 }
 
 func (r *Roster) Get() error {
-	return database.DB.Where("id = ?", r.ID).First(r).Error
+	return GetRoster(database.DB, r)
 }
 
-func GetAllRosters() ([]Roster, error) {
+// GetRoster loads the roster entry with r's ID through db, along with its certifications
+func GetRoster(db *gorm.DB, r *Roster) error {
+	return db.Where("id = ?", r.ID).Preload("Certifications", orderCertifications).First(r).Error
+}
+
+func orderCertifications(db *gorm.DB) *gorm.DB {
+	return db.Order("type, position")
+}
+
+func GetAllRosters(db *gorm.DB) ([]Roster, error) {
 	var rosters []Roster
-	return rosters, database.DB.Find(&rosters).Error
+	return rosters, db.Preload("Certifications", orderCertifications).Find(&rosters).Error
 }
 
 func GetAllRostersByCID(db *gorm.DB, cid uint) ([]Roster, error) {
 	var rosters []Roster
-	return rosters, db.Where("cid = ?", cid).Find(&rosters).Error
+	return rosters, db.Where("c_id = ?", cid).Preload("Certifications", orderCertifications).Find(&rosters).Error
 }
 
 func GetAllRostersByFacility(db *gorm.DB, facility string) ([]Roster, error) {
 	var rosters []Roster
-	return rosters, db.Where("facility = ?", facility).Find(&rosters).Error
+	return rosters, db.Where("facility = ?", facility).Preload("Certifications", orderCertifications).Find(&rosters).Error
+}
+
+// IsOnRoster reports whether the user is on the facility's roster, as a home or visiting controller
+func IsOnRoster(cid uint, facility string) bool {
+	var count int64
+	database.DB.Model(&Roster{}).Where("c_id = ? AND facility = ?", cid, facility).Count(&count)
+	return count > 0
+}
+
+// GetHomeCIDs returns the CIDs with a home roster entry at any facility other than those given
+func GetHomeCIDs(except ...string) ([]uint, error) {
+	var cids []uint
+	query := database.DB.Model(&Roster{}).Distinct("c_id").Where("home = ?", true)
+	if len(except) > 0 {
+		query = query.Where("facility NOT IN ?", except)
+	}
+	return cids, query.Pluck("c_id", &cids).Error
+}
+
+// MoveToFacility replaces every roster entry the member holds, along with their certifications, with a home
+// entry at the facility. It reports false without changing anything if that is already their only entry.
+func MoveToFacility(cid uint, facility string) (bool, error) {
+	var rosters []Roster
+	if err := database.DB.Where("c_id = ?", cid).Find(&rosters).Error; err != nil {
+		return false, err
+	}
+	if len(rosters) == 1 && rosters[0].Facility == facility && rosters[0].Home {
+		return false, nil
+	}
+
+	return true, database.DB.Transaction(func(tx *gorm.DB) error {
+		for i := range rosters {
+			if err := rosters[i].delete(tx); err != nil {
+				return err
+			}
+		}
+		added := &Roster{CID: cid, Facility: facility, Home: true, Status: "active"}
+		if err := tx.Create(added).Error; err != nil {
+			return err
+		}
+		return added.emit(tx, types.RosterAdded)
+	})
 }
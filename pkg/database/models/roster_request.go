@@ -26,6 +26,16 @@ func (rr *RosterRequest) Update() error {
 	return database.DB.Save(rr).Error
 }
 
+// Accept saves the request along with the roster entry it grants, in a single transaction
+func (rr *RosterRequest) Accept(roster *Roster) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := roster.create(tx); err != nil {
+			return err
+		}
+		return tx.Save(rr).Error
+	})
+}
+
 func (rr *RosterRequest) Delete() error {
 	return database.DB.Delete(rr).Error
 }
@@ -41,22 +51,22 @@ func GetAllRosterRequests() ([]RosterRequest, error) {
 
 func GetAllRosterRequestsByCID(db *gorm.DB, cid uint) ([]RosterRequest, error) {
 	var rosterRequests []RosterRequest
-	return rosterRequests, database.DB.Where("cid = ?", cid).Find(&rosterRequests).Error
+	return rosterRequests, db.Where("c_id = ?", cid).Find(&rosterRequests).Error
 }
 
 func GetAllRosterRequestsByFacility(db *gorm.DB, facility string) ([]RosterRequest, error) {
 	var rosterRequests []RosterRequest
-	return rosterRequests, database.DB.Where("requested_facility = ?", facility).Find(&rosterRequests).Error
+	return rosterRequests, db.Where("requested_facility = ?", facility).Find(&rosterRequests).Error
 }
 
 func GetAllPendingVisitingRequestsByCID(db *gorm.DB, cid uint) ([]RosterRequest, error) {
 	var rosterRequests []RosterRequest
-	return rosterRequests, database.DB.Where("cid = ? AND request_type = ? AND status = ?", cid, types.Visiting, types.Pending).Find(&rosterRequests).Error
+	return rosterRequests, db.Where("c_id = ? AND request_type = ? AND status = ?", cid, types.Visiting, types.Pending).Find(&rosterRequests).Error
 }
 
 func GetAllPendingTransferringRequestsByCID(db *gorm.DB, cid uint) ([]RosterRequest, error) {
 	var rosterRequests []RosterRequest
-	return rosterRequests, database.DB.Where("cid = ? AND request_type = ? AND status = ?", cid, types.Transferring, types.Pending).Find(&rosterRequests).Error
+	return rosterRequests, db.Where("c_id = ? AND request_type = ? AND status = ?", cid, types.Transferring, types.Pending).Find(&rosterRequests).Error
 }
 
 func GetAllPendingVisitingRequestsByFacility(db *gorm.DB, facility string) ([]RosterRequest, error) {
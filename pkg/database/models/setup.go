@@ -5,24 +5,51 @@ import (
 	"log"
 )
 
+// Models are every model with a table. New columns and tables need a migration in pkg/database/migrations as
+// well as a model change.
+var Models = []interface{}{
+	&Facility{},
+	&User{},
+	&ActionLogEntry{},
+	&APIKey{},
+	&CallsignPrefix{},
+	&Certification{},
+	&ControllingSession{},
+	&DiscordRoleMapping{},
+	&DisciplinaryLogEntry{},
+	&Document{},
+	&DocumentVersion{},
+	&Event{},
+	&EventFacility{},
+	&EventPosition{},
+	&EventSignup{},
+	&FacilityLogEntry{},
+	&FAQ{},
+	&Feedback{},
+	&News{},
+	&Notification{},
+	&NotificationBroadcast{},
+	&OutboxEvent{},
+	&RatingChange{},
+	&Roster{},
+	&RosterRequest{},
+	&TrainingAvailability{},
+	&TrainingProgression{},
+	&TrainingRequest{},
+	&TrainingSession{},
+	&TrainingStep{},
+	&TrainingStepCompletion{},
+	&UserFlag{},
+	&UserRole{},
+	&WebhookDelivery{},
+	&WebhookSubscription{},
+	&WebhookSubscriptionEvent{},
+}
+
+// AutoMigrate creates the schema straight from the models. The API's schema is managed by the versioned migrations
+// in pkg/database/migrations; this is for test databases, which aren't MySQL.
 func AutoMigrate() {
-	err := database.DB.AutoMigrate(
-		&Facility{},
-		&User{},
-		&ActionLogEntry{},
-		&DisciplinaryLogEntry{},
-		&Document{},
-		&FacilityLogEntry{},
-		&FAQ{},
-		&Feedback{},
-		&News{},
-		&Notification{},
-		&RatingChange{},
-		&Roster{},
-		&RosterRequest{},
-		&UserFlag{},
-		&UserRole{},
-	)
+	err := database.DB.AutoMigrate(Models...)
 	if err != nil {
 		log.Fatal("[Database] Migration Error:", err)
 	}
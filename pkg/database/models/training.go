@@ -0,0 +1,233 @@
+package models
+
+import (
+	"github.com/VATUSA/primary-api/pkg/constants"
+	"github.com/VATUSA/primary-api/pkg/database"
+	"github.com/VATUSA/primary-api/pkg/database/types"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// TrainingSession is a record of a single training session given to a student
+type TrainingSession struct {
+	ID              uint                     `json:"id" gorm:"primaryKey" example:"1"`
+	CID             uint                     `json:"cid" gorm:"index" example:"1293257"`
+	InstructorCID   uint                     `json:"instructor_cid" gorm:"index" example:"1000001"`
+	Facility        string                   `json:"facility" gorm:"size:3;index" example:"ZDV"`
+	Position        string                   `json:"position" example:"DEN_APP"`
+	Type            types.SessionType        `gorm:"type:enum('classroom', 'sweatbox', 'live', 'ots');" json:"type" example:"sweatbox"`
+	StartAt         time.Time                `json:"start_at" example:"2021-01-01T00:00:00Z"`
+	DurationMinutes uint                     `json:"duration_minutes" example:"90"`
+	Score           *uint                    `json:"score" example:"4"` // 1 to 5
+	Notes           string                   `json:"notes" gorm:"type:text" example:"Worked on sequencing into 17R"`
+	Completions     []TrainingStepCompletion `json:"completed_steps" gorm:"foreignKey:SessionID"`
+	CreatedAt       time.Time                `json:"created_at" example:"2021-01-01T00:00:00Z"`
+	UpdatedAt       time.Time                `json:"updated_at" example:"2021-01-01T00:00:00Z"`
+}
+
+// TrainingProgression is a facility's ordered list of steps that make up a training program, such as
+// the path to a tower certification
+type TrainingProgression struct {
+	ID          uint           `json:"id" gorm:"primaryKey" example:"1"`
+	Facility    string         `json:"facility" gorm:"size:3;index" example:"ZDV"`
+	Name        string         `json:"name" example:"S2 Tower"`
+	Description string         `json:"description" example:"From S1 to a tower certification at DEN"`
+	Steps       []TrainingStep `json:"steps" gorm:"foreignKey:ProgressionID"`
+	CreatedAt   time.Time      `json:"created_at" example:"2021-01-01T00:00:00Z"`
+	UpdatedAt   time.Time      `json:"updated_at" example:"2021-01-01T00:00:00Z"`
+}
+
+type TrainingStep struct {
+	ID            uint   `json:"id" gorm:"primaryKey" example:"1"`
+	ProgressionID uint   `json:"progression_id" gorm:"index" example:"1"`
+	SortOrder     int    `json:"sort_order" example:"1"`
+	Name          string `json:"name" example:"Ground control basics"`
+	Position      string `json:"position" example:"DEN_GND"`
+}
+
+// TrainingStepCompletion records a student completing a step, usually during a session
+type TrainingStepCompletion struct {
+	ID          uint      `json:"id" gorm:"primaryKey" example:"1"`
+	CID         uint      `json:"cid" gorm:"uniqueIndex:idx_training_completion" example:"1293257"`
+	StepID      uint      `json:"step_id" gorm:"uniqueIndex:idx_training_completion" example:"1"`
+	SessionID   *uint     `json:"session_id" gorm:"index" example:"1"`
+	CompletedBy uint      `json:"completed_by" example:"1000001"`
+	CreatedAt   time.Time `json:"created_at" example:"2021-01-01T00:00:00Z"`
+}
+
+// CreateWithCompletions saves the session and marks the steps complete for its student. Steps the student
+// has already completed are left as they were.
+func (s *TrainingSession) CreateWithCompletions(steps []uint) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Create(s).Error; err != nil {
+			return err
+		}
+		return completeSteps(tx, s, steps)
+	})
+}
+
+// UpdateWithCompletions saves the session and adds completions for any new steps
+func (s *TrainingSession) UpdateWithCompletions(steps []uint) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Save(s).Error; err != nil {
+			return err
+		}
+		return completeSteps(tx, s, steps)
+	})
+}
+
+func completeSteps(tx *gorm.DB, s *TrainingSession, steps []uint) error {
+	for _, step := range steps {
+		completion := TrainingStepCompletion{CID: s.CID, StepID: step, SessionID: &s.ID, CompletedBy: s.InstructorCID}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&completion)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected > 0 {
+			s.Completions = append(s.Completions, completion)
+		}
+	}
+	return nil
+}
+
+// Delete removes the session. Steps it completed stay complete but are no longer tied to a session.
+func (s *TrainingSession) Delete() error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&TrainingStepCompletion{}).Where("session_id = ?", s.ID).Update("session_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(s).Error
+	})
+}
+
+func (s *TrainingSession) Get() error {
+	return database.DB.Where("id = ?", s.ID).Preload("Completions").First(s).Error
+}
+
+// GetTrainingSessionsByCID returns a student's sessions, newest first
+func GetTrainingSessionsByCID(cid uint) ([]TrainingSession, error) {
+	var sessions []TrainingSession
+	return sessions, database.DB.Where("c_id = ?", cid).Preload("Completions").Order("start_at DESC").Find(&sessions).Error
+}
+
+// GetTrainingStepCompletionsByCID returns every step the student has completed
+func GetTrainingStepCompletionsByCID(cid uint) ([]TrainingStepCompletion, error) {
+	var completions []TrainingStepCompletion
+	return completions, database.DB.Where("c_id = ?", cid).Find(&completions).Error
+}
+
+func (p *TrainingProgression) Create() error {
+	return database.DB.Create(p).Error
+}
+
+// Update saves the progression and replaces its steps with p.Steps. Completions of steps that are kept
+// are unaffected.
+func (p *TrainingProgression) Update() error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Save(p).Error; err != nil {
+			return err
+		}
+
+		keep := []uint{0}
+		for i := range p.Steps {
+			p.Steps[i].ProgressionID = p.ID
+			if err := tx.Save(&p.Steps[i]).Error; err != nil {
+				return err
+			}
+			keep = append(keep, p.Steps[i].ID)
+		}
+
+		removed := tx.Model(&TrainingStep{}).Select("id").Where("progression_id = ? AND id NOT IN ?", p.ID, keep)
+		if err := tx.Where("step_id IN (?)", removed).Delete(&TrainingStepCompletion{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("progression_id = ? AND id NOT IN ?", p.ID, keep).Delete(&TrainingStep{}).Error
+	})
+}
+
+// Delete removes the progression with its steps and their completions
+func (p *TrainingProgression) Delete() error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		steps := tx.Model(&TrainingStep{}).Select("id").Where("progression_id = ?", p.ID)
+		if err := tx.Where("step_id IN (?)", steps).Delete(&TrainingStepCompletion{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("progression_id = ?", p.ID).Delete(&TrainingStep{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(p).Error
+	})
+}
+
+func (p *TrainingProgression) Get() error {
+	return database.DB.Where("id = ?", p.ID).Preload("Steps", orderSteps).First(p).Error
+}
+
+func orderSteps(db *gorm.DB) *gorm.DB {
+	return db.Order("sort_order, id")
+}
+
+// GetTrainingProgressions returns the progressions, only those of a facility if one is given
+func GetTrainingProgressions(facility string) ([]TrainingProgression, error) {
+	var progressions []TrainingProgression
+	query := database.DB.Preload("Steps", orderSteps).Order("facility, name")
+	if facility != "" {
+		query = query.Where("facility = ?", facility)
+	}
+	return progressions, query.Find(&progressions).Error
+}
+
+// GetTrainingStepsForFacility returns the steps of every progression the facility has
+func GetTrainingStepsForFacility(facility string) ([]TrainingStep, error) {
+	var steps []TrainingStep
+	return steps, database.DB.Where("progression_id IN (?)",
+		database.DB.Model(&TrainingProgression{}).Select("id").Where("facility = ?", facility)).Find(&steps).Error
+}
+
+// IsTrainingBlocked reports whether the user has a NoTraining flag
+func IsTrainingBlocked(cid uint) (bool, error) {
+	var count int64
+	err := database.DB.Model(&UserFlag{}).Where("c_id = ? AND no_training = ?", cid, true).Count(&count).Error
+	return count > 0, err
+}
+
+// CanTrain reports whether the user may record training for students of the facility. Division staff and
+// division training staff can train anywhere; otherwise the user needs a training or management role at the
+// facility, or to be marked as a mentor or instructor on its roster.
+func (u *User) CanTrain(facility string) bool {
+	if u.CanManageTraining(facility) {
+		return true
+	}
+
+	for _, role := range u.Roles {
+		if role.FacilityID == facility && role.RoleID.InGroup(constants.FacilityTraining) {
+			return true
+		}
+	}
+
+	var count int64
+	database.DB.Model(&Roster{}).Where("c_id = ? AND facility = ? AND (mentor = ? OR instructor = ?)", u.CID, facility, true, true).Count(&count)
+	return count > 0
+}
+
+// CanManageTraining reports whether the user may change the facility's training progressions
+func (u *User) CanManageTraining(facility string) bool {
+	if u.IsDivisionStaff() {
+		return true
+	}
+
+	for _, role := range u.Roles {
+		if role.RoleID.InGroup(constants.DivisionTraining) {
+			return true
+		}
+		if role.FacilityID != facility {
+			continue
+		}
+		if role.RoleID == constants.TrainingAdministratorRole || role.RoleID.InGroup(constants.FacilityManagement) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,195 @@
+package models
+
+import (
+	"github.com/VATUSA/primary-api/pkg/database"
+	"github.com/VATUSA/primary-api/pkg/database/types"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// TrainingRequest is a student's place in a facility's training queue
+type TrainingRequest struct {
+	ID           uint                        `json:"id" gorm:"primaryKey" example:"1"`
+	CID          uint                        `json:"cid" gorm:"index" example:"1293257"`
+	Facility     string                      `json:"facility" gorm:"size:3;index" example:"ZDV"`
+	Position     string                      `json:"position" example:"DEN_TWR"`
+	Notes        string                      `json:"notes" gorm:"type:text" example:"Looking to start tower training"`
+	Status       types.TrainingRequestStatus `json:"status" gorm:"type:enum('open', 'assigned', 'completed', 'cancelled');default:'open';index" example:"open"`
+	AssignedCID  *uint                       `json:"assigned_cid" example:"1000001"`
+	AssignedAt   *time.Time                  `json:"assigned_at" example:"2021-01-02T00:00:00Z"`
+	Availability []TrainingAvailability      `json:"availability" gorm:"foreignKey:RequestID"`
+	CreatedAt    time.Time                   `json:"created_at" example:"2021-01-01T00:00:00Z"`
+	UpdatedAt    time.Time                   `json:"updated_at" example:"2021-01-01T00:00:00Z"`
+}
+
+// TrainingAvailability is a window in which the student is available for training
+type TrainingAvailability struct {
+	ID        uint      `json:"id" gorm:"primaryKey" example:"1"`
+	RequestID uint      `json:"request_id" gorm:"index" example:"1"`
+	StartAt   time.Time `json:"start_at" example:"2021-01-03T18:00:00Z"`
+	EndAt     time.Time `json:"end_at" example:"2021-01-03T22:00:00Z"`
+}
+
+// TrainingQueueStats summarizes a facility's training queue
+type TrainingQueueStats struct {
+	Facility           string     `json:"facility" example:"ZDV"`
+	Open               int        `json:"open" example:"12"`
+	Assigned           int        `json:"assigned" example:"4"`
+	OldestOpenAt       *time.Time `json:"oldest_open_at" example:"2021-01-01T00:00:00Z"`
+	AverageWaitMinutes int64      `json:"average_wait_minutes" example:"4320"` // Created to picked up, over the window
+	LongestWaitMinutes int64      `json:"longest_wait_minutes" example:"20160"`
+	PickedUp           int        `json:"picked_up" example:"9"` // Picked up within the window
+	WindowDays         int        `json:"window_days" example:"90"`
+}
+
+func (t *TrainingRequest) Create() error {
+	return database.DB.Create(t).Error
+}
+
+// Update saves the request, replacing its availability windows
+func (t *TrainingRequest) Update() error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Save(t).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("request_id = ?", t.ID).Delete(&TrainingAvailability{}).Error; err != nil {
+			return err
+		}
+		for i := range t.Availability {
+			t.Availability[i].ID = 0
+			t.Availability[i].RequestID = t.ID
+		}
+		if len(t.Availability) == 0 {
+			return nil
+		}
+		return tx.Create(&t.Availability).Error
+	})
+}
+
+// Assign hands an open request to an instructor. It returns false if the request was no longer open, such as
+// when another instructor picked it up first.
+func (t *TrainingRequest) Assign(cid uint, now time.Time) (bool, error) {
+	result := database.DB.Model(&TrainingRequest{}).
+		Where("id = ? AND status = ?", t.ID, types.OpenTrainingRequest).
+		Updates(map[string]interface{}{"status": types.AssignedTrainingRequest, "assigned_c_id": cid, "assigned_at": now})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return false, result.Error
+	}
+	return true, t.Get()
+}
+
+// Release puts an assigned request back in the queue, keeping its place. It returns false if the request
+// changed since it was loaded, such as when it was completed or handed to another instructor.
+func (t *TrainingRequest) Release() (bool, error) {
+	ok, err := t.transition(map[string]interface{}{"status": types.OpenTrainingRequest, "assigned_c_id": nil, "assigned_at": nil})
+	if ok {
+		t.Status = types.OpenTrainingRequest
+		t.AssignedCID = nil
+		t.AssignedAt = nil
+	}
+	return ok, err
+}
+
+// SetStatus moves the request to status. It returns false if the request changed since it was loaded.
+func (t *TrainingRequest) SetStatus(status types.TrainingRequestStatus) (bool, error) {
+	ok, err := t.transition(map[string]interface{}{"status": status})
+	if ok {
+		t.Status = status
+	}
+	return ok, err
+}
+
+// transition applies updates only while the request still has the status and instructor it was loaded with
+func (t *TrainingRequest) transition(updates map[string]interface{}) (bool, error) {
+	query := database.DB.Model(&TrainingRequest{}).Where("id = ? AND status = ?", t.ID, t.Status)
+	if t.AssignedCID == nil {
+		query = query.Where("assigned_c_id IS NULL")
+	} else {
+		query = query.Where("assigned_c_id = ?", *t.AssignedCID)
+	}
+
+	result := query.Updates(updates)
+	return result.Error == nil && result.RowsAffected == 1, result.Error
+}
+
+func (t *TrainingRequest) Delete() error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("request_id = ?", t.ID).Delete(&TrainingAvailability{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(t).Error
+	})
+}
+
+func (t *TrainingRequest) Get() error {
+	return database.DB.Where("id = ?", t.ID).Preload("Availability", orderAvailability).First(t).Error
+}
+
+func orderAvailability(db *gorm.DB) *gorm.DB {
+	return db.Order("start_at")
+}
+
+// GetTrainingQueue returns a facility's requests with the given status, oldest first
+func GetTrainingQueue(facility string, status types.TrainingRequestStatus) ([]TrainingRequest, error) {
+	var requests []TrainingRequest
+	return requests, database.DB.Where("facility = ? AND status = ?", facility, status).
+		Preload("Availability", orderAvailability).Order("created_at, id").Find(&requests).Error
+}
+
+func GetTrainingRequestsByCID(cid uint) ([]TrainingRequest, error) {
+	var requests []TrainingRequest
+	return requests, database.DB.Where("c_id = ?", cid).
+		Preload("Availability", orderAvailability).Order("created_at desc").Find(&requests).Error
+}
+
+// HasActiveTrainingRequest reports whether the student already has an open or assigned request for the position
+func HasActiveTrainingRequest(cid uint, facility, position string) (bool, error) {
+	var count int64
+	err := database.DB.Model(&TrainingRequest{}).
+		Where("c_id = ? AND facility = ? AND position = ? AND status IN ?", cid, facility, position,
+			[]types.TrainingRequestStatus{types.OpenTrainingRequest, types.AssignedTrainingRequest}).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// GetTrainingQueueStats reports the facility's queue depth and how long requests picked up in the last
+// window waited
+func GetTrainingQueueStats(facility string, now time.Time, window time.Duration) (*TrainingQueueStats, error) {
+	stats := &TrainingQueueStats{Facility: facility, WindowDays: int(window.Hours() / 24)}
+
+	open, err := GetTrainingQueue(facility, types.OpenTrainingRequest)
+	if err != nil {
+		return nil, err
+	}
+	stats.Open = len(open)
+	if len(open) > 0 {
+		stats.OldestOpenAt = &open[0].CreatedAt
+	}
+
+	var assigned int64
+	if err := database.DB.Model(&TrainingRequest{}).Where("facility = ? AND status = ?", facility, types.AssignedTrainingRequest).Count(&assigned).Error; err != nil {
+		return nil, err
+	}
+	stats.Assigned = int(assigned)
+
+	var pickedUp []TrainingRequest
+	if err := database.DB.Where("facility = ? AND assigned_at >= ?", facility, now.Add(-window)).Find(&pickedUp).Error; err != nil {
+		return nil, err
+	}
+
+	var total time.Duration
+	for _, t := range pickedUp {
+		wait := t.AssignedAt.Sub(t.CreatedAt)
+		total += wait
+		if m := int64(wait.Minutes()); m > stats.LongestWaitMinutes {
+			stats.LongestWaitMinutes = m
+		}
+	}
+	stats.PickedUp = len(pickedUp)
+	if len(pickedUp) > 0 {
+		stats.AverageWaitMinutes = int64((total / time.Duration(len(pickedUp))).Minutes())
+	}
+
+	return stats, nil
+}
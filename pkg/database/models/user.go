@@ -1,8 +1,11 @@
 package models
 
 import (
+	"fmt"
+	"github.com/VATUSA/primary-api/pkg/constants"
 	"github.com/VATUSA/primary-api/pkg/database"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"strings"
 	"time"
 )
@@ -18,6 +21,8 @@ type User struct {
 	PilotRating          uint                   `json:"pilot_rating" example:"1"`
 	ControllerRating     uint                   `json:"controller_rating" example:"1"`
 	DiscordID            string                 `json:"discord_id" example:"1234567890"`
+	Region               string                 `json:"region" example:"AMAS"`
+	Division             string                 `json:"division" example:"USA"`
 	LastLogin            time.Time              `json:"last_login" example:"2021-01-01T00:00:00Z"`
 	LastCertSync         time.Time              `json:"last_cert_sync" example:"2021-01-01T00:00:00Z"`
 	Flags                []UserFlag             `json:"flags" gorm:"foreignKey:CID"`
@@ -57,28 +62,184 @@ func (u *User) Get() error {
 	return database.DB.Where("c_id = ?", u.CID).Preload("Roles").First(u).Error
 }
 
-func GetAllUsers() ([]User, error) {
+func GetAllUsers(db *gorm.DB) ([]User, error) {
 	var users []User
-	return users, database.DB.Find(&users).Error
+	return users, db.Find(&users).Error
 }
 
 func SearchUsersByName(db *gorm.DB, query string) ([]User, error) {
+	users, _, err := SearchUsers(db, UserSearch{Query: query})
+	return users, err
+}
+
+// UserSearch is a ranked search for users. Every word of Query must match the user's CID as a prefix, part
+// of their first, last or preferred name, their operating initials on a roster or, with IncludeEmail, part
+// of their email.
+type UserSearch struct {
+	Query        string
+	Facility     string // Only users on this facility's roster, matching its operating initials
+	IncludeEmail bool
+	Limit        int
+	Offset       int
+}
+
+// SearchUsers returns a page of users matching the search, best matches first, and the total number of matches
+func SearchUsers(db *gorm.DB, s UserSearch) ([]User, int64, error) {
 	var users []User
+	var total int64
 
-	// Split the query into parts
-	queryParts := strings.Fields(query)
+	words := strings.Fields(strings.ToLower(s.Query))
+	if len(words) == 0 {
+		return users, 0, nil
+	}
+
+	oiFilter := "SELECT 1 FROM rosters WHERE rosters.c_id = users.c_id AND LOWER(rosters.o_is) %s ?"
+	if s.Facility != "" {
+		oiFilter += " AND rosters.facility = ?"
+	}
+	oiArgs := func(value string) []interface{} {
+		if s.Facility != "" {
+			return []interface{}{value, s.Facility}
+		}
+		return []interface{}{value}
+	}
+
+	var conditions, scores []string
+	var conditionArgs, scoreArgs []interface{}
+	for _, word := range words {
+		prefix := escapeLike(word) + "%"
+		contains := "%" + escapeLike(word) + "%"
+
+		matches := []string{
+			"CAST(users.c_id AS CHAR) LIKE ? ESCAPE '!'",
+			"LOWER(users.first_name) LIKE ? ESCAPE '!'",
+			"LOWER(users.last_name) LIKE ? ESCAPE '!'",
+			"LOWER(users.preferred_name) LIKE ? ESCAPE '!'",
+			"EXISTS (" + fmt.Sprintf(oiFilter, "=") + ")",
+		}
+		conditionArgs = append(conditionArgs, prefix, contains, contains, contains)
+		conditionArgs = append(conditionArgs, oiArgs(word)...)
+		if s.IncludeEmail {
+			matches = append(matches, "LOWER(users.email) LIKE ? ESCAPE '!'")
+			conditionArgs = append(conditionArgs, contains)
+		}
+		conditions = append(conditions, "("+strings.Join(matches, " OR ")+")")
 
-	// Using LIKE condition for case-insensitive partial matching on both first name and last name
-	for _, part := range queryParts {
-		if err := database.DB.Where("lower(first_name) LIKE ?", "%"+strings.ToLower(part)+"%").
-			Or("lower(last_name) LIKE ?", "%"+strings.ToLower(part)+"%").
-			Or("lower(preferred_name) LIKE ?", "%"+strings.ToLower(part)+"%").
-			Find(&users).Error; err != nil {
-			return nil, err
+		// Exact matches rank above prefixes, which rank above anything else
+		scores = append(scores,
+			"CASE WHEN CAST(users.c_id AS CHAR) = ? THEN 100 WHEN CAST(users.c_id AS CHAR) LIKE ? ESCAPE '!' THEN 50 ELSE 0 END",
+			"CASE WHEN EXISTS ("+fmt.Sprintf(oiFilter, "=")+") THEN 40 ELSE 0 END",
+			"CASE WHEN LOWER(users.last_name) = ? OR LOWER(users.first_name) = ? OR LOWER(users.preferred_name) = ? THEN 30 "+
+				"WHEN LOWER(users.last_name) LIKE ? ESCAPE '!' OR LOWER(users.first_name) LIKE ? ESCAPE '!' OR LOWER(users.preferred_name) LIKE ? ESCAPE '!' THEN 20 "+
+				"ELSE 0 END",
+		)
+		scoreArgs = append(scoreArgs, word, prefix)
+		scoreArgs = append(scoreArgs, oiArgs(word)...)
+		scoreArgs = append(scoreArgs, word, word, word, prefix, prefix, prefix)
+		if s.IncludeEmail {
+			scores = append(scores, "CASE WHEN LOWER(users.email) = ? THEN 100 ELSE 0 END")
+			scoreArgs = append(scoreArgs, word)
 		}
 	}
 
-	return users, nil
+	query := func() *gorm.DB {
+		q := db.Model(&User{}).Where(strings.Join(conditions, " AND "), conditionArgs...)
+		if s.Facility != "" {
+			q = q.Where("EXISTS (SELECT 1 FROM rosters WHERE rosters.c_id = users.c_id AND rosters.facility = ?)", s.Facility)
+		}
+		return q
+	}
+
+	if err := query().Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	q := query().
+		Preload("Roles").
+		// Order ignores a clause.OrderBy, so the ranking is added as a clause with its tie-breakers
+		Clauses(clause.OrderBy{Expression: clause.Expr{
+			SQL:                "(" + strings.Join(scores, " + ") + ") DESC, users.last_name, users.first_name, users.c_id",
+			Vars:               scoreArgs,
+			WithoutParentheses: true,
+		}}).
+		Offset(s.Offset)
+	if s.Limit > 0 {
+		q = q.Limit(s.Limit)
+	}
+
+	return users, total, q.Find(&users).Error
+}
+
+// escapeLike escapes the LIKE wildcards in s, using ! as the escape character
+func escapeLike(s string) string {
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(s)
+}
+
+// IsDivisionStaff reports whether the user holds a division management or division staff role
+func (u *User) IsDivisionStaff() bool {
+	for _, role := range u.Roles {
+		if role.RoleID.InGroup(constants.DivisionManagement) || role.RoleID.InGroup(constants.DivisionStaff) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasFacilityRole reports whether the user holds any role at the facility
+func (u *User) HasFacilityRole(facility string) bool {
+	for _, role := range u.Roles {
+		if role.FacilityID == facility {
+			return true
+		}
+	}
+	return false
+}
+
+// ManagesFacility reports whether the user is part of the facility's management or is division staff
+func (u *User) ManagesFacility(facility string) bool {
+	if u.IsDivisionStaff() {
+		return true
+	}
+
+	for _, role := range u.Roles {
+		if role.FacilityID == facility && role.RoleID.InGroup(constants.FacilityManagement) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsFacilityStaff reports whether the user is one of the facility's senior staff or is division staff. Mentors,
+// instructors and assistants aren't staff.
+func (u *User) IsFacilityStaff(facility string) bool {
+	if u.IsDivisionStaff() {
+		return true
+	}
+
+	for _, role := range u.Roles {
+		if role.FacilityID != facility {
+			continue
+		}
+		if role.RoleID == constants.TrainingAdministratorRole || role.RoleID.InGroup(constants.FacilityManagement) || role.RoleID.InGroup(constants.FacilityStaff) {
+			return true
+		}
+	}
+	return false
+}
+
+// CanManageWebhooks reports whether the user may manage the facility's webhooks: its management, its
+// developers, or division staff
+func (u *User) CanManageWebhooks(facility string) bool {
+	if u.ManagesFacility(facility) {
+		return true
+	}
+
+	for _, role := range u.Roles {
+		if role.FacilityID == facility && role.RoleID.InGroup(constants.FacilityDevelopment) {
+			return true
+		}
+	}
+	return false
 }
 
 func IsValidUser(cid uint) bool {
@@ -44,5 +44,5 @@ func GetAllFlags() ([]UserFlag, error) {
 
 func GetAllFlagsByCID(db *gorm.DB, cid uint) ([]UserFlag, error) {
 	var flags []UserFlag
-	return flags, database.DB.Where("cid = ?", cid).Find(&flags).Error
+	return flags, db.Where("c_id = ?", cid).Find(&flags).Error
 }
@@ -3,7 +3,9 @@ package models
 import (
 	"github.com/VATUSA/primary-api/pkg/constants"
 	"github.com/VATUSA/primary-api/pkg/database"
+	"github.com/VATUSA/primary-api/pkg/database/types"
 	"gorm.io/gorm"
+	"strconv"
 	"time"
 )
 
@@ -16,8 +18,19 @@ type UserRole struct {
 	UpdatedAt  time.Time        `json:"updated_at" example:"2021-01-01T00:00:00Z"`
 }
 
+// Create grants the role and raises role.granted for the member
 func (ur *UserRole) Create() error {
-	return database.DB.Create(ur).Error
+	return CreateUserRole(database.DB, ur)
+}
+
+// CreateUserRole is Create through db
+func CreateUserRole(db *gorm.DB, ur *UserRole) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(ur).Error; err != nil {
+			return err
+		}
+		return emit(tx, UserAggregate, strconv.FormatUint(uint64(ur.CID), 10), types.RoleGranted, ur.FacilityID, ur)
+	})
 }
 
 func (ur *UserRole) Update() error {
@@ -32,24 +45,24 @@ func (ur *UserRole) Get() error {
 	return database.DB.Where("id = ?", ur.ID).First(ur).Error
 }
 
-func GetAllUserRoles() ([]UserRole, error) {
+func GetAllUserRoles(db *gorm.DB) ([]UserRole, error) {
 	var userRoles []UserRole
-	return userRoles, database.DB.Find(&userRoles).Error
+	return userRoles, db.Find(&userRoles).Error
 }
 
 func GetAllUserRolesByCID(db *gorm.DB, cid uint) ([]UserRole, error) {
 	var userRoles []UserRole
-	return userRoles, database.DB.Where("cid = ?", cid).Find(&userRoles).Error
+	return userRoles, db.Where("c_id = ?", cid).Find(&userRoles).Error
 }
 
 func GetAllUserRolesByRoleID(db *gorm.DB, roleID string) ([]UserRole, error) {
 	var userRoles []UserRole
-	return userRoles, database.DB.Where("role_id = ?", roleID).Find(&userRoles).Error
+	return userRoles, db.Where("role_id = ?", roleID).Find(&userRoles).Error
 }
 
 func GetAllUserRolesByFacilityID(db *gorm.DB, facilityID string) ([]UserRole, error) {
 	var userRoles []UserRole
-	return userRoles, database.DB.Where("facility_id = ?", facilityID).Find(&userRoles).Error
+	return userRoles, db.Where("facility_id = ?", facilityID).Find(&userRoles).Error
 }
 
 func CanModifyRole(user *User, role constants.RoleID) bool {
@@ -0,0 +1,181 @@
+package models
+
+import (
+	"github.com/VATUSA/primary-api/pkg/database"
+	"github.com/VATUSA/primary-api/pkg/database/types"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// WebhookSubscription sends the facility's events of the chosen types to URL, signed with Secret
+type WebhookSubscription struct {
+	ID        uint                       `json:"id" gorm:"primaryKey" example:"1"`
+	Facility  string                     `json:"facility" gorm:"size:3;index" example:"ZDV"`
+	URL       string                     `json:"url" example:"https://zdvartcc.org/api/vatusa/webhook"`
+	Secret    string                     `json:"-"`
+	Events    []WebhookSubscriptionEvent `json:"events" gorm:"foreignKey:SubscriptionID"`
+	Active    bool                       `json:"active" example:"true"`
+	CreatedBy uint                       `json:"created_by" example:"1293257"`
+	CreatedAt time.Time                  `json:"created_at" example:"2021-01-01T00:00:00Z"`
+	UpdatedAt time.Time                  `json:"updated_at" example:"2021-01-01T00:00:00Z"`
+}
+
+// WebhookSubscriptionEvent is an event type a subscription receives
+type WebhookSubscriptionEvent struct {
+	ID             uint               `json:"id" gorm:"primaryKey" example:"1"`
+	SubscriptionID uint               `json:"subscription_id" gorm:"index" example:"1"`
+	Event          types.WebhookEvent `json:"event" gorm:"type:varchar(32);index" example:"roster.added"`
+}
+
+// WebhookDelivery is one attempt to get an event to a subscription, retried until it is delivered or runs out
+// of attempts. Redelivering an event creates a new delivery with the same EventID.
+type WebhookDelivery struct {
+	ID             uint                        `json:"id" gorm:"primaryKey" example:"1"`
+	SubscriptionID uint                        `json:"subscription_id" gorm:"index" example:"1"`
+	EventID        string                      `json:"event_id" gorm:"size:32;index" example:"42"`
+	Event          types.WebhookEvent          `json:"event" gorm:"type:varchar(32)" example:"roster.added"`
+	Payload        string                      `json:"payload" gorm:"type:text"`
+	Status         types.WebhookDeliveryStatus `json:"status" gorm:"type:enum('pending', 'delivered', 'failed');default:'pending';index" example:"delivered"`
+	Attempts       int                         `json:"attempts" example:"1"`
+	NextAttemptAt  *time.Time                  `json:"next_attempt_at" gorm:"index" example:"2021-01-01T00:00:30Z"`
+	LastStatusCode int                         `json:"last_status_code" example:"200"`
+	LastError      string                      `json:"last_error" gorm:"size:512" example:""`
+	DeliveredAt    *time.Time                  `json:"delivered_at" example:"2021-01-01T00:00:01Z"`
+	RedeliveryOf   *uint                       `json:"redelivery_of" example:"1"`
+	CreatedAt      time.Time                   `json:"created_at" example:"2021-01-01T00:00:00Z"`
+	UpdatedAt      time.Time                   `json:"updated_at" example:"2021-01-01T00:00:01Z"`
+}
+
+func (s *WebhookSubscription) Create() error {
+	return database.DB.Create(s).Error
+}
+
+// Update saves the subscription, replacing the events it receives
+func (s *WebhookSubscription) Update() error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Save(s).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("subscription_id = ?", s.ID).Delete(&WebhookSubscriptionEvent{}).Error; err != nil {
+			return err
+		}
+		for i := range s.Events {
+			s.Events[i].ID = 0
+			s.Events[i].SubscriptionID = s.ID
+		}
+		if len(s.Events) == 0 {
+			return nil
+		}
+		return tx.Create(&s.Events).Error
+	})
+}
+
+// Delete removes the subscription along with its events and delivery log
+func (s *WebhookSubscription) Delete() error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("subscription_id = ?", s.ID).Delete(&WebhookSubscriptionEvent{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("subscription_id = ?", s.ID).Delete(&WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(s).Error
+	})
+}
+
+func (s *WebhookSubscription) Get() error {
+	return database.DB.Where("id = ?", s.ID).Preload("Events").First(s).Error
+}
+
+func GetWebhookSubscriptions(facility string) ([]WebhookSubscription, error) {
+	var subscriptions []WebhookSubscription
+	query := database.DB.Preload("Events").Order("id")
+	if facility != "" {
+		query = query.Where("facility = ?", facility)
+	}
+	return subscriptions, query.Find(&subscriptions).Error
+}
+
+// GetWebhookSubscriptionsFor returns the active subscriptions of the facility that receive the event
+func GetWebhookSubscriptionsFor(facility string, event types.WebhookEvent) ([]WebhookSubscription, error) {
+	var subscriptions []WebhookSubscription
+	return subscriptions, database.DB.
+		Where("facility = ? AND active = ?", facility, true).
+		Where("id IN (?)", database.DB.Model(&WebhookSubscriptionEvent{}).Select("subscription_id").Where("event = ?", event)).
+		Find(&subscriptions).Error
+}
+
+func (d *WebhookDelivery) Create() error {
+	return database.DB.Create(d).Error
+}
+
+func (d *WebhookDelivery) Update() error {
+	return database.DB.Save(d).Error
+}
+
+func (d *WebhookDelivery) Get() error {
+	return database.DB.Where("id = ?", d.ID).First(d).Error
+}
+
+// Claim pushes a due delivery's next attempt back by lease so no other worker picks it up while it is being
+// sent. It returns false if another worker claimed it first.
+func (d *WebhookDelivery) Claim(now time.Time, lease time.Duration) (bool, error) {
+	until := now.Add(lease)
+	result := database.DB.Model(&WebhookDelivery{}).
+		Where("id = ? AND status = ? AND next_attempt_at <= ?", d.ID, types.PendingDelivery, now).
+		Update("next_attempt_at", until)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return false, result.Error
+	}
+	d.NextAttemptAt = &until
+	return true, nil
+}
+
+// CreateWebhookDeliveries queues a delivery of the payload to each subscription
+func CreateWebhookDeliveries(subscriptions []WebhookSubscription, eventID string, event types.WebhookEvent, payload string, now time.Time) error {
+	if len(subscriptions) == 0 {
+		return nil
+	}
+
+	deliveries := make([]WebhookDelivery, 0, len(subscriptions))
+	for _, s := range subscriptions {
+		deliveries = append(deliveries, WebhookDelivery{
+			SubscriptionID: s.ID,
+			EventID:        eventID,
+			Event:          event,
+			Payload:        payload,
+			Status:         types.PendingDelivery,
+			NextAttemptAt:  &now,
+		})
+	}
+	return database.DB.Create(&deliveries).Error
+}
+
+// GetWebhookSubscriptionIDsForEvent returns the subscriptions that already have a delivery of the event
+func GetWebhookSubscriptionIDsForEvent(eventID string) (map[uint]bool, error) {
+	var ids []uint
+	if err := database.DB.Model(&WebhookDelivery{}).Distinct("subscription_id").Where("event_id = ?", eventID).Pluck("subscription_id", &ids).Error; err != nil {
+		return nil, err
+	}
+
+	queued := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		queued[id] = true
+	}
+	return queued, nil
+}
+
+// GetWebhookDeliveries returns the subscription's most recent deliveries, newest first
+func GetWebhookDeliveries(subscriptionID uint, limit int) ([]WebhookDelivery, error) {
+	var deliveries []WebhookDelivery
+	return deliveries, database.DB.Where("subscription_id = ?", subscriptionID).Order("id desc").Limit(limit).Find(&deliveries).Error
+}
+
+// GetDueWebhookDeliveries returns pending deliveries whose next attempt is due, oldest first
+func GetDueWebhookDeliveries(now time.Time, limit int) ([]WebhookDelivery, error) {
+	var deliveries []WebhookDelivery
+	return deliveries, database.DB.
+		Where("status = ? AND next_attempt_at <= ?", types.PendingDelivery, now).
+		Order("next_attempt_at").Limit(limit).Find(&deliveries).Error
+}
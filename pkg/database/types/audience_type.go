@@ -0,0 +1,28 @@
+package types
+
+import (
+	"database/sql/driver"
+	"errors"
+)
+
+type AudienceType string
+
+const (
+	FacilityAudience AudienceType = "facility"
+	RoleAudience     AudienceType = "role"
+	GroupAudience    AudienceType = "group"
+)
+
+func (s *AudienceType) Scan(value interface{}) error {
+	strValue, ok := value.(string)
+	if !ok {
+		return errors.New("failed to scan AudienceType")
+	}
+
+	*s = AudienceType(strValue)
+	return nil
+}
+
+func (s *AudienceType) Value() (driver.Value, error) {
+	return string(*s), nil
+}
@@ -0,0 +1,47 @@
+package types
+
+import (
+	"database/sql/driver"
+	"errors"
+)
+
+type CertificationType string
+
+const (
+	DeliveryCertification CertificationType = "DEL"
+	GroundCertification   CertificationType = "GND"
+	TowerCertification    CertificationType = "TWR"
+	ApproachCertification CertificationType = "APP"
+	CenterCertification   CertificationType = "CTR"
+	Tier1Endorsement      CertificationType = "tier1"   // A Tier 1 airport or approach control
+	SpecialEndorsement    CertificationType = "special" // Special use airspace, such as a SFRA
+)
+
+// IsEndorsement reports whether the certification is an endorsement, which always names the airport or
+// airspace it covers
+func (c CertificationType) IsEndorsement() bool {
+	return c == Tier1Endorsement || c == SpecialEndorsement
+}
+
+// AllowsSolo reports whether the certification can be granted as a solo certification
+func (c CertificationType) AllowsSolo() bool {
+	switch c {
+	case GroundCertification, TowerCertification, ApproachCertification, CenterCertification:
+		return true
+	}
+	return false
+}
+
+func (c *CertificationType) Scan(value interface{}) error {
+	strValue, ok := value.(string)
+	if !ok {
+		return errors.New("failed to scan CertificationType")
+	}
+
+	*c = CertificationType(strValue)
+	return nil
+}
+
+func (c *CertificationType) Value() (driver.Value, error) {
+	return string(*c), nil
+}
@@ -0,0 +1,36 @@
+package types
+
+import (
+	"database/sql/driver"
+	"errors"
+)
+
+type DocumentVisibility string
+
+const (
+	PublicVisibility   DocumentVisibility = "public"
+	FacilityVisibility DocumentVisibility = "facility"
+	StaffVisibility    DocumentVisibility = "staff"
+)
+
+// DefaultVisibility is the visibility a new document in the category gets when none is given
+func (c DocumentCategory) DefaultVisibility() DocumentVisibility {
+	if c == LOAs {
+		return StaffVisibility
+	}
+	return PublicVisibility
+}
+
+func (s *DocumentVisibility) Scan(value interface{}) error {
+	strValue, ok := value.(string)
+	if !ok {
+		return errors.New("failed to scan DocumentVisibility")
+	}
+
+	*s = DocumentVisibility(strValue)
+	return nil
+}
+
+func (s *DocumentVisibility) Value() (driver.Value, error) {
+	return string(*s), nil
+}
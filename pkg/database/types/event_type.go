@@ -0,0 +1,32 @@
+package types
+
+import (
+	"database/sql/driver"
+	"errors"
+)
+
+// EventType names a domain event written to the outbox
+type EventType string
+
+const (
+	RosterAdded      EventType = "roster.added"
+	RosterRemoved    EventType = "roster.removed"
+	RoleGranted      EventType = "role.granted"
+	RatingChanged    EventType = "rating.changed"
+	FeedbackApproved EventType = "feedback.approved"
+	DocumentUpdated  EventType = "document.updated"
+)
+
+func (e *EventType) Scan(value interface{}) error {
+	strValue, ok := value.(string)
+	if !ok {
+		return errors.New("failed to scan EventType")
+	}
+
+	*e = EventType(strValue)
+	return nil
+}
+
+func (e *EventType) Value() (driver.Value, error) {
+	return string(*e), nil
+}
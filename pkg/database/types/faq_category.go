@@ -0,0 +1,41 @@
+package types
+
+import (
+	"database/sql/driver"
+	"errors"
+)
+
+type FAQCategory string
+
+const (
+	FAQMembership FAQCategory = "membership"
+	FAQTraining   FAQCategory = "training"
+	FAQTechnology FAQCategory = "technology"
+	FAQMisc       FAQCategory = "misc"
+)
+
+// FAQCategories lists every FAQ category in the order they are displayed
+var FAQCategories = []FAQCategory{FAQMembership, FAQTraining, FAQTechnology, FAQMisc}
+
+func (s FAQCategory) IsValid() bool {
+	for _, c := range FAQCategories {
+		if c == s {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *FAQCategory) Scan(value interface{}) error {
+	strValue, ok := value.(string)
+	if !ok {
+		return errors.New("failed to scan FAQCategory")
+	}
+
+	*s = FAQCategory(strValue)
+	return nil
+}
+
+func (s *FAQCategory) Value() (driver.Value, error) {
+	return string(*s), nil
+}
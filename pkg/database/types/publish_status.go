@@ -0,0 +1,28 @@
+package types
+
+import (
+	"database/sql/driver"
+	"errors"
+)
+
+type PublishStatus string
+
+const (
+	Draft     PublishStatus = "draft"
+	Scheduled PublishStatus = "scheduled" // Published once the publish time passes
+	Published PublishStatus = "published"
+)
+
+func (s *PublishStatus) Scan(value interface{}) error {
+	strValue, ok := value.(string)
+	if !ok {
+		return errors.New("failed to scan PublishStatus")
+	}
+
+	*s = PublishStatus(strValue)
+	return nil
+}
+
+func (s *PublishStatus) Value() (driver.Value, error) {
+	return string(*s), nil
+}
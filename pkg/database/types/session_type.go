@@ -0,0 +1,29 @@
+package types
+
+import (
+	"database/sql/driver"
+	"errors"
+)
+
+type SessionType string
+
+const (
+	ClassroomSession SessionType = "classroom"
+	SweatboxSession  SessionType = "sweatbox"
+	LiveSession      SessionType = "live"
+	OTSSession       SessionType = "ots" // Over-the-shoulder rating exam
+)
+
+func (s *SessionType) Scan(value interface{}) error {
+	strValue, ok := value.(string)
+	if !ok {
+		return errors.New("failed to scan SessionType")
+	}
+
+	*s = SessionType(strValue)
+	return nil
+}
+
+func (s *SessionType) Value() (driver.Value, error) {
+	return string(*s), nil
+}
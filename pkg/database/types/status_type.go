@@ -11,6 +11,10 @@ const (
 	Pending  StatusType = "pending"
 	Accepted StatusType = "accepted"
 	Rejected StatusType = "rejected"
+
+	// Feedback is approved or denied rather than accepted or rejected
+	Approved StatusType = "approved"
+	Denied   StatusType = "denied"
 )
 
 func (s *StatusType) Scan(value interface{}) error {
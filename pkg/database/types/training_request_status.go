@@ -0,0 +1,29 @@
+package types
+
+import (
+	"database/sql/driver"
+	"errors"
+)
+
+type TrainingRequestStatus string
+
+const (
+	OpenTrainingRequest      TrainingRequestStatus = "open"
+	AssignedTrainingRequest  TrainingRequestStatus = "assigned"
+	CompletedTrainingRequest TrainingRequestStatus = "completed"
+	CancelledTrainingRequest TrainingRequestStatus = "cancelled"
+)
+
+func (s *TrainingRequestStatus) Scan(value interface{}) error {
+	strValue, ok := value.(string)
+	if !ok {
+		return errors.New("failed to scan TrainingRequestStatus")
+	}
+
+	*s = TrainingRequestStatus(strValue)
+	return nil
+}
+
+func (s *TrainingRequestStatus) Value() (driver.Value, error) {
+	return string(*s), nil
+}
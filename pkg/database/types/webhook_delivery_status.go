@@ -0,0 +1,28 @@
+package types
+
+import (
+	"database/sql/driver"
+	"errors"
+)
+
+type WebhookDeliveryStatus string
+
+const (
+	PendingDelivery   WebhookDeliveryStatus = "pending"
+	DeliveredDelivery WebhookDeliveryStatus = "delivered"
+	FailedDelivery    WebhookDeliveryStatus = "failed"
+)
+
+func (s *WebhookDeliveryStatus) Scan(value interface{}) error {
+	strValue, ok := value.(string)
+	if !ok {
+		return errors.New("failed to scan WebhookDeliveryStatus")
+	}
+
+	*s = WebhookDeliveryStatus(strValue)
+	return nil
+}
+
+func (s *WebhookDeliveryStatus) Value() (driver.Value, error) {
+	return string(*s), nil
+}
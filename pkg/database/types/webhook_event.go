@@ -0,0 +1,51 @@
+package types
+
+import (
+	"database/sql/driver"
+	"errors"
+)
+
+// WebhookEvent is a domain event that webhooks can subscribe to
+type WebhookEvent string
+
+const (
+	RosterAddedEvent      = WebhookEvent(RosterAdded)
+	RosterRemovedEvent    = WebhookEvent(RosterRemoved)
+	RoleGrantedEvent      = WebhookEvent(RoleGranted)
+	RatingChangedEvent    = WebhookEvent(RatingChanged)
+	FeedbackApprovedEvent = WebhookEvent(FeedbackApproved)
+	DocumentUpdatedEvent  = WebhookEvent(DocumentUpdated)
+)
+
+// WebhookEvents lists every event a webhook can subscribe to
+var WebhookEvents = []WebhookEvent{
+	RosterAddedEvent,
+	RosterRemovedEvent,
+	RoleGrantedEvent,
+	RatingChangedEvent,
+	FeedbackApprovedEvent,
+	DocumentUpdatedEvent,
+}
+
+func (e WebhookEvent) IsValid() bool {
+	for _, event := range WebhookEvents {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *WebhookEvent) Scan(value interface{}) error {
+	strValue, ok := value.(string)
+	if !ok {
+		return errors.New("failed to scan WebhookEvent")
+	}
+
+	*e = WebhookEvent(strValue)
+	return nil
+}
+
+func (e *WebhookEvent) Value() (driver.Value, error) {
+	return string(*e), nil
+}
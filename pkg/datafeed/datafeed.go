@@ -0,0 +1,65 @@
+package datafeed
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/VATUSA/primary-api/pkg/config"
+	"io"
+	"time"
+)
+
+// Controller is a controller connected to the network, as seen in a single snapshot of the feed
+type Controller struct {
+	CID       uint
+	Callsign  string
+	Frequency string
+	LogonTime time.Time
+}
+
+// Feed provides snapshots of the controllers currently connected
+type Feed interface {
+	Controllers(ctx context.Context) ([]Controller, error)
+}
+
+// observerFacility is the facility type the VATSIM data feed gives observers
+const observerFacility = 0
+
+type document struct {
+	Controllers []struct {
+		CID       uint      `json:"cid"`
+		Callsign  string    `json:"callsign"`
+		Frequency string    `json:"frequency"`
+		Facility  int       `json:"facility"`
+		LogonTime time.Time `json:"logon_time"`
+	} `json:"controllers"`
+}
+
+// Parse reads the controllers out of a VATSIM v3 data feed document, leaving out observers
+func Parse(r io.Reader) ([]Controller, error) {
+	var doc document
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	controllers := []Controller{}
+	for _, c := range doc.Controllers {
+		if c.Facility == observerFacility {
+			continue
+		}
+		controllers = append(controllers, Controller{
+			CID:       c.CID,
+			Callsign:  c.Callsign,
+			Frequency: c.Frequency,
+			LogonTime: c.LogonTime,
+		})
+	}
+	return controllers, nil
+}
+
+// New returns the feed the configuration asks for: the file stand-in if one is set, otherwise the VATSIM feed
+func New(cfg *config.ActivityConfig) Feed {
+	if cfg.FeedFile != "" {
+		return NewFileFeed(cfg.FeedFile)
+	}
+	return NewVATSIMFeed(cfg.FeedURL)
+}
@@ -0,0 +1,28 @@
+package datafeed
+
+import (
+	"context"
+	"os"
+)
+
+var _ Feed = (*FileFeed)(nil)
+
+// FileFeed reads a data feed document from disk on every poll. It stands in for the VATSIM feed in tests and
+// development; change the file to change who is online.
+type FileFeed struct {
+	Path string
+}
+
+func NewFileFeed(path string) *FileFeed {
+	return &FileFeed{Path: path}
+}
+
+func (f *FileFeed) Controllers(ctx context.Context) ([]Controller, error) {
+	file, err := os.Open(f.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return Parse(file)
+}
@@ -0,0 +1,45 @@
+package datafeed
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// DefaultURL is VATSIM's public v3 data feed
+const DefaultURL = "https://data.vatsim.net/v3/vatsim-data.json"
+
+var _ Feed = (*VATSIMFeed)(nil)
+
+// VATSIMFeed polls the VATSIM data feed over HTTP
+type VATSIMFeed struct {
+	URL    string
+	Client *http.Client
+}
+
+func NewVATSIMFeed(url string) *VATSIMFeed {
+	if url == "" {
+		url = DefaultURL
+	}
+	return &VATSIMFeed{URL: url, Client: &http.Client{Timeout: 30 * time.Second}}
+}
+
+func (f *VATSIMFeed) Controllers(ctx context.Context) ([]Controller, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, f.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := f.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("data feed returned %s", res.Status)
+	}
+
+	return Parse(res.Body)
+}
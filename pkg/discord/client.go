@@ -0,0 +1,122 @@
+package discord
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/VATUSA/primary-api/pkg/config"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Scopes are the OAuth scopes asked for when linking; identify is enough to learn the account's ID
+const Scopes = "identify"
+
+var ErrInvalidGrant = errors.New("discord rejected the authorization code")
+
+// Token is an OAuth access token for a Discord user
+type Token struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+	Scope        string `json:"scope"`
+}
+
+// User is the Discord account behind a token
+type User struct {
+	ID         string `json:"id"`
+	Username   string `json:"username"`
+	GlobalName string `json:"global_name"`
+}
+
+// Client is the OAuth side of a Discord application
+type Client struct {
+	APIURL       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	HTTP         *http.Client
+}
+
+func NewClient(cfg *config.DiscordConfig) *Client {
+	return &Client{
+		APIURL:       cfg.APIURL,
+		ClientID:     cfg.ClientID,
+		ClientSecret: cfg.ClientSecret,
+		RedirectURL:  cfg.RedirectURL,
+		HTTP:         &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// AuthorizeURL is where to send the user to approve linking their account
+func (c *Client) AuthorizeURL(state string) string {
+	query := url.Values{
+		"client_id":     {c.ClientID},
+		"redirect_uri":  {c.RedirectURL},
+		"response_type": {"code"},
+		"scope":         {Scopes},
+		"state":         {state},
+		"prompt":        {"consent"},
+	}
+	return c.APIURL + "/oauth2/authorize?" + query.Encode()
+}
+
+// Exchange trades the authorization code from the callback for a token
+func (c *Client) Exchange(ctx context.Context, code string) (*Token, error) {
+	form := url.Values{
+		"grant_type":   {"authorization_code"},
+		"code":         {code},
+		"redirect_uri": {c.RedirectURL},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.APIURL+"/oauth2/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(c.ClientID, c.ClientSecret)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var token Token
+	if err := c.do(req, &token); err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// CurrentUser returns the account the token belongs to
+func (c *Client) CurrentUser(ctx context.Context, token *Token) (*User, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.APIURL+"/users/@me", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+
+	var user User
+	if err := c.do(req, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (c *Client) do(req *http.Request, v interface{}) error {
+	req.Header.Set("Accept", "application/json")
+
+	res, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	switch {
+	case res.StatusCode == http.StatusBadRequest || res.StatusCode == http.StatusUnauthorized:
+		return ErrInvalidGrant
+	case res.StatusCode != http.StatusOK:
+		return fmt.Errorf("%s %s returned %s", req.Method, req.URL.Path, res.Status)
+	}
+
+	return json.NewDecoder(res.Body).Decode(v)
+}
@@ -0,0 +1,59 @@
+package discord
+
+import (
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"sort"
+)
+
+// Matches reports whether the user meets every condition the mapping sets
+func Matches(m *models.DiscordRoleMapping, user *models.User, rosters []models.Roster) bool {
+	if m.Rating != nil && *m.Rating != user.ControllerRating {
+		return false
+	}
+
+	if m.RoleID != "" {
+		for _, role := range user.Roles {
+			if role.RoleID == m.RoleID && (m.Facility == "" || role.FacilityID == m.Facility) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if m.Facility != "" {
+		for _, r := range rosters {
+			if r.Facility == m.Facility && (r.Home || !m.HomeOnly) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return true
+}
+
+// Roles works out the Discord roles a user should hold in a guild. It also returns every role the guild's
+// mappings manage, so a bot knows which roles to take away; roles outside that set are left alone. A nil
+// user, for an account that isn't linked, holds none of the managed roles.
+func Roles(mappings []models.DiscordRoleMapping, user *models.User, rosters []models.Roster) (desired, managed []string) {
+	want := map[string]bool{}
+	all := map[string]bool{}
+	for i := range mappings {
+		m := &mappings[i]
+		all[m.DiscordRoleID] = true
+		if user != nil && Matches(m, user, rosters) {
+			want[m.DiscordRoleID] = true
+		}
+	}
+
+	return keys(want), keys(all)
+}
+
+func keys(set map[string]bool) []string {
+	list := []string{}
+	for k := range set {
+		list = append(list, k)
+	}
+	sort.Strings(list)
+	return list
+}
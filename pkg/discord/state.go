@@ -0,0 +1,56 @@
+package discord
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StateLifetime is how long a user has to approve the link on Discord
+const StateLifetime = 10 * time.Minute
+
+var ErrInvalidState = errors.New("invalid or expired state")
+
+// SignState ties an OAuth flow to the user who started it. The state is "cid.expiry.signature".
+func SignState(secret string, cid uint, now time.Time) string {
+	payload := fmt.Sprintf("%d.%d", cid, now.Add(StateLifetime).Unix())
+	return payload + "." + sign(secret, payload)
+}
+
+// VerifyState returns the CID a state was signed for
+func VerifyState(secret, state string, now time.Time) (uint, error) {
+	i := strings.LastIndex(state, ".")
+	if i < 0 {
+		return 0, ErrInvalidState
+	}
+	payload, signature := state[:i], state[i+1:]
+	if !hmac.Equal([]byte(signature), []byte(sign(secret, payload))) {
+		return 0, ErrInvalidState
+	}
+
+	cidValue, expiryValue, ok := strings.Cut(payload, ".")
+	if !ok {
+		return 0, ErrInvalidState
+	}
+	cid, err := strconv.ParseUint(cidValue, 10, 64)
+	if err != nil {
+		return 0, ErrInvalidState
+	}
+	expiry, err := strconv.ParseInt(expiryValue, 10, 64)
+	if err != nil || now.Unix() > expiry {
+		return 0, ErrInvalidState
+	}
+
+	return uint(cid), nil
+}
+
+func sign(secret, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
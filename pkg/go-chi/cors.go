@@ -9,7 +9,8 @@ func NewCors(cfg *config.Config) cors.Options {
 	return cors.Options{
 		AllowedOrigins:   []string{cfg.Cors.AllowedOrigins},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "x-guest", "x-user", "x-api-key"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "x-guest", "x-user", "x-api-key", "Last-Event-ID"},
+		ExposedHeaders:   []string{"X-Total-Count"},
 		AllowCredentials: false,
 		MaxAge:           300,
 	}
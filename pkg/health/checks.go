@@ -0,0 +1,64 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/VATUSA/primary-api/pkg/database/migrations"
+	"github.com/VATUSA/primary-api/pkg/storage"
+	"gorm.io/gorm"
+)
+
+// probeObject is looked up to reach the storage backend. It is not expected to exist.
+const probeObject = ".readyz"
+
+// Database pings the database
+func Database(db *gorm.DB) Check {
+	return func(ctx context.Context) (string, error) {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return "", err
+		}
+		return "", sqlDB.PingContext(ctx)
+	}
+}
+
+// Storage looks up an object in the storage backend. A missing object still means the backend answered.
+func Storage(s storage.Storage) Check {
+	return func(ctx context.Context) (string, error) {
+		_, err := s.Stat("", probeObject)
+		if err != nil && !errors.Is(err, storage.ErrNotFound) {
+			return "", err
+		}
+		return "", nil
+	}
+}
+
+// Migrations reports the schema version, failing while any migration this build knows of is still pending
+func Migrations(m *migrations.Migrator) Check {
+	return func(ctx context.Context) (string, error) {
+		m := *m
+		m.DB = m.DB.WithContext(ctx)
+
+		statuses, err := m.Status()
+		if err != nil {
+			return "", err
+		}
+
+		var version uint
+		pending := 0
+		for _, status := range statuses {
+			if status.AppliedAt == nil {
+				pending++
+			} else if status.Version > version {
+				version = status.Version
+			}
+		}
+
+		detail := fmt.Sprintf("version %d", version)
+		if pending > 0 {
+			return detail, fmt.Errorf("%d migrations pending", pending)
+		}
+		return detail, nil
+	}
+}
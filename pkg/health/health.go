@@ -0,0 +1,153 @@
+// Package health serves the liveness and readiness probes. /healthz only says the process is up, while /readyz
+// runs a check against each dependency and reports whether the instance should be sent traffic.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusOK       = "ok"
+	StatusDown     = "down"
+	StatusDraining = "draining"
+)
+
+// DefaultTimeout is how long each readiness check may take before its component is reported down
+const DefaultTimeout = 5 * time.Second
+
+// Check reports whether a dependency is usable. The detail, such as a schema version, is shown alongside the
+// component's status.
+type Check func(ctx context.Context) (detail string, err error)
+
+// Component is the result of one check
+type Component struct {
+	Status    string `json:"status" example:"ok"`
+	Detail    string `json:"detail,omitempty" example:"version 2"`
+	Error     string `json:"error,omitempty" example:""`
+	LatencyMS int64  `json:"latency_ms" example:"3"`
+}
+
+// Report is the readiness of the instance and each of its components
+type Report struct {
+	Status     string               `json:"status" example:"ok"`
+	Components map[string]Component `json:"components"`
+}
+
+// Checker runs the readiness checks. Once Drain is called it reports the instance as draining, so load balancers
+// stop sending it new requests while the server shuts down.
+type Checker struct {
+	Timeout  time.Duration
+	checks   map[string]Check
+	draining atomic.Bool
+}
+
+func New() *Checker {
+	return &Checker{
+		Timeout: DefaultTimeout,
+		checks:  map[string]Check{},
+	}
+}
+
+// Add registers the check for a component
+func (c *Checker) Add(name string, check Check) {
+	c.checks[name] = check
+}
+
+// Drain marks the instance as shutting down
+func (c *Checker) Drain() {
+	c.draining.Store(true)
+}
+
+// Check runs every check at once and reports the instance ready only if they all pass
+func (c *Checker) Check(ctx context.Context) Report {
+	report := Report{Status: StatusOK, Components: map[string]Component{}}
+
+	names := []string{}
+	for name := range c.checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	results := make([]Component, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, check Check) {
+			defer wg.Done()
+			results[i] = c.run(ctx, check)
+		}(i, c.checks[name])
+	}
+	wg.Wait()
+
+	for i, name := range names {
+		report.Components[name] = results[i]
+		if results[i].Status != StatusOK {
+			report.Status = StatusDown
+		}
+	}
+	if c.draining.Load() {
+		report.Status = StatusDraining
+	}
+	return report
+}
+
+// run runs one check, giving up once the timeout passes even if the check itself ignores ctx
+func (c *Checker) run(ctx context.Context, check Check) Component {
+	ctx, cancel := context.WithTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	type result struct {
+		detail string
+		err    error
+	}
+	done := make(chan result, 1)
+	start := time.Now()
+	go func() {
+		detail, err := check(ctx)
+		done <- result{detail, err}
+	}()
+
+	var res result
+	select {
+	case res = <-done:
+	case <-ctx.Done():
+		res.err = ctx.Err()
+	}
+
+	component := Component{Status: StatusOK, Detail: res.detail, LatencyMS: time.Since(start).Milliseconds()}
+	if res.err != nil {
+		component.Status = StatusDown
+		component.Error = res.err.Error()
+	}
+	return component
+}
+
+// Live answers the liveness probe. It doesn't touch any dependency, so a database outage doesn't get every
+// instance restarted.
+func Live(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": StatusOK})
+}
+
+// Ready answers the readiness probe with the report, as 503 Service Unavailable unless every component is ok
+func (c *Checker) Ready(w http.ResponseWriter, r *http.Request) {
+	report := c.Check(r.Context())
+
+	status := http.StatusOK
+	if report.Status != StatusOK {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, report)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
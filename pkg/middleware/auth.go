@@ -36,6 +36,8 @@ func HasRoles(roles ...constants.RoleID) func(http.Handler) http.Handler {
 	}
 }
 
+// HasAPIKey requires an x-api-key header. It isn't mounted on any route, and doesn't check the key against the
+// facility keys issued by vatusa-admin, so those keys aren't enforced yet.
 func HasAPIKey(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		apiKey := r.Header.Get("x-api-key")
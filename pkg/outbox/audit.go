@@ -0,0 +1,66 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/VATUSA/primary-api/pkg/database/types"
+	"strconv"
+)
+
+// AuditActor is recorded as the creator of action log entries written from events
+const AuditActor = "System"
+
+// Audit is the outbox consumer that records changes to a member's rosters, roles and rating in their action log
+func Audit(ctx context.Context, e *models.OutboxEvent) error {
+	if e.AggregateType != models.UserAggregate {
+		return nil
+	}
+
+	cid, err := strconv.ParseUint(e.AggregateID, 10, 64)
+	if err != nil {
+		return err
+	}
+
+	entry, err := describe(e)
+	if err != nil || entry == "" {
+		return err
+	}
+
+	return (&models.ActionLogEntry{
+		CID:       uint(cid),
+		Entry:     entry,
+		CreatedBy: AuditActor,
+		UpdatedBy: AuditActor,
+	}).Create()
+}
+
+func describe(e *models.OutboxEvent) (string, error) {
+	switch e.Type {
+	case types.RosterAdded:
+		roster := &models.Roster{}
+		if err := json.Unmarshal([]byte(e.Payload), roster); err != nil {
+			return "", err
+		}
+		if roster.Visiting {
+			return fmt.Sprintf("Added to the %s roster as a visitor", roster.Facility), nil
+		}
+		return fmt.Sprintf("Added to the %s roster", roster.Facility), nil
+	case types.RosterRemoved:
+		return fmt.Sprintf("Removed from the %s roster", e.Facility), nil
+	case types.RoleGranted:
+		role := &models.UserRole{}
+		if err := json.Unmarshal([]byte(e.Payload), role); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Granted %s at %s", role.RoleID, role.FacilityID), nil
+	case types.RatingChanged:
+		change := &models.RatingChange{}
+		if err := json.Unmarshal([]byte(e.Payload), change); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Rating changed from %d to %d by %s", change.OldRating, change.NewRating, change.CreatedByCID), nil
+	}
+	return "", nil
+}
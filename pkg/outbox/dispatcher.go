@@ -0,0 +1,182 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BatchSize is how many aggregates are looked at on each pass
+const BatchSize = 500
+
+// Retention is how long processed events are kept before they are removed
+const Retention = 7 * 24 * time.Hour
+
+// Failed events are retried with exponential backoff, starting at InitialBackoff and doubling up to MaxBackoff.
+// Later events in the same aggregate wait behind them, so after MaxAttempts an event is marked dead and the
+// aggregate moves on without it.
+const (
+	InitialBackoff = 10 * time.Second
+	MaxBackoff     = time.Hour
+	MaxAttempts    = 20
+)
+
+// Handler consumes an event. An event can be handed to a handler more than once, such as when the dispatcher
+// stops between handling it and recording that it was handled, so handlers must tolerate duplicates.
+type Handler func(ctx context.Context, e *models.OutboxEvent) error
+
+type consumer struct {
+	name    string
+	handler Handler
+}
+
+// Dispatcher hands outbox events to the registered consumers. Each consumer sees the events of an aggregate in
+// the order they were written; an event that fails holds back the rest of its aggregate until it succeeds or
+// is marked dead.
+type Dispatcher struct {
+	// Lease is how long an event is held by the dispatcher handling it before another may try
+	Lease time.Duration
+
+	mu        sync.Mutex
+	consumers []consumer
+}
+
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{Lease: time.Minute}
+}
+
+// Register adds a consumer. Its name is recorded on the events it has handled, so it must stay the same
+// across restarts and must not contain a comma.
+func (d *Dispatcher) Register(name string, handler Handler) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.consumers = append(d.consumers, consumer{name: name, handler: handler})
+}
+
+// Backoff is how long to wait before retrying an event that has failed attempts times
+func Backoff(attempts int) time.Duration {
+	backoff := InitialBackoff
+	for i := 1; i < attempts; i++ {
+		backoff *= 2
+		if backoff >= MaxBackoff {
+			return MaxBackoff
+		}
+	}
+	return backoff
+}
+
+// Dispatch handles due events until there are none left. Each pass takes the oldest pending event of every
+// aggregate, so an aggregate only moves on once its head is handled.
+func (d *Dispatcher) Dispatch(ctx context.Context, now time.Time) error {
+	for ctx.Err() == nil {
+		events, err := models.GetDueOutboxEvents(now, BatchSize)
+		if err != nil {
+			return err
+		}
+
+		done := 0
+		for i := range events {
+			if d.dispatch(ctx, &events[i], now) {
+				done++
+			}
+		}
+		if done == 0 {
+			return nil
+		}
+	}
+	return nil
+}
+
+// dispatch claims and handles the event, recording the outcome. It reports whether the event is out of its
+// aggregate's way, either processed or dead.
+func (d *Dispatcher) dispatch(ctx context.Context, e *models.OutboxEvent, now time.Time) bool {
+	if ok, err := e.Claim(now, d.Lease); err != nil || !ok {
+		if err != nil {
+			log.Println("[Outbox] Error claiming event:", err)
+		}
+		return false
+	}
+
+	if err := d.Handle(ctx, e); err != nil {
+		e.Attempts++
+		e.LastError = err.Error()
+		if len(e.LastError) > 512 {
+			e.LastError = e.LastError[:512]
+		}
+		if e.Attempts >= MaxAttempts {
+			dead := now
+			e.DeadAt = &dead
+			log.Printf("[Outbox] Giving up on event %d (%s) after %d attempts: %v", e.ID, e.Type, e.Attempts, err)
+		} else {
+			e.NextAttemptAt = now.Add(Backoff(e.Attempts))
+			log.Printf("[Outbox] Error handling event %d (%s), attempt %d: %v", e.ID, e.Type, e.Attempts, err)
+		}
+	} else {
+		processed := now
+		e.ProcessedAt = &processed
+		e.LastError = ""
+	}
+
+	if err := e.Update(); err != nil {
+		log.Println("[Outbox] Error saving event:", err)
+		return false
+	}
+	return e.ProcessedAt != nil || e.DeadAt != nil
+}
+
+// Handle passes the event to each consumer that hasn't handled it yet, stopping at the first that fails. The
+// consumers that succeed are added to HandledBy; saving the event is left to the caller.
+func (d *Dispatcher) Handle(ctx context.Context, e *models.OutboxEvent) error {
+	d.mu.Lock()
+	consumers := d.consumers
+	d.mu.Unlock()
+
+	handled := map[string]bool{}
+	if e.HandledBy != "" {
+		for _, name := range strings.Split(e.HandledBy, ",") {
+			handled[name] = true
+		}
+	}
+
+	for _, c := range consumers {
+		if handled[c.name] {
+			continue
+		}
+		if err := c.handler(ctx, e); err != nil {
+			return fmt.Errorf("%s: %w", c.name, err)
+		}
+
+		handled[c.name] = true
+		if e.HandledBy == "" {
+			e.HandledBy = c.name
+		} else {
+			e.HandledBy += "," + c.name
+		}
+	}
+	return nil
+}
+
+// Run dispatches due events every interval until ctx is cancelled, removing old processed events as it goes
+func (d *Dispatcher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for now := time.Now(); ; {
+		if err := d.Dispatch(ctx, now); err != nil {
+			log.Println("[Outbox] Error dispatching events:", err)
+		}
+		if _, err := models.DeleteProcessedOutboxEvents(now.Add(-Retention)); err != nil {
+			log.Println("[Outbox] Error removing processed events:", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case now = <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package pubsub
+
+import "context"
+
+// DefaultHub is the hub used by the API to fan messages out to connected clients
+var DefaultHub Hub
+
+type Message struct {
+	ID    uint64
+	Event string
+	Data  []byte
+}
+
+// Hub delivers messages published on a topic to every current subscriber of that topic.
+// Delivery is best effort; subscribers that need history should replay it from the database
+// and use message IDs to de-duplicate.
+type Hub interface {
+	Publish(ctx context.Context, topic string, msg Message) error
+	// Subscribe returns a channel of messages for topic and a function that must be called to unsubscribe.
+	// The channel is closed once the subscription is cancelled or ctx is done.
+	Subscribe(ctx context.Context, topic string) (<-chan Message, func(), error)
+}
@@ -0,0 +1,80 @@
+package pubsub
+
+import (
+	"context"
+	"sync"
+)
+
+// SubscriberBuffer is the number of messages buffered per subscriber before new messages are dropped
+const SubscriberBuffer = 16
+
+type subscriber struct {
+	ch   chan Message
+	done chan struct{}
+	once sync.Once
+}
+
+// MemoryHub is an in-process Hub. It only reaches subscribers in the same process, so it is meant
+// for single replica deployments and tests.
+type MemoryHub struct {
+	mu     sync.RWMutex
+	topics map[string]map[*subscriber]struct{}
+}
+
+func NewMemoryHub() *MemoryHub {
+	return &MemoryHub{
+		topics: map[string]map[*subscriber]struct{}{},
+	}
+}
+
+func (h *MemoryHub) Publish(ctx context.Context, topic string, msg Message) error {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for sub := range h.topics[topic] {
+		select {
+		case sub.ch <- msg:
+		default:
+			// Slow consumer, drop rather than block the publisher
+		}
+	}
+
+	return ctx.Err()
+}
+
+func (h *MemoryHub) Subscribe(ctx context.Context, topic string) (<-chan Message, func(), error) {
+	sub := &subscriber{
+		ch:   make(chan Message, SubscriberBuffer),
+		done: make(chan struct{}),
+	}
+
+	h.mu.Lock()
+	if h.topics[topic] == nil {
+		h.topics[topic] = map[*subscriber]struct{}{}
+	}
+	h.topics[topic][sub] = struct{}{}
+	h.mu.Unlock()
+
+	cancel := func() {
+		sub.once.Do(func() {
+			h.mu.Lock()
+			delete(h.topics[topic], sub)
+			if len(h.topics[topic]) == 0 {
+				delete(h.topics, topic)
+			}
+			h.mu.Unlock()
+			close(sub.done)
+			close(sub.ch)
+		})
+	}
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			cancel()
+		case <-sub.done:
+		}
+	}()
+
+	return sub.ch, cancel, nil
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/VATUSA/primary-api/pkg/database/types"
+	"gorm.io/gorm"
+)
+
+// DocumentRepository stores documents and their uploaded versions
+type DocumentRepository interface {
+	Get(id uint) (*models.Document, error)
+	List() ([]models.Document, error)
+	ListByFacility(facility string) ([]models.Document, error)
+	ListByFacilityAndCategory(facility string, category types.DocumentCategory) ([]models.Document, error)
+	Create(doc *models.Document) error
+	Update(doc *models.Document, versions []models.DocumentVersion) error
+	Delete(doc *models.Document) error
+	ViewableIDs(ids []uint, user *models.User) (map[uint]bool, error)
+
+	GetVersion(documentID, version uint) (*models.DocumentVersion, error)
+	ListVersions(documentID uint) ([]models.DocumentVersion, error)
+	AddVersion(doc *models.Document, v *models.DocumentVersion, place func(v *models.DocumentVersion) error) error
+	DeleteVersion(v *models.DocumentVersion) error
+}
+
+type GormDocumentRepository struct {
+	DB *gorm.DB
+}
+
+func NewDocumentRepository(db *gorm.DB) *GormDocumentRepository {
+	return &GormDocumentRepository{DB: db}
+}
+
+func (r *GormDocumentRepository) Get(id uint) (*models.Document, error) {
+	doc := &models.Document{}
+	return doc, r.DB.Where("id = ?", id).First(doc).Error
+}
+
+func (r *GormDocumentRepository) List() ([]models.Document, error) {
+	return models.GetAllDocuments(r.DB)
+}
+
+func (r *GormDocumentRepository) ListByFacility(facility string) ([]models.Document, error) {
+	return models.GetAllDocumentsByFacility(r.DB, facility)
+}
+
+func (r *GormDocumentRepository) ListByFacilityAndCategory(facility string, category types.DocumentCategory) ([]models.Document, error) {
+	return models.GetAllDocumentsByFacilityAndCategory(r.DB, facility, category)
+}
+
+func (r *GormDocumentRepository) Create(doc *models.Document) error {
+	return r.DB.Create(doc).Error
+}
+
+// Update saves the document and the given versions together, raising document.updated
+func (r *GormDocumentRepository) Update(doc *models.Document, versions []models.DocumentVersion) error {
+	return models.UpdateDocumentWithVersions(r.DB, doc, versions)
+}
+
+func (r *GormDocumentRepository) Delete(doc *models.Document) error {
+	return r.DB.Delete(doc).Error
+}
+
+func (r *GormDocumentRepository) ViewableIDs(ids []uint, user *models.User) (map[uint]bool, error) {
+	return models.GetViewableDocumentIDs(r.DB, ids, user)
+}
+
+func (r *GormDocumentRepository) GetVersion(documentID, version uint) (*models.DocumentVersion, error) {
+	v := &models.DocumentVersion{}
+	return v, r.DB.Where("document_id = ? AND version = ?", documentID, version).First(v).Error
+}
+
+func (r *GormDocumentRepository) ListVersions(documentID uint) ([]models.DocumentVersion, error) {
+	return models.GetAllDocumentVersions(r.DB, documentID)
+}
+
+func (r *GormDocumentRepository) AddVersion(doc *models.Document, v *models.DocumentVersion, place func(v *models.DocumentVersion) error) error {
+	return models.AddDocumentVersion(r.DB, doc, v, place)
+}
+
+func (r *GormDocumentRepository) DeleteVersion(v *models.DocumentVersion) error {
+	return r.DB.Delete(v).Error
+}
@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"gorm.io/gorm"
+)
+
+type FacilityRepository interface {
+	Exists(id string) (bool, error)
+}
+
+type GormFacilityRepository struct {
+	DB *gorm.DB
+}
+
+func NewFacilityRepository(db *gorm.DB) *GormFacilityRepository {
+	return &GormFacilityRepository{DB: db}
+}
+
+func (r *GormFacilityRepository) Exists(id string) (bool, error) {
+	return exists(r.DB.Where("id = ?", id), &models.Facility{})
+}
@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"gorm.io/gorm"
+)
+
+type FeedbackRepository interface {
+	Get(id uint) (*models.Feedback, error)
+	List() ([]models.Feedback, error)
+	Create(f *models.Feedback) error
+	Update(f *models.Feedback) error
+	Delete(f *models.Feedback) error
+}
+
+type GormFeedbackRepository struct {
+	DB *gorm.DB
+}
+
+func NewFeedbackRepository(db *gorm.DB) *GormFeedbackRepository {
+	return &GormFeedbackRepository{DB: db}
+}
+
+func (r *GormFeedbackRepository) Get(id uint) (*models.Feedback, error) {
+	f := &models.Feedback{}
+	return f, r.DB.Where("id = ?", id).First(f).Error
+}
+
+func (r *GormFeedbackRepository) List() ([]models.Feedback, error) {
+	return models.GetAllFeedback(r.DB)
+}
+
+func (r *GormFeedbackRepository) Create(f *models.Feedback) error {
+	return models.CreateFeedback(r.DB, f)
+}
+
+func (r *GormFeedbackRepository) Update(f *models.Feedback) error {
+	return models.UpdateFeedback(r.DB, f)
+}
+
+func (r *GormFeedbackRepository) Delete(f *models.Feedback) error {
+	return r.DB.Delete(f).Error
+}
@@ -0,0 +1,41 @@
+// Package repository is the storage behind the service layer. Each aggregate has an interface, so services can
+// be handed fakes in tests, and a GORM implementation that works through whatever *gorm.DB it is given,
+// including a transaction.
+package repository
+
+import (
+	"gorm.io/gorm"
+)
+
+// ErrNotFound is returned when the record asked for doesn't exist. It is gorm.ErrRecordNotFound, so fakes can
+// return it and callers can match it either way.
+var ErrNotFound = gorm.ErrRecordNotFound
+
+// Repositories is a set of repositories sharing the same database or transaction
+type Repositories struct {
+	Users      UserRepository
+	Facilities FacilityRepository
+	Roster     RosterRepository
+	Roles      RoleRepository
+	Feedback   FeedbackRepository
+	Documents  DocumentRepository
+}
+
+// New returns the GORM repositories working through db
+func New(db *gorm.DB) Repositories {
+	return Repositories{
+		Users:      NewUserRepository(db),
+		Facilities: NewFacilityRepository(db),
+		Roster:     NewRosterRepository(db),
+		Roles:      NewRoleRepository(db),
+		Feedback:   NewFeedbackRepository(db),
+		Documents:  NewDocumentRepository(db),
+	}
+}
+
+// exists reports whether query matches a row of model
+func exists(query *gorm.DB, model interface{}) (bool, error) {
+	var count int64
+	err := query.Model(model).Count(&count).Error
+	return count > 0, err
+}
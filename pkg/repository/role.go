@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"gorm.io/gorm"
+)
+
+type RoleRepository interface {
+	Get(id uint) (*models.UserRole, error)
+	List() ([]models.UserRole, error)
+	ListByCID(cid uint) ([]models.UserRole, error)
+	Create(role *models.UserRole) error
+	Update(role *models.UserRole) error
+	Delete(role *models.UserRole) error
+}
+
+type GormRoleRepository struct {
+	DB *gorm.DB
+}
+
+func NewRoleRepository(db *gorm.DB) *GormRoleRepository {
+	return &GormRoleRepository{DB: db}
+}
+
+func (r *GormRoleRepository) Get(id uint) (*models.UserRole, error) {
+	role := &models.UserRole{}
+	return role, r.DB.Where("id = ?", id).First(role).Error
+}
+
+func (r *GormRoleRepository) List() ([]models.UserRole, error) {
+	return models.GetAllUserRoles(r.DB)
+}
+
+func (r *GormRoleRepository) ListByCID(cid uint) ([]models.UserRole, error) {
+	return models.GetAllUserRolesByCID(r.DB, cid)
+}
+
+func (r *GormRoleRepository) Create(role *models.UserRole) error {
+	return models.CreateUserRole(r.DB, role)
+}
+
+func (r *GormRoleRepository) Update(role *models.UserRole) error {
+	return r.DB.Save(role).Error
+}
+
+func (r *GormRoleRepository) Delete(role *models.UserRole) error {
+	return r.DB.Delete(role).Error
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"gorm.io/gorm"
+	"time"
+)
+
+// RosterRepository stores roster entries and the certifications held through them
+type RosterRepository interface {
+	Get(id uint) (*models.Roster, error)
+	List() ([]models.Roster, error)
+	ListByCID(cid uint) ([]models.Roster, error)
+	ListByFacility(facility string) ([]models.Roster, error)
+	Create(roster *models.Roster) error
+	Update(roster *models.Roster) error
+	Delete(roster *models.Roster) error
+
+	GetCertification(id uint) (*models.Certification, error)
+	ExpiredSoloCertifications(now time.Time) ([]models.Certification, error)
+	DeleteExpiredSolo(cert *models.Certification, now time.Time) (bool, error)
+	CreateCertification(cert *models.Certification) error
+	UpdateCertification(cert *models.Certification) error
+	DeleteCertification(cert *models.Certification) error
+}
+
+type GormRosterRepository struct {
+	DB *gorm.DB
+}
+
+func NewRosterRepository(db *gorm.DB) *GormRosterRepository {
+	return &GormRosterRepository{DB: db}
+}
+
+// Get loads the roster entry along with its certifications
+func (r *GormRosterRepository) Get(id uint) (*models.Roster, error) {
+	roster := &models.Roster{ID: id}
+	return roster, models.GetRoster(r.DB, roster)
+}
+
+func (r *GormRosterRepository) List() ([]models.Roster, error) {
+	return models.GetAllRosters(r.DB)
+}
+
+func (r *GormRosterRepository) ListByCID(cid uint) ([]models.Roster, error) {
+	return models.GetAllRostersByCID(r.DB, cid)
+}
+
+func (r *GormRosterRepository) ListByFacility(facility string) ([]models.Roster, error) {
+	return models.GetAllRostersByFacility(r.DB, facility)
+}
+
+func (r *GormRosterRepository) Create(roster *models.Roster) error {
+	return models.CreateRoster(r.DB, roster)
+}
+
+func (r *GormRosterRepository) Update(roster *models.Roster) error {
+	return models.UpdateRoster(r.DB, roster)
+}
+
+func (r *GormRosterRepository) Delete(roster *models.Roster) error {
+	return models.DeleteRoster(r.DB, roster)
+}
+
+func (r *GormRosterRepository) GetCertification(id uint) (*models.Certification, error) {
+	cert := &models.Certification{}
+	return cert, r.DB.Where("id = ?", id).First(cert).Error
+}
+
+func (r *GormRosterRepository) ExpiredSoloCertifications(now time.Time) ([]models.Certification, error) {
+	return models.GetExpiredSoloCertifications(r.DB, now)
+}
+
+func (r *GormRosterRepository) DeleteExpiredSolo(cert *models.Certification, now time.Time) (bool, error) {
+	return models.DeleteExpiredSoloCertification(r.DB, cert, now)
+}
+
+func (r *GormRosterRepository) CreateCertification(cert *models.Certification) error {
+	return r.DB.Create(cert).Error
+}
+
+func (r *GormRosterRepository) UpdateCertification(cert *models.Certification) error {
+	return r.DB.Save(cert).Error
+}
+
+func (r *GormRosterRepository) DeleteCertification(cert *models.Certification) error {
+	return r.DB.Delete(cert).Error
+}
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"gorm.io/gorm"
+)
+
+type UserRepository interface {
+	Get(cid uint) (*models.User, error)
+	Exists(cid uint) (bool, error)
+	List() ([]models.User, error)
+	Search(s models.UserSearch) ([]models.User, int64, error)
+	Create(user *models.User) error
+	Update(user *models.User) error
+	Delete(user *models.User) error
+}
+
+type GormUserRepository struct {
+	DB *gorm.DB
+}
+
+func NewUserRepository(db *gorm.DB) *GormUserRepository {
+	return &GormUserRepository{DB: db}
+}
+
+// Get loads the user along with their roles
+func (r *GormUserRepository) Get(cid uint) (*models.User, error) {
+	user := &models.User{}
+	return user, r.DB.Where("c_id = ?", cid).Preload("Roles").First(user).Error
+}
+
+func (r *GormUserRepository) Exists(cid uint) (bool, error) {
+	return exists(r.DB.Where("c_id = ?", cid), &models.User{})
+}
+
+func (r *GormUserRepository) List() ([]models.User, error) {
+	return models.GetAllUsers(r.DB)
+}
+
+func (r *GormUserRepository) Search(s models.UserSearch) ([]models.User, int64, error) {
+	return models.SearchUsers(r.DB, s)
+}
+
+func (r *GormUserRepository) Create(user *models.User) error {
+	return r.DB.Create(user).Error
+}
+
+func (r *GormUserRepository) Update(user *models.User) error {
+	return r.DB.Save(user).Error
+}
+
+func (r *GormUserRepository) Delete(user *models.User) error {
+	return r.DB.Delete(user).Error
+}
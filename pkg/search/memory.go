@@ -0,0 +1,88 @@
+package search
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type memoryEntry struct {
+	result Result
+	body   string
+}
+
+// MemoryIndex is an in-process Searcher for tests. Titles weigh twice as much as bodies.
+type MemoryIndex struct {
+	mu      sync.RWMutex
+	entries []memoryEntry
+}
+
+func NewMemoryIndex() *MemoryIndex {
+	return &MemoryIndex{}
+}
+
+// Add indexes an item. Only the Type, ID, Facility and Title of result are used.
+func (m *MemoryIndex) Add(result Result, body string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries = append(m.entries, memoryEntry{result: result, body: body})
+}
+
+func (m *MemoryIndex) Search(ctx context.Context, q Query) ([]Result, error) {
+	terms := Terms(q.Text)
+	if len(terms) == 0 {
+		return []Result{}, nil
+	}
+
+	facilities := map[string]bool{}
+	for _, f := range q.Facilities() {
+		facilities[f] = true
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	results := []Result{}
+	for _, entry := range m.entries {
+		if !q.WantsType(entry.result.Type) {
+			continue
+		}
+		if q.Facility != "" && !facilities[entry.result.Facility] {
+			continue
+		}
+
+		title := strings.ToLower(entry.result.Title)
+		body := strings.ToLower(entry.body)
+
+		var score float64
+		for _, term := range terms {
+			score += 2*float64(strings.Count(title, term)) + float64(strings.Count(body, term))
+		}
+		if score == 0 {
+			continue
+		}
+
+		result := entry.result
+		result.Score = score
+		result.Snippet = Highlight(entry.body, terms)
+		results = append(results, result)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+
+	return page(results, q), ctx.Err()
+}
+
+func page(results []Result, q Query) []Result {
+	if q.Offset >= len(results) {
+		return []Result{}
+	}
+	results = results[q.Offset:]
+	if q.Limit > 0 && q.Limit < len(results) {
+		results = results[:q.Limit]
+	}
+	return results
+}
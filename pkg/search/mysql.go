@@ -0,0 +1,103 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"gorm.io/gorm"
+	"sort"
+	"time"
+)
+
+// MySQL searches with the FULLTEXT indexes added by migration 0006
+type MySQL struct {
+	DB *gorm.DB
+}
+
+func NewMySQL(db *gorm.DB) *MySQL {
+	return &MySQL{DB: db}
+}
+
+type source struct {
+	kind    string
+	model   interface{}
+	title   string
+	body    string
+	columns string
+	scope   func(*gorm.DB) *gorm.DB // Limits the rows that are searchable
+}
+
+var sources = []source{
+	{kind: DocumentType, model: &models.Document{}, title: "name", body: "description", columns: "name, description"},
+	{kind: FAQType, model: &models.FAQ{}, title: "question", body: "answer", columns: "question, answer", scope: models.PublishedFAQ},
+	{kind: NewsType, model: &models.News{}, title: "title", body: "description", columns: "title, description", scope: liveNews},
+}
+
+func liveNews(db *gorm.DB) *gorm.DB {
+	return models.LiveNews(time.Now())(db)
+}
+
+type row struct {
+	ID       uint
+	Facility string
+	Title    string
+	Body     string
+	Score    float64
+}
+
+func (m *MySQL) Search(ctx context.Context, q Query) ([]Result, error) {
+	terms := Terms(q.Text)
+	if len(terms) == 0 {
+		return []Result{}, nil
+	}
+
+	// Each source is asked for enough rows to fill the requested page once they are merged
+	perSource := q.Offset + q.Limit
+	if q.Limit <= 0 {
+		perSource = 0
+	}
+
+	results := []Result{}
+	for _, src := range sources {
+		if !q.WantsType(src.kind) {
+			continue
+		}
+
+		match := fmt.Sprintf("MATCH(%s) AGAINST (? IN NATURAL LANGUAGE MODE)", src.columns)
+		query := m.DB.WithContext(ctx).Model(src.model).
+			Select(fmt.Sprintf("id, facility, %s AS title, %s AS body, %s AS score", src.title, src.body, match), q.Text).
+			Where(match, q.Text).
+			Order("score DESC")
+		if src.scope != nil {
+			query = query.Scopes(src.scope)
+		}
+		if q.Facility != "" {
+			query = query.Where("facility IN ?", q.Facilities())
+		}
+		if perSource > 0 {
+			query = query.Limit(perSource)
+		}
+
+		var rows []row
+		if err := query.Scan(&rows).Error; err != nil {
+			return nil, err
+		}
+
+		for _, r := range rows {
+			results = append(results, Result{
+				Type:     src.kind,
+				ID:       r.ID,
+				Facility: r.Facility,
+				Title:    r.Title,
+				Snippet:  Highlight(r.Body, terms),
+				Score:    r.Score,
+			})
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+
+	return page(results, q), nil
+}
@@ -0,0 +1,143 @@
+package search
+
+import (
+	"context"
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"html"
+	"regexp"
+	"strings"
+)
+
+const (
+	DocumentType = "document"
+	FAQType      = "faq"
+	NewsType     = "news"
+)
+
+// DivisionFacility holds division-wide content, which is included whenever a search is scoped to a facility
+const DivisionFacility = models.DivisionFacility
+
+type Query struct {
+	Text     string
+	Facility string
+	Types    []string
+	Limit    int
+	Offset   int
+}
+
+// WantsType reports whether results of type t were asked for
+func (q Query) WantsType(t string) bool {
+	if len(q.Types) == 0 {
+		return true
+	}
+	for _, want := range q.Types {
+		if want == t {
+			return true
+		}
+	}
+	return false
+}
+
+// Facilities are the facilities a scoped search matches
+func (q Query) Facilities() []string {
+	if q.Facility == "" || q.Facility == DivisionFacility {
+		return []string{DivisionFacility}
+	}
+	return []string{q.Facility, DivisionFacility}
+}
+
+type Result struct {
+	Type     string  `json:"type" example:"document"`
+	ID       uint    `json:"id" example:"1"`
+	Facility string  `json:"facility" example:"ZDV"`
+	Title    string  `json:"title" example:"DP001"`
+	Snippet  string  `json:"snippet" example:"General <mark>Division</mark> Policy"`
+	Score    float64 `json:"score" example:"1.5"`
+}
+
+// Searcher finds documents, FAQs and news matching a query, best matches first
+type Searcher interface {
+	Search(ctx context.Context, q Query) ([]Result, error)
+}
+
+// SnippetRadius is the number of characters kept either side of the first match in a snippet
+const SnippetRadius = 80
+
+var termPattern = regexp.MustCompile(`[\pL\pN]+`)
+
+// Terms splits text into the lower case words used for matching
+func Terms(text string) []string {
+	var terms []string
+	for _, term := range termPattern.FindAllString(strings.ToLower(text), -1) {
+		if len([]rune(term)) > 1 {
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+// Highlight returns an HTML-escaped excerpt of text around the first match of any term, with every match
+// wrapped in <mark>
+func Highlight(text string, terms []string) string {
+	if len(terms) == 0 {
+		return html.EscapeString(truncate(text, 0, 2*SnippetRadius))
+	}
+
+	quoted := make([]string, len(terms))
+	for i, term := range terms {
+		quoted[i] = regexp.QuoteMeta(term)
+	}
+	pattern := regexp.MustCompile(`(?i)` + strings.Join(quoted, "|"))
+
+	start := 0
+	if first := pattern.FindStringIndex(text); first != nil {
+		start = first[0] - SnippetRadius
+	}
+	window := truncate(text, start, 2*SnippetRadius)
+
+	var b strings.Builder
+	last := 0
+	for _, match := range pattern.FindAllStringIndex(window, -1) {
+		b.WriteString(html.EscapeString(window[last:match[0]]))
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(window[match[0]:match[1]]))
+		b.WriteString("</mark>")
+		last = match[1]
+	}
+	b.WriteString(html.EscapeString(window[last:]))
+
+	return b.String()
+}
+
+// truncate returns up to length bytes of text from start, moved onto rune boundaries and marked with
+// ellipses where text was cut
+func truncate(text string, start, length int) string {
+	if start < 0 {
+		start = 0
+	}
+	for start > 0 && start < len(text) && !isRuneStart(text[start]) {
+		start--
+	}
+
+	end := start + length
+	if end >= len(text) {
+		end = len(text)
+	} else {
+		for end > start && !isRuneStart(text[end]) {
+			end--
+		}
+	}
+
+	excerpt := text[start:end]
+	if start > 0 {
+		excerpt = "…" + excerpt
+	}
+	if end < len(text) {
+		excerpt += "…"
+	}
+	return excerpt
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
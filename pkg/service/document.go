@@ -0,0 +1,89 @@
+package service
+
+import (
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/VATUSA/primary-api/pkg/database/types"
+	"github.com/VATUSA/primary-api/pkg/repository"
+)
+
+type DocumentService struct {
+	documents repository.DocumentRepository
+}
+
+func NewDocumentService(documents repository.DocumentRepository) *DocumentService {
+	return &DocumentService{documents: documents}
+}
+
+func (s *DocumentService) Get(id uint) (*models.Document, error) {
+	return s.documents.Get(id)
+}
+
+func (s *DocumentService) List() ([]models.Document, error) {
+	return s.documents.List()
+}
+
+func (s *DocumentService) ListByFacility(facility string) ([]models.Document, error) {
+	return s.documents.ListByFacility(facility)
+}
+
+func (s *DocumentService) ListByFacilityAndCategory(facility string, category types.DocumentCategory) ([]models.Document, error) {
+	return s.documents.ListByFacilityAndCategory(facility, category)
+}
+
+// NameTaken reports whether another document in doc's facility and category already uses its name. Names are
+// compared as they appear in storage keys, so "DP 001" and "DP-001" collide.
+func (s *DocumentService) NameTaken(doc *models.Document) (bool, error) {
+	docs, err := s.documents.ListByFacilityAndCategory(doc.Facility, doc.Category)
+	if err != nil {
+		return false, err
+	}
+
+	for _, d := range docs {
+		if d.ID != doc.ID && d.ObjectName() == doc.ObjectName() {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *DocumentService) Create(doc *models.Document) error {
+	return s.documents.Create(doc)
+}
+
+// Update saves the document and raises document.updated
+func (s *DocumentService) Update(doc *models.Document) error {
+	return s.documents.Update(doc, nil)
+}
+
+// UpdateWithVersions saves the document and its versions in a single transaction
+func (s *DocumentService) UpdateWithVersions(doc *models.Document, versions []models.DocumentVersion) error {
+	return s.documents.Update(doc, versions)
+}
+
+func (s *DocumentService) Delete(doc *models.Document) error {
+	return s.documents.Delete(doc)
+}
+
+// Viewable returns which of the documents the user may download
+func (s *DocumentService) Viewable(ids []uint, user *models.User) (map[uint]bool, error) {
+	return s.documents.ViewableIDs(ids, user)
+}
+
+func (s *DocumentService) GetVersion(doc *models.Document, version uint) (*models.DocumentVersion, error) {
+	return s.documents.GetVersion(doc.ID, version)
+}
+
+// Versions returns the document's versions, newest first
+func (s *DocumentService) Versions(doc *models.Document) ([]models.DocumentVersion, error) {
+	return s.documents.ListVersions(doc.ID)
+}
+
+// AddVersion records a newly uploaded version as the next version of the document and makes it the current
+// version. place is called with the version's number before it is saved, as in models.AddDocumentVersion.
+func (s *DocumentService) AddVersion(doc *models.Document, v *models.DocumentVersion, place func(v *models.DocumentVersion) error) error {
+	return s.documents.AddVersion(doc, v, place)
+}
+
+func (s *DocumentService) DeleteVersion(v *models.DocumentVersion) error {
+	return s.documents.DeleteVersion(v)
+}
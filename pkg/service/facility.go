@@ -0,0 +1,23 @@
+package service
+
+import (
+	"github.com/VATUSA/primary-api/pkg/repository"
+	"log"
+)
+
+type FacilityService struct {
+	facilities repository.FacilityRepository
+}
+
+func NewFacilityService(facilities repository.FacilityRepository) *FacilityService {
+	return &FacilityService{facilities: facilities}
+}
+
+// Exists reports whether there is a facility with the ID, treating a failed lookup as no
+func (s *FacilityService) Exists(id string) bool {
+	ok, err := s.facilities.Exists(id)
+	if err != nil {
+		log.Println("[Service] Error looking up facility:", err)
+	}
+	return ok
+}
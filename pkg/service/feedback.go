@@ -0,0 +1,36 @@
+package service
+
+import (
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/VATUSA/primary-api/pkg/repository"
+)
+
+type FeedbackService struct {
+	feedback repository.FeedbackRepository
+}
+
+func NewFeedbackService(feedback repository.FeedbackRepository) *FeedbackService {
+	return &FeedbackService{feedback: feedback}
+}
+
+func (s *FeedbackService) Get(id uint) (*models.Feedback, error) {
+	return s.feedback.Get(id)
+}
+
+func (s *FeedbackService) List() ([]models.Feedback, error) {
+	return s.feedback.List()
+}
+
+// Create saves the feedback, raising feedback.approved if it is entered already approved
+func (s *FeedbackService) Create(f *models.Feedback) error {
+	return s.feedback.Create(f)
+}
+
+// Update saves the feedback, raising feedback.approved when this change approves it
+func (s *FeedbackService) Update(f *models.Feedback) error {
+	return s.feedback.Update(f)
+}
+
+func (s *FeedbackService) Delete(f *models.Feedback) error {
+	return s.feedback.Delete(f)
+}
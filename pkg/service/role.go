@@ -0,0 +1,35 @@
+package service
+
+import (
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/VATUSA/primary-api/pkg/repository"
+)
+
+type RoleService struct {
+	roles repository.RoleRepository
+}
+
+func NewRoleService(roles repository.RoleRepository) *RoleService {
+	return &RoleService{roles: roles}
+}
+
+func (s *RoleService) Get(id uint) (*models.UserRole, error) {
+	return s.roles.Get(id)
+}
+
+func (s *RoleService) List() ([]models.UserRole, error) {
+	return s.roles.List()
+}
+
+// Create grants the role and raises role.granted for the member
+func (s *RoleService) Create(role *models.UserRole) error {
+	return s.roles.Create(role)
+}
+
+func (s *RoleService) Update(role *models.UserRole) error {
+	return s.roles.Update(role)
+}
+
+func (s *RoleService) Delete(role *models.UserRole) error {
+	return s.roles.Delete(role)
+}
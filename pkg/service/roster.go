@@ -0,0 +1,111 @@
+package service
+
+import (
+	"errors"
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/VATUSA/primary-api/pkg/repository"
+	"time"
+)
+
+// ErrCertificationHeld is returned when a roster member already holds a certification of the same type and position
+var ErrCertificationHeld = errors.New("certification is already held")
+
+type RosterService struct {
+	rosters repository.RosterRepository
+}
+
+func NewRosterService(rosters repository.RosterRepository) *RosterService {
+	return &RosterService{rosters: rosters}
+}
+
+// Get loads the roster entry along with its certifications
+func (s *RosterService) Get(id uint) (*models.Roster, error) {
+	return s.rosters.Get(id)
+}
+
+func (s *RosterService) List() ([]models.Roster, error) {
+	return s.rosters.List()
+}
+
+// Create adds the member to the facility roster and raises roster.added
+func (s *RosterService) Create(roster *models.Roster) error {
+	return s.rosters.Create(roster)
+}
+
+// Update saves the roster entry, keeping its certifications in step with the member and facility
+func (s *RosterService) Update(roster *models.Roster) error {
+	return s.rosters.Update(roster)
+}
+
+// Delete removes the roster entry along with its certifications and raises roster.removed
+func (s *RosterService) Delete(roster *models.Roster) error {
+	return s.rosters.Delete(roster)
+}
+
+// GetCertification loads a certification held through the roster entry, returning repository.ErrNotFound if it
+// belongs to another entry
+func (s *RosterService) GetCertification(roster *models.Roster, id uint) (*models.Certification, error) {
+	cert, err := s.rosters.GetCertification(id)
+	if err != nil {
+		return nil, err
+	}
+	if cert.RosterID != roster.ID {
+		return nil, repository.ErrNotFound
+	}
+	return cert, nil
+}
+
+// held reports whether the roster member already holds a certification of that type and position, other than cert
+func held(roster *models.Roster, cert *models.Certification) bool {
+	for _, c := range roster.Certifications {
+		if c.ID != cert.ID && c.Type == cert.Type && c.Position == cert.Position {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateCertification grants the certification through the roster entry
+func (s *RosterService) CreateCertification(roster *models.Roster, cert *models.Certification) error {
+	if held(roster, cert) {
+		return ErrCertificationHeld
+	}
+
+	cert.RosterID = roster.ID
+	cert.CID = roster.CID
+	cert.Facility = roster.Facility
+	return s.rosters.CreateCertification(cert)
+}
+
+func (s *RosterService) UpdateCertification(roster *models.Roster, cert *models.Certification) error {
+	if held(roster, cert) {
+		return ErrCertificationHeld
+	}
+	return s.rosters.UpdateCertification(cert)
+}
+
+func (s *RosterService) DeleteCertification(cert *models.Certification) error {
+	return s.rosters.DeleteCertification(cert)
+}
+
+// RemoveExpiredSolos deletes the solo certifications that have expired by now and returns the ones this call
+// removed, leaving out any another sweep got to first. If a delete fails, the certifications removed before it
+// are returned along with the error.
+func (s *RosterService) RemoveExpiredSolos(now time.Time) ([]models.Certification, error) {
+	expired, err := s.rosters.ExpiredSoloCertifications(now)
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []models.Certification
+	for i := range expired {
+		ok, err := s.rosters.DeleteExpiredSolo(&expired[i], now)
+		if err != nil {
+			return removed, err
+		}
+		if ok {
+			removed = append(removed, expired[i])
+		}
+	}
+	return removed, nil
+}
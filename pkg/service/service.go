@@ -0,0 +1,51 @@
+// Package service holds the logic behind the API handlers for the core aggregates: users, facilities, the
+// roster, roles, feedback and documents. Services work through the repositories they are built with, so the
+// same code runs against the database, a transaction, or fakes in tests.
+package service
+
+import (
+	"github.com/VATUSA/primary-api/pkg/repository"
+	"gorm.io/gorm"
+)
+
+// Services is the set of services handed to the routers
+type Services struct {
+	Users      *UserService
+	Facilities *FacilityService
+	Roster     *RosterService
+	Roles      *RoleService
+	Feedback   *FeedbackService
+	Documents  *DocumentService
+
+	db *gorm.DB
+}
+
+// New returns services backed by db, which may itself be a transaction
+func New(db *gorm.DB) *Services {
+	s := NewWithRepositories(repository.New(db))
+	s.db = db
+	return s
+}
+
+// NewWithRepositories returns services backed by the given repositories, such as fakes in tests
+func NewWithRepositories(repos repository.Repositories) *Services {
+	return &Services{
+		Users:      NewUserService(repos.Users),
+		Facilities: NewFacilityService(repos.Facilities),
+		Roster:     NewRosterService(repos.Roster),
+		Roles:      NewRoleService(repos.Roles),
+		Feedback:   NewFeedbackService(repos.Feedback),
+		Documents:  NewDocumentService(repos.Documents),
+	}
+}
+
+// Transaction calls fn with services bound to a single transaction, committing it if fn returns nil and rolling
+// it back otherwise. Services built from repositories rather than a database just pass themselves to fn.
+func (s *Services) Transaction(fn func(tx *Services) error) error {
+	if s.db == nil {
+		return fn(s)
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		return fn(New(tx))
+	})
+}
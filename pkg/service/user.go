@@ -0,0 +1,48 @@
+package service
+
+import (
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/VATUSA/primary-api/pkg/repository"
+	"log"
+)
+
+type UserService struct {
+	users repository.UserRepository
+}
+
+func NewUserService(users repository.UserRepository) *UserService {
+	return &UserService{users: users}
+}
+
+func (s *UserService) Get(cid uint) (*models.User, error) {
+	return s.users.Get(cid)
+}
+
+// Exists reports whether there is a user with the CID, treating a failed lookup as no
+func (s *UserService) Exists(cid uint) bool {
+	ok, err := s.users.Exists(cid)
+	if err != nil {
+		log.Println("[Service] Error looking up user:", err)
+	}
+	return ok
+}
+
+func (s *UserService) List() ([]models.User, error) {
+	return s.users.List()
+}
+
+func (s *UserService) Search(search models.UserSearch) ([]models.User, int64, error) {
+	return s.users.Search(search)
+}
+
+func (s *UserService) Create(user *models.User) error {
+	return s.users.Create(user)
+}
+
+func (s *UserService) Update(user *models.User) error {
+	return s.users.Update(user)
+}
+
+func (s *UserService) Delete(user *models.User) error {
+	return s.users.Delete(user)
+}
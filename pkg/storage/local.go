@@ -0,0 +1,279 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	cfg "github.com/VATUSA/primary-api/pkg/config"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var _ Storage = (*LocalStorage)(nil)
+
+// MinSecretLength is the shortest secret the local backend will sign presigned URLs with
+const MinSecretLength = 32
+
+// LocalStorage is a Storage kept on the local filesystem. Presigned URLs point at BaseURL, which must be
+// served by Handler.
+type LocalStorage struct {
+	Root    string
+	BaseURL string
+	secret  []byte
+}
+
+func NewLocalStorage(cfg *cfg.StorageConfig) (*LocalStorage, error) {
+	if cfg.LocalPath == "" {
+		return nil, errors.New("local storage requires STORAGE_LOCAL_PATH")
+	}
+
+	// The secret signs presigned URLs, so a short one would let them be forged
+	if len(cfg.Secret) < MinSecretLength {
+		return nil, fmt.Errorf("local storage requires STORAGE_SECRET of at least %d bytes", MinSecretLength)
+	}
+
+	// Handler is mounted on the path of the base URL, so it can't be the root
+	base, err := url.Parse(cfg.LocalURL)
+	if err != nil {
+		return nil, err
+	}
+	if strings.Trim(base.Path, "/") == "" {
+		return nil, errors.New("local storage requires STORAGE_LOCAL_URL with a path, such as http://localhost:8080/storage")
+	}
+
+	root, err := filepath.Abs(cfg.LocalPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, err
+	}
+
+	return &LocalStorage{
+		Root:    root,
+		BaseURL: strings.TrimSuffix(cfg.LocalURL, "/"),
+		secret:  []byte(cfg.Secret),
+	}, nil
+}
+
+// resolve maps a key onto the filesystem, refusing keys that would escape Root
+func (l *LocalStorage) resolve(key string) (string, error) {
+	cleaned := path.Clean("/" + key)
+	if cleaned == "/" {
+		return "", errors.New("invalid key")
+	}
+	return filepath.Join(l.Root, filepath.FromSlash(cleaned)), nil
+}
+
+func (l *LocalStorage) Upload(directory string, filename string, body io.Reader) error {
+	target, err := l.resolve(path.Join(directory, filename))
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so readers never see a partial object
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, body); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), target)
+}
+
+func (l *LocalStorage) Replace(directory string, filename string, body io.Reader) error {
+	return l.Upload(directory, filename, body)
+}
+
+func (l *LocalStorage) Delete(directory, filename string) error {
+	target, err := l.resolve(path.Join(directory, filename))
+	if err != nil {
+		return err
+	}
+
+	// Match S3, where deleting a missing object is not an error
+	if err := os.Remove(target); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (l *LocalStorage) Get(directory, filename string) (io.ReadCloser, error) {
+	target, err := l.resolve(path.Join(directory, filename))
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(target)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+func (l *LocalStorage) Stat(directory, filename string) (*ObjectInfo, error) {
+	key := path.Join(directory, filename)
+	target, err := l.resolve(key)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(target)
+	if errors.Is(err, fs.ErrNotExist) || (err == nil && info.IsDir()) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &ObjectInfo{
+		Key:          key,
+		Size:         info.Size(),
+		ContentType:  mime.TypeByExtension(path.Ext(filename)),
+		LastModified: info.ModTime(),
+	}, nil
+}
+
+func (l *LocalStorage) List(directory string) ([]ObjectInfo, error) {
+	var objects []ObjectInfo
+
+	dir, err := l.resolve(directory)
+	if err != nil {
+		return nil, err
+	}
+
+	err = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".upload-") {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(l.Root, p)
+		if err != nil {
+			return err
+		}
+
+		objects = append(objects, ObjectInfo{
+			Key:          filepath.ToSlash(rel),
+			Size:         info.Size(),
+			ContentType:  mime.TypeByExtension(filepath.Ext(p)),
+			LastModified: info.ModTime(),
+		})
+		return nil
+	})
+
+	return objects, err
+}
+
+func (l *LocalStorage) PresignGet(directory, filename string, expires time.Duration) (string, error) {
+	key := strings.TrimPrefix(path.Clean("/"+path.Join(directory, filename)), "/")
+	expiry := strconv.FormatInt(time.Now().Add(expires).Unix(), 10)
+
+	return fmt.Sprintf("%s/%s?expires=%s&signature=%s", l.BaseURL, key, expiry, l.sign(key, expiry)), nil
+}
+
+func (l *LocalStorage) Copy(srcDirectory, srcFilename, dstDirectory, dstFilename string) error {
+	src, err := l.Get(srcDirectory, srcFilename)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	return l.Upload(dstDirectory, dstFilename, src)
+}
+
+func (l *LocalStorage) Move(srcDirectory, srcFilename, dstDirectory, dstFilename string) error {
+	src, err := l.resolve(path.Join(srcDirectory, srcFilename))
+	if err != nil {
+		return err
+	}
+
+	dst, err := l.resolve(path.Join(dstDirectory, dstFilename))
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+
+	if err := os.Rename(src, dst); errors.Is(err, fs.ErrNotExist) {
+		return ErrNotFound
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (l *LocalStorage) sign(key, expiry string) string {
+	mac := hmac.New(sha256.New, l.secret)
+	mac.Write([]byte(key + "\n" + expiry))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Handler serves objects for URLs returned by PresignGet. It should be mounted with the BaseURL path stripped.
+func (l *LocalStorage) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+		expiry := r.URL.Query().Get("expires")
+		signature := r.URL.Query().Get("signature")
+
+		expiresAt, err := strconv.ParseInt(expiry, 10, 64)
+		if err != nil || time.Now().Unix() > expiresAt {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+
+		if !hmac.Equal([]byte(signature), []byte(l.sign(key, expiry))) {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+
+		target, err := l.resolve(key)
+		if err != nil {
+			http.Error(w, "Not found", http.StatusNotFound)
+			return
+		}
+
+		if info, err := os.Stat(target); err != nil || info.IsDir() {
+			http.Error(w, "Not found", http.StatusNotFound)
+			return
+		}
+
+		http.ServeFile(w, r, target)
+	})
+}
@@ -0,0 +1,161 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	cfg "github.com/VATUSA/primary-api/pkg/config"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"io"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+var _ Storage = (*StorageClient)(nil)
+
+// StorageClient is the S3 implementation of Storage
+type StorageClient struct {
+	client *s3.Client
+	bucket string
+}
+
+func NewS3Client(cfg *cfg.S3Config) (*StorageClient, error) {
+	credentialsProvider := credentials.NewStaticCredentialsProvider(cfg.AccessKey, cfg.SecretKey, "")
+	s3Config := aws.Config{
+		Credentials:  credentialsProvider,
+		Region:       cfg.Region,
+		BaseEndpoint: aws.String(cfg.Endpoint),
+	}
+
+	client := &StorageClient{
+		client: s3.NewFromConfig(s3Config, func(options *s3.Options) {}),
+		bucket: cfg.Bucket,
+	}
+
+	return client, nil
+}
+
+func (s *StorageClient) Upload(directory string, filename string, body io.Reader) error {
+	fullKey := path.Join(directory, filename)
+	_, err := s.client.PutObject(context.Background(), &s3.PutObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(fullKey),
+		Body:   body,
+	})
+	return err
+}
+
+func (s *StorageClient) Replace(directory string, filename string, body io.Reader) error {
+	// In S3, replace is the same as upload. It will overwrite the existing object.
+	return s.Upload(directory, filename, body)
+}
+
+func (s *StorageClient) Delete(directory, filename string) error {
+	fullKey := path.Join(directory, filename)
+	_, err := s.client.DeleteObject(context.Background(), &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(fullKey),
+	})
+	return err
+}
+
+func (s *StorageClient) Get(directory, filename string) (io.ReadCloser, error) {
+	out, err := s.client.GetObject(context.Background(), &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(path.Join(directory, filename)),
+	})
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return out.Body, nil
+}
+
+func (s *StorageClient) Stat(directory, filename string) (*ObjectInfo, error) {
+	fullKey := path.Join(directory, filename)
+	out, err := s.client.HeadObject(context.Background(), &s3.HeadObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(fullKey),
+	})
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &ObjectInfo{
+		Key:          fullKey,
+		Size:         aws.ToInt64(out.ContentLength),
+		ContentType:  aws.ToString(out.ContentType),
+		LastModified: aws.ToTime(out.LastModified),
+	}, nil
+}
+
+func (s *StorageClient) List(directory string) ([]ObjectInfo, error) {
+	var objects []ObjectInfo
+
+	paginator := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucket),
+		Prefix: aws.String(strings.TrimSuffix(directory, "/") + "/"),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.Background())
+		if err != nil {
+			return nil, err
+		}
+
+		for _, object := range page.Contents {
+			objects = append(objects, ObjectInfo{
+				Key:          aws.ToString(object.Key),
+				Size:         aws.ToInt64(object.Size),
+				LastModified: aws.ToTime(object.LastModified),
+			})
+		}
+	}
+
+	return objects, nil
+}
+
+func (s *StorageClient) PresignGet(directory, filename string, expires time.Duration) (string, error) {
+	req, err := s3.NewPresignClient(s.client).PresignGetObject(context.Background(), &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(path.Join(directory, filename)),
+	}, s3.WithPresignExpires(expires))
+	if err != nil {
+		return "", err
+	}
+	return req.URL, nil
+}
+
+func (s *StorageClient) Copy(srcDirectory, srcFilename, dstDirectory, dstFilename string) error {
+	// CopySource must be URL encoded, but the separators between segments must be kept
+	segments := strings.Split(path.Join(s.bucket, srcDirectory, srcFilename), "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+
+	_, err := s.client.CopyObject(context.Background(), &s3.CopyObjectInput{
+		Bucket:     aws.String(s.bucket),
+		CopySource: aws.String(strings.Join(segments, "/")),
+		Key:        aws.String(path.Join(dstDirectory, dstFilename)),
+	})
+	return translateError(err)
+}
+
+func (s *StorageClient) Move(srcDirectory, srcFilename, dstDirectory, dstFilename string) error {
+	// S3 has no rename, so copy to the new key and remove the old one
+	if err := s.Copy(srcDirectory, srcFilename, dstDirectory, dstFilename); err != nil {
+		return err
+	}
+	return s.Delete(srcDirectory, srcFilename)
+}
+
+func translateError(err error) error {
+	var noSuchKey *types.NoSuchKey
+	var notFound *types.NotFound
+	if errors.As(err, &noSuchKey) || errors.As(err, &notFound) {
+		return ErrNotFound
+	}
+	return err
+}
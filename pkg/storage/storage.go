@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"github.com/VATUSA/primary-api/pkg/config"
+	"io"
+	"time"
+)
+
+var ErrNotFound = errors.New("object not found")
+
+type ObjectInfo struct {
+	Key          string
+	Size         int64
+	ContentType  string
+	LastModified time.Time
+}
+
+// Storage is a bucket of objects addressed by a directory and a filename
+type Storage interface {
+	Upload(directory, filename string, body io.Reader) error
+	Replace(directory, filename string, body io.Reader) error
+	Delete(directory, filename string) error
+	// Get returns the object's contents, or ErrNotFound. The caller must close the reader.
+	Get(directory, filename string) (io.ReadCloser, error)
+	// Stat returns the object's metadata, or ErrNotFound
+	Stat(directory, filename string) (*ObjectInfo, error)
+	// List returns every object below directory
+	List(directory string) ([]ObjectInfo, error)
+	// PresignGet returns a URL that can be used to download the object until it expires
+	PresignGet(directory, filename string, expires time.Duration) (string, error)
+	Copy(srcDirectory, srcFilename, dstDirectory, dstFilename string) error
+	Move(srcDirectory, srcFilename, dstDirectory, dstFilename string) error
+}
+
+// PublicEndpoint is the base URL objects in the configured backend are served from
+func PublicEndpoint(cfg *config.Config) string {
+	if cfg.Storage.Backend == "local" {
+		return cfg.Storage.LocalURL
+	}
+	return cfg.S3.Endpoint
+}
+
+// New returns the storage backend selected by the config
+func New(cfg *config.Config) (Storage, error) {
+	switch cfg.Storage.Backend {
+	case "", "s3":
+		return NewS3Client(cfg.S3)
+	case "local":
+		return NewLocalStorage(cfg.Storage)
+	}
+
+	return nil, fmt.Errorf("unknown storage backend %q", cfg.Storage.Backend)
+}
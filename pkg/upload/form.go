@@ -0,0 +1,70 @@
+package upload
+
+import (
+	"errors"
+	"github.com/VATUSA/primary-api/pkg/utils"
+	"github.com/go-chi/render"
+	"log"
+	"mime/multipart"
+	"net/http"
+)
+
+// formOverhead leaves room for the other multipart fields on top of the file size limit
+const formOverhead = 1 << 20
+
+// formMemory is how much of a multipart form is kept in memory before the rest is spooled to disk
+const formMemory = 32 << 20
+
+// ParseForm limits the request body to the upload size and parses the multipart form, so the other fields can be
+// read before the file. It renders the error response and returns false when the form can't be read.
+func (v *Validator) ParseForm(w http.ResponseWriter, r *http.Request) bool {
+	if r.MultipartForm != nil {
+		return true
+	}
+	if v.MaxSize > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, v.MaxSize+formOverhead)
+	}
+
+	if err := r.ParseMultipartForm(formMemory); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			render.Render(w, r, utils.ErrTooLarge(ErrTooLarge))
+			return false
+		}
+		http.Error(w, "Error reading form", http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// ReadFile reads the "file" form field and validates it for the category. It renders the error response and
+// returns ok=false when the file is rejected.
+func (v *Validator) ReadFile(w http.ResponseWriter, r *http.Request, category string) (multipart.File, *multipart.FileHeader, string, bool) {
+	if !v.ParseForm(w, r) {
+		return nil, nil, "", false
+	}
+
+	file, fileHeader, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, "Error reading file", http.StatusBadRequest)
+		return nil, nil, "", false
+	}
+
+	contentType, err := v.Check(r.Context(), file, fileHeader.Size, category)
+	switch {
+	case err == nil:
+		return file, fileHeader, contentType, true
+	case errors.Is(err, ErrTooLarge):
+		render.Render(w, r, utils.ErrTooLarge(err))
+	case errors.Is(err, ErrUnsupportedType):
+		render.Render(w, r, utils.ErrUnsupportedMediaType(err))
+	case errors.Is(err, ErrInfected):
+		render.Render(w, r, utils.ErrInvalidRequest(err))
+	default:
+		log.Println("[Upload] Error validating upload:", err)
+		render.Render(w, r, utils.ErrInternalServer)
+	}
+
+	file.Close()
+	return nil, nil, "", false
+}
@@ -0,0 +1,99 @@
+package upload
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+var ErrInfected = errors.New("file failed virus scan")
+
+// Scanner inspects a file before it is stored. Scan returns ErrInfected (possibly wrapped) when the file
+// must be rejected, and any other error when the scan itself could not be completed.
+type Scanner interface {
+	Scan(ctx context.Context, r io.Reader) error
+}
+
+// ClamAV scans files with clamd's INSTREAM command
+type ClamAV struct {
+	Network   string
+	Address   string
+	ChunkSize int
+	Timeout   time.Duration
+}
+
+func NewClamAV(network, address string) *ClamAV {
+	return &ClamAV{
+		Network:   network,
+		Address:   address,
+		ChunkSize: 64 << 10,
+		Timeout:   time.Minute,
+	}
+}
+
+func (c *ClamAV) Scan(ctx context.Context, r io.Reader) error {
+	dialer := net.Dialer{Timeout: c.Timeout}
+	conn, err := dialer.DialContext(ctx, c.Network, c.Address)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	} else {
+		conn.SetDeadline(time.Now().Add(c.Timeout))
+	}
+
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return err
+	}
+
+	// The stream is sent as length-prefixed chunks and ended with a zero length chunk
+	buf := make([]byte, c.ChunkSize)
+	size := make([]byte, 4)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			binary.BigEndian.PutUint32(size, uint32(n))
+			if _, err := conn.Write(size); err != nil {
+				return err
+			}
+			if _, err := conn.Write(buf[:n]); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	binary.BigEndian.PutUint32(size, 0)
+	if _, err := conn.Write(size); err != nil {
+		return err
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString('\x00')
+	if err != nil && err != io.EOF {
+		return err
+	}
+	reply = strings.TrimRight(reply, "\x00\n")
+
+	switch {
+	case strings.HasSuffix(reply, "OK"):
+		return nil
+	case strings.HasSuffix(reply, "FOUND"):
+		return fmt.Errorf("%w: %s", ErrInfected, strings.TrimPrefix(reply, "stream: "))
+	}
+
+	return fmt.Errorf("unexpected clamd reply: %s", reply)
+}
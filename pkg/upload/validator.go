@@ -0,0 +1,75 @@
+package upload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/VATUSA/primary-api/pkg/config"
+	"github.com/gabriel-vasile/mimetype"
+	"io"
+)
+
+var (
+	ErrTooLarge        = errors.New("file is too large")
+	ErrUnsupportedType = errors.New("file type is not allowed")
+)
+
+// Validator checks uploaded files before they are committed to storage
+type Validator struct {
+	MaxSize      int64
+	AllowedTypes map[string][]string
+	Scanner      Scanner
+}
+
+func NewValidator(cfg *config.UploadConfig) *Validator {
+	v := &Validator{
+		MaxSize:      cfg.MaxSize,
+		AllowedTypes: cfg.AllowedTypes,
+	}
+
+	if cfg.ScannerSocket != "" {
+		v.Scanner = NewClamAV("unix", cfg.ScannerSocket)
+	}
+
+	return v
+}
+
+// Allowed returns the MIME types accepted for the category
+func (v *Validator) Allowed(category string) []string {
+	if allowed, ok := v.AllowedTypes[category]; ok {
+		return allowed
+	}
+	return v.AllowedTypes["*"]
+}
+
+// Check sniffs the content type of file, makes sure the category accepts it and runs the scanner if one is
+// configured. file is rewound before Check returns so it can be uploaded.
+func (v *Validator) Check(ctx context.Context, file io.ReadSeeker, size int64, category string) (string, error) {
+	if v.MaxSize > 0 && size > v.MaxSize {
+		return "", ErrTooLarge
+	}
+
+	detected, err := mimetype.DetectReader(file)
+	if err != nil {
+		return "", err
+	}
+
+	if !mimetype.EqualsAny(detected.String(), v.Allowed(category)...) {
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedType, detected.String())
+	}
+
+	if v.Scanner != nil {
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return "", err
+		}
+		if err := v.Scanner.Scan(ctx, file); err != nil {
+			return "", err
+		}
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	return detected.String(), nil
+}
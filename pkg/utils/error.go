@@ -37,6 +37,24 @@ func ErrConflict(err error) render.Renderer {
 	}
 }
 
+func ErrTooLarge(err error) render.Renderer {
+	return &ErrResponse{
+		Err:            err,
+		HTTPStatusCode: 413,
+		StatusText:     "Request entity too large.",
+		ErrorText:      err.Error(),
+	}
+}
+
+func ErrUnsupportedMediaType(err error) render.Renderer {
+	return &ErrResponse{
+		Err:            err,
+		HTTPStatusCode: 415,
+		StatusText:     "Unsupported media type.",
+		ErrorText:      err.Error(),
+	}
+}
+
 func ErrRender(err error) render.Renderer {
 	return &ErrResponse{
 		Err:            err,
@@ -49,7 +67,10 @@ func ErrRender(err error) render.Renderer {
 var (
 	ErrNotFound        = &ErrResponse{HTTPStatusCode: 404, StatusText: "Resource not found."}
 	ErrBadRequest      = &ErrResponse{HTTPStatusCode: 400, StatusText: "Bad request"}
+	ErrUnauthorized    = &ErrResponse{HTTPStatusCode: 401, StatusText: "Unauthorized"}
+	ErrForbidden       = &ErrResponse{HTTPStatusCode: 403, StatusText: "Forbidden"}
 	ErrInternalServer  = &ErrResponse{HTTPStatusCode: 500, StatusText: "Internal Server Error"}
+	ErrUnavailable     = &ErrResponse{HTTPStatusCode: 503, StatusText: "Service Unavailable"}
 	ErrInvalidFacility = &ErrResponse{HTTPStatusCode: 400, StatusText: "Invalid facility"}
 	ErrInvalidRole     = &ErrResponse{HTTPStatusCode: 400, StatusText: "Invalid role"}
 	ErrInvalidCID      = &ErrResponse{HTTPStatusCode: 400, StatusText: "Invalid CID"}
@@ -0,0 +1,109 @@
+package vatsim
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// PageSize is how many members are asked for in each page of a division listing
+const PageSize = 1000
+
+var ErrNotFound = errors.New("member not found")
+
+// Ratings below RatingSuspended mark an inactive account
+const (
+	RatingInactive  = -1
+	RatingSuspended = 0
+)
+
+// Member is a VATSIM member as the API reports them
+type Member struct {
+	CID         uint   `json:"id"`
+	FirstName   string `json:"name_first"`
+	LastName    string `json:"name_last"`
+	Email       string `json:"email"`
+	Rating      int    `json:"rating"`
+	PilotRating int    `json:"pilotrating"`
+	Region      string `json:"region_id"`
+	Division    string `json:"division_id"`
+	Subdivision string `json:"subdivision_id"`
+}
+
+type page struct {
+	Items []Member `json:"items"`
+	Count int      `json:"count"`
+}
+
+// Client talks to a VATSIM API compatible endpoint
+type Client struct {
+	BaseURL string
+	APIKey  string
+	HTTP    *http.Client
+}
+
+func NewClient(baseURL, apiKey string) *Client {
+	return &Client{BaseURL: baseURL, APIKey: apiKey, HTTP: &http.Client{Timeout: 30 * time.Second}}
+}
+
+func (c *Client) get(ctx context.Context, path string, query url.Values, v interface{}) error {
+	u := c.BaseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.APIKey != "" {
+		req.Header.Set("X-API-Key", c.APIKey)
+	}
+
+	res, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	switch {
+	case res.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case res.StatusCode != http.StatusOK:
+		return fmt.Errorf("GET %s returned %s", path, res.Status)
+	}
+
+	return json.NewDecoder(res.Body).Decode(v)
+}
+
+// DivisionMembers returns every member of the division, following the listing page by page
+func (c *Client) DivisionMembers(ctx context.Context, division string) ([]Member, error) {
+	var members []Member
+	for offset := 0; ; offset += PageSize {
+		var p page
+		query := url.Values{"limit": {strconv.Itoa(PageSize)}, "offset": {strconv.Itoa(offset)}}
+		if err := c.get(ctx, "/v2/orgs/division/"+url.PathEscape(division), query, &p); err != nil {
+			return nil, err
+		}
+
+		members = append(members, p.Items...)
+		if len(p.Items) == 0 || len(members) >= p.Count {
+			return members, nil
+		}
+	}
+}
+
+// Member looks up a single member, wherever they are. It returns ErrNotFound for unknown CIDs.
+func (c *Client) Member(ctx context.Context, cid uint) (*Member, error) {
+	var m Member
+	if err := c.get(ctx, fmt.Sprintf("/v2/members/%d", cid), nil, &m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
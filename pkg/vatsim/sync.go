@@ -0,0 +1,267 @@
+package vatsim
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/VATUSA/primary-api/pkg/constants"
+	"github.com/VATUSA/primary-api/pkg/database"
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"gorm.io/gorm"
+	"log"
+	"sync"
+	"time"
+)
+
+// RatingChangeActor is recorded as the creator of rating changes the sync finds
+const RatingChangeActor = "VATSIM"
+
+var ErrSyncRunning = errors.New("a sync is already running")
+
+// MinListedFraction is how many members the division listing must hold, as a fraction of the controllers rostered
+// here, before anyone missing from it is looked up and moved off the roster
+var MinListedFraction = 0.9
+
+// Status describes the current or most recent sync
+type Status struct {
+	Running       bool       `json:"running" example:"false"`
+	StartedAt     *time.Time `json:"started_at" example:"2021-01-01T00:00:00Z"`
+	FinishedAt    *time.Time `json:"finished_at" example:"2021-01-01T00:05:00Z"`
+	LastError     string     `json:"last_error" example:""`
+	Members       int        `json:"members" example:"9000"`      // Members listed by the division
+	Created       int        `json:"created" example:"12"`        // Users seen for the first time
+	Updated       int        `json:"updated" example:"8990"`      // Existing users refreshed
+	RatingChanges int        `json:"rating_changes" example:"15"` // Rating changes recorded
+	Moved         int        `json:"moved" example:"3"`           // Members moved to the non-member or inactive roster
+}
+
+// Syncer keeps users in step with VATSIM. Members of the division are refreshed from the division listing;
+// members who are on a roster here but no longer in the listing are looked up one at a time and, if they are now
+// in another division, moved to the non-member roster, or the inactive roster if their account is inactive.
+type Syncer struct {
+	Client   *Client
+	Division string
+
+	mu      sync.Mutex
+	status  Status
+	trigger chan struct{}
+}
+
+func NewSyncer(client *Client, division string) *Syncer {
+	return &Syncer{Client: client, Division: division, trigger: make(chan struct{}, 1)}
+}
+
+func (s *Syncer) Status() Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.status
+}
+
+// Sync runs a full sync. It returns ErrSyncRunning if another sync hasn't finished.
+func (s *Syncer) Sync(ctx context.Context, now time.Time) error {
+	s.mu.Lock()
+	if s.status.Running {
+		s.mu.Unlock()
+		return ErrSyncRunning
+	}
+	s.status = Status{Running: true, StartedAt: &now}
+	s.mu.Unlock()
+
+	stats := &Status{}
+	err := s.sync(ctx, now, stats)
+
+	finished := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats.StartedAt = s.status.StartedAt
+	stats.FinishedAt = &finished
+	if err != nil {
+		stats.LastError = err.Error()
+	}
+	s.status = *stats
+
+	return err
+}
+
+// Trigger asks Run for a sync now rather than at the next interval, reporting false if one is already running or
+// has been asked for. The sync runs under Run's context, so it is stopped along with the server.
+func (s *Syncer) Trigger() bool {
+	if s.Status().Running {
+		return false
+	}
+
+	select {
+	case s.trigger <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// Run syncs straight away and then every interval, or sooner when triggered, until ctx is done
+func (s *Syncer) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for now := time.Now(); ; {
+		// A trigger that arrived while the sync was starting is served by it
+		select {
+		case <-s.trigger:
+		default:
+		}
+
+		if err := s.Sync(ctx, now); err != nil {
+			log.Println("[VATSIM] Error syncing members:", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case now = <-ticker.C:
+		case <-s.trigger:
+			now = time.Now()
+		}
+	}
+}
+
+func (s *Syncer) sync(ctx context.Context, now time.Time, stats *Status) error {
+	members, err := s.Client.DivisionMembers(ctx, s.Division)
+	if err != nil {
+		return err
+	}
+	stats.Members = len(members)
+
+	listed := map[uint]bool{}
+	for i := range members {
+		m := &members[i]
+		listed[m.CID] = true
+
+		if err := apply(m, now, stats); err != nil {
+			return err
+		}
+		if m.Rating == RatingInactive {
+			if err := move(m.CID, constants.InactiveFacility, stats); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Anyone still rostered here who the division no longer lists may have left it
+	cids, err := models.GetHomeCIDs(string(constants.NonMemberFacility), string(constants.InactiveFacility))
+	if err != nil {
+		return err
+	}
+
+	// A listing cut short by the API would otherwise look like most of the division leaving at once
+	if float64(len(members)) < MinListedFraction*float64(len(cids)) {
+		return fmt.Errorf("division listed %d members but %d are rostered, skipping leavers", len(members), len(cids))
+	}
+
+	for _, cid := range cids {
+		if listed[cid] {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		m, err := s.Client.Member(ctx, cid)
+		if errors.Is(err, ErrNotFound) {
+			log.Printf("[VATSIM] %d is rostered but unknown to VATSIM", cid)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := apply(m, now, stats); err != nil {
+			return err
+		}
+
+		facility := constants.NonMemberFacility
+		if m.Rating == RatingInactive {
+			facility = constants.InactiveFacility
+		} else if m.Division == s.Division {
+			// Still a member, just missing from the listing
+			continue
+		}
+		if err := move(cid, facility, stats); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// apply copies the member onto their user, creating the user if needed and recording any rating change. The
+// user and the rating change are saved together, so a failed sync can't record a change the user doesn't show.
+func apply(m *Member, now time.Time, stats *Status) error {
+	user := &models.User{}
+	err := database.DB.Where("c_id = ?", m.CID).First(user).Error
+	created := errors.Is(err, gorm.ErrRecordNotFound)
+	if err != nil && !created {
+		return err
+	}
+
+	var change *models.RatingChange
+	if !created && m.Rating >= RatingSuspended && uint(m.Rating) != user.ControllerRating {
+		change = &models.RatingChange{
+			CID:          m.CID,
+			OldRating:    user.ControllerRating,
+			NewRating:    uint(m.Rating),
+			CreatedByCID: RatingChangeActor,
+		}
+	}
+
+	// Single member lookups don't carry personal details, so only overwrite them when they're given
+	user.CID = m.CID
+	if m.FirstName != "" || m.LastName != "" {
+		user.FirstName = m.FirstName
+		user.LastName = m.LastName
+	}
+	if m.Email != "" {
+		user.Email = m.Email
+	}
+	if m.Rating >= RatingSuspended {
+		user.ControllerRating = uint(m.Rating)
+	}
+	if m.PilotRating >= 0 {
+		user.PilotRating = uint(m.PilotRating)
+	}
+	user.Region = m.Region
+	user.Division = m.Division
+	user.LastCertSync = now
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if change != nil {
+			if err := models.CreateRatingChange(tx, change); err != nil {
+				return err
+			}
+		}
+		if created {
+			return tx.Create(user).Error
+		}
+		return tx.Save(user).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	if change != nil {
+		stats.RatingChanges++
+	}
+	if created {
+		stats.Created++
+	} else {
+		stats.Updated++
+	}
+	return nil
+}
+
+func move(cid uint, facility constants.Facility, stats *Status) error {
+	moved, err := models.MoveToFacility(cid, string(facility))
+	if moved {
+		stats.Moved++
+	}
+	return err
+}
@@ -0,0 +1,67 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// ErrInternalAddress is returned when a subscription's host resolves to an address inside the network
+var ErrInternalAddress = errors.New("webhook receiver resolves to an internal address")
+
+// metadataAddresses are the cloud instance metadata services that aren't already in a private or link-local range
+var metadataAddresses = []netip.Addr{
+	netip.MustParseAddr("fd00:ec2::254"),
+	netip.MustParseAddr("100.100.100.200"),
+}
+
+// IsInternalAddress reports whether ip is one receivers must not be reached at: loopback, private, link-local,
+// unspecified, multicast or a metadata service
+func IsInternalAddress(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return true
+	}
+	for _, metadata := range metadataAddresses {
+		if ip == metadata {
+			return true
+		}
+	}
+	return false
+}
+
+// NewClient returns the client deliveries are sent with. The address is checked after DNS resolution, so a hostname
+// pointing inside the network is refused too, and redirects aren't followed since they'd skip the https check made
+// on the subscription.
+func NewClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			addr, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if IsInternalAddress(addr.Addr()) {
+				return fmt.Errorf("%w: %s", ErrInternalAddress, addr.Addr())
+			}
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
@@ -0,0 +1,149 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/VATUSA/primary-api/pkg/database/types"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// BatchSize is how many due deliveries are sent on each pass
+const BatchSize = 100
+
+var errInactive = errors.New("subscription is inactive")
+
+// Sender delivers queued webhook payloads
+type Sender struct {
+	HTTP *http.Client
+}
+
+func NewSender() *Sender {
+	return &Sender{HTTP: NewClient(10 * time.Second)}
+}
+
+// Deliver makes one attempt at the delivery and records the outcome on it, scheduling the next attempt or
+// marking it failed once MaxAttempts is reached. The caller saves it.
+func (s *Sender) Deliver(ctx context.Context, sub *models.WebhookSubscription, d *models.WebhookDelivery, now time.Time) error {
+	d.Attempts++
+
+	var err error
+	if sub.Active {
+		d.LastStatusCode, err = s.post(ctx, sub, d, now)
+	} else {
+		err = errInactive
+	}
+
+	if err == nil {
+		d.Status = types.DeliveredDelivery
+		d.DeliveredAt = &now
+		d.NextAttemptAt = nil
+		d.LastError = ""
+		return nil
+	}
+
+	d.LastError = err.Error()
+	if len(d.LastError) > 512 {
+		d.LastError = d.LastError[:512]
+	}
+	if d.Attempts >= MaxAttempts || errors.Is(err, errInactive) {
+		d.Status = types.FailedDelivery
+		d.NextAttemptAt = nil
+		return err
+	}
+
+	next := now.Add(Backoff(d.Attempts))
+	d.NextAttemptAt = &next
+	return err
+}
+
+func (s *Sender) post(ctx context.Context, sub *models.WebhookSubscription, d *models.WebhookDelivery, now time.Time) (int, error) {
+	body := []byte(d.Payload)
+	timestamp := now.Unix()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "VATUSA-Webhooks")
+	req.Header.Set(EventHeader, string(d.Event))
+	req.Header.Set(EventIDHeader, d.EventID)
+	req.Header.Set(DeliveryHeader, strconv.FormatUint(uint64(d.ID), 10))
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(SignatureHeader, Sign(sub.Secret, timestamp, body))
+
+	res, err := s.HTTP.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	// Drain a little of the body so the connection can be reused
+	io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Errorf("receiver returned %s", res.Status)
+	}
+	return res.StatusCode, nil
+}
+
+// Dispatch sends every delivery that is due
+func (s *Sender) Dispatch(ctx context.Context, now time.Time) error {
+	deliveries, err := models.GetDueWebhookDeliveries(now, BatchSize)
+	if err != nil {
+		return err
+	}
+
+	subscriptions := map[uint]*models.WebhookSubscription{}
+	for i := range deliveries {
+		d := &deliveries[i]
+
+		// Hold the delivery for longer than an attempt can take so another instance doesn't send it too
+		if ok, err := d.Claim(now, 2*s.HTTP.Timeout); err != nil || !ok {
+			if err != nil {
+				log.Println("[Webhook] Error claiming delivery:", err)
+			}
+			continue
+		}
+
+		sub, ok := subscriptions[d.SubscriptionID]
+		if !ok {
+			sub = &models.WebhookSubscription{ID: d.SubscriptionID}
+			if err := sub.Get(); err != nil {
+				log.Println("[Webhook] Error loading subscription:", err)
+				continue
+			}
+			subscriptions[d.SubscriptionID] = sub
+		}
+
+		s.Deliver(ctx, sub, d, now)
+		if err := d.Update(); err != nil {
+			log.Println("[Webhook] Error saving delivery:", err)
+		}
+	}
+	return nil
+}
+
+// Run dispatches due deliveries every interval until ctx is cancelled
+func (s *Sender) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for now := time.Now(); ; {
+		if err := s.Dispatch(ctx, now); err != nil {
+			log.Println("[Webhook] Error dispatching deliveries:", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case now = <-ticker.C:
+		}
+	}
+}
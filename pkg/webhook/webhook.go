@@ -0,0 +1,144 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/VATUSA/primary-api/pkg/database"
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/VATUSA/primary-api/pkg/database/types"
+	"strconv"
+	"time"
+)
+
+// Headers sent with every delivery. The signature covers "<timestamp>.<body>" so receivers can reject replays.
+const (
+	EventHeader     = "X-VATUSA-Event"
+	EventIDHeader   = "X-VATUSA-Event-ID"
+	DeliveryHeader  = "X-VATUSA-Delivery"
+	TimestampHeader = "X-VATUSA-Timestamp"
+	SignatureHeader = "X-VATUSA-Signature"
+)
+
+// Deliveries are retried with exponential backoff, starting at InitialBackoff and doubling up to MaxBackoff,
+// until MaxAttempts have failed
+const (
+	MaxAttempts    = 8
+	InitialBackoff = 30 * time.Second
+	MaxBackoff     = 6 * time.Hour
+)
+
+// Envelope is the body of every delivery
+type Envelope struct {
+	ID        string             `json:"id" example:"42"`
+	Event     types.WebhookEvent `json:"event" example:"roster.added"`
+	Facility  string             `json:"facility" example:"ZDV"`
+	CreatedAt time.Time          `json:"created_at" example:"2021-01-01T00:00:00Z"`
+	Data      interface{}        `json:"data"`
+}
+
+// Sign returns the signature header value for a body sent at timestamp
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Backoff is how long to wait before retrying a delivery that has failed attempts times
+func Backoff(attempts int) time.Duration {
+	backoff := InitialBackoff
+	for i := 1; i < attempts; i++ {
+		backoff *= 2
+		if backoff >= MaxBackoff {
+			return MaxBackoff
+		}
+	}
+	return backoff
+}
+
+// NewSecret returns a random secret for signing a subscription's deliveries
+func NewSecret() (string, error) {
+	return randomHex(32)
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Publish queues the event for every subscription of the facility that receives it. id identifies the event to
+// receivers; publishing the same id again skips subscriptions that already have a delivery of it.
+func Publish(id string, event types.WebhookEvent, facility string, data interface{}, at time.Time) error {
+	subscriptions, err := models.GetWebhookSubscriptionsFor(facility, event)
+	if err != nil || len(subscriptions) == 0 {
+		return err
+	}
+
+	queued, err := models.GetWebhookSubscriptionIDsForEvent(id)
+	if err != nil {
+		return err
+	}
+	pending := subscriptions[:0]
+	for _, s := range subscriptions {
+		if !queued[s.ID] {
+			pending = append(pending, s)
+		}
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(Envelope{ID: id, Event: event, Facility: facility, CreatedAt: at, Data: data})
+	if err != nil {
+		return err
+	}
+
+	return models.CreateWebhookDeliveries(pending, id, event, string(payload), time.Now())
+}
+
+// Consume is the outbox consumer for webhooks. Events that aren't tied to a facility, such as rating changes,
+// go to every facility whose roster the member is on.
+func Consume(ctx context.Context, e *models.OutboxEvent) error {
+	event := types.WebhookEvent(e.Type)
+	if !event.IsValid() {
+		return nil
+	}
+
+	id := strconv.FormatUint(uint64(e.ID), 10)
+	data := json.RawMessage(e.Payload)
+	if e.Facility != "" {
+		return Publish(id, event, e.Facility, data, e.CreatedAt)
+	}
+	if e.AggregateType != models.UserAggregate {
+		return nil
+	}
+
+	cid, err := strconv.ParseUint(e.AggregateID, 10, 64)
+	if err != nil {
+		return err
+	}
+	rosters, err := models.GetAllRostersByCID(database.DB, uint(cid))
+	if err != nil {
+		return err
+	}
+
+	seen := map[string]bool{}
+	for _, roster := range rosters {
+		if seen[roster.Facility] {
+			continue
+		}
+		seen[roster.Facility] = true
+		if err := Publish(id, event, roster.Facility, data, e.CreatedAt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package harness
+
+import (
+	"embed"
+	"encoding/json"
+	"os"
+
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+//go:embed fixtures/*.json
+var embedded embed.FS
+
+// Fixtures are rows loaded into the test database before a test runs. Each list uses the JSON form of its
+// model, so a fixture file reads like the API responses for the same rows.
+type Fixtures struct {
+	Facilities []models.Facility `json:"facilities"`
+	Users      []models.User     `json:"users"`
+	Roles      []models.UserRole `json:"roles"`
+	Rosters    []models.Roster   `json:"rosters"`
+}
+
+// ReadFixtures reads a fixture file, looking first at the fixtures bundled with the harness and then at path
+// on disk
+func ReadFixtures(path string) (*Fixtures, error) {
+	data, err := embedded.ReadFile("fixtures/" + path)
+	if err != nil {
+		if data, err = os.ReadFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	f := &Fixtures{}
+	if err := json.Unmarshal(data, f); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Load inserts the fixtures as they are. Rows are written directly, so no domain events are raised for them.
+func (f *Fixtures) Load(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if len(f.Facilities) > 0 {
+			if err := tx.Omit(clause.Associations).Create(&f.Facilities).Error; err != nil {
+				return err
+			}
+		}
+		if len(f.Users) > 0 {
+			if err := tx.Omit(clause.Associations).Create(&f.Users).Error; err != nil {
+				return err
+			}
+		}
+		if len(f.Roles) > 0 {
+			if err := tx.Create(&f.Roles).Error; err != nil {
+				return err
+			}
+		}
+		if len(f.Rosters) > 0 {
+			if err := tx.Omit(clause.Associations).Create(&f.Rosters).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
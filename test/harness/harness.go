@@ -0,0 +1,252 @@
+// Package harness runs the API end to end for tests. Each harness boots the full router against its own
+// in-memory SQLite database, loaded with fixtures, keeps documents in a local storage backend under the test's
+// temporary directory and searches an in-memory index the test fills.
+package harness
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+
+	"github.com/VATUSA/primary-api/internal"
+	"github.com/VATUSA/primary-api/pkg/config"
+	"github.com/VATUSA/primary-api/pkg/constants"
+	"github.com/VATUSA/primary-api/pkg/database"
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	gochi "github.com/VATUSA/primary-api/pkg/go-chi"
+	"github.com/VATUSA/primary-api/pkg/pubsub"
+	"github.com/VATUSA/primary-api/pkg/search"
+	"github.com/VATUSA/primary-api/pkg/service"
+	"github.com/VATUSA/primary-api/pkg/storage"
+	"github.com/go-chi/chi/v5"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// V1 is the path the v1 internal API is served under
+const V1 = "/internal/v1"
+
+// DefaultFixtures are loaded into every harness before any fixtures passed to New
+const DefaultFixtures = "default.json"
+
+// StorageURL is where the local storage backend serves its presigned URLs
+const StorageURL = "http://api.vatusa.local/storage"
+
+var databases atomic.Uint64
+
+// Harness is a running API with the database, services, storage, search index and router it was booted with
+type Harness struct {
+	t        *testing.T
+	DB       *gorm.DB
+	Config   *config.Config
+	Services *service.Services
+	Storage  *storage.LocalStorage
+	Search   *search.MemoryIndex
+	Router   *chi.Mux
+
+	// next is the CID given to the next user created by AsRole
+	next uint
+}
+
+// New boots the API for t against a fresh database holding DefaultFixtures and then each of fixtures, which are
+// names of bundled fixture files or paths on disk. The package globals the API relies on are restored when t
+// finishes, so tests using a harness must not run in parallel.
+func New(t *testing.T, fixtures ...string) *Harness {
+	t.Helper()
+
+	dsn := fmt.Sprintf("file:harness%d?mode=memory&cache=shared", databases.Add(1))
+	db, err := gorm.Open(openSQLite(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("[Harness] Database Error: %v", err)
+	}
+
+	// One connection keeps the shared in-memory database alive for the whole test and avoids SQLite's
+	// table locks between connections
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("[Harness] Database Error: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+
+	previousDB, previousHub := database.DB, pubsub.DefaultHub
+	database.DB = db
+	pubsub.DefaultHub = pubsub.NewMemoryHub()
+	t.Cleanup(func() {
+		database.DB, pubsub.DefaultHub = previousDB, previousHub
+		sqlDB.Close()
+	})
+
+	models.AutoMigrate()
+
+	h := &Harness{t: t, DB: db, next: 9000001}
+	for _, name := range append([]string{DefaultFixtures}, fixtures...) {
+		h.Load(name)
+	}
+
+	h.Config = config.New()
+	h.Config.Storage = &config.StorageConfig{
+		Backend:   "local",
+		LocalPath: t.TempDir(),
+		LocalURL:  StorageURL,
+		Secret:    "harness-presigned-url-signing-secret",
+	}
+	h.Storage, err = storage.NewLocalStorage(h.Config.Storage)
+	if err != nil {
+		t.Fatalf("[Harness] Storage Error: %v", err)
+	}
+
+	h.Services = service.New(db)
+	h.Search = search.NewMemoryIndex()
+	h.Router = gochi.New(h.Config)
+	internal.Router(h.Router, h.Config, h.Services, h.Storage, h.Search, nil)
+
+	base, _ := url.Parse(StorageURL)
+	h.Router.Mount(base.Path, http.StripPrefix(base.Path, h.Storage.Handler()))
+
+	return h
+}
+
+// Load inserts the fixtures in the bundled fixture file or path name
+func (h *Harness) Load(name string) {
+	h.t.Helper()
+
+	f, err := ReadFixtures(name)
+	if err != nil {
+		h.t.Fatalf("[Harness] Fixture Error: %s: %v", name, err)
+	}
+	if err := f.Load(h.DB); err != nil {
+		h.t.Fatalf("[Harness] Fixture Error: %s: %v", name, err)
+	}
+}
+
+// Do sends an unauthenticated request. See Client.Do.
+func (h *Harness) Do(method, path string, body interface{}) *httptest.ResponseRecorder {
+	h.t.Helper()
+	return (&Client{h: h, header: http.Header{}}).Do(method, path, body)
+}
+
+// Guest returns a client whose requests are marked as coming from a guest
+func (h *Harness) Guest() *Client {
+	header := http.Header{}
+	header.Set("x-guest", "true")
+	return &Client{h: h, header: header}
+}
+
+// As returns a client whose requests are made as the user cid, roles included, the way the gateway forwards
+// them in the x-user header
+func (h *Harness) As(cid uint) *Client {
+	h.t.Helper()
+
+	user := &models.User{}
+	if err := h.DB.Preload("Roles").First(user, cid).Error; err != nil {
+		h.t.Fatalf("[Harness] User Error: %d: %v", cid, err)
+	}
+
+	self, err := json.Marshal(user)
+	if err != nil {
+		h.t.Fatalf("[Harness] User Error: %d: %v", cid, err)
+	}
+
+	header := http.Header{}
+	header.Set("x-user", string(self))
+	return &Client{h: h, header: header}
+}
+
+// AsRole returns a client for a user holding role at facility. A fixture user with the role is used when there
+// is one; otherwise a new user is created and given the role.
+func (h *Harness) AsRole(role constants.RoleID, facility string) *Client {
+	h.t.Helper()
+
+	held := &models.UserRole{}
+	err := h.DB.Where("role_id = ? AND facility_id = ?", role, facility).First(held).Error
+	if err == nil {
+		return h.As(held.CID)
+	}
+
+	user := &models.User{
+		CID:          h.next,
+		FirstName:    string(role),
+		LastName:     facility,
+		PreferredOIs: string(role)[:1] + facility[:1],
+		Division:     "USA",
+	}
+	h.next++
+
+	if err := h.DB.Create(user).Error; err != nil {
+		h.t.Fatalf("[Harness] User Error: %v", err)
+	}
+	if err := h.DB.Create(&models.UserRole{CID: user.CID, RoleID: role, FacilityID: facility}).Error; err != nil {
+		h.t.Fatalf("[Harness] Role Error: %v", err)
+	}
+	return h.As(user.CID)
+}
+
+// Client sends requests to a harness with a fixed set of headers
+type Client struct {
+	h      *Harness
+	header http.Header
+}
+
+// Header returns a copy of the headers the client sends, for requests that can't go through Do, such as streams
+// read from a server started on the harness's Router
+func (c *Client) Header() http.Header {
+	return c.header.Clone()
+}
+
+// WithHeader returns a copy of the client that also sends the header, e.g. the Content-Type of a multipart body
+func (c *Client) WithHeader(key, value string) *Client {
+	header := c.header.Clone()
+	header.Set(key, value)
+	return &Client{h: c.h, header: header}
+}
+
+// Do sends a request to the router and returns the recorded response. A body that is not a string, []byte or
+// io.Reader is sent as JSON.
+func (c *Client) Do(method, path string, body interface{}) *httptest.ResponseRecorder {
+	c.h.t.Helper()
+
+	var reader io.Reader
+	switch b := body.(type) {
+	case nil:
+	case io.Reader:
+		reader = b
+	case string:
+		reader = bytes.NewBufferString(b)
+	case []byte:
+		reader = bytes.NewBuffer(b)
+	default:
+		data, err := json.Marshal(b)
+		if err != nil {
+			c.h.t.Fatalf("[Harness] Request Error: %v", err)
+		}
+		reader = bytes.NewBuffer(data)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	for key, values := range c.header {
+		req.Header[key] = values
+	}
+	if reader != nil && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	rr := httptest.NewRecorder()
+	c.h.Router.ServeHTTP(rr, req)
+	return rr
+}
+
+// Decode reads the JSON body of rr into v
+func Decode(t *testing.T, rr *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(rr.Body.Bytes(), v); err != nil {
+		t.Fatalf("[Harness] Response Error: %v: %s", err, rr.Body.String())
+	}
+}
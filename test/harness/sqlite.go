@@ -0,0 +1,35 @@
+package harness
+
+import (
+	"strings"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/migrator"
+	"gorm.io/gorm/schema"
+)
+
+// dialector is SQLite with the MySQL enum columns declared by the models stored as text
+type dialector struct {
+	*sqlite.Dialector
+}
+
+func openSQLite(dsn string) gorm.Dialector {
+	return dialector{Dialector: sqlite.Open(dsn).(*sqlite.Dialector)}
+}
+
+func (d dialector) DataTypeOf(field *schema.Field) string {
+	if strings.HasPrefix(strings.ToLower(string(field.DataType)), "enum(") {
+		return "text"
+	}
+	return d.Dialector.DataTypeOf(field)
+}
+
+// Migrator is the SQLite migrator, built around d so the tables it creates use DataTypeOf above
+func (d dialector) Migrator(db *gorm.DB) gorm.Migrator {
+	return sqlite.Migrator{Migrator: migrator.Migrator{Config: migrator.Config{
+		DB:                          db,
+		Dialector:                   d,
+		CreateIndexAfterCreateTable: true,
+	}}}
+}
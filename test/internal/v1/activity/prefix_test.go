@@ -0,0 +1,41 @@
+package activity_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/VATUSA/primary-api/test/harness"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetPrefixes(t *testing.T) {
+	h := harness.New(t)
+	assert.NoError(t, h.DB.Create(&models.CallsignPrefix{Prefix: "LAX", Facility: "ZLA"}).Error)
+	path := harness.V1 + "/activity/ZDV/prefixes"
+
+	tests := []struct {
+		name     string
+		client   *harness.Client
+		body     string
+		expected int
+		owner    string
+	}{
+		{"own prefixes", h.As(1000002), `{"prefixes":["DEN","cos"]}`, http.StatusOK, "ZLA"},
+		{"another facility's prefix", h.As(1000002), `{"prefixes":["DEN","lax"]}`, http.StatusConflict, "ZLA"},
+		{"not a manager", h.As(1000003), `{"prefixes":["DEN"]}`, http.StatusForbidden, "ZLA"},
+		{"division staff", h.As(1000001), `{"prefixes":["DEN","LAX"]}`, http.StatusOK, "ZDV"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := tt.client.Do("PUT", path, tt.body)
+			assert.Equal(t, tt.expected, rr.Code, rr.Body.String())
+
+			prefixes, err := models.GetCallsignPrefixes()
+			assert.NoError(t, err)
+			assert.Equal(t, tt.owner, prefixes["LAX"])
+			assert.Equal(t, "ZDV", prefixes["DEN"])
+		})
+	}
+}
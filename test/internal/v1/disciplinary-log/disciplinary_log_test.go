@@ -1,121 +1,103 @@
-package disciplinary_log_test
-
-import (
-	"net/http"
-	"net/http/httptest"
-	"strings"
-	"testing"
-
-	"github.com/VATUSA/primary-api/internal/v1/disciplinary_log"
-	"github.com/VATUSA/primary-api/pkg/database"
-	"github.com/VATUSA/primary-api/pkg/database/models"
-	"github.com/go-chi/chi/v5"
-	"github.com/stretchr/testify/assert"
-)
-
-func setupRouter() *chi.Mux {
-	r := chi.NewRouter()
-	r.Route("/disciplinary", func(r chi.Router) {
-		r.Post("/", disciplinary_log.CreateDisciplinaryLogEntry)
-		r.Get("/", disciplinary_log.ListDisciplinaryLog)
-		r.Route("/{id}", func(r chi.Router) {
-			r.Use(disciplinary_log.GetDisciplinaryLogCtx)
-			r.Get("/", disciplinary_log.GetDisciplinaryLog)
-			r.Put("/", disciplinary_log.UpdateDisciplinaryLog)
-			r.Patch("/", disciplinary_log.PatchDisciplinaryLog)
-			r.Delete("/", disciplinary_log.DeleteDisciplinaryLog)
-		})
-	})
-	return r
-}
-
-func TestDisciplinaryLog(t *testing.T) {
-	r := setupRouter()
-
-	t.Run("create", func(t *testing.T) {
-		tests := []struct {
-			name     string
-			body     string
-			expected int
-		}{
-			{"valid request", `{"cid":123456,"entry":"Test entry","vatusa_only":false}`, http.StatusCreated},
-			{"invalid cid", `{"cid":0,"entry":"Test entry","vatusa_only":false}`, http.StatusBadRequest},
-			{"empty entry", `{"cid":123456,"entry":"","vatusa_only":false}`, http.StatusBadRequest},
-			{"missing field", `{"cid":123456,"vatusa_only":false}`, http.StatusBadRequest},
-		}
-
-		for _, tt := range tests {
-			t.Run(tt.name, func(t *testing.T) {
-				req := httptest.NewRequest("POST", "/disciplinary", strings.NewReader(tt.body))
-				req.Header.Set("Content-Type", "application/json")
-
-				rr := httptest.NewRecorder()
-				r.ServeHTTP(rr, req)
-
-				defer rr.Body.Close()
-				assert.Equal(t, tt.expected, rr.Code)
-			})
-		}
-	})
-
-	t.Run("list", func(t *testing.T) {
-		req := httptest.NewRequest("GET", "/disciplinary", nil)
-		rr := httptest.NewRecorder()
-		r.ServeHTTP(rr, req)
-
-		defer rr.Body.Close()
-		assert.Equal(t, http.StatusOK, rr.Code)
-	})
-
-	t.Run("get", func(t *testing.T) {
-		dle := &models.DisciplinaryLogEntry{CID: 123456, Entry: "Test entry", VATUSAOnly: false}
-		database.DB.Create(&dle)
-
-		req := httptest.NewRequest("GET", "/disciplinary/"+dle.ID, nil)
-		rr := httptest.NewRecorder()
-		r.ServeHTTP(rr, req)
-
-		defer rr.Body.Close()
-		assert.Equal(t, http.StatusOK, rr.Code)
-	})
-
-	t.Run("update", func(t *testing.T) {
-		dle := &models.DisciplinaryLogEntry{CID: 123456, Entry: "Test entry", VATUSAOnly: false}
-		database.DB.Create(&dle)
-
-		req := httptest.NewRequest("PUT", "/disciplinary/"+dle.ID, strings.NewReader(`{"cid":654321,"entry":"Updated entry","vatusa_only":true}`))
-		req.Header.Set("Content-Type", "application/json")
-
-		rr := httptest.NewRecorder()
-		r.ServeHTTP(rr, req)
-
-		defer rr.Body.Close()
-		assert.Equal(t, http.StatusOK, rr.Code)
-	})
-
-	t.Run("patch", func(t *testing.T) {
-		dle := &models.DisciplinaryLogEntry{CID: 123456, Entry: "Test entry", VATUSAOnly: false}
-		database.DB.Create(&dle)
-
-		req := httptest.NewRequest("PATCH", "/disciplinary/"+dle.ID, strings.NewReader(`{"entry":"Patched entry"}`))
-		req.Header.Set("Content-Type", "application/json")
-
-		rr := httptest.NewRecorder()
-		r.ServeHTTP(rr, req)
-
-		defer rr.Body.Close()
-		assert.Equal(t, http.StatusOK, rr.Code)
-	})
-
-	t.Run("delete", func(t *testing.T) {
-		dle := &models.DisciplinaryLogEntry{CID: 123456, Entry: "Test entry", VATUSAOnly: false}
-		database.DB.Create(&dle)
-
-		req := httptest.NewRequest("DELETE", "/disciplinary/"+dle.ID, nil)
-		rr := httptest.NewRecorder()
-		r.ServeHTTP(rr, req)
-
-		defer rr.Body.Close()
-		assert.Equal(t, http.StatusNoContent, rr.Code)
-	})
-}
+package disciplinary_log_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/VATUSA/primary-api/pkg/constants"
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/VATUSA/primary-api/test/harness"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDisciplinaryLog(t *testing.T) {
+	h := harness.New(t)
+	staff := h.AsRole(constants.DivisionDirectorRole, "ZHQ")
+
+	create := func(t *testing.T) *models.DisciplinaryLogEntry {
+		dle := &models.DisciplinaryLogEntry{CID: 1000003, Entry: "Test entry", VATUSAOnly: false}
+		assert.NoError(t, h.DB.Create(dle).Error)
+		return dle
+	}
+	path := func(dle *models.DisciplinaryLogEntry) string {
+		return fmt.Sprintf("%s/disciplinary-log/%d", harness.V1, dle.ID)
+	}
+
+	t.Run("create", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			body     string
+			expected int
+		}{
+			{"valid request", `{"cid":1000003,"entry":"Test entry","vatusa_only":false}`, http.StatusCreated},
+			{"invalid cid", `{"cid":0,"entry":"Test entry","vatusa_only":false}`, http.StatusBadRequest},
+			{"unknown cid", `{"cid":123456,"entry":"Test entry","vatusa_only":false}`, http.StatusBadRequest},
+			{"empty entry", `{"cid":1000003,"entry":"","vatusa_only":false}`, http.StatusBadRequest},
+			{"missing field", `{"cid":1000003,"vatusa_only":false}`, http.StatusBadRequest},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				rr := staff.Do("POST", harness.V1+"/disciplinary-log", tt.body)
+				assert.Equal(t, tt.expected, rr.Code, rr.Body.String())
+			})
+		}
+	})
+
+	t.Run("list", func(t *testing.T) {
+		assert.NoError(t, h.DB.Create(&models.DisciplinaryLogEntry{CID: 1000004, Entry: "Division entry", VATUSAOnly: true}).Error)
+
+		rr := staff.Do("GET", harness.V1+"/disciplinary-log", nil)
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		var list []models.DisciplinaryLogEntry
+		harness.Decode(t, rr, &list)
+		assert.Len(t, list, 1)
+		assert.True(t, list[0].VATUSAOnly)
+	})
+
+	t.Run("get", func(t *testing.T) {
+		dle := create(t)
+
+		rr := staff.Do("GET", path(dle), nil)
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		got := &models.DisciplinaryLogEntry{}
+		harness.Decode(t, rr, got)
+		assert.Equal(t, "Test entry", got.Entry)
+	})
+
+	t.Run("update", func(t *testing.T) {
+		dle := create(t)
+
+		rr := staff.Do("PUT", path(dle), `{"cid":1000004,"entry":"Updated entry","vatusa_only":true}`)
+		assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+		assert.NoError(t, h.DB.First(dle, dle.ID).Error)
+		assert.Equal(t, uint(1000004), dle.CID)
+		assert.Equal(t, "Updated entry", dle.Entry)
+		assert.True(t, dle.VATUSAOnly)
+	})
+
+	t.Run("patch", func(t *testing.T) {
+		dle := create(t)
+
+		rr := staff.Do("PATCH", path(dle), `{"entry":"Patched entry"}`)
+		assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+		assert.NoError(t, h.DB.First(dle, dle.ID).Error)
+		assert.Equal(t, uint(1000003), dle.CID)
+		assert.Equal(t, "Patched entry", dle.Entry)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		dle := create(t)
+
+		rr := staff.Do("DELETE", path(dle), nil)
+		assert.Less(t, rr.Code, 300)
+
+		rr = staff.Do("GET", path(dle), nil)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
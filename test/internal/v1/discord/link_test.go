@@ -0,0 +1,38 @@
+package discord_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/VATUSA/primary-api/test/harness"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLinkDisabledWithoutStateSecret(t *testing.T) {
+	h := harness.New(t)
+	assert.Empty(t, h.Config.Discord.StateSecret)
+
+	rr := h.As(1000003).Do("GET", harness.V1+"/discord/link", nil)
+	assert.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+
+	rr = h.Do("GET", harness.V1+"/discord/callback?code=code&state=state", nil)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestUnlink(t *testing.T) {
+	h := harness.New(t)
+	assert.NoError(t, h.DB.Model(&models.User{}).Where("c_id = ?", 1000003).Update("discord_id", "1234567890").Error)
+	client := h.As(1000003)
+
+	// The caller's other fields are left alone even if the stored user has changed since
+	assert.NoError(t, h.DB.Model(&models.User{}).Where("c_id = ?", 1000003).Update("first_name", "Renamed").Error)
+
+	rr := client.Do("DELETE", harness.V1+"/discord/link", nil)
+	assert.Less(t, rr.Code, 300, rr.Body.String())
+
+	user := &models.User{}
+	assert.NoError(t, h.DB.First(user, 1000003).Error)
+	assert.Empty(t, user.DiscordID)
+	assert.Equal(t, "Renamed", user.FirstName)
+}
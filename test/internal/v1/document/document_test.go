@@ -0,0 +1,258 @@
+package document_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/VATUSA/primary-api/pkg/constants"
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/VATUSA/primary-api/pkg/database/types"
+	"github.com/VATUSA/primary-api/test/harness"
+	"github.com/stretchr/testify/assert"
+)
+
+// createDocument creates a ZDV document with a single version stored where the API would have put it
+func createDocument(t *testing.T, h *harness.Harness, visibility types.DocumentVisibility) *models.Document {
+	directory := path.Join("ZDV", "general", "DP001")
+	if visibility != types.PublicVisibility {
+		directory = path.Join("private", directory)
+	}
+
+	doc := &models.Document{Facility: "ZDV", Name: "DP001", Description: "General Division Policy", Category: types.General, Visibility: visibility}
+	assert.NoError(t, h.DB.Create(doc).Error)
+
+	version := &models.DocumentVersion{
+		Version:     1,
+		Key:         path.Join(directory, "v1.pdf"),
+		URL:         harness.StorageURL + "/" + path.Join(directory, "v1.pdf"),
+		ContentType: "application/pdf",
+	}
+	assert.NoError(t, models.AddDocumentVersion(h.DB, doc, version, nil))
+	assert.NoError(t, h.Storage.Upload(directory, "v1.pdf", strings.NewReader("%PDF-1.4")))
+	return doc
+}
+
+func documentPath(doc *models.Document) string {
+	return fmt.Sprintf("%s/document/%s/%s/%d", harness.V1, doc.Facility, doc.Category, doc.ID)
+}
+
+func TestDownloadVisibility(t *testing.T) {
+	h := harness.New(t)
+
+	clients := map[string]*harness.Client{
+		"guest":             h.Guest(),
+		"no roster":         h.As(1000005),
+		"visiting":          h.As(1000004),
+		"mentor":            h.As(1000003),
+		"other facility":    h.AsRole(constants.AirTrafficManagerRole, "ZLA"),
+		"training admin":    h.AsRole(constants.TrainingAdministratorRole, "ZDV"),
+		"facility manager":  h.As(1000002),
+		"division director": h.As(1000001),
+	}
+
+	tests := []struct {
+		visibility types.DocumentVisibility
+		expected   map[string]int
+	}{
+		{types.PublicVisibility, map[string]int{
+			"guest": http.StatusFound, "no roster": http.StatusFound, "visiting": http.StatusFound, "mentor": http.StatusFound,
+			"other facility": http.StatusFound, "training admin": http.StatusFound, "facility manager": http.StatusFound, "division director": http.StatusFound,
+		}},
+		{types.FacilityVisibility, map[string]int{
+			"guest": http.StatusUnauthorized, "no roster": http.StatusForbidden, "visiting": http.StatusFound, "mentor": http.StatusFound,
+			"other facility": http.StatusForbidden, "training admin": http.StatusFound, "facility manager": http.StatusFound, "division director": http.StatusFound,
+		}},
+		{types.StaffVisibility, map[string]int{
+			"guest": http.StatusUnauthorized, "no roster": http.StatusForbidden, "visiting": http.StatusForbidden, "mentor": http.StatusForbidden,
+			"other facility": http.StatusForbidden, "training admin": http.StatusFound, "facility manager": http.StatusFound, "division director": http.StatusFound,
+		}},
+	}
+
+	for _, tt := range tests {
+		doc := createDocument(t, h, tt.visibility)
+		for name, client := range clients {
+			t.Run(string(tt.visibility)+"/"+name, func(t *testing.T) {
+				rr := client.Do("GET", documentPath(doc)+"/download", nil)
+				assert.Equal(t, tt.expected[name], rr.Code, rr.Body.String())
+
+				rr = client.Do("GET", documentPath(doc)+"/versions/1/download", nil)
+				assert.Equal(t, tt.expected[name], rr.Code, rr.Body.String())
+			})
+		}
+		assert.NoError(t, h.DB.Delete(&models.DocumentVersion{}, "document_id = ?", doc.ID).Error)
+		assert.NoError(t, h.DB.Delete(doc).Error)
+	}
+}
+
+func TestResponsesOmitStorageURL(t *testing.T) {
+	h := harness.New(t)
+	doc := createDocument(t, h, types.StaffVisibility)
+
+	for _, p := range []string{documentPath(doc), documentPath(doc) + "/versions", harness.V1 + "/document/ZDV"} {
+		rr := h.Do("GET", p, nil)
+		assert.Equal(t, http.StatusOK, rr.Code, p)
+		assert.NotContains(t, rr.Body.String(), "\"url\"", p)
+		assert.NotContains(t, rr.Body.String(), harness.StorageURL, p)
+	}
+}
+
+func TestVisibilityChangeMovesObjects(t *testing.T) {
+	h := harness.New(t)
+	doc := createDocument(t, h, types.PublicVisibility)
+	client := h.As(1000002)
+
+	rr := client.Do("PATCH", documentPath(doc), `{"visibility":"staff"}`)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	_, err := h.Storage.Stat("private/ZDV/general/DP001", "v1.pdf")
+	assert.NoError(t, err)
+	_, err = h.Storage.Stat("ZDV/general/DP001", "v1.pdf")
+	assert.Error(t, err)
+
+	rr = client.Do("PATCH", documentPath(doc), `{"visibility":"public"}`)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	_, err = h.Storage.Stat("ZDV/general/DP001", "v1.pdf")
+	assert.NoError(t, err)
+	_, err = h.Storage.Stat("private/ZDV/general/DP001", "v1.pdf")
+	assert.Error(t, err)
+}
+
+func TestAddVersionNumbersUnderLock(t *testing.T) {
+	h := harness.New(t)
+	doc := createDocument(t, h, types.PublicVisibility)
+
+	// A stale number from the caller is ignored, and place sees the number the version is saved with
+	var placed []uint
+	place := func(v *models.DocumentVersion) error {
+		placed = append(placed, v.Version)
+		v.Key = fmt.Sprintf("ZDV/general/DP001/v%d.pdf", v.Version)
+		return nil
+	}
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, h.Services.Documents.AddVersion(doc, &models.DocumentVersion{Version: 1}, place))
+	}
+	assert.Equal(t, []uint{2, 3}, placed)
+	assert.Equal(t, uint(3), doc.Version)
+
+	// Nothing is saved when the upload can't be placed
+	assert.Error(t, h.Services.Documents.AddVersion(doc, &models.DocumentVersion{}, func(v *models.DocumentVersion) error {
+		return fmt.Errorf("storage unavailable")
+	}))
+	versions, err := h.Services.Documents.Versions(doc)
+	assert.NoError(t, err)
+	assert.Len(t, versions, 3)
+	assert.NoError(t, h.DB.First(doc).Error)
+	assert.Equal(t, uint(3), doc.Version)
+}
+
+func TestRenameMovesObjects(t *testing.T) {
+	h := harness.New(t)
+	doc := createDocument(t, h, types.PublicVisibility)
+	client := h.As(1000002)
+
+	rr := client.Do("PATCH", documentPath(doc), `{"name":"DP 002"}`)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	_, err := h.Storage.Stat("ZDV/general/DP-002", "v1.pdf")
+	assert.NoError(t, err)
+	_, err = h.Storage.Stat("ZDV/general/DP001", "v1.pdf")
+	assert.Error(t, err)
+
+	rr = client.Do("GET", documentPath(doc)+"/download", nil)
+	assert.Equal(t, http.StatusFound, rr.Code, rr.Body.String())
+}
+
+func TestFacilityChangeMovesObjects(t *testing.T) {
+	h := harness.New(t)
+	doc := createDocument(t, h, types.PublicVisibility)
+
+	rr := h.As(1000001).Do("PATCH", documentPath(doc), `{"facility":"ZLA"}`)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	_, err := h.Storage.Stat("ZLA/general/DP001", "v1.pdf")
+	assert.NoError(t, err)
+	_, err = h.Storage.Stat("ZDV/general/DP001", "v1.pdf")
+	assert.Error(t, err)
+
+	versions, err := h.Services.Documents.Versions(doc)
+	assert.NoError(t, err)
+	assert.Equal(t, "ZLA/general/DP001/v1.pdf", versions[0].Key)
+}
+
+func TestRenameKeepingObjectName(t *testing.T) {
+	h := harness.New(t)
+	doc := createDocument(t, h, types.PublicVisibility)
+	client := h.As(1000002)
+
+	rr := client.Do("PATCH", documentPath(doc), `{"name":"DP 001"}`)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	// "DP 001" and "DP-001" share storage keys, so the rename must leave the object where it is
+	rr = client.Do("PATCH", documentPath(doc), `{"name":"DP-001"}`)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	_, err := h.Storage.Stat("ZDV/general/DP-001", "v1.pdf")
+	assert.NoError(t, err)
+
+	rr = client.Do("GET", documentPath(doc)+"/download", nil)
+	assert.Equal(t, http.StatusFound, rr.Code, rr.Body.String())
+
+	// Another document can't take a name that maps to the same keys
+	other := &models.Document{Facility: "ZDV", Name: "DP 001", Category: types.General}
+	taken, err := h.Services.Documents.NameTaken(other)
+	assert.NoError(t, err)
+	assert.True(t, taken)
+}
+
+func TestCreateDocumentFromMultipartForm(t *testing.T) {
+	h := harness.New(t)
+
+	content := "%PDF-1.4\n%harness\n"
+	body := &bytes.Buffer{}
+	form := multipart.NewWriter(body)
+	for field, value := range map[string]string{"facility": "ZDV", "name": "SOP 100", "description": "Tower SOP", "category": "sops", "change_note": "First issue"} {
+		assert.NoError(t, form.WriteField(field, value))
+	}
+	file, err := form.CreateFormFile("file", "sop.pdf")
+	assert.NoError(t, err)
+	_, err = file.Write([]byte(content))
+	assert.NoError(t, err)
+	assert.NoError(t, form.Close())
+
+	rr := h.As(1000002).WithHeader("Content-Type", form.FormDataContentType()).Do("POST", harness.V1+"/document", body)
+	assert.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+
+	created := &models.Document{}
+	harness.Decode(t, rr, created)
+	assert.Equal(t, "SOP 100", created.Name)
+	assert.Equal(t, types.SOPs, created.Category)
+	assert.Equal(t, uint(1), created.Version)
+
+	checksum := sha256.Sum256([]byte(content))
+	assert.Equal(t, hex.EncodeToString(checksum[:]), created.SHA256)
+
+	versions, err := h.Services.Documents.Versions(created)
+	assert.NoError(t, err)
+	if assert.Len(t, versions, 1) {
+		assert.Equal(t, uint(1), versions[0].Version)
+		assert.Equal(t, hex.EncodeToString(checksum[:]), versions[0].Checksum)
+		assert.Equal(t, "First issue", versions[0].ChangeNote)
+		assert.Equal(t, "ZDV/sops/SOP-100/v1.pdf", versions[0].Key)
+	}
+
+	object, err := h.Storage.Get("ZDV/sops/SOP-100", "v1.pdf")
+	assert.NoError(t, err)
+	data, err := io.ReadAll(object)
+	assert.NoError(t, err)
+	object.Close()
+	assert.Equal(t, content, string(data))
+}
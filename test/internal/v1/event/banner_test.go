@@ -0,0 +1,49 @@
+package event_test
+
+import (
+	"bytes"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/VATUSA/primary-api/test/harness"
+	"github.com/stretchr/testify/assert"
+)
+
+var png = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01\x08\x06\x00\x00\x00\x1f\x15\xc4\x89")
+
+func TestBannerIsServedThroughTheAPI(t *testing.T) {
+	h := harness.New(t)
+	start := time.Now().Add(24 * time.Hour)
+	event := &models.Event{Title: "Denver Nights", HostFacility: "ZDV", StartAt: start, EndAt: start.Add(3 * time.Hour)}
+	assert.NoError(t, h.DB.Create(event).Error)
+	path := fmt.Sprintf("%s/event/%d/banner", harness.V1, event.ID)
+
+	rr := h.Guest().Do("GET", path, nil)
+	assert.Equal(t, http.StatusNotFound, rr.Code, "events without a banner")
+
+	body := &bytes.Buffer{}
+	form := multipart.NewWriter(body)
+	file, err := form.CreateFormFile("file", "banner.png")
+	assert.NoError(t, err)
+	_, err = file.Write(png)
+	assert.NoError(t, err)
+	assert.NoError(t, form.Close())
+
+	rr = h.As(1000002).WithHeader("Content-Type", form.FormDataContentType()).Do("PUT", path, body)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	updated := &models.Event{}
+	harness.Decode(t, rr, updated)
+	assert.Equal(t, path, updated.BannerURL)
+
+	rr = h.Guest().Do("GET", path, nil)
+	assert.Equal(t, http.StatusFound, rr.Code, rr.Body.String())
+	location := rr.Header().Get("Location")
+	assert.True(t, strings.HasPrefix(location, harness.StorageURL+"/events/"), location)
+	assert.Contains(t, location, "signature=")
+}
@@ -0,0 +1,54 @@
+package event_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/VATUSA/primary-api/test/harness"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAssignPosition(t *testing.T) {
+	h := harness.New(t)
+	start := time.Now().Add(24 * time.Hour)
+	event := &models.Event{Title: "Denver Nights", HostFacility: "ZDV", StartAt: start, EndAt: start.Add(3 * time.Hour)}
+	assert.NoError(t, h.DB.Create(event).Error)
+	position := &models.EventPosition{EventID: event.ID, Callsign: "DEN_APP"}
+	assert.NoError(t, h.DB.Create(position).Error)
+	assert.NoError(t, h.DB.Create(&models.EventSignup{PositionID: position.ID, CID: 1000003}).Error)
+
+	path := fmt.Sprintf("%s/event/%d/positions/%d/assign", harness.V1, event.ID, position.ID)
+	atm := h.As(1000002)
+
+	tests := []struct {
+		name     string
+		body     string
+		expected int
+		assigned bool
+	}{
+		{"not signed up", `{"cid":1000004}`, http.StatusBadRequest, false},
+		{"unknown controller", `{"cid":1999999}`, http.StatusBadRequest, false},
+		{"signed up", `{"cid":1000003}`, http.StatusOK, true},
+		{"unassign", `{"cid":null}`, http.StatusOK, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := atm.Do("PUT", path, tt.body)
+			assert.Equal(t, tt.expected, rr.Code, rr.Body.String())
+
+			assert.NoError(t, h.DB.First(position).Error)
+			if !tt.assigned {
+				assert.Nil(t, position.AssignedCID)
+			} else if assert.NotNil(t, position.AssignedCID) {
+				assert.Equal(t, uint(1000003), *position.AssignedCID)
+			}
+		})
+	}
+
+	rr := h.As(1000003).Do("PUT", path, `{"cid":1000003}`)
+	assert.Equal(t, http.StatusForbidden, rr.Code, "controllers can't assign themselves")
+}
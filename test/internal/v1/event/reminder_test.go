@@ -0,0 +1,43 @@
+package event_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/VATUSA/primary-api/internal/v1/event"
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/VATUSA/primary-api/test/harness"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSendRemindersOncePerStage(t *testing.T) {
+	h := harness.New(t)
+	now := time.Now()
+	start := now.Add(30 * time.Minute)
+	cid := uint(1000003)
+
+	e := &models.Event{Title: "Denver Nights", HostFacility: "ZDV", StartAt: start, EndAt: start.Add(3 * time.Hour)}
+	assert.NoError(t, h.DB.Create(e).Error)
+	assert.NoError(t, h.DB.Create(&models.EventPosition{EventID: e.ID, Callsign: "DEN_APP", AssignedCID: &cid}).Error)
+
+	// A run that loaded the event before another claimed the stage doesn't claim it again
+	stale := *e
+	claimed, err := e.ClaimReminderStage(2)
+	assert.NoError(t, err)
+	assert.True(t, claimed)
+	claimed, err = stale.ClaimReminderStage(2)
+	assert.NoError(t, err)
+	assert.False(t, claimed)
+	assert.NoError(t, h.DB.Model(e).Update("reminders_sent", 0).Error)
+
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, event.SendReminders(context.Background(), now))
+	}
+
+	var notifications []models.Notification
+	assert.NoError(t, h.DB.Where("c_id = ?", cid).Find(&notifications).Error)
+	if assert.Len(t, notifications, 1) {
+		assert.Equal(t, "Denver Nights starts in 30 minutes. You are assigned to DEN_APP.", notifications[0].Body)
+	}
+}
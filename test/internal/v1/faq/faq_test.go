@@ -0,0 +1,114 @@
+package faq_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/VATUSA/primary-api/pkg/constants"
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/VATUSA/primary-api/pkg/database/types"
+	"github.com/VATUSA/primary-api/test/harness"
+	"github.com/stretchr/testify/assert"
+)
+
+func createFAQ(t *testing.T, h *harness.Harness, facility string, status types.PublishStatus, sortOrder int) *models.FAQ {
+	faq := &models.FAQ{Facility: facility, Question: "How do I visit?", Answer: "Apply on the website.", Category: types.FAQCategories[0], Status: status, SortOrder: sortOrder}
+	assert.NoError(t, h.DB.Create(faq).Error)
+	return faq
+}
+
+func TestDraftVisibility(t *testing.T) {
+	h := harness.New(t)
+	published := createFAQ(t, h, "ZDV", types.Published, 0)
+	draft := createFAQ(t, h, "ZDV", types.Draft, 1)
+
+	tests := []struct {
+		name   string
+		client *harness.Client
+		drafts bool
+	}{
+		{"guest", h.Guest(), false},
+		{"mentor", h.As(1000003), false},
+		{"other facility", h.AsRole(constants.AirTrafficManagerRole, "ZLA"), false},
+		{"facility manager", h.As(1000002), true},
+		{"division staff", h.As(1000001), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := tt.client.Do("GET", fmt.Sprintf("%s/faq/%d", harness.V1, published.ID), nil)
+			assert.Equal(t, http.StatusOK, rr.Code)
+
+			rr = tt.client.Do("GET", fmt.Sprintf("%s/faq/%d", harness.V1, draft.ID), nil)
+			if tt.drafts {
+				assert.Equal(t, http.StatusOK, rr.Code)
+			} else {
+				assert.Equal(t, http.StatusNotFound, rr.Code)
+			}
+
+			rr = tt.client.Do("GET", harness.V1+"/faq", nil)
+			assert.Equal(t, http.StatusOK, rr.Code)
+			var list []models.FAQ
+			harness.Decode(t, rr, &list)
+			if tt.drafts {
+				assert.Len(t, list, 2)
+			} else if assert.Len(t, list, 1) {
+				assert.Equal(t, published.ID, list[0].ID)
+			}
+
+			rr = tt.client.Do("GET", harness.V1+"/faq/ZDV/"+string(published.Category)+"?include_drafts=true", nil)
+			assert.Equal(t, http.StatusOK, rr.Code)
+			harness.Decode(t, rr, &list)
+			if tt.drafts {
+				assert.Len(t, list, 2)
+			} else {
+				assert.Len(t, list, 1)
+			}
+		})
+	}
+}
+
+func TestReorderFAQ(t *testing.T) {
+	h := harness.New(t)
+	first := createFAQ(t, h, "ZDV", types.Published, 0)
+	second := createFAQ(t, h, "ZDV", types.Published, 1)
+	body := fmt.Sprintf(`{"ids":[%d,%d]}`, second.ID, first.ID)
+
+	tests := []struct {
+		name     string
+		client   *harness.Client
+		expected int
+	}{
+		{"guest", h.Guest(), http.StatusUnauthorized},
+		{"mentor", h.As(1000003), http.StatusForbidden},
+		{"other facility", h.AsRole(constants.AirTrafficManagerRole, "ZLA"), http.StatusForbidden},
+		{"facility manager", h.As(1000002), http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := tt.client.Do("POST", harness.V1+"/faq/ZDV/reorder", body)
+			assert.Equal(t, tt.expected, rr.Code, rr.Body.String())
+		})
+	}
+
+	assert.NoError(t, h.DB.First(first).Error)
+	assert.Equal(t, 1, first.SortOrder)
+}
+
+func TestPatchSortOrder(t *testing.T) {
+	h := harness.New(t)
+	faq := createFAQ(t, h, "ZDV", types.Published, 3)
+	path := fmt.Sprintf("%s/faq/%d", harness.V1, faq.ID)
+
+	rr := h.Do("PATCH", path, `{"question":"How do I transfer?"}`)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.NoError(t, h.DB.First(faq).Error)
+	assert.Equal(t, 3, faq.SortOrder)
+
+	rr = h.Do("PATCH", path, `{"sort_order":0}`)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.NoError(t, h.DB.First(faq).Error)
+	assert.Equal(t, 0, faq.SortOrder)
+}
@@ -0,0 +1,75 @@
+package news_test
+
+import (
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/VATUSA/primary-api/internal/v1/news"
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func testFeed() *news.Feed {
+	published := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	return &news.Feed{
+		Facility: models.Facility{ID: "ZDV", Name: "Denver ARTCC", URL: "https://zdvartcc.org"},
+		SelfURL:  "https://api.vatusa.net/v1/news/ZDV/feed.xml",
+		Items: []models.News{
+			{ID: 2, Facility: "ZDV", Title: "Pinned <notice>", Description: "Read this & that", Pinned: true, PublishAt: &published, UpdatedAt: published},
+			{ID: 1, Facility: "ZHQ", Title: "Division news", Description: "Hello", CreatedAt: published.Add(-time.Hour), UpdatedAt: published.Add(time.Hour)},
+		},
+	}
+}
+
+func TestRSS(t *testing.T) {
+	out, err := xml.Marshal(news.NewRSS(testFeed()))
+	assert.NoError(t, err)
+
+	var rss struct {
+		Version string `xml:"version,attr"`
+		Channel struct {
+			Title         string `xml:"title"`
+			LastBuildDate string `xml:"lastBuildDate"`
+			Items         []struct {
+				Title    string `xml:"title"`
+				GUID     string `xml:"guid"`
+				PubDate  string `xml:"pubDate"`
+				Category string `xml:"category"`
+			} `xml:"item"`
+		} `xml:"channel"`
+	}
+	assert.NoError(t, xml.Unmarshal(out, &rss))
+
+	assert.Equal(t, "2.0", rss.Version)
+	assert.Equal(t, "Denver ARTCC News", rss.Channel.Title)
+	assert.Equal(t, "Fri, 01 Mar 2024 13:00:00 +0000", rss.Channel.LastBuildDate)
+	assert.Len(t, rss.Channel.Items, 2)
+	assert.Equal(t, "Pinned <notice>", rss.Channel.Items[0].Title)
+	assert.Equal(t, "Pinned", rss.Channel.Items[0].Category)
+	assert.Equal(t, "urn:vatusa:news:2", rss.Channel.Items[0].GUID)
+	assert.Equal(t, "Fri, 01 Mar 2024 11:00:00 +0000", rss.Channel.Items[1].PubDate)
+}
+
+func TestAtom(t *testing.T) {
+	out, err := xml.Marshal(news.NewAtom(testFeed()))
+	assert.NoError(t, err)
+
+	var atom struct {
+		XMLName xml.Name `xml:"http://www.w3.org/2005/Atom feed"`
+		ID      string   `xml:"id"`
+		Updated string   `xml:"updated"`
+		Entries []struct {
+			ID        string `xml:"id"`
+			Published string `xml:"published"`
+			Summary   string `xml:"summary"`
+		} `xml:"entry"`
+	}
+	assert.NoError(t, xml.Unmarshal(out, &atom))
+
+	assert.Equal(t, "https://api.vatusa.net/v1/news/ZDV/feed.xml", atom.ID)
+	assert.Equal(t, "2024-03-01T13:00:00Z", atom.Updated)
+	assert.Len(t, atom.Entries, 2)
+	assert.Equal(t, "2024-03-01T12:00:00Z", atom.Entries[0].Published)
+	assert.Equal(t, "Read this & that", atom.Entries[0].Summary)
+}
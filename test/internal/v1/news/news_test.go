@@ -1,92 +1,144 @@
 package news_test
 
 import (
+	"fmt"
 	"net/http"
-	"net/http/httptest"
-	"strings"
 	"testing"
+	"time"
 
-	"github.com/VATUSA/primary-api/internal/v1/news"
-	"github.com/VATUSA/primary-api/pkg/database"
+	"github.com/VATUSA/primary-api/pkg/constants"
 	"github.com/VATUSA/primary-api/pkg/database/models"
-	"github.com/go-chi/chi/v5"
+	"github.com/VATUSA/primary-api/pkg/database/types"
+	"github.com/VATUSA/primary-api/test/harness"
 	"github.com/stretchr/testify/assert"
 )
 
-func setupRouter() *chi.Mux {
-	r := chi.NewRouter()
-	r.Route("/news", func(r chi.Router) {
-		r.Post("/", news.CreateNews)
-		r.Get("/", news.ListNews)
-		r.Route("/{newsID}", func(r chi.Router) {
-			r.Use(news.Ctx)
-			r.Get("/", news.GetNews)
-			r.Put("/", news.UpdateNews)
-			r.Delete("/", news.DeleteNews)
-		})
-	})
-	return r
+func createNews(t *testing.T, h *harness.Harness) *models.News {
+	news := &models.News{Facility: "ZDV", Title: "Test News", Description: "This is a test news", Status: types.Published}
+	assert.NoError(t, h.DB.Create(news).Error)
+	return news
 }
 
 func TestCreateNews(t *testing.T) {
-	r := setupRouter()
-
-	req, _ := http.NewRequest("POST", "/news", strings.NewReader(`{"title":"Test News","content":"This is a test news"}`))
-	req.Header.Set("Content-Type", "application/json")
-
-	rr := httptest.NewRecorder()
-	r.ServeHTTP(rr, req)
+	h := harness.New(t)
+
+	tests := []struct {
+		name     string
+		body     string
+		expected int
+	}{
+		{"valid request", `{"facility":"ZDV","title":"Test News","description":"This is a test news"}`, http.StatusCreated},
+		{"unknown facility", `{"facility":"ZZZ","title":"Test News","description":"This is a test news"}`, http.StatusBadRequest},
+		{"missing title", `{"facility":"ZDV","description":"This is a test news"}`, http.StatusBadRequest},
+		{"scheduled without publish_at", `{"facility":"ZDV","title":"Test News","description":"This is a test news","status":"scheduled"}`, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := h.Do("POST", harness.V1+"/news", tt.body)
+			assert.Equal(t, tt.expected, rr.Code, rr.Body.String())
+		})
+	}
 
-	assert.Equal(t, http.StatusCreated, rr.Code)
+	var created []models.News
+	assert.NoError(t, h.DB.Find(&created).Error)
+	assert.Len(t, created, 1)
+	assert.Equal(t, types.Published, created[0].Status)
 }
 
 func TestListNews(t *testing.T) {
-	r := setupRouter()
-
-	req, _ := http.NewRequest("GET", "/news", nil)
-	rr := httptest.NewRecorder()
-	r.ServeHTTP(rr, req)
+	h := harness.New(t)
+	createNews(t, h)
 
+	rr := h.Do("GET", harness.V1+"/news", nil)
 	assert.Equal(t, http.StatusOK, rr.Code)
-}
 
-func TestGetNews(t *testing.T) {
-	r := setupRouter()
+	var list []models.News
+	harness.Decode(t, rr, &list)
+	assert.Len(t, list, 1)
+}
 
-	news := &models.News{ID: 1, Title: "Test News", Description: "This is a test news"}
-	database.DB.Create(&news)
+func TestNewsVisibility(t *testing.T) {
+	h := harness.New(t)
+	live := createNews(t, h)
+	future, past := time.Now().Add(time.Hour), time.Now().Add(-time.Hour)
+	hidden := []*models.News{
+		{Facility: "ZDV", Title: "Draft", Description: "Not ready", Status: types.Draft},
+		{Facility: "ZDV", Title: "Scheduled", Description: "Not yet", Status: types.Scheduled, PublishAt: &future},
+		{Facility: "ZDV", Title: "Expired", Description: "Too late", Status: types.Published, ExpireAt: &past},
+	}
+	for _, n := range hidden {
+		assert.NoError(t, h.DB.Create(n).Error)
+	}
+
+	tests := []struct {
+		name   string
+		client *harness.Client
+		staff  bool
+	}{
+		{"guest", h.Guest(), false},
+		{"mentor", h.As(1000003), false},
+		{"other facility", h.AsRole(constants.AirTrafficManagerRole, "ZLA"), false},
+		{"facility manager", h.As(1000002), true},
+		{"division staff", h.As(1000001), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := tt.client.Do("GET", harness.V1+"/news", nil)
+			assert.Equal(t, http.StatusOK, rr.Code)
+			var list []models.News
+			harness.Decode(t, rr, &list)
+			if tt.staff {
+				assert.Len(t, list, 4)
+			} else if assert.Len(t, list, 1) {
+				assert.Equal(t, live.ID, list[0].ID)
+			}
+
+			for _, n := range hidden {
+				rr := tt.client.Do("GET", fmt.Sprintf("%s/news/%d", harness.V1, n.ID), nil)
+				if tt.staff {
+					assert.Equal(t, http.StatusOK, rr.Code, n.Title)
+				} else {
+					assert.Equal(t, http.StatusNotFound, rr.Code, n.Title)
+				}
+			}
+		})
+	}
+}
 
-	req, _ := http.NewRequest("GET", "/news/1", nil)
-	rr := httptest.NewRecorder()
-	r.ServeHTTP(rr, req)
+func TestGetNews(t *testing.T) {
+	h := harness.New(t)
+	news := createNews(t, h)
 
+	rr := h.Do("GET", fmt.Sprintf("%s/news/%d", harness.V1, news.ID), nil)
 	assert.Equal(t, http.StatusOK, rr.Code)
+
+	got := &models.News{}
+	harness.Decode(t, rr, got)
+	assert.Equal(t, "Test News", got.Title)
 }
 
 func TestUpdateNews(t *testing.T) {
-	r := setupRouter()
+	h := harness.New(t)
+	news := createNews(t, h)
 
-	news := &models.News{ID: 1, Title: "Test News", Description: "This is a test news"}
-	database.DB.Create(&news)
+	rr := h.Do("PUT", fmt.Sprintf("%s/news/%d", harness.V1, news.ID), `{"facility":"ZDV","title":"Updated Test News","description":"This is an updated test news"}`)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
 
-	req, _ := http.NewRequest("PUT", "/news/1", strings.NewReader(`{"title":"Updated Test News","content":"This is an updated test news"}`))
-	req.Header.Set("Content-Type", "application/json")
-
-	rr := httptest.NewRecorder()
-	r.ServeHTTP(rr, req)
-
-	assert.Equal(t, http.StatusOK, rr.Code)
+	updated := &models.News{}
+	assert.NoError(t, h.DB.First(updated, news.ID).Error)
+	assert.Equal(t, "Updated Test News", updated.Title)
 }
 
 func TestDeleteNews(t *testing.T) {
-	r := setupRouter()
-
-	news := &models.News{ID: 1, Title: "Test News", Description: "This is a test news"}
-	database.DB.Create(&news)
+	h := harness.New(t)
+	news := createNews(t, h)
 
-	req, _ := http.NewRequest("DELETE", "/news/1", nil)
-	rr := httptest.NewRecorder()
-	r.ServeHTTP(rr, req)
+	rr := h.Do("DELETE", fmt.Sprintf("%s/news/%d", harness.V1, news.ID), nil)
+	assert.Less(t, rr.Code, 300)
 
-	assert.Equal(t, http.StatusNoContent, rr.Code)
+	var count int64
+	h.DB.Model(&models.News{}).Where("id = ?", news.ID).Count(&count)
+	assert.Zero(t, count)
 }
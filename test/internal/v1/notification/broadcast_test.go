@@ -0,0 +1,88 @@
+package notification_test
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/VATUSA/primary-api/pkg/constants"
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/VATUSA/primary-api/pkg/database/types"
+	"github.com/VATUSA/primary-api/test/harness"
+	"github.com/stretchr/testify/assert"
+)
+
+// instructors gives 1000003 a second training role at ZDV, and 1000005 instructor roles at two facilities
+func instructors(t *testing.T, h *harness.Harness) {
+	for _, role := range []models.UserRole{
+		{CID: 1000003, RoleID: constants.InstructorRole, FacilityID: "ZDV"},
+		{CID: 1000004, RoleID: constants.InstructorRole, FacilityID: "ZLA"},
+		{CID: 1000005, RoleID: constants.InstructorRole, FacilityID: "ZLA"},
+		{CID: 1000005, RoleID: constants.InstructorRole, FacilityID: "ZDV"},
+	} {
+		assert.NoError(t, h.DB.Create(&role).Error)
+	}
+}
+
+func TestBroadcastRecipients(t *testing.T) {
+	h := harness.New(t)
+	instructors(t, h)
+
+	tests := []struct {
+		name      string
+		broadcast models.NotificationBroadcast
+		expected  []uint
+	}{
+		{"facility", models.NotificationBroadcast{AudienceType: types.FacilityAudience, Audience: "ZDV"}, []uint{1000002, 1000003, 1000004}},
+		{"facility home controllers", models.NotificationBroadcast{AudienceType: types.FacilityAudience, Audience: "ZDV", HomeOnly: true}, []uint{1000002, 1000003}},
+		{"role across the division", models.NotificationBroadcast{AudienceType: types.RoleAudience, Audience: string(constants.InstructorRole)}, []uint{1000003, 1000004, 1000005}},
+		{"role at a facility", models.NotificationBroadcast{AudienceType: types.RoleAudience, Audience: string(constants.InstructorRole), Facility: "ZLA"}, []uint{1000004, 1000005}},
+		{"group across the division", models.NotificationBroadcast{AudienceType: types.GroupAudience, Audience: string(constants.FacilityTraining)}, []uint{1000003, 1000004, 1000005}},
+		{"group at a facility", models.NotificationBroadcast{AudienceType: types.GroupAudience, Audience: string(constants.FacilityTraining), Facility: "ZDV"}, []uint{1000003, 1000005}},
+		{"group without roles", models.NotificationBroadcast{AudienceType: types.GroupAudience, Audience: "nobody"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cids, err := tt.broadcast.Recipients(h.DB)
+			assert.NoError(t, err)
+			sort.Slice(cids, func(i, j int) bool { return cids[i] < cids[j] })
+			assert.Equal(t, tt.expected, cids, "each recipient is listed once, however many roles match")
+		})
+	}
+}
+
+func TestCreateBroadcastFansOut(t *testing.T) {
+	h := harness.New(t)
+	instructors(t, h)
+	client := h.As(1000001)
+	expireAt := time.Now().Add(24 * time.Hour).UTC().Format(http.TimeFormat)
+
+	body := fmt.Sprintf(`{"audience_type":"group","audience":"fac_training","category":"Training","title":"Instructor meeting","body":"Tonight at 0100z","expire_at":%q}`, expireAt)
+	rr := client.Do("POST", harness.V1+"/notification/broadcast", body)
+	assert.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+
+	broadcast := &models.NotificationBroadcast{}
+	harness.Decode(t, rr, broadcast)
+	assert.Equal(t, 3, broadcast.RecipientCount)
+
+	var notifications []models.Notification
+	assert.NoError(t, h.DB.Where("broadcast_id = ?", broadcast.ID).Order("c_id").Find(&notifications).Error)
+	if assert.Len(t, notifications, 3) {
+		for i, cid := range []uint{1000003, 1000004, 1000005} {
+			assert.Equal(t, cid, notifications[i].CID)
+			assert.Equal(t, "Instructor meeting", notifications[i].Title)
+		}
+	}
+
+	for name, expire := range map[string]string{
+		"past":    time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat),
+		"invalid": "tomorrow",
+	} {
+		body := fmt.Sprintf(`{"audience_type":"facility","audience":"ZDV","category":"Training","title":"Title","body":"Body","expire_at":%q}`, expire)
+		rr := client.Do("POST", harness.V1+"/notification/broadcast", body)
+		assert.Equal(t, http.StatusBadRequest, rr.Code, name)
+	}
+}
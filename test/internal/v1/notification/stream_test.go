@@ -0,0 +1,82 @@
+package notification_test
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VATUSA/primary-api/internal/v1/notification"
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/VATUSA/primary-api/test/harness"
+	"github.com/stretchr/testify/assert"
+)
+
+// openStream connects to the notification stream as cid and returns a channel of the event IDs it receives
+func openStream(t *testing.T, h *harness.Harness, cid uint, lastEventID string) <-chan uint64 {
+	server := httptest.NewServer(h.Router)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(func() {
+		cancel()
+		server.Close()
+	})
+
+	req, err := http.NewRequestWithContext(ctx, "GET", server.URL+harness.V1+"/me/notifications/stream", nil)
+	assert.NoError(t, err)
+	req.Header = h.As(cid).Header()
+	req.Header.Set("Last-Event-ID", lastEventID)
+
+	res, err := http.DefaultClient.Do(req)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	ids := make(chan uint64, 16)
+	go func() {
+		defer res.Body.Close()
+		defer close(ids)
+		scanner := bufio.NewScanner(res.Body)
+		for scanner.Scan() {
+			if id, ok := strings.CutPrefix(scanner.Text(), "id: "); ok {
+				parsed, _ := strconv.ParseUint(id, 10, 64)
+				ids <- parsed
+			}
+		}
+	}()
+	return ids
+}
+
+func next(t *testing.T, ids <-chan uint64) uint64 {
+	t.Helper()
+	select {
+	case id := <-ids:
+		return id
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for a notification")
+		return 0
+	}
+}
+
+func TestStreamDeliversOutOfOrderIDs(t *testing.T) {
+	h := harness.New(t)
+
+	missed := models.Notification{ID: 10, CID: 1000002, Title: "Missed", ExpireAt: time.Now().Add(time.Hour)}
+	assert.NoError(t, h.DB.Create(&missed).Error)
+
+	ids := openStream(t, h, 1000002, "5")
+	assert.Equal(t, uint64(10), next(t, ids))
+
+	// Transactions commit in any order, so a lower ID can be published after a higher one. The replayed
+	// notification is published too and must not be sent again.
+	for _, id := range []uint{12, 11, 10, 13} {
+		notification.Publish(context.Background(), models.Notification{ID: id, CID: 1000002})
+	}
+	assert.Equal(t, uint64(12), next(t, ids))
+	assert.Equal(t, uint64(11), next(t, ids))
+	assert.Equal(t, uint64(13), next(t, ids))
+}
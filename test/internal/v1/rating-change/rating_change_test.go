@@ -0,0 +1,109 @@
+package rating_change_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/VATUSA/primary-api/pkg/constants"
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/VATUSA/primary-api/pkg/database/types"
+	"github.com/VATUSA/primary-api/test/harness"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRatingChange(t *testing.T) {
+	h := harness.New(t)
+	staff := h.AsRole(constants.InstructorRole, "ZDV")
+
+	create := func(t *testing.T) *models.RatingChange {
+		rc := &models.RatingChange{CID: 1000005, OldRating: 1, NewRating: 2, CreatedByCID: "1000003"}
+		assert.NoError(t, h.DB.Create(rc).Error)
+		return rc
+	}
+	path := func(rc *models.RatingChange) string {
+		return fmt.Sprintf("%s/rating-change/%d", harness.V1, rc.ID)
+	}
+
+	t.Run("create", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			body     string
+			expected int
+		}{
+			{"valid request", `{"cid":1000005,"old_rating":1,"new_rating":2,"created_by_cid":"1000003"}`, http.StatusCreated},
+			{"unknown cid", `{"cid":123456,"old_rating":1,"new_rating":2,"created_by_cid":"1000003"}`, http.StatusBadRequest},
+			{"missing new rating", `{"cid":1000005,"old_rating":1,"created_by_cid":"1000003"}`, http.StatusBadRequest},
+			{"missing created by", `{"cid":1000005,"old_rating":1,"new_rating":2}`, http.StatusBadRequest},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				rr := staff.Do("POST", harness.V1+"/rating-change", tt.body)
+				assert.Equal(t, tt.expected, rr.Code, rr.Body.String())
+			})
+		}
+
+		var events []models.OutboxEvent
+		assert.NoError(t, h.DB.Where("type = ?", types.RatingChanged).Find(&events).Error)
+		assert.Len(t, events, 1)
+		assert.Equal(t, "1000005", events[0].AggregateID)
+	})
+
+	t.Run("list", func(t *testing.T) {
+		rr := staff.Do("GET", harness.V1+"/rating-change", nil)
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		var list []models.RatingChange
+		harness.Decode(t, rr, &list)
+		assert.NotEmpty(t, list)
+	})
+
+	t.Run("get", func(t *testing.T) {
+		rc := create(t)
+
+		rr := staff.Do("GET", path(rc), nil)
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		got := &models.RatingChange{}
+		harness.Decode(t, rr, got)
+		assert.Equal(t, uint(2), got.NewRating)
+	})
+
+	t.Run("get unknown", func(t *testing.T) {
+		rr := staff.Do("GET", harness.V1+"/rating-change/999999", nil)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("update", func(t *testing.T) {
+		rc := create(t)
+
+		rr := staff.Do("PUT", path(rc), `{"cid":1000005,"old_rating":2,"new_rating":3,"created_by_cid":"1000002"}`)
+		assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+		assert.NoError(t, h.DB.First(rc, rc.ID).Error)
+		assert.Equal(t, uint(3), rc.NewRating)
+		assert.Equal(t, "1000002", rc.CreatedByCID)
+	})
+
+	t.Run("patch", func(t *testing.T) {
+		rc := create(t)
+
+		rr := staff.Do("PATCH", path(rc), `{"new_rating":4}`)
+		assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+		assert.NoError(t, h.DB.First(rc, rc.ID).Error)
+		assert.Equal(t, uint(1), rc.OldRating)
+		assert.Equal(t, uint(4), rc.NewRating)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		rc := create(t)
+
+		rr := staff.Do("DELETE", path(rc), nil)
+		assert.Less(t, rr.Code, 300)
+
+		rr = staff.Do("GET", path(rc), nil)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
@@ -0,0 +1,107 @@
+package roster_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/VATUSA/primary-api/internal/v1/roster"
+	"github.com/VATUSA/primary-api/pkg/constants"
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/VATUSA/primary-api/pkg/database/types"
+	"github.com/VATUSA/primary-api/test/harness"
+	"github.com/stretchr/testify/assert"
+)
+
+// visitorRoster is 1000004's roster entry as a visitor at ZDV
+func visitorRoster(t *testing.T, h *harness.Harness) *models.Roster {
+	entry := &models.Roster{}
+	assert.NoError(t, h.DB.Where("c_id = ? AND facility = ?", 1000004, "ZDV").First(entry).Error)
+	return entry
+}
+
+func TestCanCertify(t *testing.T) {
+	h := harness.New(t)
+	entry := visitorRoster(t, h)
+	assert.NoError(t, h.DB.Create(&models.Roster{CID: 1000005, Facility: "ZDV", OIs: "RS", Home: true, Status: "Active", Instructor: true}).Error)
+
+	tests := []struct {
+		name     string
+		client   *harness.Client
+		expected int
+	}{
+		{"guest", h.Guest(), http.StatusUnauthorized},
+		{"mentor", h.As(1000003), http.StatusForbidden},
+		{"facility manager", h.As(1000002), http.StatusForbidden},
+		{"other facility's TA", h.AsRole(constants.TrainingAdministratorRole, "ZLA"), http.StatusForbidden},
+		{"other facility's instructor", h.AsRole(constants.InstructorRole, "ZLA"), http.StatusForbidden},
+		{"training admin", h.AsRole(constants.TrainingAdministratorRole, "ZDV"), http.StatusCreated},
+		{"instructor role", h.AsRole(constants.InstructorRole, "ZDV"), http.StatusCreated},
+		{"instructor on roster", h.As(1000005), http.StatusCreated},
+		{"division staff", h.As(1000001), http.StatusCreated},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Each grant covers its own airport so successful ones don't conflict
+			body := fmt.Sprintf(`{"type":"TWR","position":"K%03d"}`, i)
+			rr := tt.client.Do("POST", fmt.Sprintf("%s/roster/%d/certifications", harness.V1, entry.ID), body)
+			assert.Equal(t, tt.expected, rr.Code, rr.Body.String())
+		})
+	}
+
+	var count int64
+	h.DB.Model(&models.Certification{}).Where("roster_id = ?", entry.ID).Count(&count)
+	assert.Equal(t, int64(4), count)
+}
+
+func TestSweepSoloCertifications(t *testing.T) {
+	h := harness.New(t)
+	entry := visitorRoster(t, h)
+	now := time.Now()
+	expired, later := now.Add(-time.Minute), now.Add(24*time.Hour)
+
+	certs := []*models.Certification{
+		{RosterID: entry.ID, CID: entry.CID, Facility: "ZDV", Type: types.TowerCertification, Position: "DEN", Solo: true, ExpiresAt: &expired},
+		{RosterID: entry.ID, CID: entry.CID, Facility: "ZDV", Type: types.ApproachCertification, Position: "DEN", Solo: true, ExpiresAt: &later},
+		{RosterID: entry.ID, CID: entry.CID, Facility: "ZDV", Type: types.GroundCertification, Position: "DEN"},
+	}
+	for _, cert := range certs {
+		assert.NoError(t, h.DB.Create(cert).Error)
+	}
+
+	swept, err := roster.SweepSoloCertifications(context.Background(), h.Services.Roster, now)
+	assert.NoError(t, err)
+	if assert.Len(t, swept, 1) {
+		assert.Equal(t, certs[0].ID, swept[0].ID)
+	}
+
+	var remaining []models.Certification
+	assert.NoError(t, h.DB.Where("roster_id = ?", entry.ID).Order("id").Find(&remaining).Error)
+	if assert.Len(t, remaining, 2) {
+		assert.Equal(t, certs[1].ID, remaining[0].ID)
+		assert.Equal(t, certs[2].ID, remaining[1].ID)
+	}
+
+	var notifications []models.Notification
+	assert.NoError(t, h.DB.Where("c_id = ?", entry.CID).Find(&notifications).Error)
+	if assert.Len(t, notifications, 1) {
+		assert.Equal(t, "Solo certification expired", notifications[0].Title)
+		assert.Equal(t, "Your DEN TWR solo certification at ZDV has expired.", notifications[0].Body)
+	}
+
+	// A sweep that listed a certification another already removed, or that has since been extended, leaves it alone
+	removed, err := models.DeleteExpiredSoloCertification(h.DB, certs[0], now)
+	assert.NoError(t, err)
+	assert.False(t, removed)
+	removed, err = models.DeleteExpiredSoloCertification(h.DB, certs[1], now)
+	assert.NoError(t, err)
+	assert.False(t, removed)
+
+	// Nothing is left to sweep
+	swept, err = roster.SweepSoloCertifications(context.Background(), h.Services.Roster, now)
+	assert.NoError(t, err)
+	assert.Empty(t, swept)
+}
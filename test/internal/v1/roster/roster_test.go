@@ -0,0 +1,46 @@
+package roster_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/VATUSA/primary-api/pkg/constants"
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/VATUSA/primary-api/test/harness"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateRoster(t *testing.T) {
+	h := harness.New(t, "testdata/roster.json")
+	atm := h.AsRole(constants.AirTrafficManagerRole, "ZDV")
+
+	tests := []struct {
+		name     string
+		body     string
+		expected int
+		ois      string
+	}{
+		{"preferred initials", `{"cid":1000005,"facility":"ZDV","operating_initials":"XX","home":true,"status":"active"}`, http.StatusCreated, "RS"},
+		{"preferred initials taken", `{"cid":1000006,"facility":"ZDV","operating_initials":"XX","home":true,"status":"active"}`, http.StatusCreated, "JK"},
+		{"already on roster", `{"cid":1000003,"facility":"ZDV","operating_initials":"CC","home":true,"status":"active"}`, http.StatusBadRequest, ""},
+		{"unknown facility", `{"cid":1000005,"facility":"ZZZ","operating_initials":"RS","home":true,"status":"active"}`, http.StatusBadRequest, ""},
+		{"home and visiting", `{"cid":1000005,"facility":"ZLA","operating_initials":"RS","home":true,"visiting":true,"status":"active"}`, http.StatusBadRequest, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := atm.Do("POST", harness.V1+"/roster", tt.body)
+			assert.Equal(t, tt.expected, rr.Code, rr.Body.String())
+
+			if tt.expected == http.StatusCreated {
+				roster := &models.Roster{}
+				harness.Decode(t, rr, roster)
+				assert.Equal(t, tt.ois, roster.OIs)
+			}
+		})
+	}
+
+	var count int64
+	h.DB.Model(&models.Roster{}).Where("c_id = ? AND facility = ?", 1000003, "ZDV").Count(&count)
+	assert.Equal(t, int64(1), count)
+}
@@ -0,0 +1,63 @@
+package search_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/VATUSA/primary-api/pkg/database/types"
+	"github.com/VATUSA/primary-api/pkg/search"
+	"github.com/VATUSA/primary-api/test/harness"
+	"github.com/stretchr/testify/assert"
+)
+
+// index creates a ZDV document and adds it to the search index, with training in its title repeated so the
+// documents rank in the order they are created
+func index(t *testing.T, h *harness.Harness, name string, visibility types.DocumentVisibility, repeat int) uint {
+	doc := &models.Document{Facility: "ZDV", Name: name, Category: types.General, Visibility: visibility}
+	assert.NoError(t, h.DB.Create(doc).Error)
+
+	title := name
+	for i := 0; i < repeat; i++ {
+		title += " training"
+	}
+	h.Search.Add(search.Result{Type: search.DocumentType, ID: doc.ID, Facility: doc.Facility, Title: title}, "")
+	return doc.ID
+}
+
+func ids(results []search.Result) []uint {
+	list := []uint{}
+	for _, r := range results {
+		list = append(list, r.ID)
+	}
+	return list
+}
+
+func TestSearchPagesVisibleResults(t *testing.T) {
+	h := harness.New(t)
+	staff := []uint{index(t, h, "SOP001", types.StaffVisibility, 5), index(t, h, "SOP002", types.StaffVisibility, 4)}
+	public := []uint{index(t, h, "DP001", types.PublicVisibility, 3), index(t, h, "DP002", types.PublicVisibility, 2), index(t, h, "DP003", types.PublicVisibility, 1)}
+
+	tests := []struct {
+		name     string
+		client   *harness.Client
+		query    string
+		expected []uint
+	}{
+		{"first page", h.Guest(), "?q=training&limit=2", public[:2]},
+		{"second page", h.Guest(), "?q=training&limit=2&offset=2", public[2:]},
+		{"past the end", h.Guest(), "?q=training&limit=2&offset=4", []uint{}},
+		{"staff", h.As(1000002), "?q=training&limit=3", append(append([]uint{}, staff...), public[0])},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := tt.client.Do("GET", harness.V1+"/search"+tt.query, nil)
+			assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+			var results []search.Result
+			harness.Decode(t, rr, &results)
+			assert.Equal(t, tt.expected, ids(results))
+		})
+	}
+}
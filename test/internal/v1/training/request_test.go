@@ -0,0 +1,181 @@
+package training_test
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/VATUSA/primary-api/pkg/constants"
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/VATUSA/primary-api/pkg/database/types"
+	"github.com/VATUSA/primary-api/test/harness"
+	"github.com/stretchr/testify/assert"
+)
+
+// queueRequest adds a request from 1000004, who visits ZDV, to the ZDV queue
+func queueRequest(t *testing.T, h *harness.Harness, status types.TrainingRequestStatus, assigned *uint) *models.TrainingRequest {
+	request := &models.TrainingRequest{CID: 1000004, Facility: "ZDV", Position: "DEN_TWR", Status: status, AssignedCID: assigned}
+	if assigned != nil {
+		now := time.Now()
+		request.AssignedAt = &now
+	}
+	assert.NoError(t, h.DB.Create(request).Error)
+	return request
+}
+
+func requestPath(request *models.TrainingRequest, action string) string {
+	return fmt.Sprintf("%s/training/requests/%d%s", harness.V1, request.ID, action)
+}
+
+func reload(t *testing.T, h *harness.Harness, request *models.TrainingRequest) *models.TrainingRequest {
+	loaded := &models.TrainingRequest{}
+	assert.NoError(t, h.DB.First(loaded, request.ID).Error)
+	return loaded
+}
+
+func TestPickUpTrainingRequest(t *testing.T) {
+	h := harness.New(t)
+	request := queueRequest(t, h, types.OpenTrainingRequest, nil)
+
+	trainers := []*harness.Client{
+		h.As(mentor),
+		h.As(1000002),
+		h.AsRole(constants.InstructorRole, "ZDV"),
+		h.AsRole(constants.TrainingAdministratorRole, "ZDV"),
+	}
+
+	// Every trainer tries to pick the request up at once; exactly one gets it
+	var wg sync.WaitGroup
+	codes := make(chan int, len(trainers))
+	for _, client := range trainers {
+		wg.Add(1)
+		go func(client *harness.Client) {
+			defer wg.Done()
+			codes <- client.Do("POST", requestPath(request, "/pickup"), nil).Code
+		}(client)
+	}
+	wg.Wait()
+	close(codes)
+
+	counts := map[int]int{}
+	for code := range codes {
+		counts[code]++
+	}
+	assert.Equal(t, map[int]int{http.StatusOK: 1, http.StatusConflict: len(trainers) - 1}, counts)
+
+	loaded := reload(t, h, request)
+	assert.Equal(t, types.AssignedTrainingRequest, loaded.Status)
+	assert.NotNil(t, loaded.AssignedCID)
+
+	var notifications int64
+	h.DB.Model(&models.Notification{}).Where("c_id = ?", request.CID).Count(&notifications)
+	assert.Equal(t, int64(1), notifications, "the student hears from the one who picked it up")
+}
+
+func TestReleaseTrainingRequest(t *testing.T) {
+	h := harness.New(t)
+	instructor := h.AsRole(constants.InstructorRole, "ZDV")
+	cid := uint(mentor)
+	request := queueRequest(t, h, types.AssignedTrainingRequest, &cid)
+
+	// The mentor's view of the request goes stale once it is released and picked up by an instructor
+	stale := reload(t, h, request)
+	rr := h.As(mentor).Do("POST", requestPath(request, "/release"), nil)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	rr = instructor.Do("POST", requestPath(request, "/pickup"), nil)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	for name, change := range map[string]func() (bool, error){
+		"release":  stale.Release,
+		"complete": func() (bool, error) { return stale.SetStatus(types.CompletedTrainingRequest) },
+	} {
+		ok, err := change()
+		assert.NoError(t, err, name)
+		assert.False(t, ok, name)
+	}
+
+	loaded := reload(t, h, request)
+	assert.Equal(t, types.AssignedTrainingRequest, loaded.Status)
+	if assert.NotNil(t, loaded.AssignedCID) {
+		assert.NotEqual(t, uint(mentor), *loaded.AssignedCID)
+	}
+
+	rr = h.As(mentor).Do("POST", requestPath(request, "/release"), nil)
+	assert.Equal(t, http.StatusForbidden, rr.Code, "the mentor no longer holds the request")
+	rr = instructor.Do("POST", requestPath(request, "/release"), nil)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	rr = h.AsRole(constants.TrainingAdministratorRole, "ZDV").Do("POST", requestPath(request, "/release"), nil)
+	assert.Equal(t, http.StatusConflict, rr.Code, "the request is open again")
+	assert.Nil(t, reload(t, h, request).AssignedCID)
+}
+
+func TestCancelTrainingRequest(t *testing.T) {
+	h := harness.New(t)
+	request := queueRequest(t, h, types.OpenTrainingRequest, nil)
+
+	// A cancel based on the open request fails once it has been picked up
+	stale := reload(t, h, request)
+	rr := h.As(mentor).Do("POST", requestPath(request, "/pickup"), nil)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	ok, err := stale.SetStatus(types.CancelledTrainingRequest)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, types.AssignedTrainingRequest, reload(t, h, request).Status)
+
+	student := h.As(1000004)
+	rr = student.Do("DELETE", requestPath(request, ""), nil)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.Equal(t, types.CancelledTrainingRequest, reload(t, h, request).Status)
+
+	rr = student.Do("DELETE", requestPath(request, ""), nil)
+	assert.Equal(t, http.StatusConflict, rr.Code, rr.Body.String())
+}
+
+func TestTrainingQueueStats(t *testing.T) {
+	h := harness.New(t)
+	now := time.Now().UTC()
+	days := func(n int) time.Time { return now.Add(-time.Duration(n) * 24 * time.Hour) }
+	cid := uint(mentor)
+
+	requests := []models.TrainingRequest{
+		{Status: types.OpenTrainingRequest, CreatedAt: days(3)},
+		{Status: types.OpenTrainingRequest, CreatedAt: days(1)},
+		{Status: types.AssignedTrainingRequest, CreatedAt: days(10), AssignedAt: ptr(days(8))},
+		{Status: types.CompletedTrainingRequest, CreatedAt: days(20), AssignedAt: ptr(days(16))},
+		{Status: types.CompletedTrainingRequest, CreatedAt: days(200), AssignedAt: ptr(days(190))},
+		{Status: types.CancelledTrainingRequest, CreatedAt: days(5)},
+	}
+	for i := range requests {
+		requests[i].CID, requests[i].Facility, requests[i].Position = 1000004, "ZDV", fmt.Sprintf("DEN_%d", i)
+		if requests[i].AssignedAt != nil {
+			requests[i].AssignedCID = &cid
+		}
+		assert.NoError(t, h.DB.Create(&requests[i]).Error)
+	}
+	assert.NoError(t, h.DB.Create(&models.TrainingRequest{CID: 1000002, Facility: "ZLA", Position: "LAX_TWR", Status: types.OpenTrainingRequest}).Error)
+
+	rr := h.As(mentor).Do("GET", harness.V1+"/training/requests/stats?facility=ZDV", nil)
+	assert.Equal(t, http.StatusForbidden, rr.Code, "mentors don't manage the queue")
+
+	rr = h.AsRole(constants.TrainingAdministratorRole, "ZDV").Do("GET", harness.V1+"/training/requests/stats?facility=ZDV", nil)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	stats := &models.TrainingQueueStats{}
+	harness.Decode(t, rr, stats)
+	assert.Equal(t, "ZDV", stats.Facility)
+	assert.Equal(t, 2, stats.Open)
+	assert.Equal(t, 1, stats.Assigned)
+	if assert.NotNil(t, stats.OldestOpenAt) {
+		assert.WithinDuration(t, days(3), *stats.OldestOpenAt, time.Second)
+	}
+	assert.Equal(t, 2, stats.PickedUp, "the request picked up before the window is left out")
+	assert.Equal(t, int64(3*24*60), stats.AverageWaitMinutes)
+	assert.Equal(t, int64(4*24*60), stats.LongestWaitMinutes)
+	assert.Equal(t, 90, stats.WindowDays)
+}
+
+func ptr(t time.Time) *time.Time {
+	return &t
+}
@@ -0,0 +1,136 @@
+package training_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/VATUSA/primary-api/internal/v1/training"
+	"github.com/VATUSA/primary-api/pkg/constants"
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/VATUSA/primary-api/pkg/database/types"
+	"github.com/VATUSA/primary-api/test/harness"
+	"github.com/stretchr/testify/assert"
+)
+
+// 1000003 mentors at ZDV, where 1000002 is a home controller and 1000004 visits
+const mentor = 1000003
+
+func sessionBody(cid uint) string {
+	return fmt.Sprintf(`{"cid":%d,"facility":"ZDV","position":"DEN_APP","type":"sweatbox","start_at":"2024-03-01T18:00:00Z","duration_minutes":90}`, cid)
+}
+
+func blockTraining(t *testing.T, h *harness.Harness, cid uint) {
+	assert.NoError(t, h.DB.Create(&models.UserFlag{CID: cid, NoTraining: true}).Error)
+}
+
+func TestCreateSession(t *testing.T) {
+	h := harness.New(t)
+	blockTraining(t, h, 1000004)
+	client := h.As(mentor)
+
+	tests := []struct {
+		name     string
+		cid      uint
+		expected int
+	}{
+		{"student", 1000002, http.StatusCreated},
+		{"yourself", mentor, http.StatusBadRequest},
+		{"no training flag", 1000004, http.StatusBadRequest},
+		{"not on the roster", 1000005, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := client.Do("POST", harness.V1+"/training/sessions", sessionBody(tt.cid))
+			assert.Equal(t, tt.expected, rr.Code, rr.Body.String())
+		})
+	}
+
+	var sessions []models.TrainingSession
+	assert.NoError(t, h.DB.Find(&sessions).Error)
+	assert.Len(t, sessions, 1)
+	assert.Equal(t, uint(mentor), sessions[0].InstructorCID)
+}
+
+func TestUpdateSession(t *testing.T) {
+	h := harness.New(t)
+	blockTraining(t, h, 1000004)
+	client := h.As(mentor)
+
+	session := &models.TrainingSession{CID: 1000002, InstructorCID: mentor, Facility: "ZDV", Position: "DEN_APP", Type: types.SweatboxSession, StartAt: time.Now(), DurationMinutes: 60}
+	assert.NoError(t, h.DB.Create(session).Error)
+	path := fmt.Sprintf("%s/training/sessions/%d", harness.V1, session.ID)
+
+	tests := []struct {
+		name     string
+		cid      uint
+		expected int
+	}{
+		{"to yourself", mentor, http.StatusBadRequest},
+		{"to a student with a no training flag", 1000004, http.StatusBadRequest},
+		{"same student", 1000002, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := client.Do("PUT", path, sessionBody(tt.cid))
+			assert.Equal(t, tt.expected, rr.Code, rr.Body.String())
+
+			updated := &models.TrainingSession{}
+			assert.NoError(t, h.DB.First(updated, session.ID).Error)
+			assert.Equal(t, uint(1000002), updated.CID)
+		})
+	}
+
+	// Sessions a student already has can still be corrected after they are flagged
+	blockTraining(t, h, 1000002)
+	rr := client.Do("PUT", path, sessionBody(1000002))
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+}
+
+func TestHistoryVisibility(t *testing.T) {
+	h := harness.New(t)
+
+	// 1000004 is home at ZLA and visits ZDV, and has trained at both
+	for _, facility := range []string{"ZDV", "ZLA"} {
+		session := &models.TrainingSession{CID: 1000004, Facility: facility, Position: facility + "_APP", Type: types.SweatboxSession, StartAt: time.Now(), DurationMinutes: 60}
+		assert.NoError(t, h.DB.Create(session).Error)
+	}
+	path := harness.V1 + "/training/students/1000004/history"
+
+	tests := []struct {
+		name       string
+		client     *harness.Client
+		expected   int
+		facilities []string
+	}{
+		{"student", h.As(1000004), http.StatusOK, []string{"ZDV", "ZLA"}},
+		{"division staff", h.As(1000001), http.StatusOK, []string{"ZDV", "ZLA"}},
+		{"mentor at one facility", h.As(mentor), http.StatusOK, []string{"ZDV"}},
+		{"instructor at the other", h.AsRole(constants.InstructorRole, "ZLA"), http.StatusOK, []string{"ZLA"}},
+		{"facility manager", h.As(1000002), http.StatusOK, []string{"ZDV"}},
+		{"controller", h.As(1000005), http.StatusForbidden, nil},
+		{"unauthenticated", h.Guest(), http.StatusUnauthorized, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := tt.client.Do("GET", path, nil)
+			assert.Equal(t, tt.expected, rr.Code, rr.Body.String())
+			if rr.Code != http.StatusOK {
+				return
+			}
+
+			history := &training.HistoryResponse{}
+			harness.Decode(t, rr, history)
+			facilities := []string{}
+			for _, s := range history.Sessions {
+				facilities = append(facilities, s.Facility)
+			}
+			assert.ElementsMatch(t, tt.facilities, facilities)
+			assert.Equal(t, uint(60*len(tt.facilities)), history.TotalMinutes)
+		})
+	}
+}
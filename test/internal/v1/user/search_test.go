@@ -0,0 +1,150 @@
+package user_test
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/VATUSA/primary-api/test/harness"
+	"github.com/stretchr/testify/assert"
+)
+
+func searchUsers(t *testing.T, client *harness.Client, params url.Values) ([]models.User, string) {
+	t.Helper()
+	rr := client.Do("GET", harness.V1+"/user/search?"+params.Encode(), nil)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	var users []models.User
+	harness.Decode(t, rr, &users)
+	return users, rr.Header().Get("X-Total-Count")
+}
+
+func cids(users []models.User) []uint {
+	list := []uint{}
+	for _, u := range users {
+		list = append(list, u.CID)
+	}
+	return list
+}
+
+// smiths adds users whose names match "smith" exactly, as a prefix and in the middle
+func smiths(t *testing.T, h *harness.Harness) {
+	for _, u := range []models.User{
+		{CID: 1100001, FirstName: "Alex", LastName: "Blacksmith", Email: "blacksmith@vatusa.test", DiscordID: "1100001", LastLogin: time.Now()},
+		{CID: 1100002, FirstName: "Smithers", LastName: "Jones", Email: "jones@vatusa.test"},
+		{CID: 1100003, FirstName: "Jordan", LastName: "Smith", Email: "smith@vatusa.test"},
+	} {
+		assert.NoError(t, h.DB.Create(&u).Error)
+	}
+}
+
+func TestSearchUsersRanking(t *testing.T) {
+	h := harness.New(t)
+	smiths(t, h)
+	client := h.As(1000005)
+
+	users, total := searchUsers(t, client, url.Values{"q": {"smith"}})
+	assert.Equal(t, []uint{1100003, 1100002, 1100001}, cids(users), "exact names, then prefixes, then anything else")
+	assert.Equal(t, "3", total)
+
+	// A CID ranks its own user above those it is only a prefix of
+	users, _ = searchUsers(t, client, url.Values{"q": {"1000003"}})
+	assert.Equal(t, []uint{1000003}, cids(users))
+	users, _ = searchUsers(t, client, url.Values{"q": {"100000"}})
+	assert.Len(t, users, 5)
+
+	// Operating initials match on the facility's roster and rank above names
+	users, _ = searchUsers(t, client, url.Values{"q": {"cc"}, "facility": {"ZDV"}})
+	if assert.NotEmpty(t, users) {
+		assert.Equal(t, uint(1000003), users[0].CID)
+	}
+}
+
+func TestSearchUsersMatchesEveryWord(t *testing.T) {
+	h := harness.New(t)
+	smiths(t, h)
+	client := h.As(1000005)
+
+	users, _ := searchUsers(t, client, url.Values{"q": {"alex"}})
+	assert.ElementsMatch(t, []uint{1000002, 1100001}, cids(users))
+
+	users, total := searchUsers(t, client, url.Values{"q": {"alex smith"}})
+	assert.Equal(t, []uint{1100001}, cids(users))
+	assert.Equal(t, "1", total)
+
+	users, total = searchUsers(t, client, url.Values{"q": {"alex jones"}})
+	assert.Empty(t, users)
+	assert.Equal(t, "0", total)
+}
+
+func TestSearchUsersPages(t *testing.T) {
+	h := harness.New(t)
+	smiths(t, h)
+	client := h.As(1000005)
+
+	all, _ := searchUsers(t, client, url.Values{"q": {"smith"}})
+	var paged []uint
+	for offset := 0; offset < 3; offset++ {
+		users, total := searchUsers(t, client, url.Values{"q": {"smith"}, "limit": {"1"}, "offset": {strconv.Itoa(offset)}})
+		assert.Equal(t, "3", total, "the total counts every match, not just the page")
+		assert.Len(t, users, 1)
+		paged = append(paged, cids(users)...)
+	}
+	assert.Equal(t, cids(all), paged)
+
+	users, total := searchUsers(t, client, url.Values{"q": {"smith"}, "offset": {"3"}})
+	assert.Empty(t, users)
+	assert.Equal(t, "3", total)
+
+	for _, params := range []url.Values{
+		{"q": {"smith"}, "limit": {"0"}},
+		{"q": {"smith"}, "limit": {"101"}},
+		{"q": {"smith"}, "offset": {"-1"}},
+		{"q": {" "}},
+	} {
+		rr := client.Do("GET", harness.V1+"/user/search?"+params.Encode(), nil)
+		assert.Equal(t, http.StatusBadRequest, rr.Code, params.Encode())
+	}
+}
+
+func TestSearchUsersRedactsForNonStaff(t *testing.T) {
+	h := harness.New(t)
+	smiths(t, h)
+
+	tests := []struct {
+		name     string
+		client   *harness.Client
+		params   url.Values
+		redacted bool
+	}{
+		{"controller", h.As(1000005), url.Values{"q": {"blacksmith"}}, true},
+		{"facility staff outside their facility", h.As(1000002), url.Values{"q": {"blacksmith"}}, true},
+		{"facility staff at their facility", h.As(1000002), url.Values{"q": {"visitor"}, "facility": {"ZDV"}}, false},
+		{"division staff", h.As(1000001), url.Values{"q": {"blacksmith"}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users, _ := searchUsers(t, tt.client, tt.params)
+			if !assert.Len(t, users, 1) {
+				return
+			}
+			if tt.redacted {
+				assert.Empty(t, users[0].Email)
+				assert.Empty(t, users[0].DiscordID)
+				assert.True(t, users[0].LastLogin.IsZero())
+			} else {
+				assert.NotEmpty(t, users[0].Email)
+			}
+		})
+	}
+
+	// Only those who may see emails can search by them
+	users, _ := searchUsers(t, h.As(1000005), url.Values{"q": {"blacksmith@vatusa.test"}})
+	assert.Empty(t, users)
+	users, _ = searchUsers(t, h.As(1000001), url.Values{"q": {"blacksmith@vatusa.test"}})
+	assert.Equal(t, []uint{1100001}, cids(users))
+}
@@ -0,0 +1,73 @@
+package activity_test
+
+import (
+	"github.com/VATUSA/primary-api/pkg/activity"
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+var now = time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+
+func session(cid uint, start time.Time, length time.Duration, open bool) models.ControllingSession {
+	s := models.ControllingSession{CID: cid, StartAt: start, LastSeenAt: start.Add(length)}
+	if !open {
+		end := start.Add(length)
+		s.EndAt = &end
+	}
+	return s
+}
+
+func TestFacility(t *testing.T) {
+	prefixes := map[string]string{"DEN": "ZDV", "SLC": "ZLC"}
+	assert.Equal(t, "ZDV", activity.Facility("den_app", prefixes))
+	assert.Equal(t, "ZLC", activity.Facility("SLC_1_CTR", prefixes))
+	assert.Equal(t, "", activity.Facility("EGLL_TWR", prefixes))
+}
+
+func TestHoursClipsToWindow(t *testing.T) {
+	since := now.Add(-24 * time.Hour)
+	sessions := []models.ControllingSession{
+		session(1, since.Add(-time.Hour), 3*time.Hour, false), // Two hours inside the window
+		session(1, now.Add(-time.Hour), 30*time.Minute, true), // Still online
+		session(2, since.Add(-5*time.Hour), time.Hour, false), // Entirely before the window
+	}
+
+	hours := activity.Hours(sessions, since, now)
+	assert.Equal(t, 150*time.Minute, hours[1])
+	assert.Zero(t, hours[2])
+}
+
+func TestInactive(t *testing.T) {
+	window := 90 * 24 * time.Hour
+	old := now.Add(-window - 24*time.Hour)
+	rosters := []models.Roster{
+		{ID: 1, CID: 1, Home: true, Status: "active", CreatedAt: old},
+		{ID: 2, CID: 2, Home: true, Status: "active", CreatedAt: old},
+		{ID: 3, CID: 3, Home: true, Status: "loa", CreatedAt: old},
+		{ID: 4, CID: 4, Home: true, Status: "active", CreatedAt: now.Add(-24 * time.Hour)},
+		{ID: 5, CID: 5, Visiting: true, Status: "active", CreatedAt: old},
+		{ID: 6, CID: 6, Home: true, Status: "active", CreatedAt: old},
+	}
+	sessions := []models.ControllingSession{
+		session(1, now.Add(-10*24*time.Hour), 4*time.Hour, false),
+		session(2, now.Add(-10*24*time.Hour), time.Hour, false),
+	}
+	last := map[uint]time.Time{2: now.Add(-10*24*time.Hour + time.Hour)}
+
+	th := activity.Thresholds{Window: window, MinHours: 3}
+	members := activity.Inactive(rosters, sessions, last, th, now)
+
+	var ids []uint
+	for _, m := range members {
+		ids = append(ids, m.RosterID)
+	}
+	assert.Equal(t, []uint{6, 2}, ids)
+	assert.Equal(t, 1.0, members[1].Hours)
+	assert.NotNil(t, members[1].LastControlledAt)
+	assert.Nil(t, members[0].LastControlledAt)
+
+	th.IncludeVisiting = true
+	assert.Len(t, activity.Inactive(rosters, sessions, last, th, now), 3)
+}
@@ -0,0 +1,66 @@
+package activity_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/VATUSA/primary-api/pkg/activity"
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/VATUSA/primary-api/pkg/datafeed"
+	"github.com/VATUSA/primary-api/test/harness"
+	"github.com/stretchr/testify/assert"
+)
+
+type feed struct {
+	controllers []datafeed.Controller
+}
+
+func (f *feed) Controllers(ctx context.Context) ([]datafeed.Controller, error) {
+	return f.controllers, nil
+}
+
+func TestTrackerReopensSessions(t *testing.T) {
+	h := harness.New(t)
+	assert.NoError(t, h.DB.Create(&models.CallsignPrefix{Prefix: "DEN", Facility: "ZDV"}).Error)
+
+	logon := now.Add(-time.Hour)
+	online := []datafeed.Controller{{CID: 1000003, Callsign: "DEN_APP", LogonTime: logon}}
+	f := &feed{controllers: online}
+	tracker := activity.NewTracker(f)
+
+	sessions := func() []models.ControllingSession {
+		var sessions []models.ControllingSession
+		assert.NoError(t, h.DB.Find(&sessions).Error)
+		return sessions
+	}
+
+	assert.NoError(t, tracker.Poll(context.Background(), now))
+
+	// A poll that misses the controller closes the session
+	f.controllers = nil
+	assert.NoError(t, tracker.Poll(context.Background(), now.Add(time.Minute)))
+	assert.NotNil(t, sessions()[0].EndAt)
+
+	// Seeing the same logon again reopens it rather than starting another
+	f.controllers = online
+	assert.NoError(t, tracker.Poll(context.Background(), now.Add(2*time.Minute)))
+	got := sessions()
+	assert.Len(t, got, 1)
+	assert.Nil(t, got[0].EndAt)
+	assert.True(t, now.Add(2*time.Minute).Equal(got[0].LastSeenAt))
+}
+
+func TestOpenSessionTwice(t *testing.T) {
+	h := harness.New(t)
+
+	// Two instances polling the same snapshot both try to start the session
+	for i := 0; i < 2; i++ {
+		s := &models.ControllingSession{CID: 1000003, Callsign: "DEN_APP", Facility: "ZDV", StartAt: now, LastSeenAt: now}
+		assert.NoError(t, s.Open())
+	}
+
+	var count int64
+	h.DB.Model(&models.ControllingSession{}).Count(&count)
+	assert.Equal(t, int64(1), count)
+}
@@ -0,0 +1,234 @@
+package admin_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VATUSA/primary-api/pkg/admin"
+	"github.com/VATUSA/primary-api/pkg/constants"
+	"github.com/VATUSA/primary-api/pkg/database"
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/VATUSA/primary-api/pkg/vatsim"
+	"github.com/VATUSA/primary-api/test/harness"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestDryRunRollsBack(t *testing.T) {
+	h := harness.New(t)
+
+	s := &admin.Summary{}
+	err := admin.Run(h.DB, true, func(tx *gorm.DB) error {
+		assert.Equal(t, tx, database.DB)
+		return admin.SeedFacilities(tx, []models.Facility{{ID: "ZAB", Name: "Albuquerque ARTCC"}}, s)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, s.Count(admin.Created))
+	assert.Equal(t, h.DB, database.DB)
+
+	var count int64
+	h.DB.Model(&models.Facility{}).Where("id = ?", "ZAB").Count(&count)
+	assert.Equal(t, int64(0), count)
+
+	err = admin.Run(h.DB, false, func(tx *gorm.DB) error {
+		return admin.SeedFacilities(tx, []models.Facility{{ID: "ZAB", Name: "Albuquerque ARTCC"}}, s)
+	})
+	assert.NoError(t, err)
+	h.DB.Model(&models.Facility{}).Where("id = ?", "ZAB").Count(&count)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestSeedFacilities(t *testing.T) {
+	h := harness.New(t)
+
+	s := &admin.Summary{}
+	assert.NoError(t, admin.SeedFacilities(h.DB, admin.DefaultFacilities(), s))
+	assert.Greater(t, s.Count(admin.Created), 0)
+	assert.Greater(t, s.Count(admin.Unchanged)+s.Count(admin.Updated), 0)
+
+	s = &admin.Summary{}
+	assert.NoError(t, admin.SeedFacilities(h.DB, admin.DefaultFacilities(), s))
+	assert.Equal(t, len(admin.DefaultFacilities()), s.Count(admin.Unchanged))
+}
+
+func TestGrantRole(t *testing.T) {
+	h := harness.New(t)
+
+	s := &admin.Summary{}
+	assert.NoError(t, admin.GrantRole(h.DB, 1500000, constants.DivisionDirectorRole, "ZHQ", s))
+	assert.Equal(t, 2, s.Count(admin.Created))
+
+	user := &models.User{}
+	assert.NoError(t, h.DB.Where("c_id = ?", 1500000).First(user).Error)
+	var held int64
+	h.DB.Model(&models.UserRole{}).Where("c_id = ? AND role_id = ?", 1500000, constants.DivisionDirectorRole).Count(&held)
+	assert.Equal(t, int64(1), held)
+
+	s = &admin.Summary{}
+	assert.NoError(t, admin.GrantRole(h.DB, 1500000, constants.DivisionDirectorRole, "ZHQ", s))
+	assert.Equal(t, 1, s.Count(admin.Unchanged))
+
+	assert.Error(t, admin.GrantRole(h.DB, 1500000, "NOPE", "ZHQ", s))
+	assert.Error(t, admin.GrantRole(h.DB, 1500000, constants.DivisionDirectorRole, "XXX", s))
+}
+
+func TestImportUsers(t *testing.T) {
+	h := harness.New(t)
+
+	file := "cid,first_name,last_name,controller_rating\n" +
+		"1000002,Alex,Manager,8\n" +
+		"1000003,Casey,Renamed,5\n" +
+		"1600000,New,Member,2\n"
+	s := &admin.Summary{}
+	assert.NoError(t, admin.ImportUsers(h.DB, strings.NewReader(file), s))
+	assert.Equal(t, 1, s.Count(admin.Created))
+	assert.Equal(t, 1, s.Count(admin.Updated))
+	assert.Equal(t, 1, s.Count(admin.Unchanged))
+
+	user := &models.User{}
+	assert.NoError(t, h.DB.Where("c_id = ?", 1000003).First(user).Error)
+	assert.Equal(t, "Renamed", user.LastName)
+	created := &models.User{}
+	assert.NoError(t, h.DB.Where("c_id = ?", 1600000).First(created).Error)
+	assert.Equal(t, uint(2), created.ControllerRating)
+}
+
+func TestImportUsersRejectsBadFiles(t *testing.T) {
+	h := harness.New(t)
+
+	tests := map[string]string{
+		"unknown column": "cid,shoe_size\n1600000,9\n",
+		"missing cid":    "first_name\nNew\n",
+		"bad cid":        "cid,first_name\nabc,New\n",
+		"bad rating":     "cid,controller_rating\n1600000,high\n",
+		"duplicate":      "cid,first_name\n1600000,New\n1600000,Again\n",
+	}
+	for name, file := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Error(t, admin.ImportUsers(h.DB, strings.NewReader(file), &admin.Summary{}))
+		})
+	}
+
+	// A bad row stops the whole file, including rows before it
+	file := "cid,first_name\n1600000,New\n1600001,Other\nabc,Bad\n"
+	assert.ErrorContains(t, admin.ImportUsers(h.DB, strings.NewReader(file), &admin.Summary{}), "line 4")
+	var count int64
+	h.DB.Model(&models.User{}).Where("c_id IN ?", []uint{1600000, 1600001}).Count(&count)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestExportRoster(t *testing.T) {
+	h := harness.New(t)
+
+	var buf bytes.Buffer
+	s := &admin.Summary{}
+	assert.NoError(t, admin.ExportRoster(h.DB, "ZDV", &buf, s))
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, admin.RosterColumns, records[0])
+	assert.Len(t, records, 4)
+	assert.Equal(t, []string{"1000002", "Alex", "Manager", "AM"}, records[1][:4])
+	assert.Equal(t, "visiting", records[3][5])
+	assert.Equal(t, 3, s.Count(admin.Exported))
+}
+
+func TestRotateAPIKey(t *testing.T) {
+	h := harness.New(t)
+
+	s := &admin.Summary{}
+	first, err := admin.RotateAPIKey(h.DB, "ZDV", s)
+	assert.NoError(t, err)
+	assert.Len(t, first, 64)
+	assert.Equal(t, 1, s.Count(admin.Issued))
+
+	key, err := models.GetAPIKeyByKey(h.DB, first)
+	assert.NoError(t, err)
+	assert.Equal(t, "ZDV", key.Facility)
+	assert.Equal(t, models.HashAPIKey(first), key.Hash)
+
+	second, err := admin.RotateAPIKey(h.DB, "ZDV", s)
+	assert.NoError(t, err)
+	assert.NotEqual(t, first, second)
+	assert.Equal(t, 1, s.Count(admin.Updated))
+
+	_, err = models.GetAPIKeyByKey(h.DB, first)
+	assert.Error(t, err)
+	_, err = models.GetAPIKeyByKey(h.DB, second)
+	assert.NoError(t, err)
+
+	_, err = admin.RotateAPIKey(h.DB, "XXX", s)
+	assert.Error(t, err)
+}
+
+func TestSummaryPrint(t *testing.T) {
+	var buf bytes.Buffer
+	(&admin.Summary{}).Print(&buf, false)
+	assert.Equal(t, "No changes\n", buf.String())
+
+	s := &admin.Summary{}
+	s.Add(admin.Created, "user %d", 1)
+	s.Add(admin.Unchanged, "user %d", 2)
+	s.Add(admin.Created, "user %d", 3)
+
+	buf.Reset()
+	s.Print(&buf, true)
+	out := buf.String()
+	assert.True(t, strings.HasPrefix(out, "Dry run"))
+	assert.Contains(t, out, "created   user 1")
+	assert.NotContains(t, out, "user 2")
+	assert.Contains(t, out, "2 created, 1 unchanged")
+
+	s.AddCount(admin.Updated, 0, "no users")
+	s.AddCount(admin.Updated, 12, "%d users", 12)
+	buf.Reset()
+	s.Print(&buf, false)
+	assert.NotContains(t, buf.String(), "no users")
+	assert.Contains(t, buf.String(), "updated   12 users")
+	assert.Contains(t, buf.String(), "2 created, 1 unchanged, 12 updated")
+	assert.Equal(t, 12, s.Count(admin.Updated))
+}
+
+func TestSyncVATSIMRecordsChanges(t *testing.T) {
+	h := harness.New(t)
+
+	// Everyone but 1000001 keeps their rating, and 1100001 is new
+	var members []vatsim.Member
+	for _, u := range []struct {
+		cid    uint
+		rating int
+	}{
+		{1000001, 11}, {1000002, 8}, {1000003, 4}, {1000004, 5}, {1000005, 1}, {1100001, 2},
+	} {
+		members = append(members, vatsim.Member{CID: u.cid, Rating: u.rating, PilotRating: -1, Region: "AMAS", Division: "USA"})
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		items := members
+		if r.URL.Query().Get("offset") != "0" {
+			items = nil
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"items": items, "count": len(members)})
+	}))
+	t.Cleanup(server.Close)
+	syncer := vatsim.NewSyncer(vatsim.NewClient(server.URL, "secret"), "USA")
+
+	s := &admin.Summary{}
+	assert.NoError(t, admin.SyncVATSIM(context.Background(), syncer, time.Now(), s))
+	assert.Equal(t, 2, s.Count(admin.Created), "the new user and the rating change")
+	assert.Equal(t, 5, s.Count(admin.Updated))
+
+	var buf bytes.Buffer
+	s.Print(&buf, false)
+	assert.NotContains(t, buf.String(), "No changes")
+	assert.Contains(t, buf.String(), "users new to the division: 1")
+
+	var user models.User
+	assert.NoError(t, h.DB.First(&user, 1100001).Error)
+}
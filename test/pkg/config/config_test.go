@@ -0,0 +1,47 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/VATUSA/primary-api/pkg/config"
+	"github.com/VATUSA/primary-api/pkg/upload"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUploadAllowedTypes(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected map[string][]string
+	}{
+		{"unset", "", map[string][]string{
+			"general":                  config.DefaultUploadTypes,
+			"training":                 config.DefaultUploadTypes,
+			config.EventBannerCategory: config.DefaultBannerTypes,
+		}},
+		{"one category", "training=image/png", map[string][]string{
+			"general":                  config.DefaultUploadTypes,
+			"training":                 {"image/png"},
+			config.EventBannerCategory: config.DefaultBannerTypes,
+		}},
+		{"wildcard", "*=application/pdf; sops=application/pdf|image/png", map[string][]string{
+			"general":                  {"application/pdf"},
+			"sops":                     {"application/pdf", "image/png"},
+			config.EventBannerCategory: config.DefaultBannerTypes,
+		}},
+		{"banners", "event_banner=image/png", map[string][]string{
+			"general":                  config.DefaultUploadTypes,
+			config.EventBannerCategory: {"image/png"},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("UPLOAD_ALLOWED_TYPES", tt.value)
+			validator := upload.NewValidator(config.NewUploadConfig())
+			for category, expected := range tt.expected {
+				assert.Equal(t, expected, validator.Allowed(category), category)
+			}
+		})
+	}
+}
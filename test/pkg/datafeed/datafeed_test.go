@@ -0,0 +1,63 @@
+package datafeed_test
+
+import (
+	"context"
+	"github.com/VATUSA/primary-api/pkg/datafeed"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const snapshot = `{
+	"general": {"version": 3},
+	"pilots": [],
+	"controllers": [
+		{"cid": 1293257, "callsign": "DEN_APP", "frequency": "120.350", "facility": 5, "logon_time": "2021-01-01T18:00:00Z"},
+		{"cid": 1000001, "callsign": "DEN_OBS", "frequency": "199.998", "facility": 0, "logon_time": "2021-01-01T17:00:00Z"}
+	]
+}`
+
+func TestParseSkipsObservers(t *testing.T) {
+	controllers, err := datafeed.Parse(strings.NewReader(snapshot))
+	assert.NoError(t, err)
+	assert.Len(t, controllers, 1)
+	assert.Equal(t, uint(1293257), controllers[0].CID)
+	assert.Equal(t, "DEN_APP", controllers[0].Callsign)
+	assert.True(t, controllers[0].LogonTime.Equal(time.Date(2021, 1, 1, 18, 0, 0, 0, time.UTC)))
+}
+
+func TestFileFeed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vatsim-data.json")
+	assert.NoError(t, os.WriteFile(path, []byte(snapshot), 0o644))
+
+	controllers, err := datafeed.NewFileFeed(path).Controllers(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, controllers, 1)
+}
+
+func TestVATSIMFeed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(snapshot))
+	}))
+	defer server.Close()
+
+	controllers, err := datafeed.NewVATSIMFeed(server.URL).Controllers(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, controllers, 1)
+}
+
+func TestVATSIMFeedError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	_, err := datafeed.NewVATSIMFeed(server.URL).Controllers(context.Background())
+	assert.Error(t, err)
+}
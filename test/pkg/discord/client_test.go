@@ -0,0 +1,100 @@
+package discord_test
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/VATUSA/primary-api/pkg/config"
+	"github.com/VATUSA/primary-api/pkg/discord"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// fakeDiscord accepts the code "good" for the client "app" and knows one user
+func fakeDiscord(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/oauth2/token", func(w http.ResponseWriter, r *http.Request) {
+		id, secret, ok := r.BasicAuth()
+		if !ok || id != "app" || secret != "shh" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.PostFormValue("grant_type") != "authorization_code" || r.PostFormValue("code") != "good" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		json.NewEncoder(w).Encode(discord.Token{AccessToken: "token", TokenType: "Bearer", ExpiresIn: 604800, Scope: "identify"})
+	})
+	mux.HandleFunc("/users/@me", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(discord.User{ID: "80351110224678912", Username: "raaj"})
+	})
+	return httptest.NewServer(mux)
+}
+
+func newClient(server *httptest.Server) *discord.Client {
+	return discord.NewClient(&config.DiscordConfig{
+		APIURL:       server.URL,
+		ClientID:     "app",
+		ClientSecret: "shh",
+		RedirectURL:  "https://api.vatusa.local/internal/v1/discord/callback",
+	})
+}
+
+func TestAuthorizeURL(t *testing.T) {
+	server := fakeDiscord(t)
+	defer server.Close()
+
+	u, err := url.Parse(newClient(server).AuthorizeURL("state"))
+	assert.NoError(t, err)
+	assert.Equal(t, "/oauth2/authorize", u.Path)
+	assert.Equal(t, "app", u.Query().Get("client_id"))
+	assert.Equal(t, "identify", u.Query().Get("scope"))
+	assert.Equal(t, "state", u.Query().Get("state"))
+}
+
+func TestExchangeAndCurrentUser(t *testing.T) {
+	server := fakeDiscord(t)
+	defer server.Close()
+	client := newClient(server)
+
+	token, err := client.Exchange(context.Background(), "good")
+	assert.NoError(t, err)
+
+	user, err := client.CurrentUser(context.Background(), token)
+	assert.NoError(t, err)
+	assert.Equal(t, "80351110224678912", user.ID)
+}
+
+func TestExchangeRejectsBadCode(t *testing.T) {
+	server := fakeDiscord(t)
+	defer server.Close()
+
+	_, err := newClient(server).Exchange(context.Background(), "bad")
+	assert.ErrorIs(t, err, discord.ErrInvalidGrant)
+}
+
+func TestState(t *testing.T) {
+	now := time.Now()
+	state := discord.SignState("secret", 1293257, now)
+
+	cid, err := discord.VerifyState("secret", state, now.Add(time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1293257), cid)
+
+	_, err = discord.VerifyState("other", state, now)
+	assert.ErrorIs(t, err, discord.ErrInvalidState)
+
+	_, err = discord.VerifyState("secret", state, now.Add(discord.StateLifetime+time.Second))
+	assert.ErrorIs(t, err, discord.ErrInvalidState)
+
+	_, err = discord.VerifyState("secret", "1.2.3", now)
+	assert.ErrorIs(t, err, discord.ErrInvalidState)
+}
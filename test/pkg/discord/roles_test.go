@@ -0,0 +1,45 @@
+package discord_test
+
+import (
+	"github.com/VATUSA/primary-api/pkg/constants"
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/VATUSA/primary-api/pkg/discord"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func rating(r uint) *uint {
+	return &r
+}
+
+var mappings = []models.DiscordRoleMapping{
+	{DiscordRoleID: "1"},                                                    // Everyone linked
+	{DiscordRoleID: "2", Facility: "ZDV"},                                   // On the ZDV roster
+	{DiscordRoleID: "3", Facility: "ZDV", HomeOnly: true},                   // ZDV home controllers
+	{DiscordRoleID: "4", RoleID: constants.AirTrafficManagerRole},           // Any ATM
+	{DiscordRoleID: "5", RoleID: constants.InstructorRole, Facility: "ZLC"}, // ZLC instructors
+	{DiscordRoleID: "6", Rating: rating(5)},                                 // C1
+	{DiscordRoleID: "7", Facility: "ZDV", Rating: rating(5)},                // ZDV C1
+}
+
+func TestRoles(t *testing.T) {
+	user := &models.User{
+		CID:              1293257,
+		ControllerRating: 5,
+		Roles: []models.UserRole{
+			{RoleID: constants.AirTrafficManagerRole, FacilityID: "ZDV"},
+			{RoleID: constants.InstructorRole, FacilityID: "ZDV"},
+		},
+	}
+	rosters := []models.Roster{{CID: 1293257, Facility: "ZDV", Visiting: true}}
+
+	desired, managed := discord.Roles(mappings, user, rosters)
+	assert.Equal(t, []string{"1", "2", "4", "6", "7"}, desired)
+	assert.Equal(t, []string{"1", "2", "3", "4", "5", "6", "7"}, managed)
+}
+
+func TestRolesUnlinked(t *testing.T) {
+	desired, managed := discord.Roles(mappings, nil, nil)
+	assert.Empty(t, desired)
+	assert.Len(t, managed, 7)
+}
@@ -0,0 +1,107 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/VATUSA/primary-api/pkg/database/migrations"
+	"github.com/VATUSA/primary-api/pkg/health"
+	"github.com/VATUSA/primary-api/test/harness"
+	"github.com/stretchr/testify/assert"
+)
+
+func ok(ctx context.Context) (string, error) {
+	return "", nil
+}
+
+func ready(t *testing.T, c *health.Checker) (int, health.Report) {
+	rr := httptest.NewRecorder()
+	c.Ready(rr, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	var report health.Report
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &report))
+	return rr.Code, report
+}
+
+func TestLive(t *testing.T) {
+	rr := httptest.NewRecorder()
+	health.Live(rr, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"status":"ok"}`, rr.Body.String())
+}
+
+func TestReady(t *testing.T) {
+	c := health.New()
+	c.Add("database", ok)
+	c.Add("migrations", func(ctx context.Context) (string, error) {
+		return "version 2", nil
+	})
+
+	code, report := ready(t, c)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, health.StatusOK, report.Status)
+	assert.Equal(t, health.StatusOK, report.Components["database"].Status)
+	assert.Equal(t, "version 2", report.Components["migrations"].Detail)
+}
+
+func TestReadyComponentDown(t *testing.T) {
+	c := health.New()
+	c.Add("database", ok)
+	c.Add("storage", func(ctx context.Context) (string, error) {
+		return "", errors.New("connection refused")
+	})
+
+	code, report := ready(t, c)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, health.StatusDown, report.Status)
+	assert.Equal(t, health.StatusOK, report.Components["database"].Status)
+	assert.Equal(t, health.StatusDown, report.Components["storage"].Status)
+	assert.Equal(t, "connection refused", report.Components["storage"].Error)
+}
+
+func TestReadyTimeout(t *testing.T) {
+	c := health.New()
+	c.Timeout = 10 * time.Millisecond
+	block := make(chan struct{})
+	defer close(block)
+	c.Add("storage", func(ctx context.Context) (string, error) {
+		<-block
+		return "", nil
+	})
+
+	code, report := ready(t, c)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, context.DeadlineExceeded.Error(), report.Components["storage"].Error)
+}
+
+func TestReadyDraining(t *testing.T) {
+	c := health.New()
+	c.Add("database", ok)
+	c.Drain()
+
+	code, report := ready(t, c)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, health.StatusDraining, report.Status)
+	assert.Equal(t, health.StatusOK, report.Components["database"].Status)
+}
+
+func TestChecks(t *testing.T) {
+	h := harness.New(t)
+
+	_, err := health.Database(h.DB)(context.Background())
+	assert.NoError(t, err)
+
+	_, err = health.Storage(h.Storage)(context.Background())
+	assert.NoError(t, err)
+
+	// The harness builds its schema without migrations, so every migration is pending
+	detail, err := health.Migrations(migrations.New(h.DB))(context.Background())
+	assert.Equal(t, "version 0", detail)
+	assert.ErrorContains(t, err, "migrations pending")
+}
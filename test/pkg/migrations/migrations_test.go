@@ -0,0 +1,166 @@
+package migrations_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/VATUSA/primary-api/pkg/database/migrations"
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	assert.NoError(t, err)
+	return db
+}
+
+func testFS() fstest.MapFS {
+	return fstest.MapFS{
+		"sql/0001_create_widgets.up.sql":   {Data: []byte("-- Widgets\nCREATE TABLE widgets (\n    id integer PRIMARY KEY,\n    name text\n);\n")},
+		"sql/0001_create_widgets.down.sql": {Data: []byte("DROP TABLE widgets;\n")},
+		"sql/0002_add_colour.up.sql":       {Data: []byte("ALTER TABLE widgets ADD COLUMN colour text;\nUPDATE widgets SET colour = 'red';\n")},
+		"sql/0002_add_colour.down.sql":     {Data: []byte("ALTER TABLE widgets DROP COLUMN colour;\n")},
+		"sql/README.md":                    {Data: []byte("not a migration")},
+	}
+}
+
+func names(ms []migrations.Migration) []string {
+	list := []string{}
+	for _, m := range ms {
+		list = append(list, m.String())
+	}
+	return list
+}
+
+func TestEmbedded(t *testing.T) {
+	ms, err := migrations.Load(migrations.Files)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, ms)
+
+	for i, m := range ms {
+		assert.Equal(t, uint(i+1), m.Version, "versions should have no gaps")
+		assert.NotEmpty(t, migrations.Statements(m.Up), m.String())
+		assert.NotEmpty(t, migrations.Statements(m.Down), m.String())
+	}
+}
+
+func TestStatements(t *testing.T) {
+	script := "-- comment; not a statement\nCREATE TABLE a (\n    id int\n);\n\nINSERT INTO a VALUES (1);\nSELECT 1"
+	assert.Equal(t, []string{"CREATE TABLE a (\n    id int\n)", "INSERT INTO a VALUES (1)", "SELECT 1"}, migrations.Statements(script))
+}
+
+func TestLoad(t *testing.T) {
+	ms, err := migrations.Load(testFS())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"0001_create_widgets", "0002_add_colour"}, names(ms))
+
+	fsys := testFS()
+	delete(fsys, "sql/0002_add_colour.down.sql")
+	_, err = migrations.Load(fsys)
+	assert.ErrorContains(t, err, "0002_add_colour")
+
+	fsys = testFS()
+	fsys["sql/0002_other.up.sql"] = &fstest.MapFile{Data: []byte("SELECT 1;")}
+	_, err = migrations.Load(fsys)
+	assert.ErrorContains(t, err, "two names")
+}
+
+func TestUpDown(t *testing.T) {
+	db := newDB(t)
+	m := &migrations.Migrator{DB: db, FS: testFS()}
+
+	pending, err := m.Pending()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, pending)
+
+	applied, err := m.Up()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"0001_create_widgets", "0002_add_colour"}, names(applied))
+	assert.True(t, db.Migrator().HasColumn("widgets", "colour"))
+
+	applied, err = m.Up()
+	assert.NoError(t, err)
+	assert.Empty(t, applied)
+
+	statuses, err := m.Status()
+	assert.NoError(t, err)
+	assert.Len(t, statuses, 2)
+	for _, s := range statuses {
+		assert.NotNil(t, s.AppliedAt, s.Migration.String())
+	}
+
+	rolledBack, err := m.Down(1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"0002_add_colour"}, names(rolledBack))
+	assert.False(t, db.Migrator().HasColumn("widgets", "colour"))
+
+	pending, err = m.Pending()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, pending)
+
+	rolledBack, err = m.Down(5)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"0001_create_widgets"}, names(rolledBack))
+	assert.False(t, db.Migrator().HasTable("widgets"))
+}
+
+func TestUpFailure(t *testing.T) {
+	db := newDB(t)
+	fsys := testFS()
+	fsys["sql/0003_broken.up.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE gadgets (id integer);\nNOT SQL;\n")}
+	fsys["sql/0003_broken.down.sql"] = &fstest.MapFile{Data: []byte("DROP TABLE gadgets;\n")}
+	m := &migrations.Migrator{DB: db, FS: fsys}
+
+	applied, err := m.Up()
+	assert.ErrorContains(t, err, "0003_broken")
+	assert.Equal(t, []string{"0001_create_widgets", "0002_add_colour"}, names(applied))
+
+	// SQLite rolls the whole migration back
+	assert.False(t, db.Migrator().HasTable("gadgets"))
+
+	pending, err := m.Pending()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, pending)
+}
+
+func TestUnknownVersion(t *testing.T) {
+	db := newDB(t)
+	fsys := testFS()
+	_, err := (&migrations.Migrator{DB: db, FS: fsys}).Up()
+	assert.NoError(t, err)
+
+	delete(fsys, "sql/0002_add_colour.up.sql")
+	delete(fsys, "sql/0002_add_colour.down.sql")
+	_, err = (&migrations.Migrator{DB: db, FS: fsys}).Up()
+	assert.ErrorIs(t, err, migrations.ErrUnknownVersion)
+}
+
+func TestCreate(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "sql")
+	assert.NoError(t, os.Mkdir(dir, 0755))
+	for name, file := range testFS() {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, strings.TrimPrefix(name, "sql/")), file.Data, 0644))
+	}
+
+	up, down, err := migrations.Create(dir, "Add Gadgets")
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "0003_add_gadgets.up.sql"), up)
+	assert.Equal(t, filepath.Join(dir, "0003_add_gadgets.down.sql"), down)
+
+	_, _, err = migrations.Create(dir, "bad-name!")
+	assert.Error(t, err)
+
+	ms, err := migrations.Load(os.DirFS(root))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"0001_create_widgets", "0002_add_colour", "0003_add_gadgets"}, names(ms))
+	assert.Empty(t, migrations.Statements(ms[2].Up))
+}
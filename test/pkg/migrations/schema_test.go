@@ -0,0 +1,321 @@
+package migrations_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VATUSA/primary-api/pkg/database/migrations"
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// The embedded migrations are MySQL only, so these tests replay them against a model of the schema: the columns,
+// indexes and foreign keys of each table. Applying a statement fails the way MySQL would when it doesn't fit the
+// schema, such as adding a column twice or altering a missing table.
+
+type table struct {
+	Columns     map[string]string
+	Indexes     map[string]string
+	Constraints map[string]string
+}
+
+type schema map[string]*table
+
+var (
+	createTable   = regexp.MustCompile("(?s)^CREATE TABLE (IF NOT EXISTS )?`(\\w+)` \\((.*)\\)$")
+	alterTable    = regexp.MustCompile("(?s)^ALTER TABLE `(\\w+)`\\s+(.*)$")
+	dropTable     = regexp.MustCompile("^DROP TABLE (IF EXISTS )?`(\\w+)`$")
+	createIndex   = regexp.MustCompile("^CREATE ((?:UNIQUE |FULLTEXT )?INDEX) (`\\w+`) ON `(\\w+)` (\\(.*\\))$")
+	dropIndex     = regexp.MustCompile("^DROP INDEX `(\\w+)` ON `(\\w+)`$")
+	dataChange    = regexp.MustCompile("^(?:UPDATE|DELETE `\\w+` FROM|INSERT INTO) `(\\w+)`")
+	indexClause   = regexp.MustCompile("^(?:ADD )?((?:UNIQUE |FULLTEXT )?INDEX `(\\w+)` .*)$")
+	afterClause   = regexp.MustCompile(" AFTER `\\w+`$")
+	backquoted    = regexp.MustCompile("`(\\w+)`")
+	searchIndexes = map[string]string{
+		"documents": "FULLTEXT INDEX `idx_document_search` (`name`,`description`)",
+		"faqs":      "FULLTEXT INDEX `idx_faq_search` (`question`,`answer`)",
+		"news":      "FULLTEXT INDEX `idx_news_search` (`title`,`description`)",
+	}
+)
+
+// normalize collapses whitespace so DDL written by hand compares equal to gorm's
+func normalize(s string) string {
+	return strings.ReplaceAll(strings.Join(strings.Fields(s), " "), ", ", ",")
+}
+
+// split splits s on the commas that aren't inside parentheses or quotes
+func split(s string) []string {
+	parts := []string{}
+	depth, quoted, start := 0, false, 0
+	for i, c := range s {
+		switch {
+		case c == '\'':
+			quoted = !quoted
+		case quoted:
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ',' && depth == 0:
+			parts = append(parts, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+	return append(parts, strings.TrimSpace(s[start:]))
+}
+
+func (s schema) table(name string) (*table, error) {
+	t, ok := s[name]
+	if !ok {
+		return nil, fmt.Errorf("table %s doesn't exist", name)
+	}
+	return t, nil
+}
+
+func (t *table) addIndex(name, definition string) error {
+	if _, ok := t.Indexes[name]; ok {
+		return fmt.Errorf("index %s already exists", name)
+	}
+	t.Indexes[name] = normalize(definition)
+	return nil
+}
+
+func (t *table) apply(clause string) error {
+	clause = normalize(clause)
+	word := func(i int) string {
+		fields := strings.Fields(clause)
+		if i < len(fields) {
+			return fields[i]
+		}
+		return ""
+	}
+	name := ""
+	if m := backquoted.FindStringSubmatch(clause); m != nil {
+		name = m[1]
+	}
+
+	switch {
+	case strings.HasPrefix(clause, "`"), strings.HasPrefix(clause, "ADD COLUMN "):
+		definition := afterClause.ReplaceAllString(strings.TrimPrefix(clause, "ADD COLUMN "), "")
+		if _, ok := t.Columns[name]; ok {
+			return fmt.Errorf("column %s already exists", name)
+		}
+		t.Columns[name] = definition
+	case strings.HasPrefix(clause, "MODIFY COLUMN "):
+		if _, ok := t.Columns[name]; !ok {
+			return fmt.Errorf("column %s doesn't exist", name)
+		}
+		t.Columns[name] = afterClause.ReplaceAllString(strings.TrimPrefix(clause, "MODIFY COLUMN "), "")
+	case strings.HasPrefix(clause, "DROP COLUMN "):
+		if _, ok := t.Columns[name]; !ok {
+			return fmt.Errorf("column %s doesn't exist", name)
+		}
+		for constraint, definition := range t.Constraints {
+			if strings.Contains(definition, "FOREIGN KEY (`"+name+"`)") {
+				return fmt.Errorf("column %s is used by foreign key %s", name, constraint)
+			}
+		}
+		for index, definition := range t.Indexes {
+			if strings.Contains(definition, "`"+name+"`") {
+				delete(t.Indexes, index)
+			}
+		}
+		delete(t.Columns, name)
+	case strings.HasPrefix(clause, "PRIMARY KEY"):
+		return t.addIndex("PRIMARY", clause)
+	case indexClause.MatchString(clause):
+		m := indexClause.FindStringSubmatch(clause)
+		return t.addIndex(m[2], m[1])
+	case word(0) == "DROP" && word(1) == "INDEX":
+		if _, ok := t.Indexes[name]; !ok {
+			return fmt.Errorf("index %s doesn't exist", name)
+		}
+		delete(t.Indexes, name)
+	case strings.HasPrefix(clause, "CONSTRAINT "), strings.HasPrefix(clause, "ADD CONSTRAINT "):
+		if _, ok := t.Constraints[name]; ok {
+			return fmt.Errorf("constraint %s already exists", name)
+		}
+		t.Constraints[name] = strings.TrimPrefix(clause, "ADD ")
+	case strings.HasPrefix(clause, "DROP FOREIGN KEY "):
+		if _, ok := t.Constraints[name]; !ok {
+			return fmt.Errorf("foreign key %s doesn't exist", name)
+		}
+		delete(t.Constraints, name)
+	default:
+		return fmt.Errorf("unsupported clause: %s", clause)
+	}
+	return nil
+}
+
+func (s schema) apply(statement string) error {
+	switch {
+	case createTable.MatchString(statement):
+		m := createTable.FindStringSubmatch(statement)
+		if _, ok := s[m[2]]; ok {
+			if m[1] != "" {
+				return nil
+			}
+			return fmt.Errorf("table %s already exists", m[2])
+		}
+		t := &table{Columns: map[string]string{}, Indexes: map[string]string{}, Constraints: map[string]string{}}
+		for _, clause := range split(m[3]) {
+			if err := t.apply(clause); err != nil {
+				return fmt.Errorf("%s: %w", m[2], err)
+			}
+		}
+		s[m[2]] = t
+	case alterTable.MatchString(statement):
+		m := alterTable.FindStringSubmatch(statement)
+		t, err := s.table(m[1])
+		if err != nil {
+			return err
+		}
+		for _, clause := range split(m[2]) {
+			if err := t.apply(clause); err != nil {
+				return fmt.Errorf("%s: %w", m[1], err)
+			}
+		}
+	case dropTable.MatchString(statement):
+		m := dropTable.FindStringSubmatch(statement)
+		if _, err := s.table(m[2]); err != nil && m[1] == "" {
+			return err
+		}
+		for name, t := range s {
+			for constraint, definition := range t.Constraints {
+				if name != m[2] && strings.Contains(definition, "REFERENCES `"+m[2]+"`") {
+					return fmt.Errorf("table %s is referenced by %s.%s", m[2], name, constraint)
+				}
+			}
+		}
+		delete(s, m[2])
+	case createIndex.MatchString(statement):
+		m := createIndex.FindStringSubmatch(statement)
+		t, err := s.table(m[3])
+		if err != nil {
+			return err
+		}
+		return t.addIndex(strings.Trim(m[2], "`"), m[1]+" "+m[2]+" "+m[4])
+	case dropIndex.MatchString(statement):
+		m := dropIndex.FindStringSubmatch(statement)
+		t, err := s.table(m[2])
+		if err != nil {
+			return err
+		}
+		return t.apply("DROP INDEX `" + m[1] + "`")
+	case dataChange.MatchString(statement):
+		// Changes to rows leave the schema alone, but the table must be there
+		_, err := s.table(dataChange.FindStringSubmatch(statement)[1])
+		return err
+	default:
+		return fmt.Errorf("unsupported statement: %s", statement)
+	}
+	return nil
+}
+
+func (s schema) run(t *testing.T, name, script string) {
+	t.Helper()
+	for _, statement := range migrations.Statements(script) {
+		if err := s.apply(statement); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+	}
+}
+
+// baseline is the schema AutoMigrate created at b01bd97, which databases set up before migrations are at
+func baseline(t *testing.T) schema {
+	data, err := os.ReadFile("testdata/b01bd97.sql")
+	assert.NoError(t, err)
+
+	s := schema{}
+	s.run(t, "b01bd97", string(data))
+	return s
+}
+
+type recorder struct {
+	statements *[]string
+}
+
+func (r recorder) LogMode(logger.LogLevel) logger.Interface      { return r }
+func (r recorder) Info(context.Context, string, ...interface{})  {}
+func (r recorder) Warn(context.Context, string, ...interface{})  {}
+func (r recorder) Error(context.Context, string, ...interface{}) {}
+func (r recorder) Trace(_ context.Context, _ time.Time, fc func() (string, int64), _ error) {
+	sql, _ := fc()
+	if n := len(*r.statements); n == 0 || (*r.statements)[n-1] != sql {
+		*r.statements = append(*r.statements, sql)
+	}
+}
+
+// modelSchema is the schema gorm would create for the models on MySQL, plus the search indexes
+func modelSchema(t *testing.T) schema {
+	statements := []string{}
+	db, err := gorm.Open(mysql.New(mysql.Config{DSN: "test:test@tcp(127.0.0.1:3306)/test", SkipInitializeWithVersion: true}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+		Logger:               recorder{&statements},
+	})
+	assert.NoError(t, err)
+
+	// All at once, like AutoMigrate, so foreign keys declared on the other side of a relation are included
+	assert.NoError(t, db.Migrator().CreateTable(models.Models...))
+
+	s := schema{}
+	for _, statement := range statements {
+		if err := s.apply(statement); err != nil {
+			t.Fatalf("models: %v", err)
+		}
+	}
+	for name, definition := range searchIndexes {
+		s[name].Indexes[backquoted.FindStringSubmatch(definition)[1]] = definition
+	}
+	return s
+}
+
+func TestBaselineMatchesAutoMigrate(t *testing.T) {
+	ms, err := migrations.Load(migrations.Files)
+	assert.NoError(t, err)
+
+	s := schema{}
+	s.run(t, ms[0].String(), ms[0].Up)
+	assert.Equal(t, baseline(t), s)
+}
+
+func TestMigrateFromBaseline(t *testing.T) {
+	ms, err := migrations.Load(migrations.Files)
+	assert.NoError(t, err)
+	want := modelSchema(t)
+
+	// 0001 leaves the existing tables alone, and the rest bring them up to the models
+	s := baseline(t)
+	for _, m := range ms {
+		s.run(t, m.String(), m.Up)
+	}
+	assert.Equal(t, want, s)
+
+	for i := len(ms) - 1; i > 0; i-- {
+		s.run(t, ms[i].String()+" down", ms[i].Down)
+	}
+	assert.Equal(t, baseline(t), s)
+
+	s.run(t, ms[0].String()+" down", ms[0].Down)
+	assert.Empty(t, s)
+}
+
+func TestMigrateEmptyDatabase(t *testing.T) {
+	ms, err := migrations.Load(migrations.Files)
+	assert.NoError(t, err)
+
+	s := schema{}
+	for _, m := range ms {
+		s.run(t, m.String(), m.Up)
+	}
+	assert.Equal(t, modelSchema(t), s)
+}
@@ -0,0 +1,130 @@
+package outbox_test
+
+import (
+	"context"
+	"errors"
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/VATUSA/primary-api/pkg/database/types"
+	"github.com/VATUSA/primary-api/pkg/outbox"
+	"github.com/VATUSA/primary-api/test/harness"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// recorder is a consumer that remembers the events it saw and fails while fail is set, or for the events of the
+// poison aggregate
+type recorder struct {
+	seen   []uint
+	fail   bool
+	poison string
+}
+
+func (r *recorder) handle(ctx context.Context, e *models.OutboxEvent) error {
+	if r.fail || (r.poison != "" && e.AggregateID == r.poison) {
+		return errors.New("unavailable")
+	}
+	r.seen = append(r.seen, e.ID)
+	return nil
+}
+
+func TestHandleRecordsConsumers(t *testing.T) {
+	first, second := &recorder{}, &recorder{}
+	d := outbox.NewDispatcher()
+	d.Register("first", first.handle)
+	d.Register("second", second.handle)
+
+	e := &models.OutboxEvent{ID: 1, Type: types.RosterAdded}
+	assert.NoError(t, d.Handle(context.Background(), e))
+	assert.Equal(t, "first,second", e.HandledBy)
+	assert.Equal(t, []uint{1}, first.seen)
+	assert.Equal(t, []uint{1}, second.seen)
+}
+
+func TestHandleRetriesOnlyFailedConsumers(t *testing.T) {
+	first, second, third := &recorder{}, &recorder{fail: true}, &recorder{}
+	d := outbox.NewDispatcher()
+	d.Register("first", first.handle)
+	d.Register("second", second.handle)
+	d.Register("third", third.handle)
+
+	e := &models.OutboxEvent{ID: 1, Type: types.RosterAdded}
+	err := d.Handle(context.Background(), e)
+	assert.ErrorContains(t, err, "second")
+	assert.Equal(t, "first", e.HandledBy)
+	assert.Empty(t, third.seen, "consumers after a failure wait for the retry")
+
+	second.fail = false
+	assert.NoError(t, d.Handle(context.Background(), e))
+	assert.Equal(t, "first,second,third", e.HandledBy)
+	assert.Equal(t, []uint{1}, first.seen, "consumers that succeeded aren't handed the event again")
+	assert.Equal(t, []uint{1}, second.seen)
+	assert.Equal(t, []uint{1}, third.seen)
+}
+
+func TestBackoff(t *testing.T) {
+	assert.Equal(t, 10*time.Second, outbox.Backoff(1))
+	assert.Equal(t, 20*time.Second, outbox.Backoff(2))
+	assert.Equal(t, 80*time.Second, outbox.Backoff(4))
+	assert.Equal(t, outbox.MaxBackoff, outbox.Backoff(30))
+}
+
+// write adds a pending event for the member to the outbox, due at due
+func write(t *testing.T, h *harness.Harness, cid string, due time.Time) *models.OutboxEvent {
+	e, err := models.NewOutboxEvent(models.UserAggregate, cid, types.RosterAdded, "ZDV", map[string]string{"cid": cid})
+	assert.NoError(t, err)
+	e.NextAttemptAt = due
+	assert.NoError(t, models.WriteOutboxEvents(h.DB, e))
+
+	written := &models.OutboxEvent{}
+	assert.NoError(t, h.DB.Last(written).Error)
+	return written
+}
+
+func TestDispatchWaitsBehindAggregateHead(t *testing.T) {
+	h := harness.New(t)
+	now := time.Now()
+
+	waiting := write(t, h, "1000002", now.Add(time.Minute))
+	behind := write(t, h, "1000002", now)
+	first, second, third := write(t, h, "1000003", now), write(t, h, "1000003", now), write(t, h, "1000004", now)
+
+	r := &recorder{}
+	d := outbox.NewDispatcher()
+	d.Register("recorder", r.handle)
+	assert.NoError(t, d.Dispatch(context.Background(), now))
+
+	// Each pass takes one event from every aggregate, and passes repeat until the other aggregates are drained
+	others := []uint{first.ID, third.ID, second.ID}
+	assert.Equal(t, others, r.seen)
+	assert.NoError(t, h.DB.First(behind).Error)
+	assert.Nil(t, behind.ProcessedAt, "later events wait for the aggregate's head")
+
+	assert.NoError(t, d.Dispatch(context.Background(), now.Add(time.Minute)))
+	assert.Equal(t, append(others, waiting.ID, behind.ID), r.seen)
+}
+
+func TestDispatchGivesUpAfterMaxAttempts(t *testing.T) {
+	h := harness.New(t)
+	now := time.Now()
+
+	poison := write(t, h, "1000002", now)
+	assert.NoError(t, h.DB.Model(poison).Update("attempts", outbox.MaxAttempts-1).Error)
+	next := write(t, h, "1000002", now)
+
+	r := &recorder{poison: "1000002"}
+	d := outbox.NewDispatcher()
+	d.Register("recorder", r.handle)
+	assert.NoError(t, d.Dispatch(context.Background(), now))
+
+	assert.NoError(t, h.DB.First(poison).Error)
+	assert.Equal(t, outbox.MaxAttempts, poison.Attempts)
+	assert.NotNil(t, poison.DeadAt)
+	assert.Nil(t, poison.ProcessedAt)
+
+	// The next event becomes the head and is tried in turn
+	assert.NoError(t, h.DB.First(next).Error)
+	assert.Equal(t, 1, next.Attempts)
+	assert.Nil(t, next.DeadAt)
+	assert.Empty(t, r.seen)
+}
@@ -0,0 +1,65 @@
+package pubsub_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/VATUSA/primary-api/pkg/pubsub"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryHub(t *testing.T) {
+	hub := pubsub.NewMemoryHub()
+
+	t.Run("delivers to subscribers of the topic", func(t *testing.T) {
+		messages, cancel, err := hub.Subscribe(context.Background(), "a")
+		assert.NoError(t, err)
+		defer cancel()
+
+		other, cancelOther, err := hub.Subscribe(context.Background(), "b")
+		assert.NoError(t, err)
+		defer cancelOther()
+
+		assert.NoError(t, hub.Publish(context.Background(), "a", pubsub.Message{ID: 1, Data: []byte("hello")}))
+
+		select {
+		case msg := <-messages:
+			assert.Equal(t, uint64(1), msg.ID)
+			assert.Equal(t, "hello", string(msg.Data))
+		case <-time.After(time.Second):
+			t.Fatal("message was not delivered")
+		}
+
+		select {
+		case <-other:
+			t.Fatal("message delivered to the wrong topic")
+		default:
+		}
+	})
+
+	t.Run("closes the channel when the context is done", func(t *testing.T) {
+		ctx, done := context.WithCancel(context.Background())
+		messages, _, err := hub.Subscribe(ctx, "a")
+		assert.NoError(t, err)
+
+		done()
+
+		select {
+		case _, ok := <-messages:
+			assert.False(t, ok)
+		case <-time.After(time.Second):
+			t.Fatal("channel was not closed")
+		}
+	})
+
+	t.Run("does not block on slow subscribers", func(t *testing.T) {
+		_, cancel, err := hub.Subscribe(context.Background(), "slow")
+		assert.NoError(t, err)
+		defer cancel()
+
+		for i := 0; i < pubsub.SubscriberBuffer*2; i++ {
+			assert.NoError(t, hub.Publish(context.Background(), "slow", pubsub.Message{ID: uint64(i)}))
+		}
+	})
+}
@@ -0,0 +1,66 @@
+package search_test
+
+import (
+	"context"
+	"github.com/VATUSA/primary-api/pkg/search"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func newIndex() *search.MemoryIndex {
+	index := search.NewMemoryIndex()
+	index.Add(search.Result{Type: search.DocumentType, ID: 1, Facility: "ZHQ", Title: "Training Policy"}, "How training works across the division.")
+	index.Add(search.Result{Type: search.FAQType, ID: 2, Facility: "ZDV", Title: "How do I request training?"}, "Use the training request queue to ask for training.")
+	index.Add(search.Result{Type: search.NewsType, ID: 3, Facility: "ZLC", Title: "Training update"}, "ZLC training news.")
+	index.Add(search.Result{Type: search.NewsType, ID: 4, Facility: "ZDV", Title: "Event announced"}, "Join us on Friday.")
+	return index
+}
+
+func TestMemoryIndexRanksMatches(t *testing.T) {
+	results, err := newIndex().Search(context.Background(), search.Query{Text: "training"})
+	assert.NoError(t, err)
+	assert.Len(t, results, 3)
+	assert.Equal(t, uint(2), results[0].ID)
+	for i := 1; i < len(results); i++ {
+		assert.GreaterOrEqual(t, results[i-1].Score, results[i].Score)
+	}
+}
+
+func TestMemoryIndexScopesToFacilityAndDivision(t *testing.T) {
+	results, err := newIndex().Search(context.Background(), search.Query{Text: "training", Facility: "ZDV"})
+	assert.NoError(t, err)
+
+	var ids []uint
+	for _, r := range results {
+		ids = append(ids, r.ID)
+	}
+	assert.ElementsMatch(t, []uint{1, 2}, ids)
+}
+
+func TestMemoryIndexFiltersTypesAndPages(t *testing.T) {
+	index := newIndex()
+
+	results, err := index.Search(context.Background(), search.Query{Text: "training", Types: []string{search.NewsType}})
+	assert.NoError(t, err)
+	assert.Len(t, results, 1)
+	assert.Equal(t, uint(3), results[0].ID)
+
+	results, err = index.Search(context.Background(), search.Query{Text: "training", Limit: 1, Offset: 1})
+	assert.NoError(t, err)
+	assert.Len(t, results, 1)
+
+	results, err = index.Search(context.Background(), search.Query{Text: "training", Offset: 10})
+	assert.NoError(t, err)
+	assert.Empty(t, results)
+}
+
+func TestHighlight(t *testing.T) {
+	assert.Equal(t, "Use the <mark>Training</mark> &lt;queue&gt;", search.Highlight("Use the Training <queue>", []string{"training"}))
+
+	long := strings.Repeat("a ", 100) + "needle" + strings.Repeat(" b", 100)
+	snippet := search.Highlight(long, []string{"needle"})
+	assert.True(t, strings.HasPrefix(snippet, "…"))
+	assert.True(t, strings.HasSuffix(snippet, "…"))
+	assert.Contains(t, snippet, "<mark>needle</mark>")
+}
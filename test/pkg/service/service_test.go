@@ -0,0 +1,219 @@
+package service_test
+
+import (
+	"errors"
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/VATUSA/primary-api/pkg/database/types"
+	"github.com/VATUSA/primary-api/pkg/repository"
+	"github.com/VATUSA/primary-api/pkg/service"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// fakeRoster keeps certifications in memory. The roster entry methods aren't needed by these tests.
+type fakeRoster struct {
+	repository.RosterRepository
+	certs      map[uint]*models.Certification
+	nextID     uint
+	failDelete uint
+
+	// removedElsewhere is a certification another sweep deletes between listing and deleting
+	removedElsewhere uint
+}
+
+func newFakeRoster(certs ...models.Certification) *fakeRoster {
+	f := &fakeRoster{certs: map[uint]*models.Certification{}, nextID: 100}
+	for i := range certs {
+		f.certs[certs[i].ID] = &certs[i]
+	}
+	return f
+}
+
+func (f *fakeRoster) GetCertification(id uint) (*models.Certification, error) {
+	cert, ok := f.certs[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	c := *cert
+	return &c, nil
+}
+
+func (f *fakeRoster) ExpiredSoloCertifications(now time.Time) ([]models.Certification, error) {
+	var expired []models.Certification
+	for id := uint(0); id < f.nextID; id++ {
+		if c, ok := f.certs[id]; ok && c.Solo && !c.ExpiresAt.After(now) {
+			expired = append(expired, *c)
+		}
+	}
+	return expired, nil
+}
+
+func (f *fakeRoster) DeleteExpiredSolo(cert *models.Certification, now time.Time) (bool, error) {
+	if cert.ID == f.failDelete {
+		return false, errors.New("unavailable")
+	}
+	if _, ok := f.certs[cert.ID]; !ok || cert.ID == f.removedElsewhere {
+		return false, nil
+	}
+	delete(f.certs, cert.ID)
+	return true, nil
+}
+
+func (f *fakeRoster) CreateCertification(cert *models.Certification) error {
+	f.nextID++
+	cert.ID = f.nextID
+	c := *cert
+	f.certs[cert.ID] = &c
+	return nil
+}
+
+func (f *fakeRoster) UpdateCertification(cert *models.Certification) error {
+	c := *cert
+	f.certs[cert.ID] = &c
+	return nil
+}
+
+func (f *fakeRoster) DeleteCertification(cert *models.Certification) error {
+	if cert.ID == f.failDelete {
+		return errors.New("unavailable")
+	}
+	delete(f.certs, cert.ID)
+	return nil
+}
+
+type fakeDocuments struct {
+	repository.DocumentRepository
+	docs []models.Document
+}
+
+func (f *fakeDocuments) ListByFacilityAndCategory(facility string, category types.DocumentCategory) ([]models.Document, error) {
+	var docs []models.Document
+	for _, d := range f.docs {
+		if d.Facility == facility && d.Category == category {
+			docs = append(docs, d)
+		}
+	}
+	return docs, nil
+}
+
+func TestCreateCertification(t *testing.T) {
+	held := models.Certification{ID: 1, RosterID: 7, Type: types.TowerCertification, Position: "DEN"}
+	roster := &models.Roster{ID: 7, CID: 1293257, Facility: "ZDV", Certifications: []models.Certification{held}}
+	s := service.NewWithRepositories(repository.Repositories{Roster: newFakeRoster(held)})
+
+	duplicate := &models.Certification{Type: held.Type, Position: held.Position}
+	assert.ErrorIs(t, s.Roster.CreateCertification(roster, duplicate), service.ErrCertificationHeld)
+
+	cert := &models.Certification{Type: types.ApproachCertification, Position: "DEN"}
+	assert.NoError(t, s.Roster.CreateCertification(roster, cert))
+	assert.Equal(t, roster.ID, cert.RosterID)
+	assert.Equal(t, roster.CID, cert.CID)
+	assert.Equal(t, roster.Facility, cert.Facility)
+}
+
+func TestUpdateCertificationKeepsItsOwnType(t *testing.T) {
+	held := models.Certification{ID: 1, RosterID: 7, Type: types.TowerCertification, Position: "DEN"}
+	roster := &models.Roster{ID: 7, Certifications: []models.Certification{held}}
+	s := service.NewWithRepositories(repository.Repositories{Roster: newFakeRoster(held)})
+
+	cert := held
+	assert.NoError(t, s.Roster.UpdateCertification(roster, &cert), "a certification doesn't conflict with itself")
+}
+
+func TestGetCertificationOfAnotherRoster(t *testing.T) {
+	other := models.Certification{ID: 1, RosterID: 8}
+	s := service.NewWithRepositories(repository.Repositories{Roster: newFakeRoster(other)})
+
+	_, err := s.Roster.GetCertification(&models.Roster{ID: 7}, other.ID)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	cert, err := s.Roster.GetCertification(&models.Roster{ID: 8}, other.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, other.ID, cert.ID)
+}
+
+func TestRemoveExpiredSolos(t *testing.T) {
+	now := time.Date(2021, 2, 1, 0, 0, 0, 0, time.UTC)
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+	roster := newFakeRoster(
+		models.Certification{ID: 1, Solo: true, ExpiresAt: &past},
+		models.Certification{ID: 2, Solo: true, ExpiresAt: &future},
+		models.Certification{ID: 3, Solo: true, ExpiresAt: &past},
+		models.Certification{ID: 4},
+	)
+	s := service.NewWithRepositories(repository.Repositories{Roster: roster})
+
+	removed, err := s.Roster.RemoveExpiredSolos(now)
+	assert.NoError(t, err)
+	if assert.Len(t, removed, 2) {
+		assert.Equal(t, uint(1), removed[0].ID)
+		assert.Equal(t, uint(3), removed[1].ID)
+	}
+	assert.Len(t, roster.certs, 2)
+	assert.Contains(t, roster.certs, uint(2))
+	assert.Contains(t, roster.certs, uint(4))
+}
+
+func TestRemoveExpiredSolosSkipsThoseRemovedElsewhere(t *testing.T) {
+	now := time.Date(2021, 2, 1, 0, 0, 0, 0, time.UTC)
+	past := now.Add(-time.Hour)
+	roster := newFakeRoster(
+		models.Certification{ID: 1, Solo: true, ExpiresAt: &past},
+		models.Certification{ID: 2, Solo: true, ExpiresAt: &past},
+	)
+	roster.removedElsewhere = 1
+	s := service.NewWithRepositories(repository.Repositories{Roster: roster})
+
+	removed, err := s.Roster.RemoveExpiredSolos(now)
+	assert.NoError(t, err)
+	if assert.Len(t, removed, 1, "only the certifications this sweep removed are returned to be notified") {
+		assert.Equal(t, uint(2), removed[0].ID)
+	}
+}
+
+func TestRemoveExpiredSolosStopsAtFailure(t *testing.T) {
+	now := time.Date(2021, 2, 1, 0, 0, 0, 0, time.UTC)
+	past := now.Add(-time.Hour)
+	roster := newFakeRoster(
+		models.Certification{ID: 1, Solo: true, ExpiresAt: &past},
+		models.Certification{ID: 2, Solo: true, ExpiresAt: &past},
+	)
+	roster.failDelete = 2
+	s := service.NewWithRepositories(repository.Repositories{Roster: roster})
+
+	removed, err := s.Roster.RemoveExpiredSolos(now)
+	assert.Error(t, err)
+	if assert.Len(t, removed, 1, "the certifications removed before the failure are still reported") {
+		assert.Equal(t, uint(1), removed[0].ID)
+	}
+}
+
+func TestNameTaken(t *testing.T) {
+	documents := &fakeDocuments{docs: []models.Document{
+		{ID: 1, Facility: "ZDV", Category: types.SOPs, Name: "Tower SOP"},
+		{ID: 2, Facility: "ZDV", Category: types.LOAs, Name: "ZLC LOA"},
+	}}
+	s := service.NewWithRepositories(repository.Repositories{Documents: documents})
+
+	taken, err := s.Documents.NameTaken(&models.Document{Facility: "ZDV", Category: types.SOPs, Name: "Tower SOP"})
+	assert.NoError(t, err)
+	assert.True(t, taken)
+
+	taken, _ = s.Documents.NameTaken(&models.Document{ID: 1, Facility: "ZDV", Category: types.SOPs, Name: "Tower SOP"})
+	assert.False(t, taken, "a document doesn't clash with itself")
+
+	taken, _ = s.Documents.NameTaken(&models.Document{Facility: "ZDV", Category: types.LOAs, Name: "Tower SOP"})
+	assert.False(t, taken, "names only need to be unique within a category")
+}
+
+func TestTransactionWithoutDatabase(t *testing.T) {
+	s := service.NewWithRepositories(repository.Repositories{})
+
+	var inner *service.Services
+	assert.NoError(t, s.Transaction(func(tx *service.Services) error {
+		inner = tx
+		return nil
+	}))
+	assert.Same(t, s, inner)
+}
@@ -0,0 +1,137 @@
+package storage_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VATUSA/primary-api/pkg/config"
+	"github.com/VATUSA/primary-api/pkg/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func newLocalStorage(t *testing.T) *storage.LocalStorage {
+	store, err := storage.NewLocalStorage(&config.StorageConfig{
+		Backend:   "local",
+		LocalPath: t.TempDir(),
+		LocalURL:  "http://localhost/storage",
+		Secret:    strings.Repeat("s", storage.MinSecretLength),
+	})
+	assert.NoError(t, err)
+	return store
+}
+
+func TestLocalStorageSecret(t *testing.T) {
+	for _, secret := range []string{"", "secret", strings.Repeat("s", storage.MinSecretLength-1)} {
+		_, err := storage.NewLocalStorage(&config.StorageConfig{
+			Backend:   "local",
+			LocalPath: t.TempDir(),
+			LocalURL:  "http://localhost/storage",
+			Secret:    secret,
+		})
+		assert.ErrorContains(t, err, "STORAGE_SECRET", secret)
+	}
+}
+
+func TestLocalStorage(t *testing.T) {
+	store := newLocalStorage(t)
+
+	t.Run("upload and get", func(t *testing.T) {
+		assert.NoError(t, store.Upload("ZDV/general", "DP001.pdf", strings.NewReader("v1")))
+
+		body, err := store.Get("ZDV/general", "DP001.pdf")
+		assert.NoError(t, err)
+		defer body.Close()
+
+		data, _ := io.ReadAll(body)
+		assert.Equal(t, "v1", string(data))
+
+		info, err := store.Stat("ZDV/general", "DP001.pdf")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), info.Size)
+		assert.Equal(t, "application/pdf", info.ContentType)
+	})
+
+	t.Run("missing objects", func(t *testing.T) {
+		_, err := store.Get("ZDV/general", "missing.pdf")
+		assert.ErrorIs(t, err, storage.ErrNotFound)
+
+		_, err = store.Stat("ZDV/general", "missing.pdf")
+		assert.ErrorIs(t, err, storage.ErrNotFound)
+
+		assert.NoError(t, store.Delete("ZDV/general", "missing.pdf"))
+	})
+
+	t.Run("copy, move and list", func(t *testing.T) {
+		assert.NoError(t, store.Copy("ZDV/general", "DP001.pdf", "ZDV/sops", "DP001.pdf"))
+		assert.NoError(t, store.Move("ZDV/sops", "DP001.pdf", "ZDV/sops", "DP002.pdf"))
+
+		objects, err := store.List("ZDV")
+		assert.NoError(t, err)
+
+		var keys []string
+		for _, o := range objects {
+			keys = append(keys, o.Key)
+		}
+		assert.ElementsMatch(t, []string{"ZDV/general/DP001.pdf", "ZDV/sops/DP002.pdf"}, keys)
+	})
+
+	t.Run("keys cannot escape the root", func(t *testing.T) {
+		assert.NoError(t, store.Upload("../../etc", "passwd", strings.NewReader("x")))
+
+		objects, err := store.List("etc")
+		assert.NoError(t, err)
+		assert.Len(t, objects, 1)
+	})
+}
+
+func TestLocalStoragePresign(t *testing.T) {
+	store := newLocalStorage(t)
+	assert.NoError(t, store.Upload("ZDV/general", "DP001.pdf", strings.NewReader("contents")))
+
+	server := httptest.NewServer(http.StripPrefix("/storage", store.Handler()))
+	defer server.Close()
+
+	fetch := func(presigned string) *http.Response {
+		u, err := url.Parse(presigned)
+		assert.NoError(t, err)
+
+		res, err := http.Get(server.URL + u.RequestURI())
+		assert.NoError(t, err)
+		return res
+	}
+
+	t.Run("valid signature", func(t *testing.T) {
+		presigned, err := store.PresignGet("ZDV/general", "DP001.pdf", time.Minute)
+		assert.NoError(t, err)
+
+		res := fetch(presigned)
+		defer res.Body.Close()
+		data, _ := io.ReadAll(res.Body)
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "contents", string(data))
+	})
+
+	t.Run("tampered key", func(t *testing.T) {
+		presigned, err := store.PresignGet("ZDV/general", "DP001.pdf", time.Minute)
+		assert.NoError(t, err)
+
+		res := fetch(strings.Replace(presigned, "DP001", "DP002", 1))
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusForbidden, res.StatusCode)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		presigned, err := store.PresignGet("ZDV/general", "DP001.pdf", -time.Minute)
+		assert.NoError(t, err)
+
+		res := fetch(presigned)
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusForbidden, res.StatusCode)
+	})
+}
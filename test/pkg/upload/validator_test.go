@@ -0,0 +1,123 @@
+package upload_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/VATUSA/primary-api/pkg/upload"
+	"github.com/stretchr/testify/assert"
+)
+
+var pdf = []byte("%PDF-1.4\n1 0 obj\n<<>>\nendobj\ntrailer\n<<>>\n%%EOF\n")
+
+func newValidator() *upload.Validator {
+	return &upload.Validator{
+		MaxSize: 1024,
+		AllowedTypes: map[string][]string{
+			"*":        {"application/pdf"},
+			"training": {"image/png"},
+		},
+	}
+}
+
+func TestValidator(t *testing.T) {
+	t.Run("accepts allowed types", func(t *testing.T) {
+		file := bytes.NewReader(pdf)
+		contentType, err := newValidator().Check(context.Background(), file, int64(len(pdf)), "general")
+		assert.NoError(t, err)
+		assert.Equal(t, "application/pdf", contentType)
+
+		// The file is rewound for the upload
+		data, _ := io.ReadAll(file)
+		assert.Equal(t, pdf, data)
+	})
+
+	t.Run("rejects types the category does not allow", func(t *testing.T) {
+		_, err := newValidator().Check(context.Background(), bytes.NewReader(pdf), int64(len(pdf)), "training")
+		assert.ErrorIs(t, err, upload.ErrUnsupportedType)
+	})
+
+	t.Run("ignores the file extension", func(t *testing.T) {
+		exe := []byte("MZ\x90\x00\x03\x00\x00\x00\x04\x00\x00\x00\xff\xff")
+		_, err := newValidator().Check(context.Background(), bytes.NewReader(exe), int64(len(exe)), "general")
+		assert.ErrorIs(t, err, upload.ErrUnsupportedType)
+	})
+
+	t.Run("rejects files over the limit", func(t *testing.T) {
+		_, err := newValidator().Check(context.Background(), bytes.NewReader(pdf), 2048, "general")
+		assert.ErrorIs(t, err, upload.ErrTooLarge)
+	})
+}
+
+// fakeClamd answers INSTREAM requests, reporting any stream containing the marker as infected
+func fakeClamd(t *testing.T, marker []byte) string {
+	socket := filepath.Join(t.TempDir(), "clamd.sock")
+	listener, err := net.Listen("unix", socket)
+	assert.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go func(conn net.Conn) {
+				defer conn.Close()
+
+				command := make([]byte, len("zINSTREAM\x00"))
+				if _, err := io.ReadFull(conn, command); err != nil {
+					return
+				}
+
+				var stream []byte
+				for {
+					var size uint32
+					if err := binary.Read(conn, binary.BigEndian, &size); err != nil {
+						return
+					}
+					if size == 0 {
+						break
+					}
+					chunk := make([]byte, size)
+					if _, err := io.ReadFull(conn, chunk); err != nil {
+						return
+					}
+					stream = append(stream, chunk...)
+				}
+
+				if bytes.Contains(stream, marker) {
+					conn.Write([]byte("stream: Eicar-Signature FOUND\x00"))
+					return
+				}
+				conn.Write([]byte("stream: OK\x00"))
+			}(conn)
+		}
+	}()
+
+	return socket
+}
+
+func TestClamAV(t *testing.T) {
+	socket := fakeClamd(t, []byte("EICAR"))
+
+	v := newValidator()
+	v.Scanner = upload.NewClamAV("unix", socket)
+
+	t.Run("clean", func(t *testing.T) {
+		_, err := v.Check(context.Background(), bytes.NewReader(pdf), int64(len(pdf)), "general")
+		assert.NoError(t, err)
+	})
+
+	t.Run("infected", func(t *testing.T) {
+		infected := append(append([]byte{}, pdf...), []byte("EICAR")...)
+		_, err := v.Check(context.Background(), bytes.NewReader(infected), int64(len(infected)), "general")
+		assert.ErrorIs(t, err, upload.ErrInfected)
+	})
+}
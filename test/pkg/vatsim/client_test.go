@@ -0,0 +1,84 @@
+package vatsim_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/VATUSA/primary-api/pkg/vatsim"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// fakeAPI serves a division listing and member lookups the way the VATSIM API does
+func fakeAPI(t *testing.T, members []vatsim.Member) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-API-Key") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch {
+		case r.URL.Path == "/v2/orgs/division/USA":
+			limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+			offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+			end := offset + limit
+			if end > len(members) {
+				end = len(members)
+			}
+			if offset > end {
+				offset = end
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"items": members[offset:end], "count": len(members)})
+		case strings.HasPrefix(r.URL.Path, "/v2/members/"):
+			for _, m := range members {
+				if r.URL.Path == fmt.Sprintf("/v2/members/%d", m.CID) {
+					json.NewEncoder(w).Encode(m)
+					return
+				}
+			}
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestDivisionMembersPages(t *testing.T) {
+	var members []vatsim.Member
+	for i := 0; i < vatsim.PageSize+5; i++ {
+		members = append(members, vatsim.Member{CID: uint(1000000 + i), Rating: 1, Division: "USA"})
+	}
+	server := fakeAPI(t, members)
+	defer server.Close()
+
+	got, err := vatsim.NewClient(server.URL, "secret").DivisionMembers(context.Background(), "USA")
+	assert.NoError(t, err)
+	assert.Len(t, got, len(members))
+	assert.Equal(t, members[len(members)-1].CID, got[len(got)-1].CID)
+}
+
+func TestMember(t *testing.T) {
+	server := fakeAPI(t, []vatsim.Member{{CID: 1293257, Rating: 5, Region: "AMAS", Division: "USA"}})
+	defer server.Close()
+
+	client := vatsim.NewClient(server.URL, "secret")
+	m, err := client.Member(context.Background(), 1293257)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, m.Rating)
+	assert.Equal(t, "AMAS", m.Region)
+
+	_, err = client.Member(context.Background(), 1)
+	assert.ErrorIs(t, err, vatsim.ErrNotFound)
+}
+
+func TestClientSendsAPIKey(t *testing.T) {
+	server := fakeAPI(t, nil)
+	defer server.Close()
+
+	_, err := vatsim.NewClient(server.URL, "wrong").DivisionMembers(context.Background(), "USA")
+	assert.Error(t, err)
+}
@@ -0,0 +1,145 @@
+package vatsim_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/VATUSA/primary-api/pkg/constants"
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/VATUSA/primary-api/pkg/vatsim"
+	"github.com/VATUSA/primary-api/test/harness"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeDivision serves listed as the division listing and looks up members in lookup, so a member can be missing
+// from the listing while still being in the division
+func fakeDivision(t *testing.T, listed []vatsim.Member, lookup map[uint]vatsim.Member) *vatsim.Syncer {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v2/orgs/division/USA" {
+			items := listed
+			if r.URL.Query().Get("offset") != "0" {
+				items = nil
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"items": items, "count": len(listed)})
+			return
+		}
+		for cid, m := range lookup {
+			if r.URL.Path == fmt.Sprintf("/v2/members/%d", cid) {
+				json.NewEncoder(w).Encode(m)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	t.Cleanup(server.Close)
+
+	return vatsim.NewSyncer(vatsim.NewClient(server.URL, "secret"), "USA")
+}
+
+func listing(cids ...uint) []vatsim.Member {
+	members := []vatsim.Member{}
+	for _, cid := range cids {
+		members = append(members, vatsim.Member{CID: cid, Rating: 5, PilotRating: -1, Region: "AMAS", Division: "USA"})
+	}
+	return members
+}
+
+func homeFacility(t *testing.T, h *harness.Harness, cid uint) string {
+	roster := &models.Roster{}
+	assert.NoError(t, h.DB.Where("c_id = ? AND home = ?", cid, true).First(roster).Error)
+	return roster.Facility
+}
+
+func TestSyncMovesLeavers(t *testing.T) {
+	h := harness.New(t)
+	syncer := fakeDivision(t, listing(1000001, 1000002, 1000004, 1000005), map[uint]vatsim.Member{
+		1000003: {CID: 1000003, Rating: 4, PilotRating: -1, Region: "EMEA", Division: "EUD"},
+	})
+
+	assert.NoError(t, syncer.Sync(context.Background(), time.Now()))
+	assert.Equal(t, string(constants.NonMemberFacility), homeFacility(t, h, 1000003))
+	assert.Equal(t, "ZDV", homeFacility(t, h, 1000002))
+	assert.Equal(t, 1, syncer.Status().Moved)
+}
+
+func TestSyncKeepsMembersMissingFromListing(t *testing.T) {
+	h := harness.New(t)
+	syncer := fakeDivision(t, listing(1000001, 1000002, 1000004, 1000005), map[uint]vatsim.Member{
+		1000003: {CID: 1000003, Rating: 4, PilotRating: -1, Region: "AMAS", Division: "USA"},
+	})
+
+	assert.NoError(t, syncer.Sync(context.Background(), time.Now()))
+	assert.Equal(t, "ZDV", homeFacility(t, h, 1000003))
+	assert.Zero(t, syncer.Status().Moved)
+}
+
+func TestSyncSkipsLeaversWhenListingIsShort(t *testing.T) {
+	h := harness.New(t)
+	syncer := fakeDivision(t, listing(1000001), map[uint]vatsim.Member{
+		1000002: {CID: 1000002, Rating: 8, PilotRating: -1, Region: "EMEA", Division: "EUD"},
+		1000003: {CID: 1000003, Rating: 4, PilotRating: -1, Region: "EMEA", Division: "EUD"},
+		1000004: {CID: 1000004, Rating: 5, PilotRating: -1, Region: "EMEA", Division: "EUD"},
+	})
+
+	assert.ErrorContains(t, syncer.Sync(context.Background(), time.Now()), "skipping leavers")
+	for _, cid := range []uint{1000002, 1000003} {
+		assert.Equal(t, "ZDV", homeFacility(t, h, cid))
+	}
+	assert.Equal(t, "ZLA", homeFacility(t, h, 1000004))
+	assert.Zero(t, syncer.Status().Moved)
+}
+
+func TestSyncRecordsRatingChangeWithUser(t *testing.T) {
+	h := harness.New(t)
+	members := listing(1000001, 1000002, 1000003, 1000004, 1000005)
+	for i, rating := range []int{10, 10, 4, 5, 1} {
+		members[i].Rating = rating
+	}
+
+	// Saving the user fails after the rating change has been written
+	assert.NoError(t, h.DB.Exec("CREATE TRIGGER fail_user_update BEFORE UPDATE ON users WHEN NEW.c_id = 1000002 BEGIN SELECT RAISE(ABORT, 'unavailable'); END").Error)
+	assert.Error(t, fakeDivision(t, members, nil).Sync(context.Background(), time.Now()))
+
+	var changes int64
+	h.DB.Model(&models.RatingChange{}).Where("c_id = ?", 1000002).Count(&changes)
+	assert.Zero(t, changes, "the rating change is rolled back with the user")
+
+	assert.NoError(t, h.DB.Exec("DROP TRIGGER fail_user_update").Error)
+	syncer := fakeDivision(t, members, nil)
+	assert.NoError(t, syncer.Sync(context.Background(), time.Now()))
+	h.DB.Model(&models.RatingChange{}).Where("c_id = ?", 1000002).Count(&changes)
+	assert.Equal(t, int64(1), changes)
+	assert.Equal(t, 1, syncer.Status().RatingChanges)
+}
+
+func TestTriggerRunsUnderRunContext(t *testing.T) {
+	harness.New(t)
+	syncer := fakeDivision(t, listing(1000001, 1000002, 1000003, 1000004, 1000005), nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		syncer.Run(ctx, time.Hour)
+		close(stopped)
+	}()
+
+	finished := func() *time.Time { return syncer.Status().FinishedAt }
+	assert.Eventually(t, func() bool { return finished() != nil }, time.Second, time.Millisecond)
+	first := *finished()
+
+	assert.True(t, syncer.Trigger())
+	assert.Eventually(t, func() bool { return finished() != nil && finished().After(first) }, time.Second, time.Millisecond,
+		"a triggered sync runs without waiting for the interval")
+
+	cancel()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("Run didn't stop with its context")
+	}
+}
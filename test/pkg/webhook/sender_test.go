@@ -0,0 +1,213 @@
+package webhook_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/VATUSA/primary-api/pkg/database/models"
+	"github.com/VATUSA/primary-api/pkg/database/types"
+	"github.com/VATUSA/primary-api/pkg/webhook"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// receiver checks each delivery's signature the way a subscriber would, answering with status once it verifies
+func receiver(t *testing.T, secret string, status int, received *[]*http.Request) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(r.Header.Get(webhook.TimestampHeader) + "." + string(body)))
+		expected := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+		if !hmac.Equal([]byte(expected), []byte(r.Header.Get(webhook.SignatureHeader))) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		*received = append(*received, r)
+		w.WriteHeader(status)
+	}))
+}
+
+// local is a sender that can reach the test receivers, which listen on loopback
+func local() *webhook.Sender {
+	return &webhook.Sender{HTTP: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func delivery() *models.WebhookDelivery {
+	return &models.WebhookDelivery{
+		ID:             7,
+		SubscriptionID: 1,
+		EventID:        "8f14e45fceea167a5a36dedd4bea2543",
+		Event:          types.RosterAddedEvent,
+		Payload:        `{"id":"8f14e45fceea167a5a36dedd4bea2543","event":"roster.added","facility":"ZDV","data":{"cid":1293257}}`,
+		Status:         types.PendingDelivery,
+	}
+}
+
+func TestDeliverSignsPayload(t *testing.T) {
+	var received []*http.Request
+	server := receiver(t, "shh", http.StatusOK, &received)
+	defer server.Close()
+
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	sub := &models.WebhookSubscription{ID: 1, URL: server.URL, Secret: "shh", Active: true}
+	d := delivery()
+
+	assert.NoError(t, local().Deliver(context.Background(), sub, d, now))
+	assert.Equal(t, types.DeliveredDelivery, d.Status)
+	assert.Equal(t, 1, d.Attempts)
+	assert.Equal(t, http.StatusOK, d.LastStatusCode)
+	assert.Equal(t, &now, d.DeliveredAt)
+	assert.Nil(t, d.NextAttemptAt)
+
+	if assert.Len(t, received, 1) {
+		r := received[0]
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, "roster.added", r.Header.Get(webhook.EventHeader))
+		assert.Equal(t, d.EventID, r.Header.Get(webhook.EventIDHeader))
+		assert.Equal(t, "7", r.Header.Get(webhook.DeliveryHeader))
+		assert.Equal(t, strconv.FormatInt(now.Unix(), 10), r.Header.Get(webhook.TimestampHeader))
+	}
+}
+
+func TestDeliverWrongSecretIsRetried(t *testing.T) {
+	var received []*http.Request
+	server := receiver(t, "shh", http.StatusOK, &received)
+	defer server.Close()
+
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	sub := &models.WebhookSubscription{ID: 1, URL: server.URL, Secret: "wrong", Active: true}
+	d := delivery()
+
+	assert.Error(t, local().Deliver(context.Background(), sub, d, now))
+	assert.Empty(t, received)
+	assert.Equal(t, types.PendingDelivery, d.Status)
+	assert.Equal(t, http.StatusUnauthorized, d.LastStatusCode)
+	assert.NotEmpty(t, d.LastError)
+	if assert.NotNil(t, d.NextAttemptAt) {
+		assert.Equal(t, now.Add(webhook.InitialBackoff), *d.NextAttemptAt)
+	}
+}
+
+func TestDeliverGivesUpAfterMaxAttempts(t *testing.T) {
+	var received []*http.Request
+	server := receiver(t, "shh", http.StatusInternalServerError, &received)
+	defer server.Close()
+
+	sub := &models.WebhookSubscription{ID: 1, URL: server.URL, Secret: "shh", Active: true}
+	d := delivery()
+	sender := local()
+
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 1; i < webhook.MaxAttempts; i++ {
+		assert.Error(t, sender.Deliver(context.Background(), sub, d, now))
+		assert.Equal(t, types.PendingDelivery, d.Status)
+		if assert.NotNil(t, d.NextAttemptAt) {
+			assert.Equal(t, now.Add(webhook.Backoff(i)), *d.NextAttemptAt)
+			now = *d.NextAttemptAt
+		}
+	}
+
+	assert.Error(t, sender.Deliver(context.Background(), sub, d, now))
+	assert.Equal(t, types.FailedDelivery, d.Status)
+	assert.Equal(t, webhook.MaxAttempts, d.Attempts)
+	assert.Equal(t, http.StatusInternalServerError, d.LastStatusCode)
+	assert.Nil(t, d.NextAttemptAt)
+	assert.Len(t, received, webhook.MaxAttempts)
+}
+
+func TestDeliverInactiveSubscription(t *testing.T) {
+	var received []*http.Request
+	server := receiver(t, "shh", http.StatusOK, &received)
+	defer server.Close()
+
+	sub := &models.WebhookSubscription{ID: 1, URL: server.URL, Secret: "shh", Active: false}
+	d := delivery()
+
+	assert.Error(t, local().Deliver(context.Background(), sub, d, time.Now()))
+	assert.Empty(t, received)
+	assert.Equal(t, types.FailedDelivery, d.Status)
+	assert.Nil(t, d.NextAttemptAt)
+}
+
+func TestBackoff(t *testing.T) {
+	assert.Equal(t, 30*time.Second, webhook.Backoff(1))
+	assert.Equal(t, time.Minute, webhook.Backoff(2))
+	assert.Equal(t, 2*time.Minute, webhook.Backoff(3))
+	assert.Equal(t, 64*time.Minute, webhook.Backoff(8))
+	assert.Equal(t, webhook.MaxBackoff, webhook.Backoff(20))
+}
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"event":"rating.changed"}`)
+	signature := webhook.Sign("shh", 1609459200, body)
+
+	assert.Regexp(t, "^sha256=[0-9a-f]{64}$", signature)
+	assert.Equal(t, signature, webhook.Sign("shh", 1609459200, body))
+	assert.NotEqual(t, signature, webhook.Sign("shh", 1609459201, body))
+	assert.NotEqual(t, signature, webhook.Sign("other", 1609459200, body))
+}
+
+func TestDeliverRefusesInternalAddresses(t *testing.T) {
+	var received []*http.Request
+	server := receiver(t, "shh", http.StatusOK, &received)
+	defer server.Close()
+
+	sub := &models.WebhookSubscription{ID: 1, URL: server.URL, Secret: "shh", Active: true}
+	d := delivery()
+
+	err := webhook.NewSender().Deliver(context.Background(), sub, d, time.Now())
+	assert.ErrorIs(t, err, webhook.ErrInternalAddress)
+	assert.Empty(t, received)
+	assert.Equal(t, types.PendingDelivery, d.Status)
+}
+
+func TestDeliverDoesNotFollowRedirects(t *testing.T) {
+	var received []*http.Request
+	server := receiver(t, "shh", http.StatusOK, &received)
+	defer server.Close()
+	redirect := httptest.NewServer(http.RedirectHandler(server.URL, http.StatusTemporaryRedirect))
+	defer redirect.Close()
+
+	// The guarded client can't reach loopback, so borrow only its redirect policy
+	sender := local()
+	sender.HTTP.CheckRedirect = webhook.NewClient(time.Second).CheckRedirect
+
+	sub := &models.WebhookSubscription{ID: 1, URL: redirect.URL, Secret: "shh", Active: true}
+	d := delivery()
+
+	assert.Error(t, sender.Deliver(context.Background(), sub, d, time.Now()))
+	assert.Empty(t, received)
+	assert.Equal(t, http.StatusTemporaryRedirect, d.LastStatusCode)
+}
+
+func TestIsInternalAddress(t *testing.T) {
+	tests := map[string]bool{
+		"127.0.0.1":          true,
+		"::1":                true,
+		"10.1.2.3":           true,
+		"172.16.0.1":         true,
+		"192.168.1.1":        true,
+		"169.254.169.254":    true,
+		"fe80::1":            true,
+		"fd00:ec2::254":      true,
+		"0.0.0.0":            true,
+		"::ffff:127.0.0.1":   true,
+		"224.0.0.1":          true,
+		"8.8.8.8":            false,
+		"2606:4700:4700::11": false,
+	}
+	for address, internal := range tests {
+		assert.Equal(t, internal, webhook.IsInternalAddress(netip.MustParseAddr(address)), address)
+	}
+}